@@ -17,10 +17,13 @@
 package avfs
 
 import (
+	"errors"
 	"hash"
 	"io"
+	"io/fs"
 	"os"
 	"sync"
+	"syscall"
 )
 
 var copyPool = newCopyPool() //nolint:gochecknoglobals // copyPool is the buffer pool used to copy files.
@@ -61,7 +64,7 @@ func CopyFileHash(dstFs, srcFs VFSBase, dstPath, srcPath string, hasher hash.Has
 
 	defer func() {
 		cerr := dst.Close()
-		if cerr == nil {
+		if cerr != nil && err == nil {
 			err = cerr
 		}
 	}()
@@ -102,6 +105,114 @@ func CopyFileHash(dstFs, srcFs VFSBase, dstPath, srcPath string, hasher hash.Has
 	return hasher.Sum(nil), nil
 }
 
+// CopyDir recursively copies a directory between file systems and returns an error if any.
+// Regular files, directories and symbolic links are copied, other file types are ignored.
+func CopyDir(dstFs, srcFs VFSBase, dstDir, srcDir string) error {
+	return WalkDir(srcFs, srcDir, func(srcPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := srcFs.Rel(srcDir, srcPath)
+		if err != nil {
+			return err
+		}
+
+		dstPath := dstFs.Join(dstDir, dstFs.FromSlash(srcFs.ToSlash(rel)))
+
+		return copyEntry(dstFs, srcFs, dstPath, srcPath, d.Type())
+	})
+}
+
+// copyEntry copies a single directory, regular file or symbolic link between file systems.
+func copyEntry(dstFs, srcFs VFSBase, dstPath, srcPath string, typ fs.FileMode) error {
+	switch {
+	case typ.IsDir():
+		info, err := srcFs.Stat(srcPath)
+		if err != nil {
+			return err
+		}
+
+		return dstFs.MkdirAll(dstPath, info.Mode().Perm())
+	case typ&fs.ModeSymlink != 0:
+		link, err := srcFs.Readlink(srcPath)
+		if err != nil {
+			return err
+		}
+
+		return dstFs.Symlink(link, dstPath)
+	case typ.IsRegular():
+		return CopyFile(dstFs, srcFs, dstPath, srcPath)
+	default:
+		return nil
+	}
+}
+
+// Move moves a file or a directory between file systems and returns an error if any.
+// See MoveDir for details.
+func Move(dstFs, srcFs VFSBase, dstPath, srcPath string) error {
+	info, err := srcFs.Lstat(srcPath)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return MoveDir(dstFs, srcFs, dstPath, srcPath)
+	}
+
+	return move(dstFs, srcFs, dstPath, srcPath, func() error {
+		return copyEntry(dstFs, srcFs, dstPath, srcPath, info.Mode().Type())
+	})
+}
+
+// MoveDir moves a directory between file systems and returns an error if any.
+// If both file systems are the same, the directory is renamed.
+// If the file systems are different or if Rename returns a cross-device error,
+// the directory is copied recursively and then removed from the source file system.
+// The source directory is not removed if the copy fails.
+func MoveDir(dstFs, srcFs VFSBase, dstDir, srcDir string) error {
+	return move(dstFs, srcFs, dstDir, srcDir, func() error {
+		return CopyDir(dstFs, srcFs, dstDir, srcDir)
+	})
+}
+
+// move renames srcPath to dstPath if the file systems are the same,
+// otherwise it copies srcPath to dstPath using copyFn and removes srcPath.
+func move(dstFs, srcFs VFSBase, dstPath, srcPath string, copyFn func() error) error {
+	if dstFs == srcFs {
+		err := srcFs.Rename(srcPath, dstPath)
+		if !isCrossDevice(err) {
+			return err
+		}
+	}
+
+	err := copyFn()
+	if err != nil {
+		return err
+	}
+
+	return srcFs.RemoveAll(srcPath)
+}
+
+// isCrossDevice returns true if the error is a cross-device link error.
+func isCrossDevice(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var errno syscall.Errno
+	if errors.As(err, &errno) {
+		switch CurrentOSType() {
+		case OsWindows:
+			return uintptr(errno) == uintptr(ErrWinNotSameDevice)
+		default:
+			return uintptr(errno) == uintptr(ErrCrossDevLink)
+		}
+	}
+
+	return errors.Is(err, ErrCrossDevLink) || errors.Is(err, ErrWinNotSameDevice)
+}
+
 // HashFile hashes a file and returns the hash sum.
 func HashFile(vfs VFSBase, name string, hasher hash.Hash) (sum []byte, err error) {
 	f, err := vfs.OpenFile(name, os.O_RDONLY, 0)
@@ -73,6 +73,11 @@ func (idm *DummyIdm) HasFeature(feature Features) bool {
 	return false
 }
 
+// MissingFeatures returns the features of want not provided by the file system or identity manager.
+func (idm *DummyIdm) MissingFeatures(want Features) Features {
+	return want
+}
+
 // AdminGroup returns the administrators (root) group.
 func (idm *DummyIdm) AdminGroup() GroupReader {
 	return idm.adminGroup
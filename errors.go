@@ -17,6 +17,7 @@
 package avfs
 
 import (
+	"errors"
 	"io/fs"
 	"reflect"
 	"strconv"
@@ -71,6 +72,52 @@ func (e UnknownUserIdError) Error() string {
 	return "user: unknown userid " + strconv.Itoa(int(e))
 }
 
+// ErrNotImplemented is wrapped by the errors returned by operations
+// that are not implemented by a file system.
+var ErrNotImplemented = errors.New(NotImplemented)
+
+// ErrFileTooLarge is returned by ReadFileLimit when a file is larger than the maximum size.
+var ErrFileTooLarge = errors.New("file too large")
+
+// FeatureMissingError is returned by an operation requiring a feature
+// that is not available in a file system.
+// Err is the error that the operating system would return in the same situation,
+// it is used as the error string to stay compatible with OS file systems.
+type FeatureMissingError struct {
+	Err     error    // Err is the operating system compatible error.
+	Feature Features // Feature is the missing feature.
+}
+
+// ErrFeatureMissing returns an error for the missing feature feat.
+// It can be used as a target of errors.Is to test if an operation failed because of this feature.
+func ErrFeatureMissing(feat Features) error {
+	return &FeatureMissingError{Feature: feat}
+}
+
+func (e *FeatureMissingError) Error() string {
+	if e.Err == nil {
+		return e.Feature.String() + " " + NotImplemented
+	}
+
+	return e.Err.Error()
+}
+
+// Is returns true if target is a FeatureMissingError with the same feature.
+func (e *FeatureMissingError) Is(target error) bool {
+	t, ok := target.(*FeatureMissingError)
+
+	return ok && t.Feature == e.Feature
+}
+
+// Unwrap returns ErrNotImplemented and the operating system compatible error.
+func (e *FeatureMissingError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrNotImplemented}
+	}
+
+	return []error{ErrNotImplemented, e.Err}
+}
+
 // ErrorIdentifier is the interface that wraps the Is method of an error.
 type ErrorIdentifier interface {
 	error
@@ -107,29 +154,37 @@ type LinuxError uintptr
 // Errors for Linux operating systems.
 // See https://github.com/torvalds/linux/blob/master/tools/include/uapi/asm-generic/errno-base.h
 const (
-	ErrBadFileDesc     LinuxError = errEBADF     // bad file descriptor
-	ErrCrossDevLink    LinuxError = errEXDEV     // invalid cross-device link
-	ErrDirNotEmpty     LinuxError = errENOTEMPTY // directory not empty
-	ErrFileExists      LinuxError = errEEXIST    // file exists
-	ErrInvalidArgument LinuxError = errEINVAL    // invalid argument
-	ErrIsADirectory    LinuxError = errEISDIR    // is a directory
-	ErrNoSuchFileOrDir LinuxError = errENOENT    // no such file or directory
-	ErrNotADirectory   LinuxError = errENOTDIR   // not a directory
-	ErrOpNotPermitted  LinuxError = errEPERM     // operation not permitted
-	ErrPermDenied      LinuxError = errEACCES    // permission denied
-	ErrTooManySymlinks LinuxError = errELOOP     // too many levels of symbolic links
-
-	errEACCES    = 0xd
-	errEBADF     = 0x9
-	errEEXIST    = 0x11
-	errEINVAL    = 0x16
-	errEISDIR    = 0x15
-	errENOENT    = 0x2
-	errELOOP     = 0x28
-	errENOTDIR   = 0x14
-	errENOTEMPTY = 0x27
-	errEPERM     = 0x1
-	errEXDEV     = 0x12
+	ErrBadFileDesc     LinuxError = errEBADF        // bad file descriptor
+	ErrCrossDevLink    LinuxError = errEXDEV        // invalid cross-device link
+	ErrDirNotEmpty     LinuxError = errENOTEMPTY    // directory not empty
+	ErrFileExists      LinuxError = errEEXIST       // file exists
+	ErrInvalidArgument LinuxError = errEINVAL       // invalid argument
+	ErrIsADirectory    LinuxError = errEISDIR       // is a directory
+	ErrNameTooLong     LinuxError = errENAMETOOLONG // file name too long
+	ErrNoData          LinuxError = errENODATA      // no data available
+	ErrNoDevOrAddr     LinuxError = errENXIO        // no such device or address
+	ErrNoSpaceLeft     LinuxError = errENOSPC       // no space left on device
+	ErrNoSuchFileOrDir LinuxError = errENOENT       // no such file or directory
+	ErrNotADirectory   LinuxError = errENOTDIR      // not a directory
+	ErrOpNotPermitted  LinuxError = errEPERM        // operation not permitted
+	ErrPermDenied      LinuxError = errEACCES       // permission denied
+	ErrTooManySymlinks LinuxError = errELOOP        // too many levels of symbolic links
+
+	errEACCES       = 0xd
+	errEBADF        = 0x9
+	errEEXIST       = 0x11
+	errEINVAL       = 0x16
+	errEISDIR       = 0x15
+	errENAMETOOLONG = 0x24
+	errENODATA      = 0x3d
+	errENOENT       = 0x2
+	errENOSPC       = 0x1c
+	errELOOP        = 0x28
+	errENOTDIR      = 0x14
+	errENOTEMPTY    = 0x27
+	errENXIO        = 0x6
+	errEPERM        = 0x1
+	errEXDEV        = 0x12
 )
 
 // Error returns the error string of the Linux operating system.
@@ -160,22 +215,25 @@ type WindowsError uintptr
 // Errors for Windows operating systems.
 // See https://learn.microsoft.com/en-us/windows/win32/debug/system-error-codes
 const (
-	ErrWinAccessDenied     WindowsError = 5          // Access is denied.
-	ErrWinAlreadyExists    WindowsError = 183        // Cannot create a file when that file already exists.
-	ErrWinBadNetPath       WindowsError = 53         // Bad network path.
-	ErrWinDirNameInvalid   WindowsError = 0x10B      // The directory name is invalid.
-	ErrWinDirNotEmpty      WindowsError = 145        // The directory is not empty.
-	ErrWinFileExists       WindowsError = 80         // The file exists.
-	ErrWinFileNotFound     WindowsError = 2          // The system cannot find the file specified.
-	ErrWinIncorrectFunc    WindowsError = 1          // Incorrect function.
-	ErrWinIsADirectory     WindowsError = 21         // is a directory
-	ErrWinNegativeSeek     WindowsError = 0x83       // An attempt was made to move the file pointer before the beginning of the file.
-	ErrWinNotReparsePoint  WindowsError = 4390       // The file or directory is not a reparse point.
-	ErrWinInvalidHandle    WindowsError = 6          // The handle is invalid.
-	ErrWinSharingViolation WindowsError = 32         // The process cannot access the file because it is being used by another process.
-	ErrWinNotSupported     WindowsError = 0x20000082 // not supported by windows
-	ErrWinPathNotFound     WindowsError = 3          // The system cannot find the path specified.
-	ErrWinPrivilegeNotHeld WindowsError = 1314       // A required privilege is not held by the client.
+	ErrWinAccessDenied       WindowsError = 5          // Access is denied.
+	ErrWinAlreadyExists      WindowsError = 183        // Cannot create a file when that file already exists.
+	ErrWinBadNetPath         WindowsError = 53         // Bad network path.
+	ErrWinDirNameInvalid     WindowsError = 0x10B      // The directory name is invalid.
+	ErrWinDirNotEmpty        WindowsError = 145        // The directory is not empty.
+	ErrWinDiskFull           WindowsError = 112        // There is not enough space on the disk.
+	ErrWinFileExists         WindowsError = 80         // The file exists.
+	ErrWinFileNotFound       WindowsError = 2          // The system cannot find the file specified.
+	ErrWinFilenameExcedRange WindowsError = 206        // The filename or extension is too long.
+	ErrWinIncorrectFunc      WindowsError = 1          // Incorrect function.
+	ErrWinIsADirectory       WindowsError = 21         // is a directory
+	ErrWinNegativeSeek       WindowsError = 0x83       // An attempt was made to move the file pointer before the beginning of the file.
+	ErrWinNotReparsePoint    WindowsError = 4390       // The file or directory is not a reparse point.
+	ErrWinNotSameDevice      WindowsError = 17         // The system cannot move the file to a different disk drive.
+	ErrWinInvalidHandle      WindowsError = 6          // The handle is invalid.
+	ErrWinSharingViolation   WindowsError = 32         // The process cannot access the file because it is being used by another process.
+	ErrWinNotSupported       WindowsError = 0x20000082 // not supported by windows
+	ErrWinPathNotFound       WindowsError = 3          // The system cannot find the path specified.
+	ErrWinPrivilegeNotHeld   WindowsError = 1314       // A required privilege is not held by the client.
 )
 
 // Error returns the error string of the Windows operating system.
@@ -184,9 +242,11 @@ func (i WindowsError) Error() string {
 }
 
 // Is returns true if the WindowsError can be treated as equivalent to a target error.
-// target is one of fs.ErrPermission, fs.ErrExist, fs.ErrNotExist.
+// target is one of fs.ErrPermission, fs.ErrExist, fs.ErrNotExist, errors.ErrUnsupported.
 func (i WindowsError) Is(target error) bool {
 	switch target {
+	case errors.ErrUnsupported:
+		return i == ErrWinNotSupported
 	case fs.ErrPermission:
 		return i == ErrWinAccessDenied
 	case fs.ErrExist:
@@ -198,6 +258,27 @@ func (i WindowsError) Is(target error) bool {
 	return false
 }
 
+// IsErrExist returns true if err, or one of the errors it wraps (*fs.PathError, *os.LinkError, ...),
+// reports that a file or directory already exists.
+// It is equivalent to errors.Is(err, fs.ErrExist) and works with Linux and Windows errors.
+func IsErrExist(err error) bool {
+	return errors.Is(err, fs.ErrExist)
+}
+
+// IsErrNotExist returns true if err, or one of the errors it wraps (*fs.PathError, *os.LinkError, ...),
+// reports that a file or directory does not exist.
+// It is equivalent to errors.Is(err, fs.ErrNotExist) and works with Linux and Windows errors.
+func IsErrNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// IsErrPermission returns true if err, or one of the errors it wraps (*fs.PathError, *os.LinkError, ...),
+// reports that permission is denied.
+// It is equivalent to errors.Is(err, fs.ErrPermission) and works with Linux and Windows errors.
+func IsErrPermission(err error) bool {
+	return errors.Is(err, fs.ErrPermission)
+}
+
 // Errors regroups errors depending on the OS emulated.
 type Errors struct {
 	BadFileDesc     error // bad file descriptor.
@@ -205,6 +286,10 @@ type Errors struct {
 	FileExists      error // File exists.
 	InvalidArgument error // invalid argument
 	IsADirectory    error // File Is a directory.
+	NameTooLong     error // File name too long.
+	NoData          error // No data available.
+	NoDevOrAddr     error // No such device or address.
+	NoSpaceLeft     error // No space left on device.
 	NoSuchDir       error // No such directory.
 	NoSuchFile      error // No such file.
 	NotADirectory   error // Not a directory.
@@ -222,6 +307,10 @@ func (e *Errors) SetOSType(osType OSType) {
 		e.FileExists = ErrWinFileExists
 		e.InvalidArgument = ErrWinNegativeSeek
 		e.IsADirectory = ErrWinIsADirectory
+		e.NameTooLong = ErrWinFilenameExcedRange
+		e.NoData = ErrWinNotSupported // Extended attributes are not supported on Windows (see FeatXattr).
+		e.NoDevOrAddr = ErrWinNotSupported
+		e.NoSpaceLeft = ErrWinDiskFull
 		e.NoSuchDir = ErrWinPathNotFound
 		e.NoSuchFile = ErrWinFileNotFound
 		e.NotADirectory = ErrWinPathNotFound
@@ -234,6 +323,10 @@ func (e *Errors) SetOSType(osType OSType) {
 		e.FileExists = ErrFileExists
 		e.InvalidArgument = ErrInvalidArgument
 		e.IsADirectory = ErrIsADirectory
+		e.NameTooLong = ErrNameTooLong
+		e.NoData = ErrNoData
+		e.NoDevOrAddr = ErrNoDevOrAddr
+		e.NoSpaceLeft = ErrNoSpaceLeft
 		e.NoSuchDir = ErrNoSuchFileOrDir
 		e.NoSuchFile = ErrNoSuchFileOrDir
 		e.NotADirectory = ErrNotADirectory
@@ -14,6 +14,10 @@ func _() {
 	_ = x[ErrFileExists-17]
 	_ = x[ErrInvalidArgument-22]
 	_ = x[ErrIsADirectory-21]
+	_ = x[ErrNameTooLong-36]
+	_ = x[ErrNoData-61]
+	_ = x[ErrNoDevOrAddr-6]
+	_ = x[ErrNoSpaceLeft-28]
 	_ = x[ErrNoSuchFileOrDir-2]
 	_ = x[ErrNotADirectory-20]
 	_ = x[ErrOpNotPermitted-1]
@@ -23,18 +27,22 @@ func _() {
 
 const (
 	_LinuxError_name_0 = "operation not permittedno such file or directory"
-	_LinuxError_name_1 = "bad file descriptor"
-	_LinuxError_name_2 = "permission denied"
-	_LinuxError_name_3 = "file existsinvalid cross-device link"
-	_LinuxError_name_4 = "not a directoryis a directoryinvalid argument"
-	_LinuxError_name_5 = "directory not emptytoo many levels of symbolic links"
+	_LinuxError_name_1 = "no such device or address"
+	_LinuxError_name_2 = "bad file descriptor"
+	_LinuxError_name_3 = "permission denied"
+	_LinuxError_name_4 = "file existsinvalid cross-device link"
+	_LinuxError_name_5 = "not a directoryis a directoryinvalid argument"
+	_LinuxError_name_6 = "no space left on device"
+	_LinuxError_name_7 = "file name too long"
+	_LinuxError_name_8 = "directory not emptytoo many levels of symbolic links"
+	_LinuxError_name_9 = "no data available"
 )
 
 var (
 	_LinuxError_index_0 = [...]uint8{0, 23, 48}
-	_LinuxError_index_3 = [...]uint8{0, 11, 36}
-	_LinuxError_index_4 = [...]uint8{0, 15, 29, 45}
-	_LinuxError_index_5 = [...]uint8{0, 19, 52}
+	_LinuxError_index_4 = [...]uint8{0, 11, 36}
+	_LinuxError_index_5 = [...]uint8{0, 15, 29, 45}
+	_LinuxError_index_8 = [...]uint8{0, 19, 52}
 )
 
 func (i LinuxError) String() string {
@@ -42,19 +50,27 @@ func (i LinuxError) String() string {
 	case 1 <= i && i <= 2:
 		i -= 1
 		return _LinuxError_name_0[_LinuxError_index_0[i]:_LinuxError_index_0[i+1]]
-	case i == 9:
+	case i == 6:
 		return _LinuxError_name_1
-	case i == 13:
+	case i == 9:
 		return _LinuxError_name_2
+	case i == 13:
+		return _LinuxError_name_3
 	case 17 <= i && i <= 18:
 		i -= 17
-		return _LinuxError_name_3[_LinuxError_index_3[i]:_LinuxError_index_3[i+1]]
+		return _LinuxError_name_4[_LinuxError_index_4[i]:_LinuxError_index_4[i+1]]
 	case 20 <= i && i <= 22:
 		i -= 20
-		return _LinuxError_name_4[_LinuxError_index_4[i]:_LinuxError_index_4[i+1]]
+		return _LinuxError_name_5[_LinuxError_index_5[i]:_LinuxError_index_5[i+1]]
+	case i == 28:
+		return _LinuxError_name_6
+	case i == 36:
+		return _LinuxError_name_7
 	case 39 <= i && i <= 40:
 		i -= 39
-		return _LinuxError_name_5[_LinuxError_index_5[i]:_LinuxError_index_5[i+1]]
+		return _LinuxError_name_8[_LinuxError_index_8[i]:_LinuxError_index_8[i+1]]
+	case i == 61:
+		return _LinuxError_name_9
 	default:
 		return "LinuxError(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
@@ -13,12 +13,15 @@ func _() {
 	_ = x[ErrWinBadNetPath-53]
 	_ = x[ErrWinDirNameInvalid-267]
 	_ = x[ErrWinDirNotEmpty-145]
+	_ = x[ErrWinDiskFull-112]
 	_ = x[ErrWinFileExists-80]
 	_ = x[ErrWinFileNotFound-2]
+	_ = x[ErrWinFilenameExcedRange-206]
 	_ = x[ErrWinIncorrectFunc-1]
 	_ = x[ErrWinIsADirectory-21]
 	_ = x[ErrWinNegativeSeek-131]
 	_ = x[ErrWinNotReparsePoint-4390]
+	_ = x[ErrWinNotSameDevice-17]
 	_ = x[ErrWinInvalidHandle-6]
 	_ = x[ErrWinSharingViolation-32]
 	_ = x[ErrWinNotSupported-536871042]
@@ -26,7 +29,7 @@ func _() {
 	_ = x[ErrWinPrivilegeNotHeld-1314]
 }
 
-const _WindowsError_name = "Incorrect function.The system cannot find the file specified.The system cannot find the path specified.Access is denied.The handle is invalid.is a directoryThe process cannot access the file because it is being used by another process.Bad network path.The file exists.An attempt was made to move the file pointer before the beginning of the file.The directory is not empty.Cannot create a file when that file already exists.The directory name is invalid.A required privilege is not held by the client.The file or directory is not a reparse point.not supported by windows"
+const _WindowsError_name = "Incorrect function.The system cannot find the file specified.The system cannot find the path specified.Access is denied.The handle is invalid.The system cannot move the file to a different disk drive.is a directoryThe process cannot access the file because it is being used by another process.Bad network path.The file exists.There is not enough space on the disk.An attempt was made to move the file pointer before the beginning of the file.The directory is not empty.Cannot create a file when that file already exists.The filename or extension is too long.The directory name is invalid.A required privilege is not held by the client.The file or directory is not a reparse point.not supported by windows"
 
 var _WindowsError_map = map[WindowsError]string{
 	1:         _WindowsError_name[0:19],
@@ -34,17 +37,20 @@ var _WindowsError_map = map[WindowsError]string{
 	3:         _WindowsError_name[61:103],
 	5:         _WindowsError_name[103:120],
 	6:         _WindowsError_name[120:142],
-	21:        _WindowsError_name[142:156],
-	32:        _WindowsError_name[156:235],
-	53:        _WindowsError_name[235:252],
-	80:        _WindowsError_name[252:268],
-	131:       _WindowsError_name[268:346],
-	145:       _WindowsError_name[346:373],
-	183:       _WindowsError_name[373:424],
-	267:       _WindowsError_name[424:454],
-	1314:      _WindowsError_name[454:501],
-	4390:      _WindowsError_name[501:546],
-	536871042: _WindowsError_name[546:570],
+	17:        _WindowsError_name[142:200],
+	21:        _WindowsError_name[200:214],
+	32:        _WindowsError_name[214:293],
+	53:        _WindowsError_name[293:310],
+	80:        _WindowsError_name[310:326],
+	112:       _WindowsError_name[326:364],
+	131:       _WindowsError_name[364:442],
+	145:       _WindowsError_name[442:469],
+	183:       _WindowsError_name[469:520],
+	206:       _WindowsError_name[520:558],
+	267:       _WindowsError_name[558:588],
+	1314:      _WindowsError_name[588:635],
+	4390:      _WindowsError_name[635:680],
+	536871042: _WindowsError_name[680:704],
 }
 
 func (i WindowsError) String() string {
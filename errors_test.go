@@ -19,6 +19,9 @@
 package avfs_test
 
 import (
+	"errors"
+	"io/fs"
+	"os"
 	"reflect"
 	"strconv"
 	"testing"
@@ -81,3 +84,90 @@ func TestErrors(t *testing.T) {
 		t.Errorf("UnknownUserIdError : want error to be %s, got %s", wantErrStr, uuiErr.Error())
 	}
 }
+
+func TestFeatureMissingError(t *testing.T) {
+	err := avfs.ErrFeatureMissing(avfs.FeatSymlink)
+
+	wantErrStr := "Features(Symlink) " + avfs.NotImplemented
+	if err.Error() != wantErrStr {
+		t.Errorf("ErrFeatureMissing : want error to be %s, got %s", wantErrStr, err.Error())
+	}
+
+	if !errors.Is(err, avfs.ErrNotImplemented) {
+		t.Errorf("ErrFeatureMissing : want error to wrap %v", avfs.ErrNotImplemented)
+	}
+
+	osErr := &avfs.FeatureMissingError{Err: avfs.ErrPermDenied, Feature: avfs.FeatSymlink}
+	pathErr := error(&fs.PathError{Op: "readlink", Path: "/a", Err: osErr})
+
+	if osErr.Error() != avfs.ErrPermDenied.Error() {
+		t.Errorf("FeatureMissingError : want error to be %s, got %s", avfs.ErrPermDenied, osErr.Error())
+	}
+
+	for _, target := range []error{avfs.ErrNotImplemented, avfs.ErrPermDenied, fs.ErrPermission, err} {
+		if !errors.Is(pathErr, target) {
+			t.Errorf("FeatureMissingError : want error %v to wrap %v", pathErr, target)
+		}
+	}
+
+	if errors.Is(pathErr, avfs.ErrFeatureMissing(avfs.FeatHardlink)) {
+		t.Errorf("FeatureMissingError : want error %v not to wrap missing feature Hardlink", pathErr)
+	}
+
+	if errors.Is(avfs.ErrPermDenied, avfs.ErrNotImplemented) {
+		t.Errorf("ErrPermDenied : want error not to wrap %v", avfs.ErrNotImplemented)
+	}
+}
+
+func TestErrorsIs(t *testing.T) {
+	targets := []error{fs.ErrExist, fs.ErrNotExist, fs.ErrPermission, errors.ErrUnsupported}
+
+	tests := []struct {
+		err  error
+		want error
+	}{
+		{err: avfs.ErrFileExists, want: fs.ErrExist},
+		{err: avfs.ErrDirNotEmpty, want: fs.ErrExist},
+		{err: avfs.ErrNoSuchFileOrDir, want: fs.ErrNotExist},
+		{err: avfs.ErrPermDenied, want: fs.ErrPermission},
+		{err: avfs.ErrOpNotPermitted, want: fs.ErrPermission},
+		{err: avfs.ErrBadFileDesc},
+		{err: avfs.ErrNotADirectory},
+		{err: avfs.ErrWinAlreadyExists, want: fs.ErrExist},
+		{err: avfs.ErrWinDirNotEmpty, want: fs.ErrExist},
+		{err: avfs.ErrWinFileExists, want: fs.ErrExist},
+		{err: avfs.ErrWinFileNotFound, want: fs.ErrNotExist},
+		{err: avfs.ErrWinBadNetPath, want: fs.ErrNotExist},
+		{err: avfs.ErrWinPathNotFound, want: fs.ErrNotExist},
+		{err: avfs.ErrWinAccessDenied, want: fs.ErrPermission},
+		{err: avfs.ErrWinNotSupported, want: errors.ErrUnsupported},
+		{err: avfs.ErrWinInvalidHandle},
+	}
+
+	for _, tt := range tests {
+		pathErr := &fs.PathError{Op: "open", Path: "/a", Err: tt.err}
+		linkErr := &os.LinkError{Op: "rename", Old: "/a", New: "/b", Err: tt.err}
+
+		for _, target := range targets {
+			want := target == tt.want
+
+			for _, err := range []error{tt.err, pathErr, linkErr} {
+				if got := errors.Is(err, target); got != want {
+					t.Errorf("Is %v : want errors.Is(%v) to be %t, got %t", err, target, want, got)
+				}
+			}
+		}
+
+		if got := avfs.IsErrExist(pathErr); got != (tt.want == fs.ErrExist) {
+			t.Errorf("IsErrExist %v : want %t, got %t", pathErr, !got, got)
+		}
+
+		if got := avfs.IsErrNotExist(pathErr); got != (tt.want == fs.ErrNotExist) {
+			t.Errorf("IsErrNotExist %v : want %t, got %t", pathErr, !got, got)
+		}
+
+		if got := avfs.IsErrPermission(pathErr); got != (tt.want == fs.ErrPermission) {
+			t.Errorf("IsErrPermission %v : want %t, got %t", pathErr, !got, got)
+		}
+	}
+}
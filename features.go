@@ -45,6 +45,44 @@ const (
 
 	// FeatSymlink indicates that the file system supports symbolic links (symlink(), evalSymlink() functions).
 	FeatSymlink
+
+	// FeatXattr indicates that the file system supports extended attributes (see XattrManager).
+	FeatXattr
+
+	// FeatDeadline indicates that the files of the file system support deadlines (SetDeadline functions).
+	FeatDeadline
+
+	// FeatOpenat indicates that the directory files of the file system support operations
+	// relative to the directory (see DirFile).
+	FeatOpenat
+
+	// FeatTmpFile indicates that the file system supports unnamed temporary files
+	// (see O_TMPFILE and TmpFileLinker).
+	FeatTmpFile
+
+	// FeatChroot indicates that the file system can change its root directory (see ChRooter).
+	FeatChroot
+
+	// FeatRenameFlags indicates that the file system supports renames that don't replace
+	// the destination or that exchange two paths (see FlagRenamer).
+	FeatRenameFlags
+
+	// FeatSpecialFiles indicates that the file system supports named pipes and sockets
+	// (see SpecialFileMaker).
+	FeatSpecialFiles
+
+	// FeatStatFS indicates that the file system reports its capacity and free space (see FSStater).
+	FeatStatFS
+
+	// FeatLchmod indicates that the file system can change the mode of a symbolic link itself (see Lchmoder).
+	FeatLchmod
+
+	// FeatSyncFS indicates that the file system can flush all its pending writes to its storage (see FSSyncer).
+	FeatSyncFS
+
+	// FeatDup indicates that the files of the file system can be duplicated into handles sharing
+	// the same offset (see FileDuper).
+	FeatDup
 )
 
 // Featurer is the interface that wraps the Features and HasFeature methods.
@@ -54,6 +92,9 @@ type Featurer interface {
 
 	// HasFeature returns true if the file system or identity manager provides a given feature.
 	HasFeature(feature Features) bool
+
+	// MissingFeatures returns the features of want not provided by the file system or identity manager.
+	MissingFeatures(want Features) Features
 }
 
 // FeaturesFn provides features functions to a file system or an identity manager.
@@ -71,6 +112,11 @@ func (ftf *FeaturesFn) HasFeature(feature Features) bool {
 	return ftf.features&feature == feature
 }
 
+// MissingFeatures returns the features of want not provided by the file system or identity manager.
+func (ftf *FeaturesFn) MissingFeatures(want Features) Features {
+	return want &^ ftf.features
+}
+
 // SetFeatures sets the features of the file system or identity manager.
 func (ftf *FeaturesFn) SetFeatures(feature Features) error {
 	ftf.features = feature
@@ -82,3 +128,16 @@ func (ftf *FeaturesFn) SetFeatures(feature Features) error {
 func BuildFeatures() Features {
 	return buildFeatSetOSType
 }
+
+// AllFeatures returns all the features defined, sorted in ascending order.
+func AllFeatures() []Features {
+	features := make([]Features, 0, len(_Features_map))
+
+	for feature := Features(1); feature != 0; feature <<= 1 {
+		if _, ok := _Features_map[feature]; ok {
+			features = append(features, feature)
+		}
+	}
+
+	return features
+}
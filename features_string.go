@@ -16,19 +16,41 @@ func _() {
 	_ = x[FeatRealFS-32]
 	_ = x[FeatSubFS-64]
 	_ = x[FeatSymlink-128]
+	_ = x[FeatXattr-256]
+	_ = x[FeatDeadline-512]
+	_ = x[FeatOpenat-1024]
+	_ = x[FeatTmpFile-2048]
+	_ = x[FeatChroot-4096]
+	_ = x[FeatRenameFlags-8192]
+	_ = x[FeatSpecialFiles-16384]
+	_ = x[FeatStatFS-32768]
+	_ = x[FeatLchmod-65536]
+	_ = x[FeatSyncFS-131072]
+	_ = x[FeatDup-262144]
 }
 
-const _Features_name = "HardlinkIdentityMgrSetOSTypeReadOnlyReadOnlyIdmRealFSSubFSSymlink"
+const _Features_name = "HardlinkIdentityMgrSetOSTypeReadOnlyReadOnlyIdmRealFSSubFSSymlinkXattrDeadlineOpenatTmpFileChrootRenameFlagsSpecialFilesStatFSLchmodSyncFSDup"
 
 var _Features_map = map[Features]string{
-	1:   _Features_name[0:8],
-	2:   _Features_name[8:19],
-	4:   _Features_name[19:28],
-	8:   _Features_name[28:36],
-	16:  _Features_name[36:47],
-	32:  _Features_name[47:53],
-	64:  _Features_name[53:58],
-	128: _Features_name[58:65],
+	1:      _Features_name[0:8],
+	2:      _Features_name[8:19],
+	4:      _Features_name[19:28],
+	8:      _Features_name[28:36],
+	16:     _Features_name[36:47],
+	32:     _Features_name[47:53],
+	64:     _Features_name[53:58],
+	128:    _Features_name[58:65],
+	256:    _Features_name[65:70],
+	512:    _Features_name[70:78],
+	1024:   _Features_name[78:84],
+	2048:   _Features_name[84:91],
+	4096:   _Features_name[91:97],
+	8192:   _Features_name[97:108],
+	16384:  _Features_name[108:120],
+	32768:  _Features_name[120:126],
+	65536:  _Features_name[126:132],
+	131072: _Features_name[132:138],
+	262144: _Features_name[138:141],
 }
 
 func (i Features) String() string {
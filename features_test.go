@@ -0,0 +1,81 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs_test
+
+import (
+	"testing"
+
+	"github.com/avfs/avfs"
+)
+
+// TestFeaturesString tests that the string representation of features is stable.
+func TestFeaturesString(t *testing.T) {
+	tests := []struct {
+		features avfs.Features
+		want     string
+	}{
+		{features: 0, want: "Features()"},
+		{features: avfs.FeatHardlink, want: "Features(Hardlink)"},
+		{features: avfs.FeatSymlink | avfs.FeatHardlink, want: "Features(Hardlink|Symlink)"},
+		{features: avfs.FeatDup | avfs.FeatIdentityMgr | avfs.FeatStatFS, want: "Features(IdentityMgr|StatFS|Dup)"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.features.String(); got != tt.want {
+			t.Errorf("String : want %q, got %q", tt.want, got)
+		}
+	}
+}
+
+// TestAllFeatures tests AllFeatures function.
+func TestAllFeatures(t *testing.T) {
+	features := avfs.AllFeatures()
+	if len(features) == 0 || features[0] != avfs.FeatHardlink || features[len(features)-1] != avfs.FeatDup {
+		t.Fatalf("AllFeatures : want features from %s to %s, got %v", avfs.FeatHardlink, avfs.FeatDup, features)
+	}
+
+	var all avfs.Features
+
+	for i, feature := range features {
+		if i > 0 && feature <= features[i-1] {
+			t.Errorf("AllFeatures : want features sorted, got %s after %s", feature, features[i-1])
+		}
+
+		all |= feature
+	}
+
+	if all != avfs.FeatDup<<1-1 {
+		t.Errorf("AllFeatures : want all the features to be defined, got %s", all)
+	}
+}
+
+// TestMissingFeatures tests MissingFeatures function.
+func TestMissingFeatures(t *testing.T) {
+	var ftf avfs.FeaturesFn
+
+	_ = ftf.SetFeatures(avfs.FeatHardlink | avfs.FeatSymlink)
+
+	want := avfs.FeatSymlink | avfs.FeatXattr | avfs.FeatDup
+
+	if missing := ftf.MissingFeatures(want); missing != avfs.FeatXattr|avfs.FeatDup {
+		t.Errorf("MissingFeatures : want %s, got %s", avfs.FeatXattr|avfs.FeatDup, missing)
+	}
+
+	if missing := ftf.MissingFeatures(avfs.FeatHardlink); missing != 0 {
+		t.Errorf("MissingFeatures : want no missing feature, got %s", missing)
+	}
+}
@@ -0,0 +1,73 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs
+
+import (
+	"io/fs"
+	"time"
+)
+
+// fileInfo is an immutable implementation of fs.FileInfo.
+type fileInfo struct {
+	modTime time.Time   // modTime is the modification time.
+	sys     any         // sys is the underlying data source.
+	name    string      // name is the base name of the file.
+	size    int64       // size is the length in bytes of the file.
+	mode    fs.FileMode // mode represents a file's mode and permission bits.
+}
+
+// NewFileInfo returns a fs.FileInfo built from the given values.
+// It can be used to build directory listings or to back a fs.DirEntry with fs.FileInfoToDirEntry.
+func NewFileInfo(name string, size int64, mode fs.FileMode, modTime time.Time, sys any) fs.FileInfo {
+	return &fileInfo{
+		modTime: modTime,
+		sys:     sys,
+		name:    name,
+		size:    size,
+		mode:    mode,
+	}
+}
+
+// IsDir is an abbreviation for Mode().IsDir().
+func (info *fileInfo) IsDir() bool {
+	return info.mode.IsDir()
+}
+
+// Mode returns the file mode bits.
+func (info *fileInfo) Mode() fs.FileMode {
+	return info.mode
+}
+
+// ModTime returns the modification time.
+func (info *fileInfo) ModTime() time.Time {
+	return info.modTime
+}
+
+// Name returns the base name of the file.
+func (info *fileInfo) Name() string {
+	return info.name
+}
+
+// Size returns the length in bytes for regular files; system-dependent for others.
+func (info *fileInfo) Size() int64 {
+	return info.size
+}
+
+// Sys returns the underlying data source (can return nil).
+func (info *fileInfo) Sys() any {
+	return info.sys
+}
@@ -0,0 +1,69 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs_test
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/avfs/avfs"
+)
+
+func TestNewFileInfo(t *testing.T) {
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	sys := "sys"
+
+	tests := []struct {
+		name     string
+		mode     fs.FileMode
+		size     int64
+		wantDir  bool
+		wantType fs.FileMode
+	}{
+		{name: "file", mode: 0o644, size: 42, wantType: 0},
+		{name: "dir", mode: fs.ModeDir | 0o755, size: 4096, wantDir: true, wantType: fs.ModeDir},
+		{name: "symlink", mode: fs.ModeSymlink | 0o777, size: 7, wantType: fs.ModeSymlink},
+	}
+
+	for _, tt := range tests {
+		info := avfs.NewFileInfo(tt.name, tt.size, tt.mode, modTime, sys)
+
+		if info.Name() != tt.name || info.Size() != tt.size || info.Mode() != tt.mode ||
+			!info.ModTime().Equal(modTime) || info.Sys() != sys {
+			t.Errorf("NewFileInfo %s : want (%s, %d, %s, %v, %v), got (%s, %d, %s, %v, %v)",
+				tt.name, tt.name, tt.size, tt.mode, modTime, sys,
+				info.Name(), info.Size(), info.Mode(), info.ModTime(), info.Sys())
+		}
+
+		if info.IsDir() != tt.wantDir {
+			t.Errorf("IsDir %s : want IsDir to be %t, got %t", tt.name, tt.wantDir, info.IsDir())
+		}
+
+		de := fs.FileInfoToDirEntry(info)
+
+		if de.Name() != tt.name || de.IsDir() != tt.wantDir || de.Type() != tt.wantType {
+			t.Errorf("FileInfoToDirEntry %s : want (%s, %t, %s), got (%s, %t, %s)",
+				tt.name, tt.name, tt.wantDir, tt.wantType, de.Name(), de.IsDir(), de.Type())
+		}
+
+		deInfo, err := de.Info()
+		if err != nil || deInfo != info {
+			t.Errorf("Info %s : want info to be the original info, got %v, %v", tt.name, deInfo, err)
+		}
+	}
+}
@@ -16,7 +16,7 @@
 
 package avfs
 
-// FnVFS defines the function names of a virtual file system that can return an error (see failfs.FailFS).
+// FnVFS defines the function names of a virtual file system that can return an error (see failfs.FailFS, hookfs.HookFS and slowfs.SlowFS).
 type FnVFS uint
 
 //go:generate stringer -type FnVFS -trimprefix Fn -output fnvfs_string.go
@@ -38,6 +38,9 @@ const (
 	FnFileReadDir
 	FnFileReaddirnames
 	FnFileSeek
+	FnFileSetDeadline
+	FnFileSetReadDeadline
+	FnFileSetWriteDeadline
 	FnFileStat
 	FnFileSync
 	FnFileTruncate
@@ -24,38 +24,41 @@ func _() {
 	_ = x[FnFileReadDir-14]
 	_ = x[FnFileReaddirnames-15]
 	_ = x[FnFileSeek-16]
-	_ = x[FnFileStat-17]
-	_ = x[FnFileSync-18]
-	_ = x[FnFileTruncate-19]
-	_ = x[FnFileWrite-20]
-	_ = x[FnFileWriteAt-21]
-	_ = x[FnGetwd-22]
-	_ = x[FnLchown-23]
-	_ = x[FnLink-24]
-	_ = x[FnLstat-25]
-	_ = x[FnMkdir-26]
-	_ = x[FnMkdirAll-27]
-	_ = x[FnMkdirTemp-28]
-	_ = x[FnOpenFile-29]
-	_ = x[FnReadDir-30]
-	_ = x[FnReadFile-31]
-	_ = x[FnReadlink-32]
-	_ = x[FnRemove-33]
-	_ = x[FnRemoveAll-34]
-	_ = x[FnRename-35]
-	_ = x[FnSetUser-36]
-	_ = x[FnSetUserByName-37]
-	_ = x[FnStat-38]
-	_ = x[FnSub-39]
-	_ = x[FnSymlink-40]
-	_ = x[FnTruncate-41]
-	_ = x[FnWalkDir-42]
-	_ = x[FnWriteFile-43]
+	_ = x[FnFileSetDeadline-17]
+	_ = x[FnFileSetReadDeadline-18]
+	_ = x[FnFileSetWriteDeadline-19]
+	_ = x[FnFileStat-20]
+	_ = x[FnFileSync-21]
+	_ = x[FnFileTruncate-22]
+	_ = x[FnFileWrite-23]
+	_ = x[FnFileWriteAt-24]
+	_ = x[FnGetwd-25]
+	_ = x[FnLchown-26]
+	_ = x[FnLink-27]
+	_ = x[FnLstat-28]
+	_ = x[FnMkdir-29]
+	_ = x[FnMkdirAll-30]
+	_ = x[FnMkdirTemp-31]
+	_ = x[FnOpenFile-32]
+	_ = x[FnReadDir-33]
+	_ = x[FnReadFile-34]
+	_ = x[FnReadlink-35]
+	_ = x[FnRemove-36]
+	_ = x[FnRemoveAll-37]
+	_ = x[FnRename-38]
+	_ = x[FnSetUser-39]
+	_ = x[FnSetUserByName-40]
+	_ = x[FnStat-41]
+	_ = x[FnSub-42]
+	_ = x[FnSymlink-43]
+	_ = x[FnTruncate-44]
+	_ = x[FnWalkDir-45]
+	_ = x[FnWriteFile-46]
 }
 
-const _FnVFS_name = "AbsChdirChmodChownChtimesCreateTempEvalSymlinksFileChdirFileChmodFileChownFileCloseFileReadFileReadAtFileReadDirFileReaddirnamesFileSeekFileStatFileSyncFileTruncateFileWriteFileWriteAtGetwdLchownLinkLstatMkdirMkdirAllMkdirTempOpenFileReadDirReadFileReadlinkRemoveRemoveAllRenameSetUserSetUserByNameStatSubSymlinkTruncateWalkDirWriteFile"
+const _FnVFS_name = "AbsChdirChmodChownChtimesCreateTempEvalSymlinksFileChdirFileChmodFileChownFileCloseFileReadFileReadAtFileReadDirFileReaddirnamesFileSeekFileSetDeadlineFileSetReadDeadlineFileSetWriteDeadlineFileStatFileSyncFileTruncateFileWriteFileWriteAtGetwdLchownLinkLstatMkdirMkdirAllMkdirTempOpenFileReadDirReadFileReadlinkRemoveRemoveAllRenameSetUserSetUserByNameStatSubSymlinkTruncateWalkDirWriteFile"
 
-var _FnVFS_index = [...]uint16{0, 3, 8, 13, 18, 25, 35, 47, 56, 65, 74, 83, 91, 101, 112, 128, 136, 144, 152, 164, 173, 184, 189, 195, 199, 204, 209, 217, 226, 234, 241, 249, 257, 263, 272, 278, 285, 298, 302, 305, 312, 320, 327, 336}
+var _FnVFS_index = [...]uint16{0, 3, 8, 13, 18, 25, 35, 47, 56, 65, 74, 83, 91, 101, 112, 128, 136, 151, 170, 190, 198, 206, 218, 227, 238, 243, 249, 253, 258, 263, 271, 280, 288, 295, 303, 311, 317, 326, 332, 339, 352, 356, 359, 366, 374, 381, 390}
 
 func (i FnVFS) String() string {
 	i -= 1
@@ -70,6 +70,12 @@ type UserReader interface {
 	IsAdmin() bool
 }
 
+// GroupsReader is the interface implemented by users belonging to supplementary groups.
+type GroupsReader interface {
+	// Groups returns the supplementary groups of the user, the primary group excluded.
+	Groups() []GroupReader
+}
+
 // GroupIdentifier is the interface that wraps the Gid method.
 type GroupIdentifier interface {
 	// Gid returns the primary group id.
@@ -17,7 +17,11 @@
 // Package memidm implements an in memory identity manager.
 package memidm
 
-import "github.com/avfs/avfs"
+import (
+	"slices"
+
+	"github.com/avfs/avfs"
+)
 
 // AdminGroup returns the administrator (root) group.
 func (idm *MemIdm) AdminGroup() avfs.GroupReader {
@@ -61,6 +65,15 @@ func (idm *MemIdm) GroupDel(name string) error {
 	delete(idm.groupsByName, g.name)
 	delete(idm.groupsById, g.gid)
 
+	idm.usrMu.RLock()
+	defer idm.usrMu.RUnlock()
+
+	for _, u := range idm.usersByName {
+		u.mu.Lock()
+		u.groups = slices.DeleteFunc(u.groups, func(ug *MemGroup) bool { return ug == g })
+		u.mu.Unlock()
+	}
+
 	return nil
 }
 
@@ -149,6 +162,37 @@ func (idm *MemIdm) UserAdd(name, groupName string) (avfs.UserReader, error) {
 	return u, nil
 }
 
+// AddUserToGroup adds the user userName to the supplementary group groupName.
+// Adding a user to its primary group or to a group it already belongs to does nothing.
+// If the user is not found, the returned error is of type UnknownUserError.
+// If the group is not found, the returned error is of type UnknownGroupError.
+func (idm *MemIdm) AddUserToGroup(userName, groupName string) error {
+	idm.grpMu.RLock()
+	g, ok := idm.groupsByName[groupName]
+	idm.grpMu.RUnlock()
+
+	if !ok {
+		return avfs.UnknownGroupError(groupName)
+	}
+
+	idm.usrMu.RLock()
+	u, ok := idm.usersByName[userName]
+	idm.usrMu.RUnlock()
+
+	if !ok {
+		return avfs.UnknownUserError(userName)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.gid != g.gid && !slices.Contains(u.groups, g) {
+		u.groups = append(u.groups, g)
+	}
+
+	return nil
+}
+
 // UserDel deletes an existing group.
 func (idm *MemIdm) UserDel(name string) error {
 	idm.usrMu.Lock()
@@ -177,6 +221,19 @@ func (u *MemUser) Gid() int {
 	return u.gid
 }
 
+// Groups returns the supplementary groups of the user, the primary group excluded.
+func (u *MemUser) Groups() []avfs.GroupReader {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	groups := make([]avfs.GroupReader, len(u.groups))
+	for i, g := range u.groups {
+		groups[i] = g
+	}
+
+	return groups
+}
+
 // IsAdmin returns true if the user has administrator (root) privileges.
 func (u *MemUser) IsAdmin() bool {
 	return u.uid == 0 || u.gid == 0
@@ -39,7 +39,10 @@ func NewWithOptions(opts *Options) *MemIdm {
 	}
 
 	_ = idm.SetFeatures(avfs.FeatIdentityMgr)
-	_ = idm.SetOSType(opts.OSType)
+	if err := idm.SetOSType(opts.OSType); err != nil {
+		// The OS type can't be changed without the avfs_setostype build tag.
+		_ = idm.SetOSType(avfs.CurrentOSType())
+	}
 
 	adminGroupName := avfs.AdminGroupName(idm.OSType())
 	adminUserName := avfs.AdminUserName(idm.OSType())
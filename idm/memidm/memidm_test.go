@@ -19,6 +19,7 @@
 package memidm_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/avfs/avfs"
@@ -33,6 +34,9 @@ var (
 	// MemUser implements avfs.UserReader interface.
 	_ avfs.UserReader = &memidm.MemUser{}
 
+	// MemUser implements avfs.GroupsReader interface.
+	_ avfs.GroupsReader = &memidm.MemUser{}
+
 	// MemGroup implements avfs.GroupReader interface.
 	_ avfs.GroupReader = &memidm.MemGroup{}
 )
@@ -59,3 +63,56 @@ func TestMemIdmFeatures(t *testing.T) {
 		t.Errorf("Features : want Features to be %d, got %d", avfs.FeatIdentityMgr, idm.Features())
 	}
 }
+
+func TestMemIdmAddUserToGroup(t *testing.T) {
+	idm := memidm.New()
+
+	_, err := idm.GroupAdd("primary")
+	test.RequireNoError(t, err, "GroupAdd")
+
+	g, err := idm.GroupAdd("supplementary")
+	test.RequireNoError(t, err, "GroupAdd")
+
+	u, err := idm.UserAdd("user", "primary")
+	test.RequireNoError(t, err, "UserAdd")
+
+	assertGroups := func(t *testing.T, want ...string) {
+		t.Helper()
+
+		var names []string
+		for _, g := range u.(avfs.GroupsReader).Groups() {
+			names = append(names, g.Name())
+		}
+
+		if !slices.Equal(names, want) {
+			t.Errorf("Groups : want groups to be %v, got %v", want, names)
+		}
+	}
+
+	assertGroups(t)
+
+	err = idm.AddUserToGroup("user", g.Name())
+	test.RequireNoError(t, err, "AddUserToGroup")
+	assertGroups(t, "supplementary")
+
+	err = idm.AddUserToGroup("user", g.Name())
+	test.RequireNoError(t, err, "AddUserToGroup")
+
+	err = idm.AddUserToGroup("user", "primary")
+	test.RequireNoError(t, err, "AddUserToGroup")
+	assertGroups(t, "supplementary")
+
+	err = idm.AddUserToGroup("unknown", g.Name())
+	if _, ok := err.(avfs.UnknownUserError); !ok {
+		t.Errorf("AddUserToGroup : want error to be of type UnknownUserError, got %v", err)
+	}
+
+	err = idm.AddUserToGroup("user", "unknown")
+	if _, ok := err.(avfs.UnknownGroupError); !ok {
+		t.Errorf("AddUserToGroup : want error to be of type UnknownGroupError, got %v", err)
+	}
+
+	err = idm.GroupDel(g.Name())
+	test.RequireNoError(t, err, "GroupDel")
+	assertGroups(t)
+}
@@ -60,9 +60,11 @@ type usersById map[int]*MemUser
 
 // MemUser is the implementation of avfs.UserReader.
 type MemUser struct {
-	name string
-	uid  int
-	gid  int
+	name   string
+	groups []*MemGroup // groups are the supplementary groups of the user (see MemIdm.AddUserToGroup).
+	uid    int
+	gid    int
+	mu     sync.RWMutex // mu is the mutex used to access groups.
 }
 
 // MemGroup is the implementation of avfs.GroupReader.
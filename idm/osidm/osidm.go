@@ -19,7 +19,13 @@
 // For testing only, should not be used in a production environment.
 package osidm
 
-import "github.com/avfs/avfs"
+import (
+	"errors"
+	"os/user"
+	"strconv"
+
+	"github.com/avfs/avfs"
+)
 
 // AdminGroup returns the administrator (root) group.
 func (idm *OsIdm) AdminGroup() avfs.GroupReader {
@@ -31,6 +37,111 @@ func (idm *OsIdm) AdminUser() avfs.UserReader {
 	return idm.adminUser
 }
 
+// LookupGroup looks up a group by name. If the group cannot be found, the
+// returned error is of type UnknownGroupError.
+func (idm *OsIdm) LookupGroup(name string) (avfs.GroupReader, error) {
+	if !idm.HasFeature(avfs.FeatIdentityMgr) {
+		return nil, avfs.ErrPermDenied
+	}
+
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		var e user.UnknownGroupError
+		if errors.As(err, &e) {
+			return nil, avfs.UnknownGroupError(name)
+		}
+
+		return nil, avfs.UnknownError(err.Error())
+	}
+
+	return toOsGroup(g), nil
+}
+
+// LookupGroupId looks up a group by groupid. If the group cannot be found, the
+// returned error is of type UnknownGroupIdError.
+func (idm *OsIdm) LookupGroupId(gid int) (avfs.GroupReader, error) {
+	if !idm.HasFeature(avfs.FeatIdentityMgr) {
+		return nil, avfs.ErrPermDenied
+	}
+
+	g, err := user.LookupGroupId(strconv.Itoa(gid))
+	if err != nil {
+		var e user.UnknownGroupIdError
+		if errors.As(err, &e) {
+			return nil, avfs.UnknownGroupIdError(gid)
+		}
+
+		return nil, avfs.UnknownError(err.Error())
+	}
+
+	return toOsGroup(g), nil
+}
+
+// LookupUser looks up a user by username. If the user cannot be found, the
+// returned error is of type UnknownUserError.
+func (idm *OsIdm) LookupUser(name string) (avfs.UserReader, error) {
+	if !idm.HasFeature(avfs.FeatIdentityMgr) {
+		return nil, avfs.ErrPermDenied
+	}
+
+	return lookupUser(name)
+}
+
+// LookupUserId looks up a user by userid. If the user cannot be found, the
+// returned error is of type UnknownUserIdError.
+func (idm *OsIdm) LookupUserId(uid int) (avfs.UserReader, error) {
+	if !idm.HasFeature(avfs.FeatIdentityMgr) {
+		return nil, avfs.ErrPermDenied
+	}
+
+	return lookupUserId(uid)
+}
+
+// lookupUser looks up a user by username in the user database of the OS.
+func lookupUser(name string) (*OsUser, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		var e user.UnknownUserError
+		if errors.As(err, &e) {
+			return nil, avfs.UnknownUserError(name)
+		}
+
+		return nil, avfs.UnknownError(err.Error())
+	}
+
+	return toOsUser(u), nil
+}
+
+// lookupUserId looks up a user by userid in the user database of the OS.
+func lookupUserId(uid int) (*OsUser, error) {
+	u, err := user.LookupId(strconv.Itoa(uid))
+	if err != nil {
+		var e user.UnknownUserIdError
+		if errors.As(err, &e) {
+			return nil, avfs.UnknownUserIdError(uid)
+		}
+
+		return nil, avfs.UnknownError(err.Error())
+	}
+
+	return toOsUser(u), nil
+}
+
+// toOsGroup converts a group of the os/user package to an OsGroup.
+func toOsGroup(g *user.Group) *OsGroup {
+	gid, _ := strconv.Atoi(g.Gid)
+
+	return &OsGroup{name: g.Name, gid: gid}
+}
+
+// toOsUser converts a user of the os/user package to an OsUser.
+func toOsUser(u *user.User) *OsUser {
+	uid, _ := strconv.Atoi(u.Uid)
+	gid, _ := strconv.Atoi(u.Gid)
+
+	return &OsUser{name: u.Username, uid: uid, gid: gid}
+}
+
 // OsGroup
 
 // Gid returns the group ID.
@@ -24,7 +24,6 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
-	"strconv"
 	"strings"
 	"syscall"
 
@@ -96,78 +95,6 @@ func (idm *OsIdm) GroupDel(name string) error {
 	return nil
 }
 
-// LookupGroup looks up a group by name. If the group cannot be found, the
-// returned error is of type UnknownGroupError.
-func (idm *OsIdm) LookupGroup(name string) (avfs.GroupReader, error) {
-	return getGroup(name, avfs.UnknownGroupError(name))
-}
-
-// LookupGroupId looks up a group by groupid. If the group cannot be found, the
-// returned error is of type UnknownGroupIdError.
-func (idm *OsIdm) LookupGroupId(gid int) (avfs.GroupReader, error) {
-	sGid := strconv.Itoa(gid)
-
-	return getGroup(sGid, avfs.UnknownGroupIdError(gid))
-}
-
-func getGroup(nameOrId string, notFoundErr error) (*OsGroup, error) {
-	line, err := getent("group", nameOrId, notFoundErr)
-	if err != nil {
-		return nil, err
-	}
-
-	cols := strings.Split(line, ":")
-	gid, _ := strconv.Atoi(cols[2])
-
-	g := &OsGroup{
-		name: cols[0],
-		gid:  gid,
-	}
-
-	return g, nil
-}
-
-// LookupUser looks up a user by username. If the user cannot be found, the
-// returned error is of type UnknownUserError.
-func (idm *OsIdm) LookupUser(name string) (avfs.UserReader, error) {
-	return lookupUser(name)
-}
-
-func lookupUser(name string) (avfs.UserReader, error) {
-	return getUser(name, avfs.UnknownUserError(name))
-}
-
-// LookupUserId looks up a user by userid. If the user cannot be found, the
-// returned error is of type UnknownUserIdError.
-func (idm *OsIdm) LookupUserId(uid int) (avfs.UserReader, error) {
-	return lookupUserId(uid)
-}
-
-func lookupUserId(uid int) (avfs.UserReader, error) {
-	sUid := strconv.Itoa(uid)
-
-	return getUser(sUid, avfs.UnknownUserIdError(uid))
-}
-
-func getUser(nameOrId string, notFoundErr error) (*OsUser, error) {
-	line, err := getent("passwd", nameOrId, notFoundErr)
-	if err != nil {
-		return nil, err
-	}
-
-	cols := strings.Split(line, ":")
-	uid, _ := strconv.Atoi(cols[2])
-	gid, _ := strconv.Atoi(cols[3])
-
-	u := &OsUser{
-		name: cols[0],
-		uid:  uid,
-		gid:  gid,
-	}
-
-	return u, nil
-}
-
 // SetUser sets the current user.
 // If the user can't be changed an error is returned.
 func SetUser(user avfs.UserReader) error {
@@ -246,6 +173,8 @@ func User() avfs.UserReader {
 		return nil
 	}
 
+	user.gid = syscall.Getegid()
+
 	return user
 }
 
@@ -312,28 +241,6 @@ func (idm *OsIdm) UserDel(name string) error {
 	return nil
 }
 
-func getent(database, key string, notFoundErr error) (string, error) {
-	cmd := exec.Command("getent", database, key)
-
-	buf, err := cmd.Output()
-	if err != nil {
-		if e, ok := err.(*exec.ExitError); ok {
-			switch e.ExitCode() {
-			case 1:
-				return "", avfs.UnknownError("Missing arguments, or database unknown.")
-			case 2:
-				return "", notFoundErr
-			case 3:
-				return "", avfs.UnknownError("Enumeration not supported on this database.")
-			}
-		}
-
-		return "", err
-	}
-
-	return string(buf), nil
-}
-
 // IsUserAdmin returns true if the current user has admin privileges.
 func isUserAdmin() bool {
 	return os.Geteuid() == 0
@@ -19,6 +19,8 @@
 package osidm
 
 import (
+	"os"
+
 	"github.com/avfs/avfs"
 )
 
@@ -32,30 +34,6 @@ func (idm *OsIdm) GroupDel(name string) error {
 	return avfs.ErrPermDenied
 }
 
-// LookupGroup looks up a group by name. If the group cannot be found, the
-// returned error is of type UnknownGroupError.
-func (idm *OsIdm) LookupGroup(name string) (avfs.GroupReader, error) {
-	return nil, avfs.ErrPermDenied
-}
-
-// LookupGroupId looks up a group by groupid. If the group cannot be found, the
-// returned error is of type UnknownGroupIdError.
-func (idm *OsIdm) LookupGroupId(gid int) (avfs.GroupReader, error) {
-	return nil, avfs.ErrPermDenied
-}
-
-// LookupUser looks up a user by username. If the user cannot be found, the
-// returned error is of type UnknownUserError.
-func (idm *OsIdm) LookupUser(name string) (avfs.UserReader, error) {
-	return nil, avfs.ErrPermDenied
-}
-
-// LookupUserId looks up a user by userid. If the user cannot be found, the
-// returned error is of type UnknownUserIdError.
-func (idm *OsIdm) LookupUserId(uid int) (avfs.UserReader, error) {
-	return nil, avfs.ErrPermDenied
-}
-
 // SetUser sets the current user.
 // If the user can't be changed an error is returned.
 func SetUser(user avfs.UserReader) error {
@@ -70,7 +48,14 @@ func SetUserByName(name string) error {
 
 // User returns the current user of the OS.
 func User() avfs.UserReader {
-	return avfs.NotImplementedIdm.AdminUser()
+	user, err := lookupUserId(os.Geteuid())
+	if err != nil {
+		return avfs.NotImplementedIdm.AdminUser()
+	}
+
+	user.gid = os.Getegid()
+
+	return user
 }
 
 // UserAdd adds a new user.
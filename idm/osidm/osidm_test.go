@@ -19,6 +19,7 @@
 package osidm_test
 
 import (
+	"os"
 	"testing"
 
 	"github.com/avfs/avfs"
@@ -58,3 +59,62 @@ func TestOsIdmCfg(t *testing.T) {
 		}
 	}
 }
+
+func TestOsIdmLookup(t *testing.T) {
+	idm := osidm.New()
+	if !idm.HasFeature(avfs.FeatIdentityMgr) {
+		t.Skipf("LookupUser : identity manager is not available on %s", avfs.CurrentOSType())
+	}
+
+	t.Run("LookupAdmin", func(t *testing.T) {
+		wantName := idm.AdminUser().Name()
+
+		u, err := idm.LookupUser(wantName)
+		test.RequireNoError(t, err, "LookupUser %s", wantName)
+
+		if u.Name() != wantName || u.Uid() != 0 || !u.IsAdmin() {
+			t.Errorf("LookupUser : want user to be (%s, 0, admin), got (%s, %d, %t)",
+				wantName, u.Name(), u.Uid(), u.IsAdmin())
+		}
+
+		u, err = idm.LookupUserId(0)
+		test.RequireNoError(t, err, "LookupUserId 0")
+
+		if u.Name() != wantName {
+			t.Errorf("LookupUserId : want name to be %s, got %s", wantName, u.Name())
+		}
+
+		wantGroupName := idm.AdminGroup().Name()
+
+		g, err := idm.LookupGroupId(0)
+		test.RequireNoError(t, err, "LookupGroupId 0")
+
+		if g.Name() != wantGroupName {
+			t.Errorf("LookupGroupId : want name to be %s, got %s", wantGroupName, g.Name())
+		}
+	})
+
+	t.Run("CurrentUser", func(t *testing.T) {
+		u := osidm.User()
+		if u.Uid() != os.Geteuid() || u.Gid() != os.Getegid() {
+			t.Errorf("User : want (uid, gid) to be (%d, %d), got (%d, %d)",
+				os.Geteuid(), os.Getegid(), u.Uid(), u.Gid())
+		}
+
+		lu, err := idm.LookupUserId(u.Uid())
+		test.RequireNoError(t, err, "LookupUserId %d", u.Uid())
+
+		if lu.Name() != u.Name() {
+			t.Errorf("LookupUserId : want name to be %s, got %s", u.Name(), lu.Name())
+		}
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		const name = "avfsUnknownUser"
+
+		_, err := idm.LookupUser(name)
+		if err != avfs.UnknownUserError(name) {
+			t.Errorf("LookupUser : want error to be %v, got %v", avfs.UnknownUserError(name), err)
+		}
+	})
+}
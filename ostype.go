@@ -54,6 +54,16 @@ var currentOSType = func() OSType { //nolint:gochecknoglobals // Store the curre
 	}
 }()
 
+// IsCaseSensitive returns true if file names are case-sensitive for the operating system type.
+func IsCaseSensitive(osType OSType) bool {
+	switch osType {
+	case OsWindows, OsDarwin:
+		return false
+	default:
+		return true
+	}
+}
+
 // OSTyper is the interface that wraps the OS type related methods.
 type OSTyper interface {
 	// OSType returns the operating system type of the file system.
@@ -83,7 +93,7 @@ func (osf *OSTypeFn) SetOSType(osType OSType) error {
 		osType = CurrentOSType()
 	}
 
-	if BuildFeatures()&FeatSetOSType != 0 && osType != CurrentOSType() {
+	if BuildFeatures()&FeatSetOSType == 0 && osType != CurrentOSType() {
 		return ErrSetOSType
 	}
 
@@ -98,3 +108,27 @@ func (osf *OSTypeFn) SetOSType(osType OSType) error {
 
 	return nil
 }
+
+// CaseSensitiver is the interface implemented by file systems whose case sensitivity
+// differs from the one of their OS type (see IsCaseSensitive).
+type CaseSensitiver interface {
+	// IsCaseSensitive returns true if the file names of the file system are case-sensitive.
+	IsCaseSensitive() bool
+}
+
+// SamePathSemantics returns true if the file systems a and b share the same path separator,
+// volume names handling and case sensitivity, so a path from one file system is valid verbatim in the other.
+func SamePathSemantics(a, b VFSBase) bool {
+	return a.PathSeparator() == b.PathSeparator() &&
+		(a.OSType() == OsWindows) == (b.OSType() == OsWindows) &&
+		isCaseSensitive(a) == isCaseSensitive(b)
+}
+
+// isCaseSensitive returns true if the file names of the file system vfs are case-sensitive.
+func isCaseSensitive(vfs VFSBase) bool {
+	if cs, ok := vfs.(CaseSensitiver); ok {
+		return cs.IsCaseSensitive()
+	}
+
+	return IsCaseSensitive(vfs.OSType())
+}
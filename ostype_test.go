@@ -0,0 +1,82 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package avfs_test
+
+import (
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+// pathSemanticsFS is a file system with the given path semantics.
+type pathSemanticsFS struct {
+	avfs.VFSBase
+	osType        avfs.OSType
+	sep           uint8
+	caseSensitive bool
+}
+
+func (vfs *pathSemanticsFS) OSType() avfs.OSType   { return vfs.osType }
+func (vfs *pathSemanticsFS) PathSeparator() uint8  { return vfs.sep }
+func (vfs *pathSemanticsFS) IsCaseSensitive() bool { return vfs.caseSensitive }
+
+// TestSamePathSemantics tests SamePathSemantics function.
+func TestSamePathSemantics(t *testing.T) {
+	base := memfs.New()
+
+	linuxFS1 := &pathSemanticsFS{VFSBase: base, osType: avfs.OsLinux, sep: '/', caseSensitive: true}
+	linuxFS2 := &pathSemanticsFS{VFSBase: base, osType: avfs.OsLinux, sep: '/', caseSensitive: true}
+	linuxFSNoCase := &pathSemanticsFS{VFSBase: base, osType: avfs.OsLinux, sep: '/', caseSensitive: false}
+	windowsFS := &pathSemanticsFS{VFSBase: base, osType: avfs.OsWindows, sep: '\\', caseSensitive: false}
+	windowsFSSlash := &pathSemanticsFS{VFSBase: base, osType: avfs.OsWindows, sep: '/', caseSensitive: false}
+	darwinFS := &pathSemanticsFS{VFSBase: base, osType: avfs.OsDarwin, sep: '/', caseSensitive: false}
+
+	cases := []struct {
+		name string
+		a, b avfs.VFSBase
+		want bool
+	}{
+		{name: "SameLinux", a: linuxFS1, b: linuxFS2, want: true},
+		{name: "LinuxWindows", a: linuxFS1, b: windowsFS, want: false},
+		{name: "CaseSensitivityOnly", a: linuxFS1, b: linuxFSNoCase, want: false},
+		{name: "SeparatorOnly", a: windowsFS, b: windowsFSSlash, want: false},
+		{name: "VolumesOnly", a: darwinFS, b: windowsFSSlash, want: false},
+		{name: "DifferentOSTypeSameSemantics", a: linuxFSNoCase, b: darwinFS, want: true},
+	}
+
+	for _, c := range cases {
+		if got := avfs.SamePathSemantics(c.a, c.b); got != c.want {
+			t.Errorf("SamePathSemantics %s : want %t, got %t", c.name, c.want, got)
+		}
+
+		if got := avfs.SamePathSemantics(c.b, c.a); got != c.want {
+			t.Errorf("SamePathSemantics %s (swapped) : want %t, got %t", c.name, c.want, got)
+		}
+	}
+
+	t.Run("DefaultCaseSensitivity", func(t *testing.T) {
+		vfs1 := memfs.New()
+		vfs2 := memfs.New()
+
+		if !avfs.SamePathSemantics(vfs1, vfs2) {
+			t.Errorf("SamePathSemantics : want two MemFS with the same OS type to share path semantics")
+		}
+	})
+}
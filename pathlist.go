@@ -0,0 +1,89 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs
+
+import "strings"
+
+// ListSeparator returns the OS-specific path list separator of the file system,
+// ':' on Linux and ';' on Windows, like os.PathListSeparator for the host.
+func ListSeparator[T VFSBase](vfs T) uint8 {
+	if vfs.OSType() == OsWindows {
+		return ';'
+	}
+
+	return ':'
+}
+
+// SplitList splits a list of paths joined by the OS-specific ListSeparator,
+// usually found in PATH or GOPATH environment variables.
+// Unlike strings.Split, SplitList returns an empty slice when passed an empty string.
+// On Windows, separators between double quotes are ignored and the quotes are removed.
+func SplitList[T VFSBase](vfs T, path string) []string {
+	if path == "" {
+		return []string{}
+	}
+
+	sep := ListSeparator(vfs)
+
+	if vfs.OSType() != OsWindows {
+		return strings.Split(path, string(sep))
+	}
+
+	list := []string{}
+	start := 0
+	quo := false
+
+	for i := range len(path) {
+		switch c := path[i]; {
+		case c == '"':
+			quo = !quo
+		case c == sep && !quo:
+			list = append(list, path[start:i])
+			start = i + 1
+		}
+	}
+
+	list = append(list, path[start:])
+
+	for i, s := range list {
+		list[i] = strings.ReplaceAll(s, `"`, ``)
+	}
+
+	return list
+}
+
+// JoinList joins paths with the OS-specific ListSeparator, it is the reverse of SplitList.
+// On Windows, paths containing the separator are enclosed in double quotes.
+func JoinList[T VFSBase](vfs T, elems ...string) string {
+	sep := string(ListSeparator(vfs))
+
+	if vfs.OSType() == OsWindows {
+		quoted := make([]string, len(elems))
+
+		for i, elem := range elems {
+			if strings.Contains(elem, sep) {
+				elem = `"` + elem + `"`
+			}
+
+			quoted[i] = elem
+		}
+
+		elems = quoted
+	}
+
+	return strings.Join(elems, sep)
+}
@@ -0,0 +1,343 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// pipe is the shared state of the two ends of an in-memory pipe.
+type pipe struct {
+	modTime time.Time     // modTime is the creation time of the pipe.
+	changed chan struct{} // changed is closed and replaced when the state of the pipe changes.
+	buf     []byte        // buf contains the bytes written and not yet read.
+	mu      sync.Mutex    // mu is the mutex used to access the pipe and the deadlines of its ends.
+	rClosed bool          // rClosed is true if the read end is closed.
+	wClosed bool          // wClosed is true if the write end is closed.
+}
+
+// pipeFile is one end of an in-memory pipe.
+type pipeFile struct {
+	p         *pipe     // p is the pipe shared by both ends.
+	name      string    // name is the name of the file.
+	rDeadline time.Time // rDeadline is the deadline of Read calls on this end.
+	wDeadline time.Time // wDeadline is the deadline of Write calls on this end.
+	reader    bool      // reader is true for the read end of the pipe.
+}
+
+// Pipe returns a connected pair of in-memory files, like os.Pipe.
+// Bytes written to w can be read from r. Writes never block, reads block until data is available,
+// the write end is closed (Read then returns io.EOF) or the read deadline is exceeded.
+// Writing to w after r is closed returns an error wrapping io.ErrClosedPipe.
+//
+// The files are not part of any file system: methods that don't apply to a pipe
+// (Seek, ReadAt, WriteAt, ReadDir, Truncate, ...) return an error.
+func Pipe() (r, w File, err error) {
+	p := &pipe{modTime: time.Now(), changed: make(chan struct{})}
+
+	r = &pipeFile{p: p, name: "|0", reader: true}
+	w = &pipeFile{p: p, name: "|1"}
+
+	return r, w, nil
+}
+
+// broadcast wakes up the calls waiting for a change of the pipe.
+// The mutex of the pipe must be locked.
+func (p *pipe) broadcast() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// closed returns true if the end of the pipe f is closed.
+// The mutex of the pipe must be locked.
+func (f *pipeFile) closed() bool {
+	if f.reader {
+		return f.p.rClosed
+	}
+
+	return f.p.wClosed
+}
+
+// pathError returns a *fs.PathError for the operation op on f.
+func (f *pipeFile) pathError(op string, err error) error {
+	return &fs.PathError{Op: op, Path: f.name, Err: err}
+}
+
+// Chdir returns an error, a pipe is not a directory.
+func (f *pipeFile) Chdir() error {
+	return f.pathError("chdir", ErrNotADirectory)
+}
+
+// Chmod has no effect on a pipe.
+func (f *pipeFile) Chmod(mode fs.FileMode) error {
+	return f.checkOpen("chmod")
+}
+
+// Chown has no effect on a pipe.
+func (f *pipeFile) Chown(uid, gid int) error {
+	return f.checkOpen("chown")
+}
+
+// checkOpen returns an error if the end of the pipe f is closed.
+func (f *pipeFile) checkOpen(op string) error {
+	f.p.mu.Lock()
+	defer f.p.mu.Unlock()
+
+	if f.closed() {
+		return f.pathError(op, fs.ErrClosed)
+	}
+
+	return nil
+}
+
+// Close closes the end of the pipe f.
+// Calls blocked on the other end return.
+func (f *pipeFile) Close() error {
+	p := f.p
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f.closed() {
+		return f.pathError("close", fs.ErrClosed)
+	}
+
+	if f.reader {
+		p.rClosed = true
+		p.buf = nil
+	} else {
+		p.wClosed = true
+	}
+
+	p.broadcast()
+
+	return nil
+}
+
+// Fd returns ^uintptr(0), a pipe has no file descriptor.
+func (*pipeFile) Fd() uintptr {
+	return ^uintptr(0)
+}
+
+// Name returns the name of the file: "|0" for the read end and "|1" for the write end.
+func (f *pipeFile) Name() string {
+	return f.name
+}
+
+// Read reads up to len(b) bytes from the pipe.
+// It blocks until data is available, the write end is closed or the read deadline is exceeded.
+// At end of file, when the write end is closed and all the data has been read, Read returns 0, io.EOF.
+func (f *pipeFile) Read(b []byte) (n int, err error) {
+	const op = "read"
+
+	if !f.reader {
+		return 0, f.pathError(op, ErrBadFileDesc)
+	}
+
+	p := f.p
+
+	for {
+		p.mu.Lock()
+
+		switch {
+		case p.rClosed:
+			p.mu.Unlock()
+
+			return 0, f.pathError(op, fs.ErrClosed)
+		case len(b) == 0:
+			p.mu.Unlock()
+
+			return 0, nil
+		case len(p.buf) != 0:
+			n = copy(b, p.buf)
+			p.buf = p.buf[n:]
+			p.mu.Unlock()
+
+			return n, nil
+		case p.wClosed:
+			p.mu.Unlock()
+
+			return 0, io.EOF
+		case deadlineExceeded(f.rDeadline):
+			p.mu.Unlock()
+
+			return 0, f.pathError(op, os.ErrDeadlineExceeded)
+		}
+
+		changed, deadline := p.changed, f.rDeadline
+		p.mu.Unlock()
+
+		waitChange(changed, deadline)
+	}
+}
+
+// waitChange waits until changed is closed or deadline is exceeded.
+func waitChange(changed <-chan struct{}, deadline time.Time) {
+	if deadline.IsZero() {
+		<-changed
+
+		return
+	}
+
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case <-changed:
+	case <-timer.C:
+	}
+}
+
+// deadlineExceeded returns true if the deadline is set and is in the past.
+func deadlineExceeded(deadline time.Time) bool {
+	return !deadline.IsZero() && !time.Now().Before(deadline)
+}
+
+// ReadAt returns an error, a pipe is not seekable.
+func (f *pipeFile) ReadAt(b []byte, off int64) (n int, err error) {
+	return 0, f.pathError("read", ErrInvalidArgument)
+}
+
+// ReadDir returns an error, a pipe is not a directory.
+func (f *pipeFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	return nil, f.pathError("readdirent", ErrNotADirectory)
+}
+
+// Readdirnames returns an error, a pipe is not a directory.
+func (f *pipeFile) Readdirnames(n int) (names []string, err error) {
+	return nil, f.pathError("readdirent", ErrNotADirectory)
+}
+
+// Seek returns an error, a pipe is not seekable.
+func (f *pipeFile) Seek(offset int64, whence int) (ret int64, err error) {
+	return 0, f.pathError("seek", ErrInvalidArgument)
+}
+
+// SetDeadline sets the read and write deadlines of this end of the pipe.
+// A zero value for t means Read and Write will not time out.
+func (f *pipeFile) SetDeadline(t time.Time) error {
+	return f.setDeadline("SetDeadline", t, true, true)
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *pipeFile) SetReadDeadline(t time.Time) error {
+	return f.setDeadline("SetReadDeadline", t, true, false)
+}
+
+// SetWriteDeadline sets the deadline for future Write calls.
+// Since writes never block, only the calls made after the deadline fail.
+// A zero value for t means Write will not time out.
+func (f *pipeFile) SetWriteDeadline(t time.Time) error {
+	return f.setDeadline("SetWriteDeadline", t, false, true)
+}
+
+// setDeadline sets the read and/or write deadlines of the end of the pipe f,
+// the deadlines of the other end are left unchanged.
+func (f *pipeFile) setDeadline(op string, t time.Time, read, write bool) error {
+	p := f.p
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f.closed() {
+		return f.pathError(op, fs.ErrClosed)
+	}
+
+	if read {
+		f.rDeadline = t
+	}
+
+	if write {
+		f.wDeadline = t
+	}
+
+	p.broadcast()
+
+	return nil
+}
+
+// Stat returns the fs.FileInfo of the pipe, the size is the number of bytes not yet read.
+func (f *pipeFile) Stat() (fs.FileInfo, error) {
+	p := f.p
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if f.closed() {
+		return nil, f.pathError("stat", fs.ErrClosed)
+	}
+
+	return NewFileInfo(f.name, int64(len(p.buf)), fs.ModeNamedPipe|0o600, p.modTime, nil), nil
+}
+
+// Sync returns an error, a pipe can't be synchronized.
+func (f *pipeFile) Sync() error {
+	return f.pathError("sync", ErrInvalidArgument)
+}
+
+// Truncate returns an error, a pipe can't be truncated.
+func (f *pipeFile) Truncate(size int64) error {
+	return f.pathError("truncate", ErrInvalidArgument)
+}
+
+// Write writes len(b) bytes to the pipe, it never blocks.
+// If the read end is closed, Write returns an error wrapping io.ErrClosedPipe.
+func (f *pipeFile) Write(b []byte) (n int, err error) {
+	const op = "write"
+
+	if f.reader {
+		return 0, f.pathError(op, ErrBadFileDesc)
+	}
+
+	p := f.p
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch {
+	case p.wClosed:
+		return 0, f.pathError(op, fs.ErrClosed)
+	case deadlineExceeded(f.wDeadline):
+		return 0, f.pathError(op, os.ErrDeadlineExceeded)
+	case p.rClosed:
+		return 0, f.pathError(op, io.ErrClosedPipe)
+	case len(b) == 0:
+		return 0, nil
+	}
+
+	p.buf = append(p.buf, b...)
+	p.broadcast()
+
+	return len(b), nil
+}
+
+// WriteAt returns an error, a pipe is not seekable.
+func (f *pipeFile) WriteAt(b []byte, off int64) (n int, err error) {
+	return 0, f.pathError("write", ErrInvalidArgument)
+}
+
+// WriteString is like Write, but writes the contents of string s rather than
+// a slice of bytes.
+func (f *pipeFile) WriteString(s string) (n int, err error) {
+	return f.Write([]byte(s))
+}
@@ -0,0 +1,166 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package avfs_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+)
+
+func TestPipe(t *testing.T) {
+	t.Run("ReadWrite", func(t *testing.T) {
+		r, w, err := avfs.Pipe()
+		test.RequireNoError(t, err, "Pipe")
+
+		defer r.Close()
+
+		const count = 100
+
+		chunk := []byte("0123456789")
+
+		go func() {
+			for range count {
+				_, _ = w.Write(chunk)
+			}
+
+			_ = w.Close()
+		}()
+
+		got, err := io.ReadAll(r)
+		test.RequireNoError(t, err, "ReadAll")
+
+		if want := bytes.Repeat(chunk, count); !bytes.Equal(got, want) {
+			t.Errorf("ReadAll : want %d bytes, got %d", len(want), len(got))
+		}
+
+		n, err := r.Read(make([]byte, 1))
+		if n != 0 || err != io.EOF {
+			t.Errorf("Read : want 0, EOF after the writer is closed, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("ReaderClosed", func(t *testing.T) {
+		r, w, err := avfs.Pipe()
+		test.RequireNoError(t, err, "Pipe")
+
+		defer w.Close()
+
+		err = r.Close()
+		test.RequireNoError(t, err, "Close")
+
+		_, err = w.Write([]byte("data"))
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Errorf("Write : want error to be %v, got %v", io.ErrClosedPipe, err)
+		}
+
+		_, err = r.Read(make([]byte, 1))
+		test.AssertPathError(t, err).Op("read").Path(r.Name()).Err(fs.ErrClosed).Test()
+	})
+
+	t.Run("ReadDeadline", func(t *testing.T) {
+		r, w, err := avfs.Pipe()
+		test.RequireNoError(t, err, "Pipe")
+
+		defer r.Close()
+		defer w.Close()
+
+		err = r.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+		test.RequireNoError(t, err, "SetReadDeadline")
+
+		_, err = r.Read(make([]byte, 1))
+		test.AssertPathError(t, err).Op("read").Path(r.Name()).Err(os.ErrDeadlineExceeded).Test()
+	})
+
+	t.Run("DeadlinePerEnd", func(t *testing.T) {
+		r, w, err := avfs.Pipe()
+		test.RequireNoError(t, err, "Pipe")
+
+		defer r.Close()
+
+		past := time.Now().Add(-time.Second)
+
+		err = w.SetDeadline(past)
+		test.RequireNoError(t, err, "SetDeadline")
+
+		_, err = w.WriteString("data")
+		test.AssertPathError(t, err).Op("write").Path(w.Name()).Err(os.ErrDeadlineExceeded).Test()
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_ = w.Close()
+		}()
+
+		_, err = r.Read(make([]byte, 1))
+		if err != io.EOF {
+			t.Errorf("Read : want error to be %v, got %v", io.EOF, err)
+		}
+
+		r2, w2, err := avfs.Pipe()
+		test.RequireNoError(t, err, "Pipe")
+
+		defer r2.Close()
+		defer w2.Close()
+
+		err = r2.SetDeadline(past)
+		test.RequireNoError(t, err, "SetDeadline")
+
+		_, err = w2.WriteString("data")
+		test.RequireNoError(t, err, "WriteString")
+	})
+
+	t.Run("WrongEnd", func(t *testing.T) {
+		r, w, err := avfs.Pipe()
+		test.RequireNoError(t, err, "Pipe")
+
+		defer r.Close()
+		defer w.Close()
+
+		_, err = r.Write([]byte("data"))
+		test.AssertPathError(t, err).Op("write").Path(r.Name()).Err(avfs.ErrBadFileDesc).Test()
+
+		_, err = w.Read(make([]byte, 1))
+		test.AssertPathError(t, err).Op("read").Path(w.Name()).Err(avfs.ErrBadFileDesc).Test()
+	})
+
+	t.Run("Stat", func(t *testing.T) {
+		r, w, err := avfs.Pipe()
+		test.RequireNoError(t, err, "Pipe")
+
+		defer r.Close()
+		defer w.Close()
+
+		_, err = w.WriteString("data")
+		test.RequireNoError(t, err, "WriteString")
+
+		info, err := r.Stat()
+		test.RequireNoError(t, err, "Stat")
+
+		if info.Mode()&fs.ModeNamedPipe == 0 || info.Size() != 4 {
+			t.Errorf("Stat : want a named pipe of size 4, got mode %s size %d", info.Mode(), info.Size())
+		}
+	})
+}
@@ -0,0 +1,77 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownScheme is returned by Open when no file system is registered for the scheme of an URL.
+var ErrUnknownScheme = errors.New("unknown file system scheme")
+
+// VFSFactory is a function returning a new file system from an URL.
+type VFSFactory func(u *url.URL) (VFS, error)
+
+// registry holds the file system factories registered by scheme.
+var registry = struct {
+	mu        sync.RWMutex
+	factories map[string]VFSFactory
+}{factories: make(map[string]VFSFactory)}
+
+// Register makes a file system factory available by the provided scheme.
+// It is usually called from the init function of a file system package.
+// If Register is called twice with the same scheme or if factory is nil, it panics.
+func Register(scheme string, factory VFSFactory) {
+	if factory == nil {
+		panic("avfs: Register factory is nil")
+	}
+
+	scheme = strings.ToLower(scheme)
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, dup := registry.factories[scheme]; dup {
+		panic("avfs: Register called twice for scheme " + scheme)
+	}
+
+	registry.factories[scheme] = factory
+}
+
+// Open returns a new file system from an URL like "mem://" or "os:///tmp/base".
+// The file system is created by the factory registered for the scheme of the URL,
+// if no factory is registered the returned error wraps ErrUnknownScheme.
+func Open(rawURL string) (VFS, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	registry.mu.RLock()
+	factory, ok := registry.factories[strings.ToLower(u.Scheme)]
+	registry.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("avfs: %w %q (forgotten import?)", ErrUnknownScheme, u.Scheme)
+	}
+
+	return factory(u)
+}
@@ -0,0 +1,102 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package avfs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/basepathfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	_ "github.com/avfs/avfs/vfs/orefafs"
+	"github.com/avfs/avfs/vfs/osfs"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("MemFS", func(t *testing.T) {
+		vfs, err := avfs.Open("mem://")
+		test.RequireNoError(t, err, "Open")
+
+		if _, ok := vfs.(*memfs.MemFS); !ok {
+			t.Fatalf("Open : want file system type to be MemFS, got %s", vfs.Type())
+		}
+
+		entries, err := vfs.ReadDir(vfs.TempDir())
+		test.RequireNoError(t, err, "ReadDir")
+
+		if len(entries) != 0 {
+			t.Errorf("ReadDir : want file system to be empty, got %d entries", len(entries))
+		}
+	})
+
+	t.Run("OrefaFS", func(t *testing.T) {
+		vfs, err := avfs.Open("orefa://")
+		test.RequireNoError(t, err, "Open")
+
+		if vfs.Type() != "OrefaFS" {
+			t.Errorf("Open : want file system type to be OrefaFS, got %s", vfs.Type())
+		}
+	})
+
+	t.Run("OsFS", func(t *testing.T) {
+		vfs, err := avfs.Open("os://")
+		test.RequireNoError(t, err, "Open")
+
+		if _, ok := vfs.(*osfs.OsFS); !ok {
+			t.Errorf("Open : want file system type to be OsFS, got %s", vfs.Type())
+		}
+	})
+
+	t.Run("OsFSBasePath", func(t *testing.T) {
+		osFS := osfs.NewWithNoIdm()
+		tmpDir := t.TempDir()
+
+		path := osFS.ToSlash(tmpDir)
+		if path[0] != '/' {
+			path = "/" + path // Windows volume name.
+		}
+
+		vfs, err := avfs.Open("os://" + path)
+		test.RequireNoError(t, err, "Open")
+
+		bpFS, ok := vfs.(*basepathfs.BasePathFS)
+		if !ok {
+			t.Fatalf("Open : want file system type to be BasePathFS, got %s", vfs.Type())
+		}
+
+		if bpFS.BasePath() != tmpDir {
+			t.Errorf("BasePath : want base path to be %s, got %s", tmpDir, bpFS.BasePath())
+		}
+
+		err = vfs.WriteFile("/file", []byte("data"), avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile")
+
+		_, err = osFS.Stat(osFS.Join(tmpDir, "file"))
+		test.RequireNoError(t, err, "Stat")
+	})
+
+	t.Run("UnknownScheme", func(t *testing.T) {
+		_, err := avfs.Open("unknown://")
+		if !errors.Is(err, avfs.ErrUnknownScheme) {
+			t.Errorf("Open : want error to be %v, got %v", avfs.ErrUnknownScheme, err)
+		}
+	})
+}
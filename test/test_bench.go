@@ -17,7 +17,9 @@
 package test
 
 import (
+	"fmt"
 	"io"
+	"io/fs"
 	"math/rand"
 	"os"
 	"strconv"
@@ -34,12 +36,16 @@ const (
 // BenchAll runs all benchmarks.
 func (ts *Suite) BenchAll(b *testing.B) {
 	ts.RunBenchmarks(b, UsrTest,
+		ts.BenchCopyFile,
 		ts.BenchCreate,
 		ts.BenchFileRead,
 		ts.BenchFileWrite,
 		ts.BenchMkdir,
 		ts.BenchOpenFile,
+		ts.BenchReadDir,
 		ts.BenchRemove,
+		ts.BenchStat,
+		ts.BenchWalkDir,
 	)
 }
 
@@ -53,6 +59,32 @@ func (ts *Suite) benchOpenFlags() int {
 	return 0x4000 // syscall.O_DIRECT for linux.
 }
 
+// BenchCopyFile benchmarks CopyFile function.
+func (ts *Suite) BenchCopyFile(b *testing.B, testDir string) {
+	vfs := ts.vfsTest
+	srcPath := vfs.Join(testDir, "BenchCopyFileSrc.txt")
+	dstPath := vfs.Join(testDir, "BenchCopyFileDst.txt")
+
+	err := vfs.WriteFile(srcPath, make([]byte, 4*bufSize), avfs.DefaultFilePerm)
+	RequireNoError(b, err, "WriteFile %s", srcPath)
+
+	defer func() {
+		_ = vfs.Remove(srcPath)
+		_ = vfs.Remove(dstPath)
+	}()
+
+	b.ResetTimer()
+
+	b.Run("CopyFile", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for n := 0; n < b.N; n++ {
+			err = avfs.CopyFile(vfs, vfs, dstPath, srcPath)
+			RequireNoError(b, err, "CopyFile %s, %s", dstPath, srcPath)
+		}
+	})
+}
+
 // BenchCreate benchmarks Create function.
 func (ts *Suite) BenchCreate(b *testing.B, testDir string) {
 	vfs := ts.vfsTest
@@ -196,6 +228,21 @@ func (ts *Suite) BenchOpenFile(b *testing.B, testDir string) {
 	})
 }
 
+// BenchReadDir benchmarks ReadDir function.
+func (ts *Suite) BenchReadDir(b *testing.B, testDir string) {
+	vfs := ts.vfsTest
+	_ = CreateManyFiles(b, ts.vfsSetup, testDir, 1000, 0)
+
+	b.ResetTimer()
+
+	b.Run("ReadDir", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			_, err := vfs.ReadDir(testDir)
+			RequireNoError(b, err, "ReadDir %s", testDir)
+		}
+	})
+}
+
 // BenchRemove benchmarks Remove function.
 func (ts *Suite) BenchRemove(b *testing.B, testDir string) {
 	vfs := ts.vfsTest
@@ -220,3 +267,89 @@ func (ts *Suite) BenchRemove(b *testing.B, testDir string) {
 		}
 	})
 }
+
+// BenchStat benchmarks Stat function.
+func (ts *Suite) BenchStat(b *testing.B, testDir string) {
+	vfs := ts.vfsTest
+	files := CreateManyFiles(b, ts.vfsSetup, testDir, 1000, 0)
+
+	b.ResetTimer()
+
+	b.Run("Stat", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			path := files[n%len(files)]
+
+			_, err := vfs.Stat(path)
+			RequireNoError(b, err, "Stat %s", path)
+		}
+	})
+}
+
+// BenchWalkDir benchmarks WalkDir function.
+func (ts *Suite) BenchWalkDir(b *testing.B, testDir string) {
+	vfs := ts.vfsTest
+	_ = CreateDeepTree(b, ts.vfsSetup, testDir, 100)
+
+	b.ResetTimer()
+
+	b.Run("WalkDir", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			err := vfs.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
+				return err
+			})
+			RequireNoError(b, err, "WalkDir %s", testDir)
+		}
+	})
+}
+
+// CreateManyFiles creates count files of size bytes in the directory dir and returns their paths.
+// The content of each file is deterministic and depends only on its index and size.
+// When used with a Suite, vfs should be the setup file system so that files can be created
+// even if the tested file system is read only.
+func CreateManyFiles(tb testing.TB, vfs avfs.VFSBase, dir string, count, size int) []string {
+	tb.Helper()
+
+	err := vfs.MkdirAll(dir, avfs.DefaultDirPerm)
+	RequireNoError(tb, err, "MkdirAll %s", dir)
+
+	files := make([]string, count)
+	buf := make([]byte, size)
+
+	for i := 0; i < count; i++ {
+		for j := range buf {
+			buf[j] = byte(i + j)
+		}
+
+		path := vfs.Join(dir, fmt.Sprintf("file%06d", i))
+
+		err = vfs.WriteFile(path, buf, avfs.DefaultFilePerm)
+		RequireNoError(tb, err, "WriteFile %s", path)
+
+		files[i] = path
+	}
+
+	return files
+}
+
+// CreateDeepTree creates a chain of depth nested directories in the directory dir,
+// each one containing an empty file, and returns the path of the deepest directory.
+// When used with a Suite, vfs should be the setup file system.
+func CreateDeepTree(tb testing.TB, vfs avfs.VFSBase, dir string, depth int) string {
+	tb.Helper()
+
+	path := dir
+
+	for i := 0; i < depth; i++ {
+		path = vfs.Join(path, fmt.Sprintf("dir%03d", i))
+
+		err := vfs.MkdirAll(path, avfs.DefaultDirPerm)
+		RequireNoError(tb, err, "MkdirAll %s", path)
+
+		fileName := vfs.Join(path, "file")
+
+		err = vfs.WriteFile(fileName, nil, avfs.DefaultFilePerm)
+		RequireNoError(tb, err, "WriteFile %s", fileName)
+	}
+
+	return path
+}
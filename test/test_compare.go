@@ -0,0 +1,187 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"sort"
+	"testing"
+
+	"github.com/avfs/avfs"
+)
+
+// CompareOptions defines the options used by CompareTrees and EqualTrees.
+type CompareOptions struct {
+	IgnoreMode    bool // IgnoreMode ignores the permission bits of files and directories.
+	IgnoreModTime bool // IgnoreModTime ignores the modification times of files and directories.
+	IgnoreOwner   bool // IgnoreOwner ignores the user and group ids of files and directories.
+}
+
+// treeEntry is an entry of a tree compared by CompareTrees.
+type treeEntry struct {
+	path string      // path is the path of the entry in its file system.
+	rel  string      // rel is the slash separated path relative to the root of the tree.
+	info fs.FileInfo // info is the file information returned by Lstat.
+}
+
+// EqualTrees reports an error if the tree rooted at aRoot in the file system a
+// is not identical to the tree rooted at bRoot in the file system b.
+// It returns true if both trees are identical.
+func EqualTrees(tb testing.TB, a avfs.VFSBase, aRoot string, b avfs.VFSBase, bRoot string, opts CompareOptions) bool {
+	tb.Helper()
+
+	err := CompareTrees(a, aRoot, b, bRoot, opts)
+	if err != nil {
+		tb.Errorf("EqualTrees : %v", err)
+
+		return false
+	}
+
+	return true
+}
+
+// CompareTrees compares the tree rooted at aRoot in the file system a
+// to the tree rooted at bRoot in the file system b and returns an error
+// describing the first divergence, or nil if both trees are identical.
+// The structure, the types, the contents of files (by hash), the targets of symbolic links,
+// the permissions, the modification times and the owners are compared, depending on opts.
+func CompareTrees(a avfs.VFSBase, aRoot string, b avfs.VFSBase, bRoot string, opts CompareOptions) error {
+	aEntries, err := treeEntries(a, aRoot)
+	if err != nil {
+		return err
+	}
+
+	bEntries, err := treeEntries(b, bRoot)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(aEntries) || i < len(bEntries); i++ {
+		switch {
+		case i >= len(bEntries):
+			return fmt.Errorf("%s has no counterpart in %s", aEntries[i].path, bRoot)
+		case i >= len(aEntries):
+			return fmt.Errorf("%s has no counterpart in %s", bEntries[i].path, aRoot)
+		}
+
+		ae, be := aEntries[i], bEntries[i]
+
+		switch {
+		case ae.rel < be.rel:
+			return fmt.Errorf("%s has no counterpart in %s", ae.path, bRoot)
+		case ae.rel > be.rel:
+			return fmt.Errorf("%s has no counterpart in %s", be.path, aRoot)
+		}
+
+		if err = compareEntries(a, ae, b, be, opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// treeEntries returns the entries of the tree rooted at root, sorted by relative path.
+func treeEntries(vfs avfs.VFSBase, root string) ([]treeEntry, error) {
+	var entries []treeEntry
+
+	err := avfs.WalkDir(vfs, root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := vfs.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := vfs.Lstat(path)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, treeEntry{path: path, rel: vfs.ToSlash(rel), info: info})
+
+		return nil
+	})
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].rel < entries[j].rel })
+
+	return entries, err
+}
+
+// compareEntries compares two entries having the same relative path.
+func compareEntries(a avfs.VFSBase, ae treeEntry, b avfs.VFSBase, be treeEntry, opts CompareOptions) error {
+	aMode, bMode := ae.info.Mode(), be.info.Mode()
+
+	if aMode.Type() != bMode.Type() {
+		return fmt.Errorf("%s has type %s, %s has type %s", ae.path, aMode.Type(), be.path, bMode.Type())
+	}
+
+	if !opts.IgnoreMode && aMode != bMode {
+		return fmt.Errorf("%s has mode %s, %s has mode %s", ae.path, aMode, be.path, bMode)
+	}
+
+	if !opts.IgnoreModTime && !aMode.IsDir() && !ae.info.ModTime().Equal(be.info.ModTime()) {
+		return fmt.Errorf("%s has modification time %s, %s has modification time %s",
+			ae.path, ae.info.ModTime(), be.path, be.info.ModTime())
+	}
+
+	if !opts.IgnoreOwner {
+		aSys, bSys := a.ToSysStat(ae.info), b.ToSysStat(be.info)
+		if aSys.Uid() != bSys.Uid() || aSys.Gid() != bSys.Gid() {
+			return fmt.Errorf("%s has owner %d:%d, %s has owner %d:%d",
+				ae.path, aSys.Uid(), aSys.Gid(), be.path, bSys.Uid(), bSys.Gid())
+		}
+	}
+
+	switch {
+	case aMode&fs.ModeSymlink != 0:
+		aLink, err := a.Readlink(ae.path)
+		if err != nil {
+			return err
+		}
+
+		bLink, err := b.Readlink(be.path)
+		if err != nil {
+			return err
+		}
+
+		if aLink != bLink {
+			return fmt.Errorf("%s links to %s, %s links to %s", ae.path, aLink, be.path, bLink)
+		}
+	case aMode.IsRegular():
+		aSum, err := avfs.HashFile(a, ae.path, sha256.New())
+		if err != nil {
+			return err
+		}
+
+		bSum, err := avfs.HashFile(b, be.path, sha256.New())
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(aSum, bSum) {
+			return fmt.Errorf("%s and %s have different contents", ae.path, be.path)
+		}
+	}
+
+	return nil
+}
@@ -15,4 +15,7 @@
 //
 
 // Package test implements a test suite available to all file systems.
+//
+// File systems implementing only some features can be validated with Suite.RunForFeatures,
+// which skips the tests requiring features not available in the tested file system.
 package test
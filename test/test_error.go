@@ -17,6 +17,7 @@
 package test
 
 import (
+	"errors"
 	"io/fs"
 	"os"
 	"reflect"
@@ -39,6 +40,7 @@ type assertError struct {
 	wantOld        string
 	wantNew        string
 	wantErrs       []error
+	wantFeature    avfs.Features
 	IsLinkError    bool
 }
 
@@ -77,6 +79,10 @@ func (ae *assertError) Test() *assertError {
 		return ae
 	}
 
+	if ae.wantFeature != 0 && !errors.Is(ae.err, avfs.ErrFeatureMissing(ae.wantFeature)) {
+		ae.tb.Errorf("want error to wrap missing feature %s, got %v", ae.wantFeature, ae.err)
+	}
+
 	if ae.IsLinkError {
 		return ae.testLinkError()
 	}
@@ -162,6 +168,13 @@ func (ae *assertError) testPathError() *assertError {
 	return ae
 }
 
+// FeatureMissing sets the expected missing feature wrapped by the error.
+func (ae *assertError) FeatureMissing(feat avfs.Features) *assertError {
+	ae.wantFeature = feat
+
+	return ae
+}
+
 // GoVersion sets the expected Go version.
 func (ae *assertError) GoVersion(goVersions ...string) *assertError {
 	ae.wantGoVersions = goVersions
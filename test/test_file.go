@@ -34,12 +34,14 @@ func (ts *Suite) TestFile(t *testing.T) {
 		ts.TestFileCloseWrite,
 		ts.TestFileCloseRead,
 		ts.TestFileFd,
+		ts.TestFileFlag,
 		ts.TestFileName,
 		ts.TestFileRead,
 		ts.TestFileReadAt,
 		ts.TestFileReadDir,
 		ts.TestFileReaddirnames,
 		ts.TestFileSeek,
+		ts.TestFileSetDeadline,
 		ts.TestFileStat,
 		ts.TestFileSync,
 		ts.TestFileTruncate,
@@ -265,6 +267,52 @@ func (ts *Suite) TestFileFd(t *testing.T, testDir string) {
 	}
 }
 
+// TestFileFlag tests CanRead, CanWrite and Flag functions of files implementing avfs.OpenFlagger.
+func (ts *Suite) TestFileFlag(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		t.Skip("TestFileFlag : skipping test on a read only file system")
+	}
+
+	existingFile := ts.existingFile(t, testDir, nil)
+
+	for _, ft := range []struct {
+		flag              int
+		canRead, canWrite bool
+	}{
+		{flag: os.O_RDONLY, canRead: true, canWrite: false},
+		{flag: os.O_WRONLY, canRead: false, canWrite: true},
+		{flag: os.O_RDWR, canRead: true, canWrite: true},
+		{flag: os.O_RDWR | os.O_APPEND, canRead: true, canWrite: true},
+	} {
+		f, err := vfs.OpenFile(existingFile, ft.flag, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "OpenFile %s", existingFile)
+
+		of, ok := f.(avfs.OpenFlagger)
+		if !ok {
+			_ = f.Close()
+
+			t.Skipf("TestFileFlag : skipping test, %T doesn't implement avfs.OpenFlagger", f)
+		}
+
+		if got := of.Flag(); got != ft.flag {
+			t.Errorf("Flag : want flag to be %#x, got %#x", ft.flag, got)
+		}
+
+		if got := of.CanRead(); got != ft.canRead {
+			t.Errorf("CanRead %#x : want CanRead to be %t, got %t", ft.flag, ft.canRead, got)
+		}
+
+		if got := of.CanWrite(); got != ft.canWrite {
+			t.Errorf("CanWrite %#x : want CanWrite to be %t, got %t", ft.flag, ft.canWrite, got)
+		}
+
+		err = f.Close()
+		RequireNoError(t, err, "Close %s", existingFile)
+	}
+}
+
 // TestFileName tests File.Name function.
 func (ts *Suite) TestFileName(t *testing.T, testDir string) {
 	f, wantName := ts.closedFile(t, testDir)
@@ -273,6 +321,25 @@ func (ts *Suite) TestFileName(t *testing.T, testDir string) {
 	if name != wantName {
 		t.Errorf("Name %s : want Name to be %s, got %s", wantName, wantName, name)
 	}
+
+	t.Run("FileNameNotCleaned", func(t *testing.T) {
+		vfs := ts.vfsTest
+		existingFile := ts.existingFile(t, testDir, nil)
+		existingDir := ts.existingDir(t, testDir)
+
+		sep := string(vfs.PathSeparator())
+		wantName := existingDir + sep + "." + sep + ".." + sep + vfs.Base(existingFile)
+
+		f, err := vfs.OpenFile(wantName, os.O_RDONLY, 0)
+		RequireNoError(t, err, "Open %s", wantName)
+
+		defer f.Close()
+
+		name := f.Name()
+		if name != wantName {
+			t.Errorf("Name %s : want Name to be %s, got %s", wantName, wantName, name)
+		}
+	})
 }
 
 // FileNilPtr test calls to File methods when f is a nil File.
@@ -790,6 +857,103 @@ func (ts *Suite) TestFileSeek(t *testing.T, testDir string) {
 	})
 }
 
+// TestFileSetDeadline tests File.SetDeadline, File.SetReadDeadline and File.SetWriteDeadline functions.
+func (ts *Suite) TestFileSetDeadline(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if !vfs.HasFeature(avfs.FeatDeadline) {
+		return
+	}
+
+	data := []byte("deadline")
+	path := ts.existingFile(t, testDir, data)
+	past := time.Now().Add(-time.Second)
+
+	t.Run("FileSetReadDeadline", func(t *testing.T) {
+		f, err := vfs.OpenFile(path, os.O_RDONLY, 0)
+		RequireNoError(t, err, "Open %s", path)
+
+		defer f.Close()
+
+		err = f.SetReadDeadline(past)
+		RequireNoError(t, err, "SetReadDeadline %s", path)
+
+		buf := make([]byte, len(data))
+
+		_, err = f.Read(buf)
+		AssertPathError(t, err).Op("read").Path(path).Err(os.ErrDeadlineExceeded).Test()
+
+		_, err = f.ReadAt(buf, 0)
+		AssertPathError(t, err).Op("read").Path(path).Err(os.ErrDeadlineExceeded).Test()
+
+		err = f.SetReadDeadline(time.Now().Add(time.Hour))
+		RequireNoError(t, err, "SetReadDeadline %s", path)
+
+		_, err = f.Read(buf)
+		RequireNoError(t, err, "Read %s", path)
+
+		err = f.SetDeadline(past)
+		RequireNoError(t, err, "SetDeadline %s", path)
+
+		err = f.SetDeadline(time.Time{})
+		RequireNoError(t, err, "SetDeadline %s", path)
+
+		_, err = f.ReadAt(buf, 0)
+		RequireNoError(t, err, "ReadAt %s", path)
+	})
+
+	t.Run("FileSetDeadlineClosed", func(t *testing.T) {
+		f, fileName := ts.closedFile(t, testDir)
+
+		err := f.SetDeadline(time.Time{})
+		AssertPathError(t, err).Op("SetDeadline").Path(fileName).Err(fs.ErrClosed).Test()
+
+		err = f.SetReadDeadline(time.Time{})
+		AssertPathError(t, err).Op("SetReadDeadline").Path(fileName).Err(fs.ErrClosed).Test()
+
+		err = f.SetWriteDeadline(time.Time{})
+		AssertPathError(t, err).Op("SetWriteDeadline").Path(fileName).Err(fs.ErrClosed).Test()
+	})
+
+	t.Run("FileSetDeadlineNonExisting", func(t *testing.T) {
+		f := ts.openedNonExistingFile(t, testDir)
+
+		err := f.SetDeadline(time.Time{})
+		AssertInvalid(t, err, "SetDeadline")
+	})
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		return
+	}
+
+	t.Run("FileSetWriteDeadline", func(t *testing.T) {
+		f, err := vfs.OpenFile(path, os.O_RDWR, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "OpenFile %s", path)
+
+		defer f.Close()
+
+		err = f.SetWriteDeadline(past)
+		RequireNoError(t, err, "SetWriteDeadline %s", path)
+
+		_, err = f.Write(data)
+		AssertPathError(t, err).Op("write").Path(path).Err(os.ErrDeadlineExceeded).Test()
+
+		_, err = f.WriteAt(data, 0)
+		AssertPathError(t, err).Op("write").Path(path).Err(os.ErrDeadlineExceeded).Test()
+
+		buf := make([]byte, len(data))
+
+		_, err = f.Read(buf)
+		RequireNoError(t, err, "Read %s", path)
+
+		err = f.SetWriteDeadline(time.Time{})
+		RequireNoError(t, err, "SetWriteDeadline %s", path)
+
+		_, err = f.Write(data)
+		RequireNoError(t, err, "Write %s", path)
+	})
+}
+
 // TestFileStat tests File.Stat function.
 func (ts *Suite) TestFileStat(t *testing.T, testDir string) {
 	dirs := ts.createSampleDirs(t, testDir)
@@ -1115,6 +1279,25 @@ func (ts *Suite) TestFileWrite(t *testing.T, testDir string) {
 		}
 	})
 
+	t.Run("FileWriteSeekPastEnd", func(t *testing.T) {
+		path := ts.emptyFile(t, testDir)
+
+		f, err := vfs.OpenFile(path, os.O_RDWR, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "OpenFile %s", path)
+
+		defer f.Close()
+
+		off := int64(len(data) * 2)
+
+		_, err = f.Seek(off, io.SeekStart)
+		RequireNoError(t, err, "Seek %s", path)
+
+		_, err = f.Write(data)
+		RequireNoError(t, err, "Write %s", path)
+
+		ts.assertHole(t, f, path, data, 0, off)
+	})
+
 	t.Run("FileWriteNonExisting", func(t *testing.T) {
 		f := ts.openedNonExistingFile(t, testDir)
 		buf := make([]byte, 0)
@@ -1172,6 +1355,36 @@ func (ts *Suite) TestFileWrite(t *testing.T, testDir string) {
 	})
 }
 
+// assertHole checks that the file f contains data at offset off, preceded by zeros from offset start
+// and that its size is off + len(data).
+func (ts *Suite) assertHole(t *testing.T, f avfs.File, path string, data []byte, start, off int64) {
+	t.Helper()
+
+	wantSize := off + int64(len(data))
+
+	info, err := f.Stat()
+	RequireNoError(t, err, "Stat %s", path)
+
+	if info.Size() != wantSize {
+		t.Errorf("Stat %s : want size to be %d, got %d", path, wantSize, info.Size())
+	}
+
+	got := make([]byte, wantSize)
+
+	_, err = f.ReadAt(got, 0)
+	RequireNoError(t, err, "ReadAt %s", path)
+
+	for i := start; i < off; i++ {
+		if got[i] != 0 {
+			t.Fatalf("ReadAt %s : want byte %d of the hole to be 0, got %d", path, i, got[i])
+		}
+	}
+
+	if !bytes.Equal(got[off:], data) {
+		t.Errorf("ReadAt %s : want data to be %s, got %s", path, data, got[off:])
+	}
+}
+
 // TestFileWriteAt tests File.WriteAt function.
 func (ts *Suite) TestFileWriteAt(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
@@ -1269,6 +1482,43 @@ func (ts *Suite) TestFileWriteAt(t *testing.T, testDir string) {
 		}
 	})
 
+	t.Run("FileWriteAtHole", func(t *testing.T) {
+		path := ts.emptyFile(t, testDir)
+
+		f, err := vfs.OpenFile(path, os.O_RDWR, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "OpenFile %s", path)
+
+		defer f.Close()
+
+		off := int64(len(data) * 2)
+
+		_, err = f.WriteAt(data, off)
+		RequireNoError(t, err, "WriteAt %s", path)
+
+		ts.assertHole(t, f, path, data, 0, off)
+	})
+
+	t.Run("FileWriteAtHoleAfterTruncate", func(t *testing.T) {
+		path := ts.existingFile(t, testDir, data)
+
+		f, err := vfs.OpenFile(path, os.O_RDWR, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "OpenFile %s", path)
+
+		defer f.Close()
+
+		const size = 3
+
+		err = f.Truncate(size)
+		RequireNoError(t, err, "Truncate %s", path)
+
+		off := int64(len(data) * 2)
+
+		_, err = f.WriteAt(data, off)
+		RequireNoError(t, err, "WriteAt %s", path)
+
+		ts.assertHole(t, f, path, data, size, off)
+	})
+
 	t.Run("FileWriteAtReadOnly", func(t *testing.T) {
 		path := ts.existingFile(t, testDir, data)
 
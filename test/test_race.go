@@ -17,10 +17,13 @@
 package test
 
 import (
+	"bytes"
+	"fmt"
 	"os"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/avfs/avfs"
 )
@@ -35,9 +38,11 @@ func (ts *Suite) TestRace(t *testing.T) {
 	}
 
 	ts.RunTests(t, UsrTest,
+		ts.RaceAcquireLockStale,
 		ts.RaceCreate,
 		ts.RaceCreateTemp,
 		ts.RaceFileClose,
+		ts.RaceFileReadAtWriteAt,
 		ts.RaceMkdir,
 		ts.RaceMkdirAll,
 		ts.RaceMkdirTemp,
@@ -49,6 +54,29 @@ func (ts *Suite) TestRace(t *testing.T) {
 		ts.RaceMkdirRemoveAll)
 }
 
+// RaceAcquireLockStale tests data race conditions for avfs.AcquireLockStale breaking a stale lock.
+func (ts *Suite) RaceAcquireLockStale(t *testing.T, testDir string) {
+	const staleAge = time.Hour
+
+	vfs := ts.vfsTest
+	path := vfs.Join(testDir, "lock")
+
+	_, err := avfs.AcquireLock(vfs, path)
+	RequireNoError(t, err, "AcquireLock %s", path)
+
+	old := time.Now().Add(-2 * staleAge)
+
+	err = vfs.Chtimes(path, old, old)
+	RequireNoError(t, err, "Chtimes %s", path)
+
+	// Only one caller breaks the stale lock and acquires it, the lock acquired again is never removed.
+	ts.raceFunc(t, RaceOneOk, func() error {
+		_, err := avfs.AcquireLockStale(vfs, path, staleAge)
+
+		return err
+	})
+}
+
 // RaceCreate tests data race conditions for Create.
 func (ts *Suite) RaceCreate(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
@@ -197,6 +225,58 @@ func (ts *Suite) RaceFileClose(t *testing.T, testDir string) {
 	ts.raceFunc(t, RaceOneOk, f.Close)
 }
 
+// RaceFileReadAtWriteAt tests data race conditions for File.ReadAt and File.WriteAt
+// on the same file descriptor. Each block of the file is written as a whole by WriteAt,
+// for non-real file systems, ReadAt must never return a partially written block.
+func (ts *Suite) RaceFileReadAtWriteAt(t *testing.T, testDir string) {
+	const (
+		blockSize = 64
+		nbBlocks  = 16
+	)
+
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		return
+	}
+
+	f, fileName := ts.openedEmptyFile(t, testDir)
+
+	defer f.Close()
+
+	_, err := f.WriteAt(make([]byte, blockSize*nbBlocks), 0)
+	RequireNoError(t, err, "WriteAt %s", fileName)
+
+	var count uint32
+
+	ts.raceFunc(t, RaceAllOk, func() error {
+		i := atomic.AddUint32(&count, 1)
+		off := int64(i%nbBlocks) * blockSize
+
+		_, err := f.WriteAt(bytes.Repeat([]byte{byte(i)}, blockSize), off)
+
+		return err
+	}, func() error {
+		i := atomic.AddUint32(&count, 1)
+		off := int64(i%(nbBlocks-1)) * blockSize
+		buf := make([]byte, 2*blockSize)
+
+		_, err := f.ReadAt(buf, off)
+		if err != nil || vfs.HasFeature(avfs.FeatRealFS) {
+			return err
+		}
+
+		for b := 0; b < len(buf); b += blockSize {
+			block := buf[b : b+blockSize]
+			if !bytes.Equal(block, bytes.Repeat(block[:1], blockSize)) {
+				return fmt.Errorf("ReadAt %s : partially written block at offset %d", fileName, off+int64(b))
+			}
+		}
+
+		return nil
+	})
+}
+
 // RaceMkdirRemoveAll test data race conditions for MkdirAll and RemoveAll.
 func (ts *Suite) RaceMkdirRemoveAll(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
@@ -50,6 +50,28 @@ func NewSuiteFS(tb testing.TB, vfsSetup, vfsTest avfs.VFSBase) *Suite {
 	return ts
 }
 
+// NewSuitePaths creates a new test suite for the path functions of a file system.
+// Unlike NewSuiteFS, the OS type of the file system can be different from the current OS type,
+// so only the path manipulation tests (see TestPaths) can be run with this suite.
+func NewSuitePaths(tb testing.TB, vfs avfs.VFSBase) *Suite {
+	if vfs == nil {
+		tb.Skip("NewSuitePaths : vfs must not be nil, skipping tests")
+	}
+
+	ts := &Suite{
+		vfsSetup:    vfs,
+		vfsTest:     vfs,
+		idm:         vfs.Idm(),
+		initUser:    vfs.User(),
+		testDataDir: testDataDir(),
+		maxRace:     100,
+	}
+
+	tb.Logf("VFS: Type=%s OSType=%s Features=%s", vfs.Type(), vfs.OSType(), vfs.Features())
+
+	return ts
+}
+
 // NewSuiteIdm creates a new test suite for an identity manager.
 func NewSuiteIdm(tb testing.TB, idm avfs.IdentityMgr) *Suite {
 	if idm == nil {
@@ -432,9 +454,20 @@ func (ts *Suite) RunTests(t *testing.T, userName string, testFuncs ...func(t *te
 	defer ts.setInitUser(t)
 
 	for _, tf := range testFuncs {
+		fn := funcName(tf)
+
+		if missing := ts.missingFeatures(fn); missing != 0 {
+			ts.skipped = append(ts.skipped, fn)
+
+			t.Run(fn, func(t *testing.T) {
+				t.Skipf("%s : missing %s", vfs.Type(), missing)
+			})
+
+			continue
+		}
+
 		ts.setUser(t, userName)
 
-		fn := funcName(tf)
 		testDir := vfs.Join(ts.rootDir, fn)
 
 		ts.createDir(t, testDir, avfs.DefaultDirPerm)
@@ -450,6 +483,58 @@ func (ts *Suite) RunTests(t *testing.T, userName string, testFuncs ...func(t *te
 	ts.removeDir(t, ts.rootDir)
 }
 
+// testFeatures are the features required by test functions, indexed by test function name.
+// Test functions not listed here don't require any feature.
+// Only RunForFeatures skips tests with missing features, otherwise each test checks the features itself.
+var testFeatures = map[string]avfs.Features{ //nolint:gochecknoglobals // Read only map of required features.
+	"TestChown":             avfs.FeatIdentityMgr,
+	"TestChownR":            avfs.FeatIdentityMgr,
+	"TestCreateHomeDir":     avfs.FeatIdentityMgr,
+	"TestEvalSymlink":       avfs.FeatSymlink,
+	"TestFileChown":         avfs.FeatIdentityMgr,
+	"TestFileSetDeadline":   avfs.FeatDeadline,
+	"TestIsSymlink":         avfs.FeatSymlink,
+	"TestLchmod":            avfs.FeatLchmod,
+	"TestLchown":            avfs.FeatIdentityMgr,
+	"TestLink":              avfs.FeatHardlink,
+	"TestReadlink":          avfs.FeatSymlink,
+	"TestRelSymlink":        avfs.FeatSymlink,
+	"TestRenameFlags":       avfs.FeatRenameFlags,
+	"TestResolvePath":       avfs.FeatSymlink,
+	"TestSetUserByName":     avfs.FeatIdentityMgr,
+	"TestSymlink":           avfs.FeatSymlink,
+	"TestWalkFollow":        avfs.FeatSymlink,
+	"TestWriteOnReadOnlyFS": avfs.FeatReadOnly,
+	"TestXattr":             avfs.FeatXattr,
+}
+
+// RunForFeatures runs all the tests like TestVFSAll, but the test functions requiring
+// features not available in the tested file system are skipped (see testFeatures).
+// This is useful to validate file systems implementing only some features.
+func (ts *Suite) RunForFeatures(t *testing.T) {
+	ts.forFeatures = true
+	ts.skipped = nil
+
+	defer func() { ts.forFeatures = false }()
+
+	ts.TestVFSAll(t)
+}
+
+// Skipped returns the names of the test functions skipped by the last call to RunForFeatures.
+func (ts *Suite) Skipped() []string {
+	return ts.skipped
+}
+
+// missingFeatures returns the features required by the test function fn
+// and missing in the tested file system if the tests are run by RunForFeatures.
+func (ts *Suite) missingFeatures(fn string) avfs.Features {
+	if !ts.forFeatures {
+		return 0
+	}
+
+	return ts.vfsTest.MissingFeatures(testFeatures[fn])
+}
+
 // setUser sets the test user to userName.
 func (ts *Suite) setUser(tb testing.TB, userName string) {
 	vfs := ts.vfsTest
@@ -476,6 +561,23 @@ func testDataDir() string {
 	return filepath.Join(dir, "testdata")
 }
 
+// TestPaths runs the path manipulation tests, they only depend on the OS type of the file system.
+func (ts *Suite) TestPaths(t *testing.T) {
+	ts.RunTests(t, UsrTest,
+		ts.TestAbs,
+		ts.TestBase,
+		ts.TestClean,
+		ts.TestDir,
+		ts.TestFromToSlash,
+		ts.TestIsAbs,
+		ts.TestJoin,
+		ts.TestMatch,
+		ts.TestPathSeparator,
+		ts.TestRel,
+		ts.TestSplit,
+		ts.TestSplitAbs)
+}
+
 // TestVFSAll runs all file system tests.
 func (ts *Suite) TestVFSAll(t *testing.T) {
 	ts.TestVFS(t)
@@ -0,0 +1,87 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package test
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/avfs/avfs"
+)
+
+// ErrSharedDirReleased is returned by SharedDir.Acquire and SharedDir.Release
+// when all the references to the shared directory have already been released.
+var ErrSharedDirReleased = errors.New("shared directory already released")
+
+// SharedDir is a reference counted temporary directory that can be shared between tests running in parallel.
+// The directory is removed when the last reference is released.
+type SharedDir struct {
+	vfs  avfs.VFSBase // vfs is the file system of the directory.
+	dir  string       // dir is the path of the directory.
+	mu   sync.Mutex   // mu is the mutex used to access refs.
+	refs int          // refs is the number of references to the directory.
+}
+
+// SharedTempDir creates a new temporary directory in the default directory for temporary files
+// (see MkdirTemp) and returns a SharedDir holding one reference to it.
+func SharedTempDir(vfs avfs.VFSBase, prefix string) (*SharedDir, error) {
+	dir, err := vfs.MkdirTemp("", prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SharedDir{vfs: vfs, dir: dir, refs: 1}, nil
+}
+
+// Acquire adds a reference to the shared directory, each call must be followed by a call to Release.
+func (sd *SharedDir) Acquire() error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	if sd.refs == 0 {
+		return ErrSharedDirReleased
+	}
+
+	sd.refs++
+
+	return nil
+}
+
+// Dir returns the path of the shared directory.
+func (sd *SharedDir) Dir() string {
+	return sd.dir
+}
+
+// Release removes a reference to the shared directory,
+// the directory and its content are removed when the last reference is released.
+func (sd *SharedDir) Release() error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+
+	switch sd.refs {
+	case 0:
+		return ErrSharedDirReleased
+	case 1:
+		sd.refs = 0
+
+		return sd.vfs.RemoveAll(sd.dir)
+	default:
+		sd.refs--
+
+		return nil
+	}
+}
@@ -38,6 +38,8 @@ type Suite struct {
 	rootDir     string             // rootDir is the root directory for tests and benchmarks.
 	maxRace     int                // maxRace is the maximum number of concurrent goroutines used in race tests.
 	canTestPerm bool               // canTestPerm indicates if permissions can be tested.
+	forFeatures bool               // forFeatures indicates if tests requiring missing features are skipped (see RunForFeatures).
+	skipped     []string           // skipped are the names of the tests skipped for missing features.
 }
 
 // PermTests regroups all tests for a specific function.
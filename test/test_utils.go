@@ -19,15 +19,20 @@ package test
 import (
 	"bytes"
 	"crypto/sha512"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/hookfs"
 	"github.com/avfs/avfs/vfs/memfs"
 )
 
@@ -37,15 +42,54 @@ type pathTest struct {
 
 func (ts *Suite) TestUtils(t *testing.T) {
 	ts.RunTests(t, UsrTest,
+		ts.TestAcquireLock,
+		ts.TestAncestors,
+		ts.TestAppendFile,
+		ts.TestChmodR,
+		ts.TestCommonPrefix,
 		ts.TestCopyFile,
+		ts.TestCreateDeepTree,
+		ts.TestCreateFiles,
+		ts.TestCreateManyFiles,
 		ts.TestDirExists,
+		ts.TestDirSize,
+		ts.TestEnsureDir,
+		ts.TestEqualTrees,
 		ts.TestExists,
+		ts.TestFileType,
+		ts.TestFprintf,
 		ts.TestHashFile,
 		ts.TestIsDir,
 		ts.TestIsEmpty,
 		ts.TestIsPathSeparator,
+		ts.TestJoinList,
+		ts.TestIsSymlink,
+		ts.TestLchmod,
+		ts.TestListByModTime,
+		ts.TestMkdirAllMode,
+		ts.TestMoveDir,
+		ts.TestOpenReaderWriter,
+		ts.TestReadDirFiltered,
+		ts.TestReadDirNames,
+		ts.TestReadFileLimit,
+		ts.TestRelSymlink,
+		ts.TestRemoveFiles,
+		ts.TestResolvePath,
 		ts.TestRndTree,
-		ts.TestUMask)
+		ts.TestSetExecutable,
+		ts.TestSharedTempDir,
+		ts.TestSplitList,
+		ts.TestTouch,
+		ts.TestUMask,
+		ts.TestWalkBreadthFirst,
+		ts.TestWalkFollow,
+		ts.TestWriteFileAtomic,
+		ts.TestWriteFileMkdirAll)
+
+	// Tests to be run as root
+	adminUser := ts.idm.AdminUser()
+	ts.RunTests(t, adminUser.Name(),
+		ts.TestChownR)
 }
 
 // TestAbs test Abs function.
@@ -179,6 +223,199 @@ func (ts *Suite) TestAbs(t *testing.T, testDir string) {
 	})
 }
 
+// TestAcquireLock tests avfs.AcquireLock and avfs.AcquireLockStale functions.
+func (ts *Suite) TestAcquireLock(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+	lockPath := vfs.Join(testDir, "lock")
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		_, err := avfs.AcquireLock(vfs, lockPath)
+		AssertPathError(t, err).Op("mkdir").Path(lockPath).ErrPermDenied().Test()
+
+		return
+	}
+
+	assertHeld := func(t *testing.T, want bool) {
+		t.Helper()
+
+		held, err := avfs.Exists(vfs, lockPath)
+		RequireNoError(t, err, "Exists %s", lockPath)
+
+		if held != want {
+			t.Errorf("Exists %s : want lock to be held %t, got %t", lockPath, want, held)
+		}
+	}
+
+	t.Run("AcquireLockHeld", func(t *testing.T) {
+		release, err := avfs.AcquireLock(vfs, lockPath)
+		RequireNoError(t, err, "AcquireLock %s", lockPath)
+
+		_, err = avfs.AcquireLock(vfs, lockPath)
+		if !errors.Is(err, fs.ErrExist) {
+			t.Errorf("AcquireLock %s : want error to be %v, got %v", lockPath, fs.ErrExist, err)
+		}
+
+		AssertPathError(t, err).Op("mkdir").Path(lockPath).
+			OSType(avfs.OsLinux).Err(avfs.ErrFileExists).Test()
+
+		release()
+		assertHeld(t, false)
+
+		// Releasing a lock twice is harmless.
+		release()
+
+		release, err = avfs.AcquireLock(vfs, lockPath)
+		RequireNoError(t, err, "AcquireLock %s", lockPath)
+
+		release()
+	})
+
+	t.Run("AcquireLockStale", func(t *testing.T) {
+		const staleAge = time.Hour
+
+		release1, err := avfs.AcquireLock(vfs, lockPath)
+		RequireNoError(t, err, "AcquireLock %s", lockPath)
+
+		_, err = avfs.AcquireLockStale(vfs, lockPath, staleAge)
+		if !errors.Is(err, fs.ErrExist) {
+			t.Errorf("AcquireLockStale %s : want error to be %v, got %v", lockPath, fs.ErrExist, err)
+		}
+
+		old := time.Now().Add(-2 * staleAge)
+
+		err = vfs.Chtimes(lockPath, old, old)
+		RequireNoError(t, err, "Chtimes %s", lockPath)
+
+		release2, err := avfs.AcquireLockStale(vfs, lockPath, staleAge)
+		RequireNoError(t, err, "AcquireLockStale %s", lockPath)
+
+		// The previous owner doesn't own the lock anymore and must not remove it.
+		release1()
+		assertHeld(t, true)
+
+		release2()
+		assertHeld(t, false)
+	})
+
+	t.Run("AcquireLockStaleConcurrent", func(t *testing.T) {
+		const staleAge = time.Hour
+
+		baseFS, ok := vfs.(avfs.VFS)
+		if !ok {
+			return
+		}
+
+		_, err := avfs.AcquireLock(vfs, lockPath)
+		RequireNoError(t, err, "AcquireLock %s", lockPath)
+
+		old := time.Now().Add(-2 * staleAge)
+
+		err = vfs.Chtimes(lockPath, old, old)
+		RequireNoError(t, err, "Chtimes %s", lockPath)
+
+		var (
+			release1 func()
+			err1     error
+			lstats   int
+		)
+
+		// The first caller finds the lock stale, then a second caller breaks the stale lock
+		// and acquires it before the first caller tries to break it.
+		hookFS := hookfs.New(baseFS, hookfs.Hooks{
+			After: func(op string, err error, results ...any) {
+				if op != avfs.FnLstat.String() {
+					return
+				}
+
+				lstats++
+				if lstats == 1 {
+					release1, err1 = avfs.AcquireLockStale(vfs, lockPath, staleAge)
+				}
+			},
+		})
+
+		_, err = avfs.AcquireLockStale(hookFS, lockPath, staleAge)
+		if !errors.Is(err, fs.ErrExist) {
+			t.Errorf("AcquireLockStale %s : want error to be %v, got %v", lockPath, fs.ErrExist, err)
+		}
+
+		RequireNoError(t, err1, "AcquireLockStale %s", lockPath)
+		assertHeld(t, true)
+
+		release1()
+		assertHeld(t, false)
+	})
+}
+
+// TestAncestors tests avfs.Ancestors function.
+func (ts *Suite) TestAncestors(t *testing.T, _ string) {
+	vfs := ts.vfsTest
+
+	cases := []struct {
+		path   string
+		want   []string
+		osType avfs.OSType
+	}{
+		{osType: avfs.OsLinux, path: "/", want: nil},
+		{osType: avfs.OsLinux, path: "/a", want: []string{"/"}},
+		{osType: avfs.OsLinux, path: "/a/b/c", want: []string{"/", "/a", "/a/b"}},
+		{osType: avfs.OsLinux, path: "/a//b/../c/", want: []string{"/", "/a"}},
+		{osType: avfs.OsLinux, path: "a/b", want: nil},
+		{osType: avfs.OsWindows, path: `C:\`, want: nil},
+		{osType: avfs.OsWindows, path: `C:`, want: []string{`C:\`}},
+		{osType: avfs.OsWindows, path: `C:\c`, want: []string{`C:\`, `C:`, `C:`}},
+		{osType: avfs.OsWindows, path: `C:/a/b`, want: []string{`C:\`, `C:`}},
+		{osType: avfs.OsWindows, path: `a`, want: nil},
+	}
+
+	for _, c := range cases {
+		if c.osType != vfs.OSType() {
+			continue
+		}
+
+		got := avfs.Ancestors(vfs, c.path)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("Ancestors %s : want %q, got %q", c.path, c.want, got)
+		}
+	}
+}
+
+// TestCommonPrefix tests avfs.CommonPrefix function.
+func (ts *Suite) TestCommonPrefix(t *testing.T, _ string) {
+	vfs := ts.vfsTest
+
+	cases := []struct {
+		paths  []string
+		want   string
+		osType avfs.OSType
+	}{
+		{osType: avfs.OsLinux, paths: nil, want: ""},
+		{osType: avfs.OsLinux, paths: []string{"/a/b"}, want: "/a/b"},
+		{osType: avfs.OsLinux, paths: []string{"/a/b/c", "/a/b/d"}, want: "/a/b"},
+		{osType: avfs.OsLinux, paths: []string{"/a/b/c", "/a/b/c/d", "/a/b/e"}, want: "/a/b"},
+		{osType: avfs.OsLinux, paths: []string{"/foo", "/foobar"}, want: "/"},
+		{osType: avfs.OsLinux, paths: []string{"/a/b/", "/a/./b/c"}, want: "/a/b"},
+		{osType: avfs.OsLinux, paths: []string{"/", "/a"}, want: "/"},
+		{osType: avfs.OsLinux, paths: []string{"/a", "b"}, want: ""},
+		{osType: avfs.OsWindows, paths: []string{`C:`, `C:\c`}, want: `C:`},
+		{osType: avfs.OsWindows, paths: []string{`C:oo`, `C:oobar`}, want: `C:\`},
+		{osType: avfs.OsWindows, paths: []string{`C:`, `D:`}, want: ""},
+		{osType: avfs.OsWindows, paths: []string{`C:`, `c:\c`}, want: `C:`},
+		{osType: avfs.OsWindows, paths: []string{`C:`, `a`}, want: ""},
+	}
+
+	for _, c := range cases {
+		if c.osType != vfs.OSType() {
+			continue
+		}
+
+		got := avfs.CommonPrefix(vfs, c.paths...)
+		if got != c.want {
+			t.Errorf("CommonPrefix %q : want %q, got %q", c.paths, c.want, got)
+		}
+	}
+}
+
 // TestBase tests Base function.
 func (ts *Suite) TestBase(t *testing.T, _ string) {
 	vfs := ts.vfsTest
@@ -292,9 +529,9 @@ func (ts *Suite) TestClean(t *testing.T, _ string) {
 		{`c:..\abc`, `c:..\abc`},
 		{`\`, `\`},
 		{`/`, `\`},
-		{`\\i\..\c$`, `\\i\..\c$`},
-		{`\\i\..\i\c$`, `\\i\..\i\c$`},
-		{`\\i\..\I\c$`, `\\i\..\I\c$`},
+		{`\\i\..\c$`, `\c$`},
+		{`\\i\..\i\c$`, `\i\c$`},
+		{`\\i\..\I\c$`, `\I\c$`},
 		{`\\host\share\foo\..\bar`, `\\host\share\bar`},
 		{`//host/share/foo/../baz`, `\\host\share\baz`},
 		{`\\host\share\foo\..\..\..\..\bar`, `\\host\share\bar`},
@@ -320,7 +557,7 @@ func (ts *Suite) TestClean(t *testing.T, _ string) {
 	tests := cleanTests
 	if vfs.OSType() == avfs.OsWindows {
 		for i := range tests {
-			tests[i].result = filepath.FromSlash(tests[i].result)
+			tests[i].result = vfs.FromSlash(tests[i].result)
 		}
 
 		tests = append(tests, winCleanTests...)
@@ -329,16 +566,199 @@ func (ts *Suite) TestClean(t *testing.T, _ string) {
 	}
 
 	for _, test := range tests {
-		if s := filepath.Clean(test.path); s != test.result {
+		if s := vfs.Clean(test.path); s != test.result {
 			t.Errorf("Clean(%q) = %q, want %q", test.path, s, test.result)
 		}
 
-		if s := filepath.Clean(test.result); s != test.result {
+		if s := vfs.Clean(test.result); s != test.result {
 			t.Errorf("Clean(%q) = %q, want %q", test.result, s, test.result)
 		}
 	}
 }
 
+// TestAppendFile tests avfs.AppendFile function.
+func (ts *Suite) TestAppendFile(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := ts.existingFile(t, testDir, nil)
+
+		err := avfs.AppendFile(vfs, path, []byte("data"), avfs.DefaultFilePerm)
+		AssertPathError(t, err).Op("open").Path(path).ErrPermDenied().Test()
+
+		return
+	}
+
+	t.Run("AppendFileNew", func(t *testing.T) {
+		path := vfs.Join(testDir, "appendFileNew")
+		chunks := []string{"first ", "second ", "third"}
+
+		for _, chunk := range chunks {
+			err := avfs.AppendFile(vfs, path, []byte(chunk), avfs.DefaultFilePerm)
+			RequireNoError(t, err, "AppendFile %s", path)
+		}
+
+		data, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if want := strings.Join(chunks, ""); string(data) != want {
+			t.Errorf("ReadFile %s : want content to be %q, got %q", path, want, data)
+		}
+	})
+
+	t.Run("AppendFileExisting", func(t *testing.T) {
+		path := ts.existingFile(t, testDir, []byte("existing"))
+
+		err := avfs.AppendFile(vfs, path, []byte(" appended"), avfs.DefaultFilePerm)
+		RequireNoError(t, err, "AppendFile %s", path)
+
+		data, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if want := "existing appended"; string(data) != want {
+			t.Errorf("ReadFile %s : want content to be %q, got %q", path, want, data)
+		}
+	})
+
+	t.Run("AppendFileNonExistingDir", func(t *testing.T) {
+		path := vfs.Join(ts.nonExistingFile(t, testDir), defaultFile)
+
+		err := avfs.AppendFile(vfs, path, []byte("data"), avfs.DefaultFilePerm)
+		AssertPathError(t, err).Op("open").Path(path).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinPathNotFound).Test()
+	})
+}
+
+// TestChmodR tests avfs.ChmodR function.
+func (ts *Suite) TestChmodR(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		err := avfs.ChmodR(vfs, testDir, avfs.DefaultDirPerm)
+		AssertPathError(t, err).Op("chmod").Path(testDir).ErrPermDenied().Test()
+
+		return
+	}
+
+	if vfs.OSType() == avfs.OsWindows {
+		return
+	}
+
+	_ = ts.createSampleDirs(t, testDir)
+	_ = ts.createSampleFiles(t, testDir)
+	_ = ts.createSampleSymlinks(t, testDir)
+
+	// assertModes checks the mode of every entry of the tree, symbolic links excepted.
+	assertModes := func(t *testing.T, wantMode fs.FileMode) {
+		t.Helper()
+
+		err := vfs.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
+			RequireNoError(t, err, "WalkDir %s", path)
+
+			info, err := vfs.Lstat(path)
+			RequireNoError(t, err, "Lstat %s", path)
+
+			if info.Mode()&fs.ModeSymlink == 0 && info.Mode().Perm() != wantMode {
+				t.Errorf("ChmodR %s : want mode to be %s, got %s", path, wantMode, info.Mode().Perm())
+			}
+
+			return nil
+		})
+		RequireNoError(t, err, "WalkDir %s", testDir)
+	}
+
+	t.Run("ChmodR", func(t *testing.T) {
+		const wantMode = fs.FileMode(0o750)
+
+		err := avfs.ChmodR(vfs, testDir, wantMode)
+		RequireNoError(t, err, "ChmodR %s", testDir)
+
+		assertModes(t, wantMode)
+	})
+
+	t.Run("ChmodRNoAccess", func(t *testing.T) {
+		// The directories are changed after their content.
+		const noAccessMode = fs.FileMode(0o600)
+
+		err := avfs.ChmodR(vfs, testDir, noAccessMode)
+		RequireNoError(t, err, "ChmodR %s", testDir)
+
+		info, err := vfs.Lstat(testDir)
+		RequireNoError(t, err, "Lstat %s", testDir)
+
+		if info.Mode().Perm() != noAccessMode {
+			t.Errorf("ChmodR %s : want mode to be %s, got %s", testDir, noAccessMode, info.Mode().Perm())
+		}
+
+		// The directories are changed before their content.
+		const wantMode = fs.FileMode(0o755)
+
+		err = avfs.ChmodR(vfs, testDir, wantMode)
+		RequireNoError(t, err, "ChmodR %s", testDir)
+
+		assertModes(t, wantMode)
+	})
+
+	t.Run("ChmodRNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		err := avfs.ChmodR(vfs, nonExistingFile, avfs.DefaultDirPerm)
+		AssertPathError(t, err).OpLstat().Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+}
+
+// TestChownR tests avfs.ChownR function.
+func (ts *Suite) TestChownR(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if !ts.canTestPerm && vfs.HasFeature(avfs.FeatRealFS) ||
+		vfs.HasFeature(avfs.FeatReadOnly) || avfs.CurrentOSType() == avfs.OsWindows {
+		err := avfs.ChownR(vfs, testDir, 0, 0)
+
+		AssertPathError(t, err).Op("lchown").Path(testDir).
+			OSType(avfs.OsLinux).Err(avfs.ErrOpNotPermitted).Test()
+
+		return
+	}
+
+	_ = ts.createSampleDirs(t, testDir)
+	_ = ts.createSampleFiles(t, testDir)
+	_ = ts.createSampleSymlinks(t, testDir)
+
+	// Without identity manager, the current user can't access the files of other users.
+	u := vfs.User()
+
+	if vfs.HasFeature(avfs.FeatIdentityMgr) {
+		var err error
+
+		u, err = vfs.Idm().LookupUser(UserInfos()[0].Name)
+		RequireNoError(t, err, "LookupUser")
+	}
+
+	wantUid, wantGid := u.Uid(), u.Gid()
+
+	err := avfs.ChownR(vfs, testDir, wantUid, wantGid)
+	RequireNoError(t, err, "ChownR %s", testDir)
+
+	err = vfs.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
+		RequireNoError(t, err, "WalkDir %s", path)
+
+		info, err := vfs.Lstat(path)
+		RequireNoError(t, err, "Lstat %s", path)
+
+		sst := vfs.ToSysStat(info)
+		if sst.Uid() != wantUid || sst.Gid() != wantGid {
+			t.Errorf("ChownR %s : want Uid=%d, Gid=%d, got Uid=%d, Gid=%d",
+				path, wantUid, wantGid, sst.Uid(), sst.Gid())
+		}
+
+		return nil
+	})
+	RequireNoError(t, err, "WalkDir %s", testDir)
+}
+
 // TestCopyFile tests avfs.CopyFile function.
 func (ts *Suite) TestCopyFile(t *testing.T, testDir string) {
 	const copyFile = "CopyFile"
@@ -401,6 +821,26 @@ func (ts *Suite) TestCopyFile(t *testing.T, testDir string) {
 			}
 		}
 	})
+
+	t.Run("CopyFileSmallAfterLarge", func(t *testing.T) {
+		large := bytes.Repeat([]byte("L"), 100*1024)
+		small := []byte("small")
+
+		for _, data := range [][]byte{large, small} {
+			srcPath := ts.existingFile(t, testDir, data)
+			dstPath := dstFS.Join(dstFS.TempDir(), srcFS.Base(srcPath))
+
+			err = avfs.CopyFile(dstFS, srcFS, dstPath, srcPath)
+			RequireNoError(t, err, "CopyFile (%s)%s, (%s)%s", dstFS.Type(), dstPath, srcFS.Type(), srcPath)
+
+			got, err := dstFS.ReadFile(dstPath)
+			RequireNoError(t, err, "ReadFile (%s)%s", dstFS.Type(), dstPath)
+
+			if !bytes.Equal(got, data) {
+				t.Errorf("CopyFile %s : want content of length %d, got %d", dstPath, len(data), len(got))
+			}
+		}
+	})
 }
 
 // TestMkSystemDirs tests CreateSystemDirs function.
@@ -513,6 +953,133 @@ func (ts *Suite) TestDir(t *testing.T, _ string) {
 	}
 }
 
+// TestCreateFiles tests avfs.CreateFiles function.
+func (ts *Suite) TestCreateFiles(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	files := map[string][]byte{
+		vfs.Join(testDir, "file1"):           []byte("file1"),
+		vfs.Join(testDir, "a", "b", "file2"): []byte("file2"),
+		vfs.Join(testDir, "a", "file3"):      nil,
+	}
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		err := avfs.CreateFiles(vfs, files, avfs.DefaultFilePerm)
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("CreateFiles : want error to be %v, got %v", fs.ErrPermission, err)
+		}
+
+		return
+	}
+
+	t.Run("CreateFiles", func(t *testing.T) {
+		err := avfs.CreateFiles(vfs, files, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "CreateFiles")
+
+		for path, want := range files {
+			content, err := vfs.ReadFile(path)
+			RequireNoError(t, err, "ReadFile %s", path)
+
+			if !bytes.Equal(content, want) {
+				t.Errorf("ReadFile %s : want content to be %q, got %q", path, want, content)
+			}
+		}
+	})
+
+	t.Run("CreateFilesPartialFailure", func(t *testing.T) {
+		parent := ts.existingFile(t, testDir, nil)
+		bad1 := vfs.Join(parent, "bad1")
+		bad2 := vfs.Join(parent, "bad2")
+		good := vfs.Join(testDir, "good")
+
+		err := avfs.CreateFiles(vfs, map[string][]byte{bad1: nil, good: nil, bad2: nil}, avfs.DefaultFilePerm)
+
+		joinErr, ok := err.(interface{ Unwrap() []error })
+		if !ok {
+			t.Fatalf("CreateFiles : want joined errors, got %v", err)
+		}
+
+		if errs := joinErr.Unwrap(); len(errs) != 2 {
+			t.Errorf("CreateFiles : want 2 errors, got %d : %v", len(errs), err)
+		}
+
+		_, err = vfs.Stat(good)
+		RequireNoError(t, err, "Stat %s", good)
+	})
+}
+
+// TestCreateDeepTree tests CreateDeepTree function.
+func (ts *Suite) TestCreateDeepTree(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	const depth = 10
+
+	deepest := CreateDeepTree(t, ts.vfsSetup, testDir, depth)
+
+	info, err := vfs.Stat(deepest)
+	RequireNoError(t, err, "Stat %s", deepest)
+
+	if !info.IsDir() {
+		t.Errorf("Stat %s : want a directory", deepest)
+	}
+
+	nbDirs := 0
+
+	err = vfs.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
+		if d != nil && d.IsDir() && path != testDir {
+			nbDirs++
+		}
+
+		return err
+	})
+	RequireNoError(t, err, "WalkDir %s", testDir)
+
+	if nbDirs != depth {
+		t.Errorf("WalkDir : want number of directories to be %d, got %d", depth, nbDirs)
+	}
+}
+
+// TestCreateManyFiles tests CreateManyFiles function.
+func (ts *Suite) TestCreateManyFiles(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	const (
+		count = 20
+		size  = 300
+	)
+
+	files := CreateManyFiles(t, ts.vfsSetup, testDir, count, size)
+	if len(files) != count {
+		t.Fatalf("CreateManyFiles : want %d files, got %d", count, len(files))
+	}
+
+	entries, err := vfs.ReadDir(testDir)
+	RequireNoError(t, err, "ReadDir %s", testDir)
+
+	if len(entries) != count {
+		t.Errorf("ReadDir : want %d entries, got %d", count, len(entries))
+	}
+
+	for i, path := range files {
+		buf, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if len(buf) != size {
+			t.Errorf("ReadFile %s : want size to be %d, got %d", path, size, len(buf))
+
+			continue
+		}
+
+		for j, b := range buf {
+			if b != byte(i+j) {
+				t.Errorf("ReadFile %s : want byte %d to be %d, got %d", path, j, byte(i+j), b)
+
+				break
+			}
+		}
+	}
+}
+
 // TestDirExists tests avfs.DirExists function.
 func (ts *Suite) TestDirExists(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
@@ -549,27 +1116,192 @@ func (ts *Suite) TestDirExists(t *testing.T, testDir string) {
 	})
 }
 
-// TestExists tests avfs.Exists function.
-func (ts *Suite) TestExists(t *testing.T, testDir string) {
+// TestDirSize tests avfs.DirSize and avfs.DirSizeSkipErrors functions.
+func (ts *Suite) TestDirSize(t *testing.T, testDir string) {
+	vfsSetup := ts.vfsSetup
 	vfs := ts.vfsTest
 
-	t.Run("ExistsDir", func(t *testing.T) {
-		ok, err := avfs.Exists(vfs, testDir)
-		RequireNoError(t, err, "Exists %s", testDir)
+	dir := vfs.Join(testDir, "size")
+	subDir := vfs.Join(dir, "sub")
+	file1 := vfs.Join(dir, "file1")
+	file2 := vfs.Join(subDir, "file2")
+	outside := vfs.Join(testDir, "outside")
 
-		if !ok {
-			t.Error("Exists : want DirExists to be true, got false")
-		}
-	})
+	err := vfsSetup.MkdirAll(subDir, avfs.DefaultDirPerm)
+	RequireNoError(t, err, "MkdirAll %s", subDir)
 
-	t.Run("ExistsFile", func(t *testing.T) {
-		existingFile := ts.emptyFile(t, testDir)
+	for path, size := range map[string]int{file1: 3, file2: 5, outside: 100} {
+		err = vfsSetup.WriteFile(path, make([]byte, size), avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", path)
+	}
 
-		ok, err := avfs.Exists(vfs, existingFile)
-		RequireNoError(t, err, "DirExists %s", existingFile)
+	const wantSize = 8
 
-		if !ok {
-			t.Error("Exists : want DirExists to be true, got false")
+	if vfsSetup.HasFeature(avfs.FeatHardlink) {
+		link := vfs.Join(subDir, "link")
+
+		err = vfsSetup.Link(file1, link)
+		RequireNoError(t, err, "Link %s %s", file1, link)
+	}
+
+	if vfsSetup.HasFeature(avfs.FeatSymlink) {
+		symlink := vfs.Join(dir, "symlink")
+
+		err = vfsSetup.Symlink(outside, symlink)
+		RequireNoError(t, err, "Symlink %s %s", outside, symlink)
+	}
+
+	t.Run("DirSize", func(t *testing.T) {
+		size, err := avfs.DirSize(vfs, dir)
+		RequireNoError(t, err, "DirSize %s", dir)
+
+		if size != wantSize {
+			t.Errorf("DirSize : want size to be %d, got %d", wantSize, size)
+		}
+	})
+
+	t.Run("DirSizeSkipErrors", func(t *testing.T) {
+		size, err := avfs.DirSizeSkipErrors(vfs, dir)
+		RequireNoError(t, err, "DirSizeSkipErrors %s", dir)
+
+		if size != wantSize {
+			t.Errorf("DirSizeSkipErrors : want size to be %d, got %d", wantSize, size)
+		}
+	})
+
+	t.Run("DirSizeNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		_, err := avfs.DirSize(vfs, nonExistingFile)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("DirSize : want error to be %v, got %v", fs.ErrNotExist, err)
+		}
+
+		_, err = avfs.DirSizeSkipErrors(vfs, nonExistingFile)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("DirSizeSkipErrors : want error to be %v, got %v", fs.ErrNotExist, err)
+		}
+	})
+}
+
+// TestEnsureDir tests avfs.EnsureDir function.
+func (ts *Suite) TestEnsureDir(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := vfs.Join(testDir, "a")
+
+		err := avfs.EnsureDir(vfs, path, avfs.DefaultDirPerm)
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("EnsureDir : want error to be %v, got %v", fs.ErrPermission, err)
+		}
+
+		return
+	}
+
+	t.Run("EnsureDirAbsent", func(t *testing.T) {
+		path := vfs.Join(testDir, "a", "b")
+
+		err := avfs.EnsureDir(vfs, path, avfs.DefaultDirPerm)
+		RequireNoError(t, err, "EnsureDir %s", path)
+
+		info, err := vfs.Stat(path)
+		RequireNoError(t, err, "Stat %s", path)
+
+		if !info.IsDir() {
+			t.Errorf("EnsureDir %s : want a directory, got mode %s", path, info.Mode())
+		}
+	})
+
+	t.Run("EnsureDirExisting", func(t *testing.T) {
+		path := ts.existingDir(t, testDir)
+
+		err := avfs.EnsureDir(vfs, path, avfs.DefaultDirPerm)
+		RequireNoError(t, err, "EnsureDir %s", path)
+	})
+
+	t.Run("EnsureDirFile", func(t *testing.T) {
+		path := ts.existingFile(t, testDir, nil)
+
+		err := avfs.EnsureDir(vfs, path, avfs.DefaultDirPerm)
+		AssertPathError(t, err).Op("mkdir").Path(path).Err(avfs.ErrNotADirectory).Test()
+	})
+}
+
+// TestEqualTrees tests CompareTrees and EqualTrees functions.
+func (ts *Suite) TestEqualTrees(t *testing.T, testDir string) {
+	if ts.vfsSetup.HasFeature(avfs.FeatReadOnly) {
+		t.Skip("EqualTrees needs a writable file system to copy the sample tree")
+	}
+
+	vfsSetup := ts.vfsSetup
+	vfs := ts.vfsTest
+	srcDir := vfs.Join(testDir, "src")
+	dstDir := vfs.Join(testDir, "dst")
+
+	ts.createSampleDirs(t, srcDir)
+	files := ts.createSampleFiles(t, srcDir)
+	ts.createSampleSymlinks(t, srcDir)
+
+	err := avfs.CopyDir(vfsSetup, vfsSetup, dstDir, srcDir)
+	RequireNoError(t, err, "CopyDir %s, %s", dstDir, srcDir)
+
+	opts := CompareOptions{IgnoreMode: true, IgnoreModTime: true, IgnoreOwner: true}
+
+	t.Run("EqualTreesSame", func(t *testing.T) {
+		EqualTrees(t, vfs, srcDir, vfs, dstDir, opts)
+	})
+
+	t.Run("EqualTreesContent", func(t *testing.T) {
+		rel, _ := vfs.Rel(srcDir, files[0].Path)
+		path := vfs.Join(dstDir, rel)
+
+		err = vfsSetup.WriteFile(path, []byte("mutated"), avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", path)
+
+		err = CompareTrees(vfs, srcDir, vfs, dstDir, opts)
+		if err == nil || !strings.Contains(err.Error(), files[0].Path) || !strings.Contains(err.Error(), path) {
+			t.Errorf("CompareTrees : want an error naming %s and %s, got %v", files[0].Path, path, err)
+		}
+
+		err = vfsSetup.WriteFile(path, files[0].Content, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", path)
+	})
+
+	t.Run("EqualTreesMissing", func(t *testing.T) {
+		path := vfs.Join(srcDir, "extra")
+
+		err = vfsSetup.WriteFile(path, nil, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", path)
+
+		err = CompareTrees(vfs, srcDir, vfs, dstDir, opts)
+		if err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("CompareTrees : want an error naming %s, got %v", path, err)
+		}
+	})
+}
+
+// TestExists tests avfs.Exists function.
+func (ts *Suite) TestExists(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	t.Run("ExistsDir", func(t *testing.T) {
+		ok, err := avfs.Exists(vfs, testDir)
+		RequireNoError(t, err, "Exists %s", testDir)
+
+		if !ok {
+			t.Error("Exists : want DirExists to be true, got false")
+		}
+	})
+
+	t.Run("ExistsFile", func(t *testing.T) {
+		existingFile := ts.emptyFile(t, testDir)
+
+		ok, err := avfs.Exists(vfs, existingFile)
+		RequireNoError(t, err, "DirExists %s", existingFile)
+
+		if !ok {
+			t.Error("Exists : want DirExists to be true, got false")
 		}
 	})
 
@@ -693,6 +1425,60 @@ func (ts *Suite) TestGlob(t *testing.T, testDir string) {
 	})
 }
 
+// TestFprintf tests Fprintf and Fprintln functions.
+func (ts *Suite) TestFprintf(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := ts.existingFile(t, testDir, nil)
+
+		_, err := avfs.Fprintf(vfs, path, "%d", 1)
+		AssertPathError(t, err).Op("open").Path(path).ErrPermDenied().Test()
+
+		return
+	}
+
+	assertContent := func(t *testing.T, path, want string, n int) {
+		t.Helper()
+
+		data, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if string(data) != want {
+			t.Errorf("ReadFile %s : want content to be %q, got %q", path, want, data)
+		}
+
+		if n != len(want) {
+			t.Errorf("Fprintf %s : want written bytes to be %d, got %d", path, len(want), n)
+		}
+	}
+
+	t.Run("Fprintf", func(t *testing.T) {
+		path := vfs.Join(testDir, "fprintf")
+
+		n, err := avfs.Fprintf(vfs, path, "%s=%d\n", "answer", 42)
+		RequireNoError(t, err, "Fprintf %s", path)
+		assertContent(t, path, "answer=42\n", n)
+	})
+
+	t.Run("FprintlnTruncate", func(t *testing.T) {
+		path := ts.existingFile(t, testDir, []byte("a long existing content"))
+
+		n, err := avfs.Fprintln(vfs, path, "short", 1)
+		RequireNoError(t, err, "Fprintln %s", path)
+		assertContent(t, path, "short 1\n", n)
+	})
+
+	t.Run("FprintfNonExistingDir", func(t *testing.T) {
+		path := vfs.Join(ts.nonExistingFile(t, testDir), "file")
+
+		_, err := avfs.Fprintf(vfs, path, "data")
+		AssertPathError(t, err).Op("open").Path(path).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinPathNotFound).Test()
+	})
+}
+
 // TestHashFile tests avfs.HashFile function.
 func (ts *Suite) TestHashFile(t *testing.T, testDir string) {
 	vfs := ts.vfsSetup
@@ -778,6 +1564,57 @@ func (ts *Suite) TestIsAbs(t *testing.T, _ string) {
 	}
 }
 
+// TestFileType tests avfs.FileType function.
+func (ts *Suite) TestFileType(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	dirs := ts.createSampleDirs(t, testDir)
+	files := ts.createSampleFiles(t, testDir)
+	symlinks := ts.createSampleSymlinks(t, testDir)
+
+	t.Run("FileTypeDir", func(t *testing.T) {
+		for _, dir := range dirs {
+			typ, err := avfs.FileType(vfs, dir.Path)
+			RequireNoError(t, err, "FileType %s", dir.Path)
+
+			if typ != fs.ModeDir {
+				t.Errorf("FileType %s : want type to be %s, got %s", dir.Path, fs.ModeDir, typ)
+			}
+		}
+	})
+
+	t.Run("FileTypeFile", func(t *testing.T) {
+		for _, file := range files {
+			typ, err := avfs.FileType(vfs, file.Path)
+			RequireNoError(t, err, "FileType %s", file.Path)
+
+			if typ != 0 {
+				t.Errorf("FileType %s : want type to be 0, got %s", file.Path, typ)
+			}
+		}
+	})
+
+	t.Run("FileTypeSymlink", func(t *testing.T) {
+		for _, sl := range symlinks {
+			typ, err := avfs.FileType(vfs, sl.NewPath)
+			RequireNoError(t, err, "FileType %s", sl.NewPath)
+
+			if typ != fs.ModeSymlink {
+				t.Errorf("FileType %s : want type to be %s, got %s", sl.NewPath, fs.ModeSymlink, typ)
+			}
+		}
+	})
+
+	t.Run("FileTypeNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		_, err := avfs.FileType(vfs, nonExistingFile)
+		AssertPathError(t, err).OpLstat().Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+	})
+}
+
 // TestIsDir tests avfs.IsDir function.
 func (ts *Suite) TestIsDir(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
@@ -871,6 +1708,70 @@ func (ts *Suite) TestIsEmpty(t *testing.T, testDir string) {
 	})
 }
 
+// TestIsSymlink tests avfs.IsSymlink function.
+func (ts *Suite) TestIsSymlink(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	_ = ts.createSampleDirs(t, testDir)
+	_ = ts.createSampleFiles(t, testDir)
+	symlinks := ts.createSampleSymlinks(t, testDir)
+
+	isLink := make(map[string]bool, len(symlinks))
+	for _, sl := range symlinks {
+		isLink[sl.NewPath] = true
+	}
+
+	t.Run("IsSymlink", func(t *testing.T) {
+		for _, sl := range symlinks {
+			ok, err := avfs.IsSymlink(vfs, sl.NewPath)
+			RequireNoError(t, err, "IsSymlink %s", sl.NewPath)
+
+			if !ok {
+				t.Errorf("IsSymlink %s : want IsSymlink to be true, got false", sl.NewPath)
+			}
+		}
+	})
+
+	t.Run("IsSymlinkTarget", func(t *testing.T) {
+		for _, sl := range symlinks {
+			ok, err := avfs.IsSymlink(vfs, sl.OldPath)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+
+			RequireNoError(t, err, "IsSymlink %s", sl.OldPath)
+
+			if ok != isLink[sl.OldPath] {
+				t.Errorf("IsSymlink %s : want IsSymlink to be %t, got %t", sl.OldPath, isLink[sl.OldPath], ok)
+			}
+		}
+	})
+
+	t.Run("IsSymlinkFile", func(t *testing.T) {
+		existingFile := ts.emptyFile(t, testDir)
+
+		ok, err := avfs.IsSymlink(vfs, existingFile)
+		RequireNoError(t, err, "IsSymlink %s", existingFile)
+
+		if ok {
+			t.Error("IsSymlink : want IsSymlink to be false, got true")
+		}
+	})
+
+	t.Run("IsSymlinkNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		ok, err := avfs.IsSymlink(vfs, nonExistingFile)
+		AssertPathError(t, err).OpLstat().Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+
+		if ok {
+			t.Error("IsSymlink : want IsSymlink to be false, got true")
+		}
+	})
+}
+
 // TestIsPathSeparator tests IsPathSeparator function.
 func (ts *Suite) TestIsPathSeparator(t *testing.T, _ string) {
 	vfs := ts.vfsTest
@@ -893,6 +1794,144 @@ func (ts *Suite) TestIsPathSeparator(t *testing.T, _ string) {
 	}
 }
 
+// TestLchmod tests avfs.Lchmod function.
+func (ts *Suite) TestLchmod(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if !vfs.HasFeature(avfs.FeatLchmod) {
+		path := ts.existingFile(t, testDir, nil)
+
+		err := avfs.Lchmod(vfs, path, 0o600)
+		AssertPathError(t, err).Op("lchmod").Path(path).FeatureMissing(avfs.FeatLchmod).
+			OSType(avfs.OsLinux).Err(avfs.ErrOpNotPermitted).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinNotSupported).Test()
+
+		return
+	}
+
+	target := ts.existingFile(t, testDir, nil)
+
+	info, err := vfs.Stat(target)
+	RequireNoError(t, err, "Stat %s", target)
+
+	wantTargetMode := info.Mode()
+
+	t.Run("LchmodSymlink", func(t *testing.T) {
+		link := vfs.Join(testDir, "lchmodLink")
+
+		err = vfs.Symlink(target, link)
+		RequireNoError(t, err, "Symlink %s %s", target, link)
+
+		err = avfs.Lchmod(vfs, link, 0o700)
+		RequireNoError(t, err, "Lchmod %s", link)
+
+		linkInfo, err := vfs.Lstat(link)
+		RequireNoError(t, err, "Lstat %s", link)
+
+		if wantMode := fs.ModeSymlink | 0o700; linkInfo.Mode() != wantMode {
+			t.Errorf("Lstat %s : want mode to be %s, got %s", link, wantMode, linkInfo.Mode())
+		}
+
+		info, err = vfs.Stat(target)
+		RequireNoError(t, err, "Stat %s", target)
+
+		if info.Mode() != wantTargetMode {
+			t.Errorf("Stat %s : want mode of the target to be %s, got %s", target, wantTargetMode, info.Mode())
+		}
+	})
+
+	t.Run("LchmodFile", func(t *testing.T) {
+		err = avfs.Lchmod(vfs, target, 0o640)
+		RequireNoError(t, err, "Lchmod %s", target)
+
+		info, err = vfs.Stat(target)
+		RequireNoError(t, err, "Stat %s", target)
+
+		if info.Mode() != 0o640 {
+			t.Errorf("Stat %s : want mode to be %s, got %s", target, fs.FileMode(0o640), info.Mode())
+		}
+	})
+
+	t.Run("LchmodNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		err = avfs.Lchmod(vfs, nonExistingFile, 0o600)
+		AssertPathError(t, err).Op("lchmod").Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+}
+
+// TestListByModTime tests avfs.ListByModTime function.
+func (ts *Suite) TestListByModTime(t *testing.T, testDir string) {
+	vfs := ts.vfsSetup
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	files := []struct {
+		name    string
+		modTime time.Time
+	}{
+		{name: "c.log", modTime: baseTime.Add(2 * time.Hour)},
+		{name: "a.log", modTime: baseTime},
+		{name: "d.log", modTime: baseTime.Add(time.Hour)},
+		{name: "b.log", modTime: baseTime},
+	}
+
+	for _, file := range files {
+		path := vfs.Join(testDir, file.name)
+
+		err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", path)
+
+		err = vfs.Chtimes(path, file.modTime, file.modTime)
+		RequireNoError(t, err, "Chtimes %s", path)
+	}
+
+	subDir := vfs.Join(testDir, "subDir")
+
+	err := vfs.Mkdir(subDir, avfs.DefaultDirPerm)
+	RequireNoError(t, err, "Mkdir %s", subDir)
+
+	vfs = ts.vfsTest
+
+	names := func(infos []fs.FileInfo) []string {
+		s := make([]string, len(infos))
+		for i, info := range infos {
+			s[i] = info.Name()
+		}
+
+		return s
+	}
+
+	t.Run("ListByModTimeAscending", func(t *testing.T) {
+		infos, err := avfs.ListByModTime(vfs, testDir, true)
+		RequireNoError(t, err, "ListByModTime %s", testDir)
+
+		got, want := strings.Join(names(infos), " "), "a.log b.log d.log c.log"
+		if got != want {
+			t.Errorf("ListByModTime : want files to be %s, got %s", want, got)
+		}
+	})
+
+	t.Run("ListByModTimeDescending", func(t *testing.T) {
+		infos, err := avfs.ListByModTime(vfs, testDir, false)
+		RequireNoError(t, err, "ListByModTime %s", testDir)
+
+		got, want := strings.Join(names(infos), " "), "c.log d.log a.log b.log"
+		if got != want {
+			t.Errorf("ListByModTime : want files to be %s, got %s", want, got)
+		}
+	})
+
+	t.Run("ListByModTimeNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		_, err := avfs.ListByModTime(vfs, nonExistingFile, true)
+		AssertPathError(t, err).Op("open").Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+	})
+}
+
 // TestJoin tests Join function.
 func (ts *Suite) TestJoin(t *testing.T, _ string) {
 	vfs := ts.vfsTest
@@ -972,28 +2011,69 @@ func (ts *Suite) TestJoin(t *testing.T, _ string) {
 	}
 
 	for _, test := range joinTests {
-		expected := filepath.FromSlash(test.path)
-		if p := filepath.Join(test.elem...); p != expected {
+		expected := vfs.FromSlash(test.path)
+		if p := vfs.Join(test.elem...); p != expected {
 			t.Errorf("join(%q) = %q, want %q", test.elem, p, expected)
 		}
 	}
 }
 
-func errp(e error) string {
-	if e == nil {
-		return "<nil>"
-	}
+// TestJoinList tests JoinList function.
+func (ts *Suite) TestJoinList(t *testing.T, _ string) {
+	vfs := ts.vfsTest
 
-	return e.Error()
-}
+	type joinListTest struct {
+		elems []string
+		path  string
+	}
 
-func (ts *Suite) TestMatch(t *testing.T, _ string) {
-	vfs := ts.vfsTest
+	var joinListTests []*joinListTest
 
-	matchTests := []struct {
-		pattern, s string
-		match      bool
-		err        error
+	switch vfs.OSType() {
+	case avfs.OsWindows:
+		joinListTests = []*joinListTest{
+			{elems: []string{}, path: ``},
+			{elems: []string{`a`}, path: `a`},
+			{elems: []string{`a`, `b`}, path: `a;b`},
+			{elems: []string{`a`, ``, `b`}, path: `a;;b`},
+			{elems: []string{`c:\a;b`, `d:\c`}, path: `"c:\a;b";d:\c`},
+		}
+	default:
+		joinListTests = []*joinListTest{
+			{elems: []string{}, path: ""},
+			{elems: []string{"a"}, path: "a"},
+			{elems: []string{"a", "b"}, path: "a:b"},
+			{elems: []string{"a", "", "b"}, path: "a::b"},
+			{elems: []string{"/usr/bin", "/bin"}, path: "/usr/bin:/bin"},
+		}
+	}
+
+	for _, test := range joinListTests {
+		if path := avfs.JoinList(vfs, test.elems...); path != test.path {
+			t.Errorf("JoinList(%q) = %q, want %q", test.elems, path, test.path)
+		}
+
+		if elems := avfs.SplitList(vfs, test.path); !slices.Equal(elems, test.elems) {
+			t.Errorf("SplitList(%q) = %q, want %q", test.path, elems, test.elems)
+		}
+	}
+}
+
+func errp(e error) string {
+	if e == nil {
+		return "<nil>"
+	}
+
+	return e.Error()
+}
+
+func (ts *Suite) TestMatch(t *testing.T, _ string) {
+	vfs := ts.vfsTest
+
+	matchTests := []struct {
+		pattern, s string
+		match      bool
+		err        error
 	}{
 		{"abc", "abc", true, nil},
 		{"*", "abc", true, nil},
@@ -1138,7 +2218,7 @@ func (ts *Suite) TestRel(t *testing.T, _ string) {
 	if vfs.OSType() == avfs.OsWindows {
 		relTests = append(relTests, relTestsWin...)
 		for i := range relTests {
-			relTests[i].want = filepath.FromSlash(relTests[i].want)
+			relTests[i].want = vfs.FromSlash(relTests[i].want)
 		}
 	}
 
@@ -1162,258 +2242,1557 @@ func (ts *Suite) TestRel(t *testing.T, _ string) {
 	}
 }
 
-// TestRndTree tests RndTree methods.
-func (ts *Suite) TestRndTree(t *testing.T, testDir string) {
-	vfs := ts.vfsSetup
+// TestMkdirAllMode tests MkdirAllMode function.
+func (ts *Suite) TestMkdirAllMode(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
 
-	rtOpts := []*avfs.RndTreeOpts{
-		{NbDirs: -1, NbFiles: -1, NbSymlinks: -1, MaxFileSize: -1, MaxDepth: -1},
-		{NbDirs: 0, NbFiles: 0, NbSymlinks: 0, MaxFileSize: 0, MaxDepth: 0},
-		{NbDirs: 0, NbFiles: 3, NbSymlinks: 3, MaxFileSize: 3, MaxDepth: 0},
-		{NbDirs: 3, NbFiles: 0, NbSymlinks: 3, MaxFileSize: 0, MaxDepth: 0},
-		{NbDirs: 3, NbFiles: 3, NbSymlinks: 0, MaxFileSize: 3, MaxDepth: 0},
-		{NbDirs: 3, NbFiles: 3, NbSymlinks: 3, MaxFileSize: 0, MaxDepth: 0},
-		{NbDirs: 3, NbFiles: 11, NbSymlinks: 4, MaxFileSize: 0, MaxDepth: 0},
-		{NbDirs: 20, NbFiles: 30, NbSymlinks: 10, MaxFileSize: 2048, MaxDepth: 3},
-	}
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := vfs.Join(testDir, "a")
 
-	t.Run("RndTreeGenerate", func(t *testing.T) {
-		for i, rtOpt := range rtOpts {
-			rt := avfs.NewRndTree(vfs, rtOpt)
+		err := avfs.MkdirAllMode(vfs, path, 0o755, 0o700)
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("MkdirAllMode : want error to be %v, got %v", fs.ErrPermission, err)
+		}
 
-			rt.GenTree()
+		return
+	}
 
-			nbDirs := len(rt.Dirs())
-			if nbDirs != rtOpt.NbDirs {
-				t.Errorf("Dirs %d : want nb Dirs to be %d, got %d", i, rtOpt.NbDirs, nbDirs)
+	t.Run("MkdirAllMode", func(t *testing.T) {
+		infoTestDir, err := vfs.Stat(testDir)
+		RequireNoError(t, err, "Stat %s", testDir)
+
+		a := vfs.Join(testDir, "a")
+		b := vfs.Join(a, "b")
+		c := vfs.Join(b, "c")
+
+		err = avfs.MkdirAllMode(vfs, c, 0o755, 0o700)
+		RequireNoError(t, err, "MkdirAllMode %s", c)
+
+		for _, dm := range []struct {
+			path string
+			mode fs.FileMode
+		}{
+			{path: testDir, mode: infoTestDir.Mode().Perm()},
+			{path: a, mode: 0o755},
+			{path: b, mode: 0o755},
+			{path: c, mode: 0o700},
+		} {
+			info, err := vfs.Stat(dm.path)
+			RequireNoError(t, err, "Stat %s", dm.path)
+
+			if !info.IsDir() {
+				t.Errorf("MkdirAllMode %s : want path to be a directory, got mode %s", dm.path, info.Mode())
 			}
 
-			maxDepth := 0
-
-			for _, file := range rt.Files() {
-				depth := strings.Count(file.Name, "/") - 1
-				if depth > maxDepth {
-					maxDepth = depth
-				}
+			if vfs.OSType() != avfs.OsWindows && info.Mode().Perm() != dm.mode {
+				t.Errorf("MkdirAllMode %s : want mode to be %s, got %s", dm.path, dm.mode, info.Mode().Perm())
 			}
+		}
 
-			if maxDepth > rt.MaxDepth {
-				t.Errorf("Dirs MaxDepth %d : want MaxDepth to be <= %d, got %d", i, rt.MaxDepth, maxDepth)
-			}
+		err = avfs.MkdirAllMode(vfs, c, 0o777, 0o777)
+		RequireNoError(t, err, "MkdirAllMode %s", c)
 
-			nbFiles := len(rt.Files())
-			if nbFiles != rtOpt.NbFiles {
-				t.Errorf("Files %d : want NbFiles to be %d, got %d", i, rtOpt.NbFiles, nbFiles)
-			}
+		info, err := vfs.Stat(c)
+		RequireNoError(t, err, "Stat %s", c)
 
-			maxDepth = 0
+		if vfs.OSType() != avfs.OsWindows && info.Mode().Perm() != 0o700 {
+			t.Errorf("MkdirAllMode %s : want mode of existing directory to be %s, got %s",
+				c, fs.FileMode(0o700), info.Mode().Perm())
+		}
+	})
 
-			for _, file := range rt.Files() {
-				depth := strings.Count(file.Name, "/") - 1
-				if depth > maxDepth {
-					maxDepth = depth
-				}
-			}
+	t.Run("MkdirAllModeOnFile", func(t *testing.T) {
+		existingFile := ts.emptyFile(t, testDir)
 
-			if maxDepth > rt.MaxDepth {
-				t.Errorf("Files MaxDepth %d : want MaxDepth to be <= %d, got %d", i, rt.MaxDepth, maxDepth)
-			}
+		err := avfs.MkdirAllMode(vfs, existingFile, 0o755, 0o700)
+		if err == nil {
+			t.Errorf("MkdirAllMode %s : want error, got nil", existingFile)
+		}
 
-			maxSize := 0
+		path := vfs.Join(existingFile, "dir")
 
-			for _, file := range rt.Files() {
-				size := file.Size
-				if size > maxSize {
-					maxSize = size
-				}
-			}
+		err = avfs.MkdirAllMode(vfs, path, 0o755, 0o700)
+		if err == nil {
+			t.Errorf("MkdirAllMode %s : want error, got nil", path)
+		}
+	})
+}
 
-			if maxSize > rt.MaxFileSize {
-				t.Errorf("MaxFileSize %d : want MaxFileSize to be <= %d, got %d", i, rt.MaxFileSize, maxSize)
-			}
+// TestMoveDir tests avfs.MoveDir and avfs.Move functions.
+func (ts *Suite) TestMoveDir(t *testing.T, testDir string) {
+	srcFS := ts.vfsSetup
+	h := sha512.New()
 
-			nbSymlinks := len(rt.SymLinks())
-			wantSymLinks := rtOpt.NbSymlinks
+	createTree := func(t *testing.T, name string) (string, *avfs.RndTree) {
+		srcDir := srcFS.Join(testDir, name)
+		rt := avfs.NewRndTree(srcFS, &avfs.RndTreeOpts{NbDirs: 8, NbFiles: 16, MaxFileSize: 8 * 1024, MaxDepth: 3})
 
-			if rt.NbFiles == 0 || !vfs.HasFeature(avfs.FeatSymlink) {
-				wantSymLinks = 0
-			}
+		err := rt.CreateTree(srcDir)
+		RequireNoError(t, err, "CreateTree %s", srcDir)
 
-			if nbSymlinks != wantSymLinks {
-				t.Errorf("Symlinks %d : want NbSymlinks to be %d, got %d", i, wantSymLinks, nbSymlinks)
+		return srcDir, rt
+	}
+
+	checkTree := func(t *testing.T, dstFS avfs.VFSBase, dstDir, srcDir string, rt *avfs.RndTree) {
+		for _, dir := range rt.Dirs() {
+			path := dstFS.Join(dstDir, dir.Name)
+
+			ok, err := avfs.DirExists(dstFS, path)
+			if !AssertNoError(t, err, "DirExists %s", path) || !ok {
+				t.Errorf("MoveDir : want directory %s to exist", path)
 			}
+		}
 
-			maxDepth = 0
+		for _, file := range rt.Files() {
+			path := dstFS.Join(dstDir, file.Name)
 
-			for _, symlink := range rt.SymLinks() {
-				depth := strings.Count(symlink.NewName, "/") - 1
-				if depth > maxDepth {
-					maxDepth = depth
-				}
+			info, err := dstFS.Stat(path)
+			if !AssertNoError(t, err, "Stat %s", path) {
+				continue
 			}
 
-			if maxDepth > rt.MaxDepth {
-				t.Errorf("Symlinks MaxDepth %d : want MaxDepth to be <= %d, got %d", i, rt.MaxDepth, maxDepth)
+			if info.Size() != int64(file.Size) {
+				t.Errorf("MoveDir %s : want size to be %d, got %d", path, file.Size, info.Size())
 			}
 		}
+
+		_, err := srcFS.Stat(srcDir)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("MoveDir : want source directory %s to be removed, got %v", srcDir, err)
+		}
+	}
+
+	t.Run("MoveDirSameFS", func(t *testing.T) {
+		srcDir, rt := createTree(t, "MoveDirSameFS")
+		dstDir := srcFS.Join(testDir, "MoveDirSameFSDst")
+
+		err := avfs.MoveDir(srcFS, srcFS, dstDir, srcDir)
+		RequireNoError(t, err, "MoveDir %s, %s", dstDir, srcDir)
+
+		checkTree(t, srcFS, dstDir, srcDir, rt)
 	})
 
-	t.Run("RndTreeCreate", func(t *testing.T) {
-		for i, rtOpt := range rtOpts {
-			path := vfs.Join(testDir, strconv.Itoa(i))
+	t.Run("MoveDirOtherFS", func(t *testing.T) {
+		srcDir, rt := createTree(t, "MoveDirOtherFS")
+		dstFS := memfs.New()
+		dstDir := dstFS.Join(dstFS.TempDir(), "MoveDirOtherFSDst")
 
-			ts.createDir(t, path, avfs.DefaultDirPerm)
+		err := avfs.MoveDir(dstFS, srcFS, dstDir, srcDir)
+		RequireNoError(t, err, "MoveDir %s, %s", dstDir, srcDir)
 
-			rt := avfs.NewRndTree(vfs, rtOpt)
+		checkTree(t, dstFS, dstDir, srcDir, rt)
+	})
 
-			err := rt.CreateDirs(testDir)
-			RequireNoError(t, err, "CreateDirs %s", path)
+	t.Run("MoveDirCopyError", func(t *testing.T) {
+		srcDir, _ := createTree(t, "MoveDirCopyError")
+		dstFS := memfs.New()
+		dstFile := dstFS.Join(dstFS.TempDir(), "MoveDirCopyErrorFile")
 
-			err = rt.CreateFiles(testDir)
-			RequireNoError(t, err, "CreateFiles %s", path)
+		err := dstFS.WriteFile(dstFile, nil, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", dstFile)
 
-			if !vfs.HasFeature(avfs.FeatSymlink) {
-				continue
-			}
+		dstDir := dstFS.Join(dstFile, "MoveDirCopyErrorDst")
 
-			err = rt.CreateSymlinks(testDir)
-			RequireNoError(t, err, "CreateSymlinks %s", path)
+		err = avfs.MoveDir(dstFS, srcFS, dstDir, srcDir)
+		if err == nil {
+			t.Errorf("MoveDir : want error to be not nil, got nil")
+		}
+
+		ok, err := avfs.DirExists(srcFS, srcDir)
+		if !AssertNoError(t, err, "DirExists %s", srcDir) || !ok {
+			t.Errorf("MoveDir : want source directory %s to be kept", srcDir)
 		}
 	})
-}
 
-// TestSplit tests Split function.
-func (ts *Suite) TestSplit(t *testing.T, _ string) {
-	vfs := ts.vfsTest
+	t.Run("MoveFileOtherFS", func(t *testing.T) {
+		data := []byte("MoveFileOtherFS")
+		srcPath := ts.existingFile(t, testDir, data)
+		dstFS := memfs.New()
+		dstPath := dstFS.Join(dstFS.TempDir(), "MoveFileOtherFSDst")
 
-	type splitTest struct {
-		path, dir, file string
-	}
+		wantSum, err := avfs.HashFile(srcFS, srcPath, h)
+		RequireNoError(t, err, "HashFile %s", srcPath)
 
-	var splitTests []*splitTest
+		err = avfs.Move(dstFS, srcFS, dstPath, srcPath)
+		RequireNoError(t, err, "Move %s, %s", dstPath, srcPath)
 
-	switch vfs.OSType() {
-	case avfs.OsWindows:
-		splitTests = []*splitTest{
-			{path: `c:`, dir: `c:`},
-			{path: `c:/`, dir: `c:/`},
-			{path: `c:/foo`, dir: `c:/`, file: `foo`},
-			{path: `c:/foo/bar`, dir: `c:/foo/`, file: `bar`},
-			{path: `//host/share`, dir: `//host/share`},
-			{path: `//host/share/`, dir: `//host/share/`},
-			{path: `//host/share/foo`, dir: `//host/share/`, file: `foo`},
-			{path: `\\host\share`, dir: `\\host\share`},
-			{path: `\\host\share\`, dir: `\\host\share\`},
-			{path: `\\host\share\foo`, dir: `\\host\share\`, file: `foo`},
-		}
-	default:
-		splitTests = []*splitTest{
-			{path: "a/b", dir: "a/", file: "b"},
-			{path: "a/b/", dir: "a/b/"},
-			{path: "a/", dir: "a/"},
-			{path: "a", file: "a"},
-			{path: "/", dir: "/"},
+		gotSum, err := avfs.HashFile(dstFS, dstPath, h)
+		RequireNoError(t, err, "HashFile %s", dstPath)
+
+		if !bytes.Equal(wantSum, gotSum) {
+			t.Errorf("Move %s : \nwant : %x\ngot  : %x", dstPath, wantSum, gotSum)
 		}
-	}
 
-	for _, test := range splitTests {
-		d, f := vfs.Split(test.path)
-		if d != test.dir || f != test.file {
-			t.Errorf("Split(%q) = %q, %q, want %q, %q", test.path, d, f, test.dir, test.file)
+		_, err = srcFS.Stat(srcPath)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Move : want source file %s to be removed, got %v", srcPath, err)
 		}
-	}
+	})
+
+	t.Run("MoveNonExisting", func(t *testing.T) {
+		srcPath := ts.nonExistingFile(t, testDir)
+		dstPath := srcFS.Join(testDir, "MoveNonExistingDst")
+
+		err := avfs.Move(srcFS, srcFS, dstPath, srcPath)
+		AssertPathError(t, err).Op("lstat").Path(srcPath).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+	})
 }
 
-// TestSplitAbs tests SplitAbs function.
-func (ts *Suite) TestSplitAbs(t *testing.T, _ string) {
+// TestOpenReaderWriter tests avfs.OpenReader and avfs.OpenWriter functions.
+func (ts *Suite) TestOpenReaderWriter(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
+	data := []byte("OpenReaderWriter")
 
-	cases := []struct {
-		path   string
-		dir    string
-		file   string
-		osType avfs.OSType
-	}{
-		{osType: avfs.OsLinux, path: "/", dir: "", file: ""},
-		{osType: avfs.OsLinux, path: "/home", dir: "", file: "home"},
-		{osType: avfs.OsLinux, path: "/home/user", dir: "/home", file: "user"},
-		{osType: avfs.OsLinux, path: "/usr/lib/xorg", dir: "/usr/lib", file: "xorg"},
-		{osType: avfs.OsWindows, path: `C:\`, dir: `C:`, file: ""},
-		{osType: avfs.OsWindows, path: `C:\Users`, dir: `C:`, file: "Users"},
-		{osType: avfs.OsWindows, path: `C:\Users\Default`, dir: `C:\Users`, file: "Default"},
-	}
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := ts.existingFile(t, testDir, data)
 
-	for _, c := range cases {
-		if c.osType != vfs.OSType() {
-			continue
+		_, err := avfs.OpenWriter(vfs, path, avfs.DefaultFilePerm)
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("OpenWriter : want error to be %v, got %v", fs.ErrPermission, err)
 		}
 
-		dir, file := avfs.SplitAbs(vfs, c.path)
-		if c.dir != dir {
-			t.Errorf("splitPath %s : want dir to be %s, got %s", c.path, c.dir, dir)
-		}
+		return
+	}
 
-		if c.file != file {
-			t.Errorf("splitPath %s : want file to be %s, got %s", c.path, c.file, file)
+	t.Run("OpenReaderWriterCopy", func(t *testing.T) {
+		path := vfs.Join(testDir, "copy.txt")
+
+		w, err := avfs.OpenWriter(vfs, path, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "OpenWriter %s", path)
+
+		n, err := io.Copy(w, bytes.NewReader(data))
+		RequireNoError(t, err, "Copy %s", path)
+
+		if n != int64(len(data)) {
+			t.Errorf("Copy : want %d bytes to be written, got %d", len(data), n)
 		}
-	}
-}
 
-// TestWalkDir tests WalkDir function.
-func (ts *Suite) TestWalkDir(t *testing.T, testDir string) {
-	dirs := ts.createSampleDirs(t, testDir)
-	files := ts.createSampleFiles(t, testDir)
-	symlinks := ts.createSampleSymlinks(t, testDir)
+		err = w.Close()
+		RequireNoError(t, err, "Close %s", path)
 
-	vfs := ts.vfsTest
-	lNames := len(dirs) + len(files) + len(symlinks)
-	wantNames := make([]string, 0, lNames)
+		r, err := avfs.OpenReader(vfs, path)
+		RequireNoError(t, err, "OpenReader %s", path)
 
-	wantNames = append(wantNames, testDir)
-	for _, dir := range dirs {
-		wantNames = append(wantNames, dir.Path)
-	}
+		var buf bytes.Buffer
 
-	for _, file := range files {
-		wantNames = append(wantNames, file.Path)
-	}
+		_, err = io.Copy(&buf, r)
+		RequireNoError(t, err, "Copy %s", path)
 
-	if vfs.HasFeature(avfs.FeatSymlink) {
-		for _, sl := range symlinks {
-			wantNames = append(wantNames, sl.NewPath)
+		err = r.Close()
+		RequireNoError(t, err, "Close %s", path)
+
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("OpenReader : want content to be %s, got %s", data, buf.Bytes())
 		}
-	}
+	})
 
-	sort.Strings(wantNames)
+	t.Run("OpenWriterTruncate", func(t *testing.T) {
+		path := ts.existingFile(t, testDir, []byte("previous content is longer"))
 
-	t.Run("WalkDir", func(t *testing.T) {
-		gotNames := make(map[string]int)
-		err := vfs.WalkDir(testDir, func(path string, info fs.DirEntry, err error) error {
-			gotNames[path]++
+		w, err := avfs.OpenWriter(vfs, path, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "OpenWriter %s", path)
 
-			return nil
-		})
-		RequireNoError(t, err, "WalkDir %s", testDir)
+		_, err = w.Write(data)
+		RequireNoError(t, err, "Write %s", path)
 
-		if len(wantNames) != len(gotNames) {
-			t.Errorf("Walk %s : want %d files or dirs, got %d", testDir, len(wantNames), len(gotNames))
-		}
+		err = w.Close()
+		RequireNoError(t, err, "Close %s", path)
 
-		for _, wantName := range wantNames {
-			n, ok := gotNames[wantName]
-			if !ok || n != 1 {
-				t.Errorf("Walk %s : path %s not found", testDir, wantName)
-			}
+		content, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("OpenWriter : want content to be %s, got %s", data, content)
 		}
 	})
 
-	t.Run("WalkNonExistingFile", func(t *testing.T) {
+	t.Run("OpenReaderWriterNonExisting", func(t *testing.T) {
 		nonExistingFile := ts.nonExistingFile(t, testDir)
+		path := vfs.Join(nonExistingFile, "file.txt")
 
-		err := vfs.WalkDir(nonExistingFile, func(path string, info fs.DirEntry, err error) error {
+		r, err := avfs.OpenReader(vfs, path)
+		AssertPathError(t, err).Op("open").Path(path).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinPathNotFound).Test()
+
+		if r != nil {
+			t.Errorf("OpenReader : want reader to be nil, got %v", r)
+		}
+
+		w, err := avfs.OpenWriter(vfs, path, avfs.DefaultFilePerm)
+		AssertPathError(t, err).Op("open").Path(path).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinPathNotFound).Test()
+
+		if w != nil {
+			t.Errorf("OpenWriter : want writer to be nil, got %v", w)
+		}
+	})
+}
+
+// TestReadDirFiltered tests avfs.ReadDirFiltered function.
+func (ts *Suite) TestReadDirFiltered(t *testing.T, testDir string) {
+	vfs := ts.vfsSetup
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	files := []struct {
+		name    string
+		modTime time.Time
+	}{
+		{name: "c.log", modTime: baseTime.Add(2 * time.Hour)},
+		{name: "a.log", modTime: baseTime},
+		{name: "b.txt", modTime: baseTime.Add(2 * time.Hour)},
+		{name: "d.log", modTime: baseTime.Add(time.Hour)},
+	}
+
+	for _, file := range files {
+		path := vfs.Join(testDir, file.name)
+
+		err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", path)
+
+		err = vfs.Chtimes(path, file.modTime, file.modTime)
+		RequireNoError(t, err, "Chtimes %s", path)
+	}
+
+	subDir := vfs.Join(testDir, "sub.log")
+
+	err := vfs.Mkdir(subDir, avfs.DefaultDirPerm)
+	RequireNoError(t, err, "Mkdir %s", subDir)
+
+	vfs = ts.vfsTest
+
+	names := func(infos []fs.FileInfo) string {
+		s := make([]string, len(infos))
+		for i, info := range infos {
+			s[i] = info.Name()
+		}
+
+		return strings.Join(s, " ")
+	}
+
+	t.Run("ReadDirFilteredExt", func(t *testing.T) {
+		infos, err := avfs.ReadDirFiltered(vfs, testDir, func(info fs.FileInfo) bool {
+			return info.Mode().IsRegular() && strings.HasSuffix(info.Name(), ".log")
+		})
+		RequireNoError(t, err, "ReadDirFiltered %s", testDir)
+
+		if got, want := names(infos), "a.log c.log d.log"; got != want {
+			t.Errorf("ReadDirFiltered : want files to be %s, got %s", want, got)
+		}
+	})
+
+	t.Run("ReadDirFilteredModTime", func(t *testing.T) {
+		infos, err := avfs.ReadDirFiltered(vfs, testDir, func(info fs.FileInfo) bool {
+			return !info.IsDir() && info.ModTime().After(baseTime)
+		})
+		RequireNoError(t, err, "ReadDirFiltered %s", testDir)
+
+		if got, want := names(infos), "b.txt c.log d.log"; got != want {
+			t.Errorf("ReadDirFiltered : want files to be %s, got %s", want, got)
+		}
+	})
+
+	t.Run("ReadDirFilteredNone", func(t *testing.T) {
+		infos, err := avfs.ReadDirFiltered(vfs, testDir, func(fs.FileInfo) bool { return false })
+		RequireNoError(t, err, "ReadDirFiltered %s", testDir)
+
+		if len(infos) != 0 {
+			t.Errorf("ReadDirFiltered : want no files, got %s", names(infos))
+		}
+	})
+
+	t.Run("ReadDirFilteredNonExisting", func(t *testing.T) {
+		nonExisting := ts.nonExistingFile(t, testDir)
+
+		_, err := avfs.ReadDirFiltered(vfs, nonExisting, func(fs.FileInfo) bool { return true })
+		AssertPathError(t, err).Op("open").Path(nonExisting).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+	})
+}
+
+// TestReadDirNames tests avfs.ReadDirNames and avfs.ReadDirUnsorted functions.
+func (ts *Suite) TestReadDirNames(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	_ = ts.createSampleDirs(t, testDir)
+	_ = ts.createSampleFiles(t, testDir)
+	_ = ts.createSampleSymlinks(t, testDir)
+
+	entries, err := vfs.ReadDir(testDir)
+	RequireNoError(t, err, "ReadDir %s", testDir)
+
+	wantNames := make([]string, len(entries))
+	for i, entry := range entries {
+		wantNames[i] = entry.Name()
+	}
+
+	t.Run("ReadDirNames", func(t *testing.T) {
+		names, err := avfs.ReadDirNames(vfs, testDir)
+		RequireNoError(t, err, "ReadDirNames %s", testDir)
+
+		sort.Strings(names)
+
+		if !slices.Equal(names, wantNames) {
+			t.Errorf("ReadDirNames %s : want names to be %v, got %v", testDir, wantNames, names)
+		}
+	})
+
+	t.Run("ReadDirUnsorted", func(t *testing.T) {
+		unsorted, err := avfs.ReadDirUnsorted(vfs, testDir)
+		RequireNoError(t, err, "ReadDirUnsorted %s", testDir)
+
+		names := make([]string, len(unsorted))
+		for i, entry := range unsorted {
+			names[i] = entry.Name()
+		}
+
+		sort.Strings(names)
+
+		if !slices.Equal(names, wantNames) {
+			t.Errorf("ReadDirUnsorted %s : want names to be %v, got %v", testDir, wantNames, names)
+		}
+	})
+
+	t.Run("ReadDirNamesNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		_, err := avfs.ReadDirNames(vfs, nonExistingFile)
+		AssertPathError(t, err).Op("open").Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+
+		_, err = avfs.ReadDirUnsorted(vfs, nonExistingFile)
+		AssertPathError(t, err).Op("open").Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+	})
+}
+
+// TestReadFileLimit tests ReadFileLimit function.
+func (ts *Suite) TestReadFileLimit(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+	data := []byte("ReadFileLimit")
+	path := ts.existingFile(t, testDir, data)
+	size := int64(len(data))
+
+	t.Run("ReadFileLimitUnder", func(t *testing.T) {
+		rb, err := avfs.ReadFileLimit(vfs, path, size+1)
+		RequireNoError(t, err, "ReadFileLimit %s", path)
+
+		if !bytes.Equal(rb, data) {
+			t.Errorf("ReadFileLimit : want content to be %s, got %s", data, rb)
+		}
+	})
+
+	t.Run("ReadFileLimitAt", func(t *testing.T) {
+		rb, err := avfs.ReadFileLimit(vfs, path, size)
+		RequireNoError(t, err, "ReadFileLimit %s", path)
+
+		if !bytes.Equal(rb, data) {
+			t.Errorf("ReadFileLimit : want content to be %s, got %s", data, rb)
+		}
+	})
+
+	t.Run("ReadFileLimitOver", func(t *testing.T) {
+		rb, err := avfs.ReadFileLimit(vfs, path, size-1)
+		if !errors.Is(err, avfs.ErrFileTooLarge) {
+			t.Errorf("ReadFileLimit : want error to be %v, got %v", avfs.ErrFileTooLarge, err)
+		}
+
+		if rb != nil {
+			t.Errorf("ReadFileLimit : want content to be nil, got %s", rb)
+		}
+	})
+
+	t.Run("ReadFileLimitNotExisting", func(t *testing.T) {
+		nonExistingPath := ts.nonExistingFile(t, testDir)
+
+		_, err := avfs.ReadFileLimit(vfs, nonExistingPath, size)
+		AssertPathError(t, err).Op("open").Path(nonExistingPath).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+	})
+}
+
+// TestRemoveFiles tests avfs.RemoveFiles function.
+func (ts *Suite) TestRemoveFiles(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := ts.emptyFile(t, testDir)
+
+		err := avfs.RemoveFiles(vfs, []string{path})
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("RemoveFiles : want error to be %v, got %v", fs.ErrPermission, err)
+		}
+
+		return
+	}
+
+	t.Run("RemoveFiles", func(t *testing.T) {
+		paths := []string{vfs.Join(testDir, "file1"), vfs.Join(testDir, "file2"), ts.existingDir(t, testDir)}
+		for _, path := range paths[:2] {
+			ts.createFile(t, path, avfs.DefaultFilePerm)
+		}
+
+		err := avfs.RemoveFiles(vfs, paths)
+		RequireNoError(t, err, "RemoveFiles")
+
+		for _, path := range paths {
+			_, err = vfs.Lstat(path)
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Lstat %s : want error to be %v, got %v", path, fs.ErrNotExist, err)
+			}
+		}
+	})
+
+	t.Run("RemoveFilesPartialFailure", func(t *testing.T) {
+		existing := vfs.Join(testDir, "existing")
+		ts.createFile(t, existing, avfs.DefaultFilePerm)
+
+		missing1 := ts.nonExistingFile(t, testDir)
+		missing2 := vfs.Join(testDir, "missing2")
+
+		err := avfs.RemoveFiles(vfs, []string{missing1, existing, missing2})
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("RemoveFiles : want error to be %v, got %v", fs.ErrNotExist, err)
+		}
+
+		for _, missing := range []string{missing1, missing2} {
+			if !strings.Contains(err.Error(), missing) {
+				t.Errorf("RemoveFiles : want error to report %s, got %v", missing, err)
+			}
+		}
+
+		_, err = vfs.Lstat(existing)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("Lstat %s : want error to be %v, got %v", existing, fs.ErrNotExist, err)
+		}
+	})
+}
+
+// TestRelSymlink tests avfs.RelSymlink function.
+func (ts *Suite) TestRelSymlink(t *testing.T, testDir string) {
+	vfsSetup := ts.vfsSetup
+	vfs := ts.vfsTest
+
+	if !vfs.HasFeature(avfs.FeatSymlink) || vfs.HasFeature(avfs.FeatReadOnly) {
+		return
+	}
+
+	root := vfs.Join(testDir, "tree")
+	targetDir := vfs.Join(root, "a", "b")
+	linkDir := vfs.Join(root, "c")
+	target := vfs.Join(targetDir, "file.txt")
+	link := vfs.Join(linkDir, "link")
+	data := []byte("RelSymlink")
+
+	for _, dir := range []string{targetDir, linkDir} {
+		err := vfsSetup.MkdirAll(dir, avfs.DefaultDirPerm)
+		RequireNoError(t, err, "MkdirAll %s", dir)
+	}
+
+	err := vfsSetup.WriteFile(target, data, avfs.DefaultFilePerm)
+	RequireNoError(t, err, "WriteFile %s", target)
+
+	t.Run("RelSymlink", func(t *testing.T) {
+		err = avfs.RelSymlink(vfs, target, link)
+		RequireNoError(t, err, "RelSymlink %s %s", target, link)
+
+		wantTarget := vfs.Join("..", "a", "b", "file.txt")
+
+		got, err := vfs.Readlink(link)
+		RequireNoError(t, err, "Readlink %s", link)
+
+		if got != wantTarget {
+			t.Errorf("Readlink %s : want target to be %s, got %s", link, wantTarget, got)
+		}
+	})
+
+	t.Run("RelSymlinkMoved", func(t *testing.T) {
+		moved := vfs.Join(testDir, "moved")
+
+		err = vfs.Rename(root, moved)
+		RequireNoError(t, err, "Rename %s %s", root, moved)
+
+		movedLink := vfs.Join(moved, "c", "link")
+		wantPath := vfs.Join(moved, "a", "b", "file.txt")
+
+		got, err := vfs.EvalSymlinks(movedLink)
+		RequireNoError(t, err, "EvalSymlinks %s", movedLink)
+
+		if got != wantPath {
+			t.Errorf("EvalSymlinks %s : want path to be %s, got %s", movedLink, wantPath, got)
+		}
+
+		content, err := vfs.ReadFile(movedLink)
+		RequireNoError(t, err, "ReadFile %s", movedLink)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("ReadFile %s : want content to be %s, got %s", movedLink, data, content)
+		}
+	})
+}
+
+// TestResolvePath tests avfs.ResolvePath function.
+func (ts *Suite) TestResolvePath(t *testing.T, testDir string) {
+	vfsSetup := ts.vfsSetup
+	vfs := ts.vfsTest
+
+	if !vfs.HasFeature(avfs.FeatSymlink) {
+		return
+	}
+
+	_ = ts.createSampleDirs(t, testDir)
+	_ = ts.createSampleFiles(t, testDir)
+	_ = ts.createSampleSymlinks(t, testDir)
+
+	t.Run("ResolvePath", func(t *testing.T) {
+		for _, sl := range ts.sampleSymlinksEval(testDir) {
+			gotPath, err := avfs.ResolvePath(vfs, sl.NewPath, 0)
+			if !AssertNoError(t, err, "ResolvePath %s", sl.NewPath) {
+				continue
+			}
+
+			if gotPath != sl.OldPath {
+				t.Errorf("ResolvePath %s : want path to be %s, got %s", sl.NewPath, sl.OldPath, gotPath)
+			}
+		}
+	})
+
+	chainDir := vfs.Join(testDir, "chain")
+	target := vfs.Join(chainDir, "target")
+
+	err := vfsSetup.MkdirAll(chainDir, avfs.DefaultDirPerm)
+	RequireNoError(t, err, "MkdirAll %s", chainDir)
+
+	err = vfsSetup.WriteFile(target, nil, avfs.DefaultFilePerm)
+	RequireNoError(t, err, "WriteFile %s", target)
+
+	// Each symbolic link points to the previous one, the first one points to target.
+	oldName := target
+	for i := 1; i <= avfs.MaxSymlinks; i++ {
+		newName := vfs.Join(chainDir, "link"+strconv.Itoa(i))
+
+		err = vfsSetup.Symlink(oldName, newName)
+		RequireNoError(t, err, "Symlink %s %s", oldName, newName)
+
+		oldName = newName
+	}
+
+	lastLink := oldName
+
+	t.Run("ResolvePathMaxSymlinks", func(t *testing.T) {
+		gotPath, err := avfs.ResolvePath(vfs, lastLink, 0)
+		RequireNoError(t, err, "ResolvePath %s", lastLink)
+
+		if gotPath != target {
+			t.Errorf("ResolvePath %s : want path to be %s, got %s", lastLink, target, gotPath)
+		}
+	})
+
+	t.Run("ResolvePathTooManySymlinks", func(t *testing.T) {
+		_, err := avfs.ResolvePath(vfs, lastLink, avfs.MaxSymlinks-1)
+		AssertPathError(t, err).Op("lstat").Path(lastLink).Err(avfs.ErrTooManySymlinks).Test()
+	})
+
+	t.Run("ResolvePathLoop", func(t *testing.T) {
+		loop1 := vfs.Join(chainDir, "loop1")
+		loop2 := vfs.Join(chainDir, "loop2")
+
+		err := vfsSetup.Symlink(loop1, loop2)
+		RequireNoError(t, err, "Symlink %s %s", loop1, loop2)
+
+		err = vfsSetup.Symlink(loop2, loop1)
+		RequireNoError(t, err, "Symlink %s %s", loop2, loop1)
+
+		_, err = avfs.ResolvePath(vfs, loop1, 0)
+		AssertPathError(t, err).Op("lstat").Path(loop1).Err(avfs.ErrTooManySymlinks).Test()
+	})
+
+	t.Run("ResolvePathNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		_, err := avfs.ResolvePath(vfs, nonExistingFile, 0)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("ResolvePath : want error to be %v, got %v", fs.ErrNotExist, err)
+		}
+	})
+}
+
+// TestRndTree tests RndTree methods.
+func (ts *Suite) TestRndTree(t *testing.T, testDir string) {
+	vfs := ts.vfsSetup
+
+	rtOpts := []*avfs.RndTreeOpts{
+		{NbDirs: -1, NbFiles: -1, NbSymlinks: -1, MaxFileSize: -1, MaxDepth: -1},
+		{NbDirs: 0, NbFiles: 0, NbSymlinks: 0, MaxFileSize: 0, MaxDepth: 0},
+		{NbDirs: 0, NbFiles: 3, NbSymlinks: 3, MaxFileSize: 3, MaxDepth: 0},
+		{NbDirs: 3, NbFiles: 0, NbSymlinks: 3, MaxFileSize: 0, MaxDepth: 0},
+		{NbDirs: 3, NbFiles: 3, NbSymlinks: 0, MaxFileSize: 3, MaxDepth: 0},
+		{NbDirs: 3, NbFiles: 3, NbSymlinks: 3, MaxFileSize: 0, MaxDepth: 0},
+		{NbDirs: 3, NbFiles: 11, NbSymlinks: 4, MaxFileSize: 0, MaxDepth: 0},
+		{NbDirs: 20, NbFiles: 30, NbSymlinks: 10, MaxFileSize: 2048, MaxDepth: 3},
+	}
+
+	t.Run("RndTreeGenerate", func(t *testing.T) {
+		for i, rtOpt := range rtOpts {
+			rt := avfs.NewRndTree(vfs, rtOpt)
+
+			rt.GenTree()
+
+			nbDirs := len(rt.Dirs())
+			if nbDirs != rtOpt.NbDirs {
+				t.Errorf("Dirs %d : want nb Dirs to be %d, got %d", i, rtOpt.NbDirs, nbDirs)
+			}
+
+			maxDepth := 0
+
+			for _, file := range rt.Files() {
+				depth := strings.Count(file.Name, "/") - 1
+				if depth > maxDepth {
+					maxDepth = depth
+				}
+			}
+
+			if maxDepth > rt.MaxDepth {
+				t.Errorf("Dirs MaxDepth %d : want MaxDepth to be <= %d, got %d", i, rt.MaxDepth, maxDepth)
+			}
+
+			nbFiles := len(rt.Files())
+			if nbFiles != rtOpt.NbFiles {
+				t.Errorf("Files %d : want NbFiles to be %d, got %d", i, rtOpt.NbFiles, nbFiles)
+			}
+
+			maxDepth = 0
+
+			for _, file := range rt.Files() {
+				depth := strings.Count(file.Name, "/") - 1
+				if depth > maxDepth {
+					maxDepth = depth
+				}
+			}
+
+			if maxDepth > rt.MaxDepth {
+				t.Errorf("Files MaxDepth %d : want MaxDepth to be <= %d, got %d", i, rt.MaxDepth, maxDepth)
+			}
+
+			maxSize := 0
+
+			for _, file := range rt.Files() {
+				size := file.Size
+				if size > maxSize {
+					maxSize = size
+				}
+			}
+
+			if maxSize > rt.MaxFileSize {
+				t.Errorf("MaxFileSize %d : want MaxFileSize to be <= %d, got %d", i, rt.MaxFileSize, maxSize)
+			}
+
+			nbSymlinks := len(rt.SymLinks())
+			wantSymLinks := rtOpt.NbSymlinks
+
+			if rt.NbFiles == 0 || !vfs.HasFeature(avfs.FeatSymlink) {
+				wantSymLinks = 0
+			}
+
+			if nbSymlinks != wantSymLinks {
+				t.Errorf("Symlinks %d : want NbSymlinks to be %d, got %d", i, wantSymLinks, nbSymlinks)
+			}
+
+			maxDepth = 0
+
+			for _, symlink := range rt.SymLinks() {
+				depth := strings.Count(symlink.NewName, "/") - 1
+				if depth > maxDepth {
+					maxDepth = depth
+				}
+			}
+
+			if maxDepth > rt.MaxDepth {
+				t.Errorf("Symlinks MaxDepth %d : want MaxDepth to be <= %d, got %d", i, rt.MaxDepth, maxDepth)
+			}
+		}
+	})
+
+	t.Run("RndTreeCreate", func(t *testing.T) {
+		for i, rtOpt := range rtOpts {
+			path := vfs.Join(testDir, strconv.Itoa(i))
+
+			ts.createDir(t, path, avfs.DefaultDirPerm)
+
+			rt := avfs.NewRndTree(vfs, rtOpt)
+
+			err := rt.CreateDirs(testDir)
+			RequireNoError(t, err, "CreateDirs %s", path)
+
+			err = rt.CreateFiles(testDir)
+			RequireNoError(t, err, "CreateFiles %s", path)
+
+			if !vfs.HasFeature(avfs.FeatSymlink) {
+				continue
+			}
+
+			err = rt.CreateSymlinks(testDir)
+			RequireNoError(t, err, "CreateSymlinks %s", path)
+		}
+	})
+}
+
+// TestSplit tests Split function.
+func (ts *Suite) TestSplit(t *testing.T, _ string) {
+	vfs := ts.vfsTest
+
+	type splitTest struct {
+		path, dir, file string
+	}
+
+	var splitTests []*splitTest
+
+	switch vfs.OSType() {
+	case avfs.OsWindows:
+		splitTests = []*splitTest{
+			{path: `c:`, dir: `c:`},
+			{path: `c:/`, dir: `c:/`},
+			{path: `c:/foo`, dir: `c:/`, file: `foo`},
+			{path: `c:/foo/bar`, dir: `c:/foo/`, file: `bar`},
+			{path: `//host/share`, dir: `//host/share`},
+			{path: `//host/share/`, dir: `//host/share/`},
+			{path: `//host/share/foo`, dir: `//host/share/`, file: `foo`},
+			{path: `\\host\share`, dir: `\\host\share`},
+			{path: `\\host\share\`, dir: `\\host\share\`},
+			{path: `\\host\share\foo`, dir: `\\host\share\`, file: `foo`},
+		}
+	default:
+		splitTests = []*splitTest{
+			{path: "a/b", dir: "a/", file: "b"},
+			{path: "a/b/", dir: "a/b/"},
+			{path: "a/", dir: "a/"},
+			{path: "a", file: "a"},
+			{path: "/", dir: "/"},
+		}
+	}
+
+	for _, test := range splitTests {
+		d, f := vfs.Split(test.path)
+		if d != test.dir || f != test.file {
+			t.Errorf("Split(%q) = %q, %q, want %q, %q", test.path, d, f, test.dir, test.file)
+		}
+	}
+}
+
+// TestSplitAbs tests SplitAbs function.
+func (ts *Suite) TestSplitAbs(t *testing.T, _ string) {
+	vfs := ts.vfsTest
+
+	cases := []struct {
+		path   string
+		dir    string
+		file   string
+		osType avfs.OSType
+	}{
+		{osType: avfs.OsLinux, path: "/", dir: "", file: ""},
+		{osType: avfs.OsLinux, path: "/home", dir: "", file: "home"},
+		{osType: avfs.OsLinux, path: "/home/user", dir: "/home", file: "user"},
+		{osType: avfs.OsLinux, path: "/usr/lib/xorg", dir: "/usr/lib", file: "xorg"},
+		{osType: avfs.OsWindows, path: `C:\`, dir: `C:`, file: ""},
+		{osType: avfs.OsWindows, path: `C:\Users`, dir: `C:`, file: "Users"},
+		{osType: avfs.OsWindows, path: `C:\Users\Default`, dir: `C:\Users`, file: "Default"},
+	}
+
+	for _, c := range cases {
+		if c.osType != vfs.OSType() {
+			continue
+		}
+
+		dir, file := avfs.SplitAbs(vfs, c.path)
+		if c.dir != dir {
+			t.Errorf("splitPath %s : want dir to be %s, got %s", c.path, c.dir, dir)
+		}
+
+		if c.file != file {
+			t.Errorf("splitPath %s : want file to be %s, got %s", c.path, c.file, file)
+		}
+	}
+}
+
+// TestSplitList tests SplitList function.
+func (ts *Suite) TestSplitList(t *testing.T, _ string) {
+	vfs := ts.vfsTest
+
+	if sep := avfs.ListSeparator(vfs); (vfs.OSType() == avfs.OsWindows) != (sep == ';') {
+		t.Errorf("ListSeparator : want separator for %s, got %q", vfs.OSType(), sep)
+	}
+
+	type splitListTest struct {
+		path  string
+		elems []string
+	}
+
+	var splitListTests []*splitListTest
+
+	switch vfs.OSType() {
+	case avfs.OsWindows:
+		splitListTests = []*splitListTest{
+			{path: ``, elems: []string{}},
+			{path: `;`, elems: []string{``, ``}},
+			{path: `a;b;c`, elems: []string{`a`, `b`, `c`}},
+			{path: `a;;b`, elems: []string{`a`, ``, `b`}},
+			{path: `"a"`, elems: []string{`a`}},
+			{path: `" a "`, elems: []string{` a `}},
+			{path: `"a;b"`, elems: []string{`a;b`}},
+			{path: `c:\a;"c:\b;c"`, elems: []string{`c:\a`, `c:\b;c`}},
+			{path: `"c:\a";"c:\b"`, elems: []string{`c:\a`, `c:\b`}},
+			{path: `c:\a:b`, elems: []string{`c:\a:b`}},
+		}
+	default:
+		splitListTests = []*splitListTest{
+			{path: "", elems: []string{}},
+			{path: ":", elems: []string{"", ""}},
+			{path: "a:b:c", elems: []string{"a", "b", "c"}},
+			{path: "a::b", elems: []string{"a", "", "b"}},
+			{path: `"a:b"`, elems: []string{`"a`, `b"`}},
+			{path: "/usr/bin;/bin", elems: []string{"/usr/bin;/bin"}},
+		}
+	}
+
+	for _, test := range splitListTests {
+		elems := avfs.SplitList(vfs, test.path)
+		if elems == nil || !slices.Equal(elems, test.elems) {
+			t.Errorf("SplitList(%q) = %q, want %q", test.path, elems, test.elems)
+		}
+	}
+}
+
+// TestSetExecutable tests avfs.SetExecutable function.
+func (ts *Suite) TestSetExecutable(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := ts.emptyFile(t, testDir)
+
+		err := avfs.SetExecutable(vfs, path, true)
+		AssertPathError(t, err).Op("chmod").Path(path).ErrPermDenied().Test()
+
+		return
+	}
+
+	if vfs.OSType() == avfs.OsWindows {
+		return
+	}
+
+	path := ts.existingFile(t, testDir, nil)
+
+	for _, c := range []struct {
+		mode, wantMode fs.FileMode
+		executable     bool
+	}{
+		{mode: 0o644, executable: true, wantMode: 0o755},
+		{mode: 0o755, executable: false, wantMode: 0o644},
+		{mode: 0o640, executable: true, wantMode: 0o750},
+		{mode: 0o600, executable: true, wantMode: 0o700},
+		{mode: 0o751, executable: false, wantMode: 0o640},
+		{mode: 0o755, executable: true, wantMode: 0o755},
+	} {
+		err := vfs.Chmod(path, c.mode)
+		RequireNoError(t, err, "Chmod %s", path)
+
+		err = avfs.SetExecutable(vfs, path, c.executable)
+		RequireNoError(t, err, "SetExecutable %s", path)
+
+		info, err := vfs.Stat(path)
+		RequireNoError(t, err, "Stat %s", path)
+
+		if info.Mode().Perm() != c.wantMode {
+			t.Errorf("SetExecutable %s %t : want mode to be %s, got %s", c.mode, c.executable, c.wantMode, info.Mode().Perm())
+		}
+	}
+
+	t.Run("SetExecutableNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		err := avfs.SetExecutable(vfs, nonExistingFile, true)
+		AssertPathError(t, err).Op("stat").Path(nonExistingFile).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+}
+
+// TestSharedTempDir tests SharedTempDir function and SharedDir methods.
+func (ts *Suite) TestSharedTempDir(t *testing.T, _ string) {
+	const holders = 8
+
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		return
+	}
+
+	sd, err := SharedTempDir(vfs, "shared")
+	RequireNoError(t, err, "SharedTempDir")
+
+	dir := sd.Dir()
+
+	t.Run("SharedTempDirParallel", func(t *testing.T) {
+		for i := 0; i < holders; i++ {
+			name := "holder" + strconv.Itoa(i)
+
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				err := sd.Acquire()
+				RequireNoError(t, err, "Acquire")
+
+				path := vfs.Join(sd.Dir(), name)
+
+				err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+				AssertNoError(t, err, "WriteFile %s", path)
+
+				err = sd.Release()
+				RequireNoError(t, err, "Release")
+			})
+		}
+	})
+
+	entries, err := vfs.ReadDir(dir)
+	RequireNoError(t, err, "ReadDir %s", dir)
+
+	if len(entries) != holders {
+		t.Errorf("ReadDir %s : want %d entries, got %d", dir, holders, len(entries))
+	}
+
+	err = sd.Release()
+	RequireNoError(t, err, "Release")
+
+	_, err = vfs.Stat(dir)
+	AssertPathError(t, err).Op("stat").Path(dir).
+		OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+		OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+
+	err = sd.Acquire()
+	if !errors.Is(err, ErrSharedDirReleased) {
+		t.Errorf("Acquire : want error to be %v, got %v", ErrSharedDirReleased, err)
+	}
+
+	err = sd.Release()
+	if !errors.Is(err, ErrSharedDirReleased) {
+		t.Errorf("Release : want error to be %v, got %v", ErrSharedDirReleased, err)
+	}
+}
+
+// TestTouch tests avfs.Touch and avfs.TouchAt functions.
+func (ts *Suite) TestTouch(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := ts.emptyFile(t, testDir)
+
+		err := avfs.Touch(vfs, path)
+		AssertPathError(t, err).Op("chtimes").Path(path).ErrPermDenied().Test()
+
+		return
+	}
+
+	t.Run("TouchNonExisting", func(t *testing.T) {
+		path := vfs.Join(testDir, "TouchNonExisting")
+
+		err := avfs.Touch(vfs, path)
+		RequireNoError(t, err, "Touch %s", path)
+
+		info, err := vfs.Stat(path)
+		RequireNoError(t, err, "Stat %s", path)
+
+		if !info.Mode().IsRegular() || info.Size() != 0 {
+			t.Errorf("Touch : want an empty regular file, got mode %s and size %d", info.Mode(), info.Size())
+		}
+	})
+
+	t.Run("TouchExisting", func(t *testing.T) {
+		data := []byte("TouchExisting")
+		path := ts.existingFile(t, testDir, data)
+		oldTime := time.Now().Add(-time.Hour)
+
+		err := vfs.Chtimes(path, oldTime, oldTime)
+		RequireNoError(t, err, "Chtimes %s", path)
+
+		err = avfs.Touch(vfs, path)
+		RequireNoError(t, err, "Touch %s", path)
+
+		info, err := vfs.Stat(path)
+		RequireNoError(t, err, "Stat %s", path)
+
+		if !info.ModTime().After(oldTime) {
+			t.Errorf("Touch : want modification time to be after %v, got %v", oldTime, info.ModTime())
+		}
+
+		content, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("Touch : want content to be %s, got %s", data, content)
+		}
+	})
+
+	t.Run("TouchNonExistingParent", func(t *testing.T) {
+		path := vfs.Join(ts.nonExistingFile(t, testDir), "file")
+
+		err := avfs.Touch(vfs, path)
+		AssertPathError(t, err).Op("open").Path(path).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinPathNotFound).Test()
+
+		err = avfs.TouchAt(vfs, path, time.Now(), time.Now())
+		AssertPathError(t, err).Op("open").Path(path).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinPathNotFound).Test()
+	})
+
+	t.Run("TouchAt", func(t *testing.T) {
+		data := []byte("TouchAt")
+		existing := ts.existingFile(t, testDir, data)
+		nonExisting := vfs.Join(testDir, "TouchAtNonExisting")
+		mtime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		for _, path := range []string{existing, nonExisting} {
+			err := avfs.TouchAt(vfs, path, mtime, mtime)
+			RequireNoError(t, err, "TouchAt %s", path)
+
+			info, err := vfs.Stat(path)
+			RequireNoError(t, err, "Stat %s", path)
+
+			if !info.ModTime().Equal(mtime) {
+				t.Errorf("TouchAt %s : want modification time to be %v, got %v", path, mtime, info.ModTime())
+			}
+		}
+
+		content, err := vfs.ReadFile(existing)
+		RequireNoError(t, err, "ReadFile %s", existing)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("TouchAt : want content to be %s, got %s", data, content)
+		}
+	})
+}
+
+// TestWalkDir tests WalkDir function.
+func (ts *Suite) TestWalkDir(t *testing.T, testDir string) {
+	dirs := ts.createSampleDirs(t, testDir)
+	files := ts.createSampleFiles(t, testDir)
+	symlinks := ts.createSampleSymlinks(t, testDir)
+
+	vfs := ts.vfsTest
+	lNames := len(dirs) + len(files) + len(symlinks)
+	wantNames := make([]string, 0, lNames)
+
+	wantNames = append(wantNames, testDir)
+	for _, dir := range dirs {
+		wantNames = append(wantNames, dir.Path)
+	}
+
+	for _, file := range files {
+		wantNames = append(wantNames, file.Path)
+	}
+
+	if vfs.HasFeature(avfs.FeatSymlink) {
+		for _, sl := range symlinks {
+			wantNames = append(wantNames, sl.NewPath)
+		}
+	}
+
+	sort.Strings(wantNames)
+
+	t.Run("WalkDir", func(t *testing.T) {
+		gotNames := make(map[string]int)
+		err := vfs.WalkDir(testDir, func(path string, info fs.DirEntry, err error) error {
+			gotNames[path]++
+
+			return nil
+		})
+		RequireNoError(t, err, "WalkDir %s", testDir)
+
+		if len(wantNames) != len(gotNames) {
+			t.Errorf("Walk %s : want %d files or dirs, got %d", testDir, len(wantNames), len(gotNames))
+		}
+
+		for _, wantName := range wantNames {
+			n, ok := gotNames[wantName]
+			if !ok || n != 1 {
+				t.Errorf("Walk %s : path %s not found", testDir, wantName)
+			}
+		}
+	})
+
+	t.Run("WalkDirSkipDir", func(t *testing.T) {
+		skipDir := dirs[0].Path
+		skipPrefix := skipDir + string(vfs.PathSeparator())
+
+		err := vfs.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
+			if strings.HasPrefix(path, skipPrefix) {
+				t.Errorf("WalkDir %s : want %s to be skipped, got %s", testDir, skipDir, path)
+			}
+
+			if path == skipDir {
+				return filepath.SkipDir
+			}
+
+			return nil
+		})
+		RequireNoError(t, err, "WalkDir %s", testDir)
+	})
+
+	t.Run("WalkDirSkipAll", func(t *testing.T) {
+		const maxPaths = 3
+
+		nbPaths := 0
+
+		err := vfs.WalkDir(testDir, func(path string, d fs.DirEntry, err error) error {
+			nbPaths++
+			if nbPaths == maxPaths {
+				return filepath.SkipAll
+			}
+
+			return nil
+		})
+		RequireNoError(t, err, "WalkDir %s", testDir)
+
+		if nbPaths != maxPaths {
+			t.Errorf("WalkDir %s : want %d paths to be walked, got %d", testDir, maxPaths, nbPaths)
+		}
+	})
+
+	t.Run("WalkNonExistingFile", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		err := vfs.WalkDir(nonExistingFile, func(path string, info fs.DirEntry, err error) error {
 			return nil
 		})
 
 		RequireNoError(t, err, "WalkDir %s", nonExistingFile)
 	})
 }
+
+// TestWalkBreadthFirst tests WalkBreadthFirst function.
+func (ts *Suite) TestWalkBreadthFirst(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	for _, dir := range []string{"a", "a/x", "a/x/deep", "c", "c/y"} {
+		ts.createDir(t, vfs.Join(testDir, vfs.FromSlash(dir)), avfs.DefaultDirPerm)
+	}
+
+	for _, file := range []string{"a/file", "b", "c/y/file"} {
+		ts.createFile(t, vfs.Join(testDir, vfs.FromSlash(file)), avfs.DefaultFilePerm)
+	}
+
+	walk := func(t *testing.T, maxDepth int, skip string) []string {
+		t.Helper()
+
+		var paths []string
+
+		err := avfs.WalkBreadthFirst(vfs, testDir, maxDepth, func(path string, d fs.DirEntry) error {
+			rel, err := vfs.Rel(testDir, path)
+			RequireNoError(t, err, "Rel %s", path)
+
+			rel = vfs.ToSlash(rel)
+			paths = append(paths, rel)
+
+			if rel == skip {
+				return filepath.SkipDir
+			}
+
+			return nil
+		})
+		RequireNoError(t, err, "WalkBreadthFirst %s", testDir)
+
+		return paths
+	}
+
+	tests := []struct {
+		name     string
+		skip     string
+		want     []string
+		maxDepth int
+	}{
+		{
+			name: "Unlimited", maxDepth: -1,
+			want: []string{".", "a", "b", "c", "a/file", "a/x", "c/y", "a/x/deep", "c/y/file"},
+		},
+		{
+			name: "MaxDepth0", maxDepth: 0,
+			want: []string{"."},
+		},
+		{
+			name: "MaxDepth2", maxDepth: 2,
+			want: []string{".", "a", "b", "c", "a/file", "a/x", "c/y"},
+		},
+		{
+			name: "SkipDir", maxDepth: -1, skip: "a",
+			want: []string{".", "a", "b", "c", "c/y", "c/y/file"},
+		},
+		{
+			name: "SkipFile", maxDepth: -1, skip: "a/file",
+			want: []string{".", "a", "b", "c", "a/file", "c/y", "c/y/file"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run("WalkBreadthFirst"+test.name, func(t *testing.T) {
+			paths := walk(t, test.maxDepth, test.skip)
+			if !slices.Equal(paths, test.want) {
+				t.Errorf("WalkBreadthFirst : want paths to be %v, got %v", test.want, paths)
+			}
+		})
+	}
+}
+
+// TestWalkFollow tests avfs.WalkFollow function.
+func (ts *Suite) TestWalkFollow(t *testing.T, testDir string) {
+	vfsSetup := ts.vfsSetup
+	vfs := ts.vfsTest
+
+	if !vfs.HasFeature(avfs.FeatSymlink) {
+		return
+	}
+
+	dirA := vfs.Join(testDir, "a")
+	file := vfs.Join(dirA, "file")
+
+	ts.createDir(t, dirA, avfs.DefaultDirPerm)
+	ts.createFile(t, file, avfs.DefaultFilePerm)
+
+	for _, sl := range []struct{ oldname, newname string }{
+		{oldname: dirA, newname: vfs.Join(dirA, "self")},
+		{oldname: dirA, newname: vfs.Join(testDir, "b")},
+		{oldname: file, newname: vfs.Join(testDir, "fl")},
+	} {
+		err := vfsSetup.Symlink(sl.oldname, sl.newname)
+		RequireNoError(t, err, "Symlink %s %s", sl.oldname, sl.newname)
+	}
+
+	t.Run("WalkFollow", func(t *testing.T) {
+		var paths, cycles []string
+
+		err := avfs.WalkFollow(vfs, testDir, func(path string, info fs.FileInfo, err error) error {
+			rel, errRel := vfs.Rel(testDir, path)
+			RequireNoError(t, errRel, "Rel %s", path)
+
+			rel = vfs.ToSlash(rel)
+
+			if err != nil {
+				if !errors.Is(err, avfs.ErrTooManySymlinks) {
+					t.Errorf("WalkFollow %s : want error to be %v, got %v", rel, avfs.ErrTooManySymlinks, err)
+				}
+
+				cycles = append(cycles, rel)
+
+				return nil
+			}
+
+			if info.Mode()&fs.ModeSymlink != 0 {
+				t.Errorf("WalkFollow %s : want the information of the target, got mode %s", rel, info.Mode())
+			}
+
+			paths = append(paths, rel)
+
+			return nil
+		})
+		RequireNoError(t, err, "WalkFollow %s", testDir)
+
+		wantPaths := []string{".", "a", "a/file", "b", "b/file", "fl"}
+		if !slices.Equal(paths, wantPaths) {
+			t.Errorf("WalkFollow : want paths to be %v, got %v", wantPaths, paths)
+		}
+
+		wantCycles := []string{"a/self", "b/self"}
+		if !slices.Equal(cycles, wantCycles) {
+			t.Errorf("WalkFollow : want cycles to be %v, got %v", wantCycles, cycles)
+		}
+	})
+
+	t.Run("WalkFollowCycleError", func(t *testing.T) {
+		err := avfs.WalkFollow(vfs, dirA, func(path string, info fs.FileInfo, err error) error {
+			return err
+		})
+		if !errors.Is(err, avfs.ErrTooManySymlinks) {
+			t.Errorf("WalkFollow : want error to be %v, got %v", avfs.ErrTooManySymlinks, err)
+		}
+	})
+}
+
+// TestWriteFileAtomic tests avfs.WriteFileAtomic function.
+func (ts *Suite) TestWriteFileAtomic(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+	data := []byte("WriteFileAtomic")
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := ts.emptyFile(t, testDir)
+
+		err := avfs.WriteFileAtomic(vfs, path, data, avfs.DefaultFilePerm)
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("WriteFileAtomic : want error to be %v, got %v", fs.ErrPermission, err)
+		}
+
+		return
+	}
+
+	assertNoTempFile := func(t *testing.T, dir string) {
+		t.Helper()
+
+		entries, err := vfs.ReadDir(dir)
+		RequireNoError(t, err, "ReadDir %s", dir)
+
+		for _, entry := range entries {
+			if strings.HasSuffix(entry.Name(), ".tmp") {
+				t.Errorf("WriteFileAtomic : want no temporary file, got %s", entry.Name())
+			}
+		}
+	}
+
+	t.Run("WriteFileAtomicNew", func(t *testing.T) {
+		dir := ts.existingDir(t, testDir)
+		path := vfs.Join(dir, "new.cfg")
+
+		err := avfs.WriteFileAtomic(vfs, path, data, 0o640)
+		RequireNoError(t, err, "WriteFileAtomic %s", path)
+
+		content, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("WriteFileAtomic : want content to be %s, got %s", data, content)
+		}
+
+		if vfs.OSType() != avfs.OsWindows {
+			info, err := vfs.Stat(path)
+			RequireNoError(t, err, "Stat %s", path)
+
+			wantMode := fs.FileMode(0o640) &^ vfs.UMask()
+			if info.Mode().Perm() != wantMode {
+				t.Errorf("WriteFileAtomic : want mode to be %s, got %s", wantMode, info.Mode().Perm())
+			}
+		}
+
+		assertNoTempFile(t, dir)
+	})
+
+	t.Run("WriteFileAtomicExisting", func(t *testing.T) {
+		dir := ts.existingDir(t, testDir)
+		path := vfs.Join(dir, "existing.cfg")
+
+		err := vfs.WriteFile(path, []byte("previous content"), avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", path)
+
+		err = vfs.Chmod(path, 0o600)
+		RequireNoError(t, err, "Chmod %s", path)
+
+		err = avfs.WriteFileAtomic(vfs, path, data, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFileAtomic %s", path)
+
+		content, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("WriteFileAtomic : want content to be %s, got %s", data, content)
+		}
+
+		if vfs.OSType() != avfs.OsWindows {
+			info, err := vfs.Stat(path)
+			RequireNoError(t, err, "Stat %s", path)
+
+			if info.Mode().Perm() != 0o600 {
+				t.Errorf("WriteFileAtomic : want mode to be preserved as %s, got %s", fs.FileMode(0o600), info.Mode().Perm())
+			}
+		}
+
+		assertNoTempFile(t, dir)
+	})
+
+	t.Run("WriteFileAtomicNonExistingDir", func(t *testing.T) {
+		path := vfs.Join(ts.nonExistingFile(t, testDir), "file")
+
+		err := avfs.WriteFileAtomic(vfs, path, data, avfs.DefaultFilePerm)
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("WriteFileAtomic : want error to be %v, got %v", fs.ErrNotExist, err)
+		}
+	})
+}
+
+// TestWriteFileMkdirAll tests avfs.WriteFileMkdirAll function.
+func (ts *Suite) TestWriteFileMkdirAll(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+	data := []byte("WriteFileMkdirAll")
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		path := vfs.Join(testDir, "a", "b", "c.txt")
+
+		err := avfs.WriteFileMkdirAll(vfs, path, data, avfs.DefaultFilePerm, avfs.DefaultDirPerm)
+		if !errors.Is(err, fs.ErrPermission) {
+			t.Errorf("WriteFileMkdirAll : want error to be %v, got %v", fs.ErrPermission, err)
+		}
+
+		return
+	}
+
+	assertMode := func(t *testing.T, path string, wantMode fs.FileMode) {
+		t.Helper()
+
+		info, err := vfs.Stat(path)
+		RequireNoError(t, err, "Stat %s", path)
+
+		if vfs.OSType() == avfs.OsWindows {
+			return
+		}
+
+		wantMode &^= vfs.UMask()
+		if info.Mode() != wantMode {
+			t.Errorf("Stat %s : want mode to be %s, got %s", path, wantMode, info.Mode())
+		}
+	}
+
+	t.Run("WriteFileMkdirAllNew", func(t *testing.T) {
+		dirA := vfs.Join(testDir, "a")
+		dirB := vfs.Join(dirA, "b")
+		path := vfs.Join(dirB, "c.txt")
+
+		err := avfs.WriteFileMkdirAll(vfs, path, data, 0o640, 0o750)
+		RequireNoError(t, err, "WriteFileMkdirAll %s", path)
+
+		content, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("WriteFileMkdirAll : want content to be %s, got %s", data, content)
+		}
+
+		assertMode(t, dirA, fs.ModeDir|0o750)
+		assertMode(t, dirB, fs.ModeDir|0o750)
+		assertMode(t, path, 0o640)
+	})
+
+	t.Run("WriteFileMkdirAllExistingDir", func(t *testing.T) {
+		dir := ts.existingDir(t, testDir)
+		path := vfs.Join(dir, "existing.txt")
+
+		info, err := vfs.Stat(dir)
+		RequireNoError(t, err, "Stat %s", dir)
+
+		err = avfs.WriteFileMkdirAll(vfs, path, data, avfs.DefaultFilePerm, 0o700)
+		RequireNoError(t, err, "WriteFileMkdirAll %s", path)
+
+		assertMode(t, dir, info.Mode())
+		assertMode(t, path, avfs.DefaultFilePerm)
+	})
+
+	t.Run("WriteFileMkdirAllParentIsFile", func(t *testing.T) {
+		file := ts.existingFile(t, testDir, nil)
+		path := vfs.Join(file, "c.txt")
+
+		err := avfs.WriteFileMkdirAll(vfs, path, data, avfs.DefaultFilePerm, avfs.DefaultDirPerm)
+		if err == nil {
+			t.Errorf("WriteFileMkdirAll %s : want error, got nil", path)
+		}
+	})
+}
@@ -24,6 +24,7 @@ import (
 	"os"
 	"reflect"
 	"regexp"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -42,6 +43,7 @@ func (ts *Suite) TestVFS(t *testing.T) {
 		ts.TestChtimes,
 		ts.TestCreate,
 		ts.TestCreateTemp,
+		ts.TestDirModTime,
 		ts.TestEvalSymlink,
 		ts.TestFromToSlash,
 		ts.TestGlob,
@@ -64,6 +66,7 @@ func (ts *Suite) TestVFS(t *testing.T) {
 		ts.TestRemove,
 		ts.TestRemoveAll,
 		ts.TestRename,
+		ts.TestRenameFlags,
 		ts.TestSameFile,
 		ts.TestSplit,
 		ts.TestSplitAbs,
@@ -76,6 +79,7 @@ func (ts *Suite) TestVFS(t *testing.T) {
 		ts.TestWalkDir,
 		ts.TestWriteFile,
 		ts.TestWriteString,
+		ts.TestXattr,
 	)
 
 	// Tests to be run as root
@@ -376,8 +380,20 @@ func (ts *Suite) TestChown(t *testing.T, testDir string) {
 func (ts *Suite) TestChroot(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
 	vfsR, ok := vfs.(avfs.ChRooter)
+	if !ok {
+		return
+	}
+
+	if !vfs.HasFeature(avfs.FeatChroot) {
+		err := vfsR.Chroot(testDir)
+		AssertPathError(t, err).Op("chroot").Path(testDir).FeatureMissing(avfs.FeatChroot).
+			OSType(avfs.OsLinux).Err(avfs.ErrOpNotPermitted).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinNotSupported).Test()
+
+		return
+	}
 
-	if !ok || !ts.canTestPerm {
+	if !ts.canTestPerm {
 		return
 	}
 
@@ -525,6 +541,57 @@ func (ts *Suite) TestCreate(t *testing.T, testDir string) {
 			return err
 		})
 	})
+
+	t.Run("CreateSpecialNames", func(t *testing.T) {
+		names := []string{"with space", "-leading dash", "emoji \U0001F600", "नमस्ते"}
+		if vfs.OSType() != avfs.OsWindows {
+			names = append(names, "tab\tname", "new\nline", "co:lon", " ")
+		}
+
+		dir := vfs.Join(testDir, "specialNames")
+
+		err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+		RequireNoError(t, err, "Mkdir %s", dir)
+
+		for _, name := range names {
+			path := vfs.Join(dir, name)
+
+			err = vfs.WriteFile(path, []byte(name), avfs.DefaultFilePerm)
+			RequireNoError(t, err, "WriteFile %q", path)
+		}
+
+		dirNames, err := avfs.ReadDirNames(vfs, dir)
+		RequireNoError(t, err, "ReadDirNames %s", dir)
+
+		wantNames := slices.Clone(names)
+		slices.Sort(wantNames)
+		slices.Sort(dirNames)
+
+		if !slices.Equal(dirNames, wantNames) {
+			t.Errorf("ReadDirNames %s : want names to be %q, got %q", dir, wantNames, dirNames)
+		}
+
+		for _, name := range names {
+			path := vfs.Join(dir, name)
+
+			data, err := vfs.ReadFile(path)
+			RequireNoError(t, err, "ReadFile %q", path)
+
+			if string(data) != name {
+				t.Errorf("ReadFile %q : want content to be %q, got %q", path, name, data)
+			}
+		}
+	})
+
+	t.Run("CreateNul", func(t *testing.T) {
+		path := vfs.Join(testDir, "nul\x00name")
+
+		_, err := vfs.Create(path)
+		AssertPathError(t, err).Op("open").Path(path).Err(avfs.ErrInvalidArgument).Test()
+
+		err = vfs.Mkdir(path, avfs.DefaultDirPerm)
+		AssertPathError(t, err).Op("mkdir").Path(path).Err(avfs.ErrInvalidArgument).Test()
+	})
 }
 
 // TestCreateTemp tests CreateTemp function.
@@ -556,6 +623,22 @@ func (ts *Suite) TestCreateTemp(t *testing.T, testDir string) {
 		RequireNoError(t, err, "Remove")
 	})
 
+	t.Run("CreateTempPattern", func(t *testing.T) {
+		f, err := vfs.CreateTemp(testDir, "pre-*.tmp")
+		RequireNoError(t, err, "CreateTemp")
+
+		defer f.Close()
+
+		name := vfs.Base(f.Name())
+		if !strings.HasPrefix(name, "pre-") || !strings.HasSuffix(name, ".tmp") || len(name) <= len("pre-.tmp") {
+			t.Errorf("CreateTemp : want name to match pre-*.tmp, got %s", name)
+		}
+
+		if dir := vfs.Dir(f.Name()); dir != testDir {
+			t.Errorf("CreateTemp : want directory to be %s, got %s", testDir, dir)
+		}
+	})
+
 	t.Run("CreateTempBadPattern", func(t *testing.T) {
 		badPattern := vfs.TempDir()
 
@@ -595,13 +678,103 @@ func (ts *Suite) TestUser(t *testing.T, _ string) {
 	}
 }
 
+// TestDirModTime tests that the modification time of a directory is updated
+// when entries are added or removed.
+func (ts *Suite) TestDirModTime(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	if vfs.HasFeature(avfs.FeatReadOnly) {
+		return
+	}
+
+	oldTime := time.Now().Add(-time.Hour)
+
+	// dirModTimeTest creates a directory with an old modification time, calls fn on it
+	// and checks that the modification time of the directory was updated.
+	dirModTimeTest := func(t *testing.T, name string, fn func(dir string) error) {
+		dir := vfs.Join(testDir, name)
+
+		err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+		RequireNoError(t, err, "Mkdir %s", dir)
+
+		err = vfs.WriteFile(vfs.Join(dir, "existing"), nil, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", dir)
+
+		err = vfs.Chtimes(dir, oldTime, oldTime)
+		RequireNoError(t, err, "Chtimes %s", dir)
+
+		err = fn(dir)
+		RequireNoError(t, err, "%s %s", name, dir)
+
+		info, err := vfs.Stat(dir)
+		RequireNoError(t, err, "Stat %s", dir)
+
+		if !info.ModTime().After(oldTime) {
+			t.Errorf("%s : want modification time of %s to be after %v, got %v", name, dir, oldTime, info.ModTime())
+		}
+	}
+
+	t.Run("DirModTimeCreate", func(t *testing.T) {
+		dirModTimeTest(t, "Create", func(dir string) error {
+			f, err := vfs.Create(vfs.Join(dir, "file"))
+			if err != nil {
+				return err
+			}
+
+			return f.Close()
+		})
+	})
+
+	t.Run("DirModTimeMkdir", func(t *testing.T) {
+		dirModTimeTest(t, "Mkdir", func(dir string) error {
+			return vfs.Mkdir(vfs.Join(dir, "dir"), avfs.DefaultDirPerm)
+		})
+	})
+
+	t.Run("DirModTimeRemove", func(t *testing.T) {
+		dirModTimeTest(t, "Remove", func(dir string) error {
+			return vfs.Remove(vfs.Join(dir, "existing"))
+		})
+	})
+
+	t.Run("DirModTimeRemoveAll", func(t *testing.T) {
+		dirModTimeTest(t, "RemoveAll", func(dir string) error {
+			return vfs.RemoveAll(vfs.Join(dir, "existing"))
+		})
+	})
+
+	t.Run("DirModTimeRenameFrom", func(t *testing.T) {
+		dirModTimeTest(t, "RenameFrom", func(dir string) error {
+			return vfs.Rename(vfs.Join(dir, "existing"), vfs.Join(testDir, "RenameFromFile"))
+		})
+	})
+
+	t.Run("DirModTimeRenameTo", func(t *testing.T) {
+		path := ts.existingFile(t, testDir, nil)
+
+		dirModTimeTest(t, "RenameTo", func(dir string) error {
+			return vfs.Rename(path, vfs.Join(dir, "renamed"))
+		})
+	})
+
+	t.Run("DirModTimeSymlink", func(t *testing.T) {
+		if !vfs.HasFeature(avfs.FeatSymlink) {
+			return
+		}
+
+		dirModTimeTest(t, "Symlink", func(dir string) error {
+			return vfs.Symlink(vfs.Join(dir, "existing"), vfs.Join(dir, "symlink"))
+		})
+	})
+}
+
 // TestEvalSymlink tests EvalSymlink function.
 func (ts *Suite) TestEvalSymlink(t *testing.T, testDir string) {
 	vfs := ts.vfsTest
 
 	if !vfs.HasFeature(avfs.FeatSymlink) {
 		_, err := vfs.EvalSymlinks(testDir)
-		AssertPathError(t, err).OpLstat().Path(testDir).ErrPermDenied().Test()
+		AssertPathError(t, err).OpLstat().Path(testDir).ErrPermDenied().FeatureMissing(avfs.FeatSymlink).Test()
 
 		return
 	}
@@ -1261,6 +1434,23 @@ func (ts *Suite) TestMkdirTemp(t *testing.T, testDir string) {
 		RequireNoError(t, err, "Remove %s", tmpDir)
 	})
 
+	t.Run("MkdirTempPattern", func(t *testing.T) {
+		tmpDir, err := vfs.MkdirTemp(testDir, "pre-*.tmp")
+		RequireNoError(t, err, "MkdirTemp")
+
+		name := vfs.Base(tmpDir)
+		if !strings.HasPrefix(name, "pre-") || !strings.HasSuffix(name, ".tmp") || len(name) <= len("pre-.tmp") {
+			t.Errorf("MkdirTemp : want name to match pre-*.tmp, got %s", name)
+		}
+
+		info, err := vfs.Stat(tmpDir)
+		RequireNoError(t, err, "Stat %s", tmpDir)
+
+		if !info.IsDir() {
+			t.Errorf("MkdirTemp : want %s to be a directory", tmpDir)
+		}
+	})
+
 	t.Run("MkdirTempBadPattern", func(t *testing.T) {
 		badPattern := vfs.TempDir()
 
@@ -1698,6 +1888,26 @@ func (ts *Suite) TestReadFile(t *testing.T, testDir string) {
 		}
 	})
 
+	t.Run("ReadFileEmptyAfterLarge", func(t *testing.T) {
+		large := bytes.Repeat([]byte("0123456789"), 10*1024)
+		largePath := ts.existingFile(t, testDir, large)
+		emptyPath := ts.emptyFile(t, testDir)
+
+		rb, err := vfs.ReadFile(largePath)
+		RequireNoError(t, err, "ReadFile %s", largePath)
+
+		if !bytes.Equal(rb, large) {
+			t.Errorf("ReadFile : want content of length %d, got %d", len(large), len(rb))
+		}
+
+		rb, err = vfs.ReadFile(emptyPath)
+		RequireNoError(t, err, "ReadFile %s", emptyPath)
+
+		if len(rb) != 0 {
+			t.Errorf("ReadFile : want read bytes to be 0, got %d", len(rb))
+		}
+	})
+
 	t.Run("ReadFileNotExisting", func(t *testing.T) {
 		path := ts.nonExistingFile(t, testDir)
 
@@ -1719,7 +1929,7 @@ func (ts *Suite) TestReadlink(t *testing.T, testDir string) {
 	if !vfs.HasFeature(avfs.FeatSymlink) {
 		_, err := vfs.Readlink(testDir)
 
-		AssertPathError(t, err).Op("readlink").Path(testDir).
+		AssertPathError(t, err).Op("readlink").Path(testDir).FeatureMissing(avfs.FeatSymlink).
 			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
 			OSType(avfs.OsWindows).Err(avfs.ErrWinNotReparsePoint).Test()
 
@@ -1947,6 +2157,40 @@ func (ts *Suite) TestRemoveAll(t *testing.T, testDir string) {
 		RequireNoError(t, err, "RemoveAll %s", nonExistingFile)
 	})
 
+	t.Run("RemoveAllSymlinkToDir", func(t *testing.T) {
+		if !vfs.HasFeature(avfs.FeatSymlink) {
+			return
+		}
+
+		victimDir := vfs.Join(testDir, "victim")
+		keepFile := vfs.Join(victimDir, "keep.txt")
+		targetDir := vfs.Join(testDir, "target")
+		link := vfs.Join(targetDir, "link")
+		rootLink := vfs.Join(testDir, "rootLink")
+
+		ts.createDir(t, victimDir, avfs.DefaultDirPerm)
+		ts.createFile(t, keepFile, avfs.DefaultFilePerm)
+		ts.createDir(t, targetDir, avfs.DefaultDirPerm)
+
+		for _, sl := range []string{link, rootLink} {
+			err := vfs.Symlink(victimDir, sl)
+			RequireNoError(t, err, "Symlink %s", sl)
+		}
+
+		for _, path := range []string{targetDir, rootLink} {
+			err := vfs.RemoveAll(path)
+			RequireNoError(t, err, "RemoveAll %s", path)
+
+			_, err = vfs.Lstat(path)
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Lstat %s : want error to be %v, got %v", path, fs.ErrNotExist, err)
+			}
+
+			_, err = vfs.Stat(keepFile)
+			RequireNoError(t, err, "Stat %s", keepFile)
+		}
+	})
+
 	t.Run("RemoveAllPerm", func(t *testing.T) {
 		if !ts.canTestPerm {
 			return
@@ -2085,6 +2329,130 @@ func (ts *Suite) TestRename(t *testing.T, testDir string) {
 	})
 }
 
+// TestRenameFlags tests RenameNoReplace and RenameExchange functions.
+func (ts *Suite) TestRenameFlags(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	fr, ok := vfs.(avfs.FlagRenamer)
+	if !ok || vfs.HasFeature(avfs.FeatReadOnly) {
+		return
+	}
+
+	if !vfs.HasFeature(avfs.FeatRenameFlags) {
+		pathA := ts.existingFile(t, testDir, nil)
+		pathB := vfs.Join(testDir, "fileB")
+
+		err := fr.RenameNoReplace(pathA, pathB)
+		AssertLinkError(t, err).Op("rename").Old(pathA).New(pathB).FeatureMissing(avfs.FeatRenameFlags).
+			OSType(avfs.OsLinux).Err(avfs.ErrOpNotPermitted).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinNotSupported).Test()
+
+		err = fr.RenameExchange(pathA, pathB)
+		AssertLinkError(t, err).Op("rename").Old(pathA).New(pathB).FeatureMissing(avfs.FeatRenameFlags).
+			OSType(avfs.OsLinux).Err(avfs.ErrOpNotPermitted).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinNotSupported).Test()
+
+		return
+	}
+
+	pathA := vfs.Join(testDir, "fileA")
+	pathB := vfs.Join(testDir, "fileB")
+	dataA := []byte("AAAA")
+	dataB := []byte("BB")
+
+	assertContent := func(t *testing.T, path string, want []byte) {
+		t.Helper()
+
+		got, err := vfs.ReadFile(path)
+		RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFile %s : want content to be %s, got %s", path, want, got)
+		}
+	}
+
+	t.Run("RenameNoReplace", func(t *testing.T) {
+		err := vfs.WriteFile(pathA, dataA, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", pathA)
+
+		err = fr.RenameNoReplace(pathA, pathB)
+		RequireNoError(t, err, "RenameNoReplace %s %s", pathA, pathB)
+
+		_, err = vfs.Stat(pathA)
+		AssertPathError(t, err).OpStat().Path(pathA).Err(avfs.ErrNoSuchFileOrDir).Test()
+
+		assertContent(t, pathB, dataA)
+	})
+
+	t.Run("RenameNoReplaceExisting", func(t *testing.T) {
+		err := vfs.WriteFile(pathA, dataB, avfs.DefaultFilePerm)
+		RequireNoError(t, err, "WriteFile %s", pathA)
+
+		err = fr.RenameNoReplace(pathA, pathB)
+		AssertLinkError(t, err).Op("rename").Old(pathA).New(pathB).Err(avfs.ErrFileExists).Test()
+
+		assertContent(t, pathA, dataB)
+		assertContent(t, pathB, dataA)
+	})
+
+	t.Run("RenameNoReplaceNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+		newPath := vfs.Join(testDir, "newPath")
+
+		err := fr.RenameNoReplace(nonExistingFile, newPath)
+		AssertLinkError(t, err).Op("rename").Old(nonExistingFile).New(newPath).Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+
+	t.Run("RenameExchange", func(t *testing.T) {
+		infoA, err := vfs.Stat(pathA)
+		RequireNoError(t, err, "Stat %s", pathA)
+
+		infoB, err := vfs.Stat(pathB)
+		RequireNoError(t, err, "Stat %s", pathB)
+
+		err = fr.RenameExchange(pathA, pathB)
+		RequireNoError(t, err, "RenameExchange %s %s", pathA, pathB)
+
+		assertContent(t, pathA, dataA)
+		assertContent(t, pathB, dataB)
+
+		newInfoA, err := vfs.Stat(pathA)
+		RequireNoError(t, err, "Stat %s", pathA)
+
+		newInfoB, err := vfs.Stat(pathB)
+		RequireNoError(t, err, "Stat %s", pathB)
+
+		if !vfs.SameFile(newInfoA, infoB) || !vfs.SameFile(newInfoB, infoA) {
+			t.Errorf("RenameExchange : want files %s and %s to be exchanged", pathA, pathB)
+		}
+	})
+
+	t.Run("RenameExchangeDir", func(t *testing.T) {
+		dir := ts.existingDir(t, testDir)
+
+		err := fr.RenameExchange(dir, pathA)
+		RequireNoError(t, err, "RenameExchange %s %s", dir, pathA)
+
+		info, err := vfs.Stat(pathA)
+		RequireNoError(t, err, "Stat %s", pathA)
+
+		if !info.IsDir() {
+			t.Errorf("RenameExchange : want %s to be a directory", pathA)
+		}
+
+		assertContent(t, dir, dataA)
+	})
+
+	t.Run("RenameExchangeNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		err := fr.RenameExchange(pathB, nonExistingFile)
+		AssertLinkError(t, err).Op("rename").Old(pathB).New(nonExistingFile).Err(avfs.ErrNoSuchFileOrDir).Test()
+
+		assertContent(t, pathB, dataB)
+	})
+}
+
 // TestSameFile tests SameFile function.
 func (ts *Suite) TestSameFile(t *testing.T, testDir string) {
 	vfs := ts.vfsSetup
@@ -2169,6 +2537,75 @@ func (ts *Suite) TestSameFile(t *testing.T, testDir string) {
 			RequireNoError(t, err, "Remove %s", path2)
 		}
 	})
+
+	t.Run("SameFileRename", func(t *testing.T) {
+		for _, file1 := range files1 {
+			path1 := file1.Path
+			path2 := vfs.Join(testDir2, strings.TrimPrefix(path1, testDir1))
+
+			info1, err := vfs.Stat(path1)
+			if !AssertNoError(t, err, "Stat %s", path1) {
+				continue
+			}
+
+			err = vfs.Rename(path1, path2)
+			RequireNoError(t, err, "Rename %s %s", path1, path2)
+
+			info2, err := vfs.Stat(path2)
+			if !AssertNoError(t, err, "Stat %s", path2) {
+				continue
+			}
+
+			if !vfs.SameFile(info1, info2) {
+				t.Errorf("SameFile %s, %s : not same files\n%v\n%v", path1, path2, info1, info2)
+			}
+
+			err = vfs.Rename(path2, path1)
+			RequireNoError(t, err, "Rename %s %s", path2, path1)
+		}
+	})
+
+	t.Run("SameFileDifferent", func(t *testing.T) {
+		info1, err := vfs.Stat(testDir1)
+		RequireNoError(t, err, "Stat %s", testDir1)
+
+		info2, err := vfs.Stat(testDir2)
+		RequireNoError(t, err, "Stat %s", testDir2)
+
+		if vfs.SameFile(info1, info2) {
+			t.Errorf("SameFile %s, %s : want different files\n%v\n%v", testDir1, testDir2, info1, info2)
+		}
+
+		if !vfs.HasFeature(avfs.FeatSymlink) {
+			return
+		}
+
+		path := files1[0].Path
+		link1 := vfs.Join(testDir2, "link1")
+		link2 := vfs.Join(testDir2, "link2")
+
+		for _, link := range []string{link1, link2} {
+			err = vfs.Symlink(path, link)
+			RequireNoError(t, err, "Symlink %s %s", path, link)
+		}
+
+		info1, err = vfs.Lstat(link1)
+		RequireNoError(t, err, "Lstat %s", link1)
+
+		info2, err = vfs.Lstat(link2)
+		RequireNoError(t, err, "Lstat %s", link2)
+
+		if vfs.SameFile(info1, info2) {
+			t.Errorf("SameFile %s, %s : want different files\n%v\n%v", link1, link2, info1, info2)
+		}
+
+		info3, err := vfs.Stat(path)
+		RequireNoError(t, err, "Stat %s", path)
+
+		if vfs.SameFile(info1, info3) {
+			t.Errorf("SameFile %s, %s : want different files\n%v\n%v", link1, path, info1, info3)
+		}
+	})
 }
 
 func (ts *Suite) TestSetUserByName(t *testing.T, testDir string) {
@@ -2367,8 +2804,13 @@ func (ts *Suite) TestSymlink(t *testing.T, testDir string) {
 
 	if !vfs.HasFeature(avfs.FeatSymlink) || vfs.HasFeature(avfs.FeatReadOnly) {
 		err := vfs.Symlink(testDir, testDir)
-		AssertLinkError(t, err).Op("symlink").Old(testDir).New(testDir).
-			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+
+		ae := AssertLinkError(t, err).Op("symlink").Old(testDir).New(testDir)
+		if !vfs.HasFeature(avfs.FeatReadOnly) {
+			ae.FeatureMissing(avfs.FeatSymlink)
+		}
+
+		ae.OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
 			OSType(avfs.OsWindows).Err(avfs.ErrWinPrivilegeNotHeld).Test()
 
 		return
@@ -2721,3 +3163,111 @@ func (ts *Suite) TestWriteString(t *testing.T, testDir string) {
 		}
 	})
 }
+
+// TestXattr tests extended attributes functions.
+func (ts *Suite) TestXattr(t *testing.T, testDir string) {
+	vfs := ts.vfsTest
+
+	xm, ok := vfs.(avfs.XattrManager)
+	if !ok || vfs.HasFeature(avfs.FeatReadOnly) {
+		return
+	}
+
+	if !vfs.HasFeature(avfs.FeatXattr) {
+		path := ts.existingFile(t, testDir, nil)
+
+		err := xm.SetXattr(path, "user.avfs", nil, 0)
+		AssertPathError(t, err).Op("setxattr").Path(path).FeatureMissing(avfs.FeatXattr).
+			OSType(avfs.OsLinux).Err(avfs.ErrOpNotPermitted).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinNotSupported).Test()
+
+		_, err = xm.ListXattr(path)
+		AssertPathError(t, err).Op("listxattr").Path(path).FeatureMissing(avfs.FeatXattr).
+			OSType(avfs.OsLinux).Err(avfs.ErrOpNotPermitted).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinNotSupported).Test()
+
+		return
+	}
+
+	const (
+		attrName  = "user.avfs"
+		attrName2 = "user.avfs2"
+	)
+
+	path := ts.emptyFile(t, testDir)
+	data := []byte("AAABBBCCCDDD")
+
+	t.Run("XattrSetGet", func(t *testing.T) {
+		err := xm.SetXattr(path, attrName, data, 0)
+		RequireNoError(t, err, "SetXattr %s", path)
+
+		got, err := xm.GetXattr(path, attrName)
+		RequireNoError(t, err, "GetXattr %s", path)
+
+		if !bytes.Equal(got, data) {
+			t.Errorf("GetXattr : want value to be %s, got %s", data, got)
+		}
+	})
+
+	t.Run("XattrList", func(t *testing.T) {
+		err := xm.LSetXattr(path, attrName2, data, 0)
+		RequireNoError(t, err, "LSetXattr %s", path)
+
+		names, err := xm.ListXattr(path)
+		RequireNoError(t, err, "ListXattr %s", path)
+
+		got, want := strings.Join(names, ","), attrName+","+attrName2
+		if got != want {
+			t.Errorf("ListXattr : want names to be %s, got %s", want, got)
+		}
+	})
+
+	t.Run("XattrCreateExisting", func(t *testing.T) {
+		err := xm.SetXattr(path, attrName, data, avfs.XattrCreate)
+		AssertPathError(t, err).Op("setxattr").Path(path).Err(avfs.ErrFileExists).Test()
+	})
+
+	t.Run("XattrReplaceNonExisting", func(t *testing.T) {
+		err := xm.SetXattr(path, "user.nonExisting", data, avfs.XattrReplace)
+		AssertPathError(t, err).Op("setxattr").Path(path).Err(avfs.ErrNoData).Test()
+	})
+
+	t.Run("XattrRemove", func(t *testing.T) {
+		err := xm.RemoveXattr(path, attrName2)
+		RequireNoError(t, err, "RemoveXattr %s", path)
+
+		_, err = xm.GetXattr(path, attrName2)
+		AssertPathError(t, err).Op("getxattr").Path(path).Err(avfs.ErrNoData).Test()
+
+		err = xm.RemoveXattr(path, attrName2)
+		AssertPathError(t, err).Op("removexattr").Path(path).Err(avfs.ErrNoData).Test()
+	})
+
+	t.Run("XattrSymlink", func(t *testing.T) {
+		if !vfs.HasFeature(avfs.FeatSymlink) {
+			return
+		}
+
+		symlink := vfs.Join(testDir, "XattrSymlink")
+
+		err := vfs.Symlink(path, symlink)
+		RequireNoError(t, err, "Symlink %s", symlink)
+
+		got, err := xm.GetXattr(symlink, attrName)
+		RequireNoError(t, err, "GetXattr %s", symlink)
+
+		if !bytes.Equal(got, data) {
+			t.Errorf("GetXattr : want value to be %s, got %s", data, got)
+		}
+
+		_, err = xm.LGetXattr(symlink, attrName)
+		AssertPathError(t, err).Op("lgetxattr").Path(symlink).Err(avfs.ErrNoData).Test()
+	})
+
+	t.Run("XattrNonExisting", func(t *testing.T) {
+		nonExistingFile := ts.nonExistingFile(t, testDir)
+
+		_, err := xm.GetXattr(nonExistingFile, attrName)
+		AssertPathError(t, err).Op("getxattr").Path(nonExistingFile).Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"io"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
@@ -32,14 +33,48 @@ import (
 // working directory to turn it into an absolute path. The absolute
 // path name for a given file is not guaranteed to be unique.
 // Abs calls [Clean] on the result.
+// On Windows, a rooted path without a volume name (like \dir) is relative
+// to the volume of the current working directory.
 func Abs[T VFSBase](vfs T, path, curDir string) (string, error) {
 	if vfs.IsAbs(path) {
 		return vfs.Clean(path), nil
 	}
 
+	if vfs.OSType() == OsWindows && path != "" && isSlash(path[0]) {
+		vl := VolumeNameLen(vfs, curDir)
+
+		return vfs.Clean(curDir[:vl] + path), nil
+	}
+
 	return vfs.Join(curDir, path), nil
 }
 
+// Ancestors returns the ancestor directories of path, from the root directory down to the parent of path.
+// The path is cleaned first, the root directory has no ancestors and a relative path returns nil.
+func Ancestors[T VFSBase](vfs T, path string) []string {
+	path = vfs.Clean(path)
+	if !vfs.IsAbs(path) {
+		return nil
+	}
+
+	var dirs []string
+
+	pi := NewPathIterator(vfs, path)
+	for pi.Next() {
+		if dirs == nil {
+			dirs = append(dirs, path[:pi.Start()])
+		}
+
+		if pi.IsLast() {
+			break
+		}
+
+		dirs = append(dirs, pi.LeftPart())
+	}
+
+	return dirs
+}
+
 // cleanGlobPath prepares path for glob matching.
 func cleanGlobPath[T VFSBase](vfs T, path string) string {
 	pathSeparator := vfs.PathSeparator()
@@ -76,6 +111,52 @@ func cleanGlobPathWindows[T VFSBase](vfs T, path string) (prefixLen int, cleaned
 	}
 }
 
+// CommonPrefix returns the longest common directory of the cleaned absolute paths.
+// Paths are compared part by part and not byte by byte, so /foo and /foobar only share /.
+// It returns an empty string if there are no paths, if one of the paths is relative
+// or if the paths don't share the same volume name (Windows only).
+func CommonPrefix[T VFSBase](vfs T, paths ...string) string {
+	if len(paths) == 0 {
+		return ""
+	}
+
+	prefix := vfs.Clean(paths[0])
+	if !vfs.IsAbs(prefix) {
+		return ""
+	}
+
+	for _, path := range paths[1:] {
+		path = vfs.Clean(path)
+		if !vfs.IsAbs(path) {
+			return ""
+		}
+
+		prefix = commonPrefix(vfs, prefix, path)
+		if prefix == "" {
+			return ""
+		}
+	}
+
+	return prefix
+}
+
+// commonPrefix returns the longest common directory of two cleaned absolute paths.
+func commonPrefix[T VFSBase](vfs T, path1, path2 string) string {
+	pi1 := NewPathIterator(vfs, path1)
+	pi2 := NewPathIterator(vfs, path2)
+
+	if !strings.EqualFold(pi1.VolumeName(), pi2.VolumeName()) {
+		return ""
+	}
+
+	end := min(pi1.VolumeNameLen()+1, len(path1))
+	for pi1.Next() && pi2.Next() && pi1.Part() == pi2.Part() {
+		end = pi1.End()
+	}
+
+	return path1[:end]
+}
+
 // Create creates or truncates the named file. If the file already exists,
 // it is truncated. If the file does not exist, it is created with mode 0666
 // (before umask). If successful, methods on the returned DummyFile can
@@ -87,7 +168,7 @@ func Create[T VFSBase](vfs T, name string) (File, error) {
 
 // CreateTemp creates a new temporary file in the directory dir,
 // opens the file for reading and writing, and returns the resulting file.
-// The filename is generated by taking pattern and adding a random string to the end.
+// The filename is generated by taking pattern and adding a random string to the end (see RandomNamer).
 // If pattern includes a "*", the random string replaces the last "*".
 // If dir is the empty string, CreateTemp uses the default directory for temporary files, as returned by TempDir.
 // Multiple programs or goroutines calling CreateTemp simultaneously will not choose the same file.
@@ -110,7 +191,7 @@ func CreateTemp[T VFSBase](vfs T, dir, pattern string) (File, error) {
 	try := 0
 
 	for {
-		name := prefix + nextRandom() + suffix
+		name := prefix + nextTempRandom(vfs) + suffix
 
 		f, err := vfs.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
 		if IsExist(err) {
@@ -310,7 +391,7 @@ func joinPath[T VFSBase](vfs T, dir, name string) string {
 
 // MkdirTemp creates a new temporary directory in the directory dir
 // and returns the pathname of the new directory.
-// The new directory's name is generated by adding a random string to the end of pattern.
+// The new directory's name is generated by adding a random string to the end of pattern (see RandomNamer).
 // If pattern includes a "*", the random string replaces the last "*" instead.
 // If dir is the empty string, MkdirTemp uses the default directory for temporary files, as returned by TempDir.
 // Multiple programs or goroutines calling MkdirTemp simultaneously will not choose the same directory.
@@ -331,7 +412,7 @@ func MkdirTemp[T VFSBase](vfs T, dir, pattern string) (string, error) {
 	try := 0
 
 	for {
-		name := prefix + nextRandom() + suffix
+		name := prefix + nextTempRandom(vfs) + suffix
 
 		err := vfs.Mkdir(name, 0o700)
 		if err == nil {
@@ -406,6 +487,16 @@ func MkSystemDirs[T VFSBase](vfs T, dirs []DirInfo) error {
 //go:linkname nextRandom os.nextRandom
 func nextRandom() string
 
+// nextTempRandom returns the random part of a temporary name,
+// provided by the file system if it implements RandomNamer.
+func nextTempRandom[T VFSBase](vfs T) string {
+	if rn, ok := any(vfs).(RandomNamer); ok {
+		return rn.NextRandom()
+	}
+
+	return nextRandom()
+}
+
 // prefixAndSuffix splits pattern by the last wildcard "*", if applicable,
 // returning prefix as the part before "*" and suffix as the part after "*".
 func prefixAndSuffix[T VFSBase](vfs T, pattern string) (prefix, suffix string, err error) {
@@ -456,25 +547,79 @@ func ReadFile[T VFSBase](vfs T, name string) ([]byte, error) {
 
 	defer f.Close()
 
-	var size int
+	if info, err := f.Stat(); err == nil {
+		size64 := info.Size()
+		if size64 > 0 && int64(int(size64)) == size64 {
+			return readSized(f, int(size64)+1) // one byte for final read at EOF
+		}
+	}
+
+	// Files in Linux's /proc claim size 0 but then do not work right if read in small pieces,
+	// so the file is read using buffers from the buffer pool.
+	buf := copyPool.Get().(*[]byte) //nolint:forcetypeassert // Get() always returns a pointer to a byte slice.
+	defer copyPool.Put(buf)
+
+	data := []byte{}
+
+	for {
+		n, err := f.Read(*buf)
+		data = append(data, (*buf)[:n]...)
+
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+
+			return data, err
+		}
+	}
+}
+
+// ReadFileLimit reads the named file and returns the contents like ReadFile,
+// but it returns an error wrapping ErrFileTooLarge if the file is larger than maxBytes.
+// At most maxBytes+1 bytes are read, so the whole file is never loaded into memory.
+func ReadFileLimit[T VFSBase](vfs T, name string, maxBytes int64) ([]byte, error) {
+	const op = "read"
+
+	f, err := vfs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	size := 0
 
 	if info, err := f.Stat(); err == nil {
 		size64 := info.Size()
-		if int64(int(size64)) == size64 {
+		if size64 > maxBytes {
+			return nil, &fs.PathError{Op: op, Path: name, Err: ErrFileTooLarge}
+		}
+
+		if size64 > 0 {
 			size = int(size64)
 		}
 	}
 
-	size++ // one byte for final read at EOF
+	limit := maxBytes
+	if limit < math.MaxInt64 {
+		limit++ // one more byte to detect a file larger than maxBytes.
+	}
 
-	// If a file claims a small size, read at least 512 bytes.
-	// In particular, files in Linux's /proc claim size 0 but
-	// then do not work right if read in small pieces,
-	// so an initial read of 1 byte would not work correctly.
-	if size < 512 {
-		size = 512
+	data, err := readSized(io.LimitReader(f, limit), size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > maxBytes {
+		return nil, &fs.PathError{Op: op, Path: name, Err: ErrFileTooLarge}
 	}
 
+	return data, nil
+}
+
+// readSized reads from r until EOF, size being the expected length of the data.
+func readSized(r io.Reader, size int) ([]byte, error) {
 	data := make([]byte, 0, size)
 
 	for {
@@ -483,7 +628,7 @@ func ReadFile[T VFSBase](vfs T, name string) ([]byte, error) {
 			data = d[:len(data)]
 		}
 
-		n, err := f.Read(data[len(data):cap(data)])
+		n, err := r.Read(data[len(data):cap(data)])
 		data = data[:len(data)+n]
 
 		if err != nil {
@@ -617,6 +762,10 @@ func ToOpenMode(flag int) OpenMode {
 		om |= OpenWrite
 	}
 
+	if flag&O_TMPFILE == O_TMPFILE {
+		om |= OpenTmpFile | OpenWrite
+	}
+
 	return om
 }
 
@@ -628,9 +777,6 @@ func VolumeName[T VFSBase](vfs T, path string) string {
 	return FromSlash(vfs, path[:VolumeNameLen(vfs, path)])
 }
 
-//go:linkname volumeNameLen path/filepath.volumeNameLen
-func volumeNameLen(path string) int
-
 // WalkDir walks the file tree rooted at root, calling fn for each file or
 // directory in the tree, including root.
 //
@@ -641,6 +787,10 @@ func volumeNameLen(path string) int
 // but requires WalkDir to read an entire directory into memory before proceeding
 // to walk that directory.
 //
+// Unlike a walk based on filepath.WalkFunc, WalkDir does not call Lstat for each entry:
+// fn receives a fs.DirEntry and only pays for the file information if it calls Info.
+// Returning filepath.SkipDir skips the current directory and filepath.SkipAll stops the walk.
+//
 // WalkDir does not follow symbolic links.
 func WalkDir[T VFSBase](vfs T, root string, fn fs.WalkDirFunc) error {
 	info, err := vfs.Lstat(root)
@@ -650,7 +800,7 @@ func WalkDir[T VFSBase](vfs T, root string, fn fs.WalkDirFunc) error {
 		err = walkDir(vfs, root, &statDirEntry{info}, fn)
 	}
 
-	if err == filepath.SkipDir {
+	if err == filepath.SkipDir || err == filepath.SkipAll {
 		return nil
 	}
 
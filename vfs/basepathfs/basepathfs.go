@@ -15,6 +15,10 @@
 //
 
 // Package basepathfs restricts all operations to a given path within a file system.
+//
+// The symbolic links of each path are evaluated in the base path before an operation,
+// an operation on a path whose symbolic links resolve outside the base path fails with
+// a permission denied error.
 package basepathfs
 
 import (
@@ -31,12 +35,19 @@ import (
 // path name for a given file is not guaranteed to be unique.
 // Abs calls [Clean] on the result.
 func (vfs *BasePathFS) Abs(path string) (string, error) {
-	abs, err := vfs.baseFS.Abs(vfs.ToBasePath(path))
-	if err != nil {
-		return "", vfs.FromPathError(err)
+	if vfs.IsAbs(path) {
+		return vfs.Clean(path), nil
+	}
+
+	basePath := vfs.BasePath()
+
+	wd, err := vfs.baseFS.Getwd()
+	if err != nil || !vfs.isInBase(basePath, wd) {
+		// The current directory of the base file system is outside the base path.
+		wd = basePath
 	}
 
-	return vfs.FromBasePath(abs), nil
+	return vfs.Join(vfs.fromBasePath(basePath, wd), path), nil
 }
 
 // Base returns the last element of path.
@@ -50,9 +61,14 @@ func (vfs *BasePathFS) Base(path string) string {
 // Chdir changes the current working directory to the named directory.
 // If there is an error, it will be of type *PathError.
 func (vfs *BasePathFS) Chdir(dir string) error {
-	err := vfs.baseFS.Chdir(vfs.ToBasePath(dir))
+	path, err := vfs.resolve("chdir", dir, true)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Chdir(path)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(dir, path, err)
 }
 
 // Chmod changes the mode of the named file to mode.
@@ -74,9 +90,14 @@ func (vfs *BasePathFS) Chdir(dir string) error {
 // On Plan 9, the mode's permission bits, ModeAppend, ModeExclusive,
 // and ModeTemporary are used.
 func (vfs *BasePathFS) Chmod(name string, mode fs.FileMode) error {
-	err := vfs.baseFS.Chmod(vfs.ToBasePath(name), mode)
+	path, err := vfs.resolve("chmod", name, true)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Chmod(path, mode)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(name, path, err)
 }
 
 // Chown changes the numeric uid and gid of the named file.
@@ -87,9 +108,14 @@ func (vfs *BasePathFS) Chmod(name string, mode fs.FileMode) error {
 // On Windows or Plan 9, Chown always returns the syscall.EWINDOWS or
 // EPLAN9 error, wrapped in *PathError.
 func (vfs *BasePathFS) Chown(name string, uid, gid int) error {
-	err := vfs.baseFS.Chown(vfs.ToBasePath(name), uid, gid)
+	path, err := vfs.resolve("chown", name, true)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Chown(path, uid, gid)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(name, path, err)
 }
 
 // Chtimes changes the access and modification times of the named
@@ -99,9 +125,14 @@ func (vfs *BasePathFS) Chown(name string, uid, gid int) error {
 // less precise time unit.
 // If there is an error, it will be of type *PathError.
 func (vfs *BasePathFS) Chtimes(name string, atime, mtime time.Time) error {
-	err := vfs.baseFS.Chtimes(vfs.ToBasePath(name), atime, mtime)
+	path, err := vfs.resolve("chtimes", name, true)
+	if err != nil {
+		return err
+	}
 
-	return vfs.FromPathError(err)
+	err = vfs.baseFS.Chtimes(path, atime, mtime)
+
+	return vfs.restorePathError(name, path, err)
 }
 
 // Clean returns the shortest path name equivalent to path
@@ -168,15 +199,27 @@ func (vfs *BasePathFS) Dir(path string) string {
 // unless one of the components is an absolute symbolic link.
 // EvalSymlinks calls Clean on the result.
 func (vfs *BasePathFS) EvalSymlinks(path string) (string, error) {
-	op := "lstat"
-	err := error(avfs.ErrPermDenied)
+	basePath := vfs.BasePath()
 
-	if vfs.OSType() == avfs.OsWindows {
-		op = "CreateFile"
-		err = avfs.ErrWinAccessDenied
+	resolved, err := vfs.resolve("lstat", path, true)
+	if err != nil {
+		return "", err
+	}
+
+	evalPath, err := vfs.baseFS.EvalSymlinks(resolved)
+	if err != nil {
+		return "", vfs.fromSymlinkPathError(basePath, path, err)
 	}
 
-	return "", &fs.PathError{Op: op, Path: path, Err: err}
+	if !vfs.IsAbs(evalPath) {
+		return evalPath, nil
+	}
+
+	if !vfs.isInBase(basePath, evalPath) {
+		return "", &fs.PathError{Op: "lstat", Path: path, Err: vfs.errPermDenied()}
+	}
+
+	return vfs.fromBasePath(basePath, evalPath), nil
 }
 
 // FromSlash returns the result of replacing each slash ('/') character
@@ -205,13 +248,7 @@ func (vfs *BasePathFS) Getwd() (dir string, err error) {
 // The only possible returned error is ErrBadPattern, when pattern
 // is malformed.
 func (vfs *BasePathFS) Glob(pattern string) (matches []string, err error) {
-	matches, err = vfs.baseFS.Glob(vfs.ToBasePath(pattern))
-
-	for i, m := range matches {
-		matches[i] = vfs.FromBasePath(m)
-	}
-
-	return matches, err
+	return avfs.Glob(vfs, pattern)
 }
 
 // Idm returns the identity manager of the file system.
@@ -244,17 +281,27 @@ func (vfs *BasePathFS) Join(elem ...string) string {
 // On Windows, it always returns the syscall.EWINDOWS error, wrapped
 // in *PathError.
 func (vfs *BasePathFS) Lchown(name string, uid, gid int) error {
-	err := vfs.baseFS.Lchown(vfs.ToBasePath(name), uid, gid)
+	path, err := vfs.resolve("lchown", name, false)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Lchown(path, uid, gid)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(name, path, err)
 }
 
 // Link creates newname as a hard link to the oldname file.
 // If there is an error, it will be of type *LinkError.
 func (vfs *BasePathFS) Link(oldname, newname string) error {
-	err := vfs.baseFS.Link(vfs.ToBasePath(oldname), vfs.ToBasePath(newname))
+	oldpath, newpath, err := vfs.resolveLink("link", oldname, newname)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Link(oldpath, newpath)
 
-	return vfs.FromLinkError(err)
+	return vfs.restoreLinkError(oldname, newname, oldpath, newpath, err)
 }
 
 // Lstat returns a FileInfo describing the named file.
@@ -262,9 +309,14 @@ func (vfs *BasePathFS) Link(oldname, newname string) error {
 // describes the symbolic link. Lstat makes no attempt to follow the link.
 // If there is an error, it will be of type *PathError.
 func (vfs *BasePathFS) Lstat(path string) (fs.FileInfo, error) {
-	info, err := vfs.baseFS.Lstat(vfs.ToBasePath(path))
+	basePath, err := vfs.resolve("lstat", path, false)
+	if err != nil {
+		return nil, err
+	}
 
-	return info, vfs.FromPathError(err)
+	info, err := vfs.baseFS.Lstat(basePath)
+
+	return info, vfs.restorePathError(path, basePath, err)
 }
 
 // Match reports whether name matches the shell file name pattern.
@@ -308,9 +360,14 @@ func (vfs *BasePathFS) Mkdir(name string, perm fs.FileMode) error {
 		return &fs.PathError{Op: "mkdir", Path: "", Err: err}
 	}
 
-	err := vfs.baseFS.Mkdir(vfs.ToBasePath(name), perm)
+	path, err := vfs.resolve("mkdir", name, false)
+	if err != nil {
+		return err
+	}
 
-	return vfs.FromPathError(err)
+	err = vfs.baseFS.Mkdir(path, perm)
+
+	return vfs.restorePathError(name, path, err)
 }
 
 // MkdirAll creates a directory named name,
@@ -321,9 +378,14 @@ func (vfs *BasePathFS) Mkdir(name string, perm fs.FileMode) error {
 // If name is already a directory, MkdirAll does nothing
 // and returns nil.
 func (vfs *BasePathFS) MkdirAll(path string, perm fs.FileMode) error {
-	err := vfs.baseFS.MkdirAll(vfs.ToBasePath(path), perm)
+	basePath, err := vfs.resolve("mkdir", path, true)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.MkdirAll(basePath, perm)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(path, basePath, err)
 }
 
 // MkdirTemp creates a new temporary directory in the directory dir
@@ -352,14 +414,23 @@ func (vfs *BasePathFS) Open(name string) (avfs.File, error) {
 // methods on the returned File can be used for I/O.
 // If there is an error, it will be of type *PathError.
 func (vfs *BasePathFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File, error) {
-	f, err := vfs.baseFS.OpenFile(vfs.ToBasePath(name), flag, perm)
+	basePath := vfs.BasePath()
+
+	path, err := vfs.resolve("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := vfs.baseFS.OpenFile(path, flag, perm)
 	if err != nil {
-		return f, vfs.FromPathError(err)
+		return f, vfs.restorePathError(name, path, err)
 	}
 
 	bf := &BasePathFile{
 		vfs:      vfs,
 		baseFile: f,
+		basePath: basePath,
+		name:     name,
 	}
 
 	return bf, nil
@@ -389,11 +460,27 @@ func (vfs *BasePathFS) ReadFile(filename string) ([]byte, error) {
 func (vfs *BasePathFS) Readlink(name string) (string, error) {
 	const op = "readlink"
 
-	if vfs.OSType() == avfs.OsWindows {
-		return "", &fs.PathError{Op: op, Path: name, Err: avfs.ErrWinNotReparsePoint}
+	basePath := vfs.BasePath()
+
+	path, err := vfs.resolve(op, name, false)
+	if err != nil {
+		return "", err
+	}
+
+	link, err := vfs.baseFS.Readlink(path)
+	if err != nil {
+		return "", vfs.fromSymlinkPathError(basePath, name, err)
 	}
 
-	return "", &fs.PathError{Op: op, Path: name, Err: avfs.ErrPermDenied}
+	if !vfs.IsAbs(link) {
+		return link, nil
+	}
+
+	if !vfs.isInBase(basePath, link) {
+		return "", &fs.PathError{Op: op, Path: name, Err: vfs.errPermDenied()}
+	}
+
+	return vfs.fromBasePath(basePath, link), nil
 }
 
 // Rel returns a relative path that is lexically equivalent to targpath when
@@ -411,9 +498,14 @@ func (vfs *BasePathFS) Rel(basepath, targpath string) (string, error) {
 // Remove removes the named file or (empty) directory.
 // If there is an error, it will be of type *PathError.
 func (vfs *BasePathFS) Remove(name string) error {
-	err := vfs.baseFS.Remove(vfs.ToBasePath(name))
+	path, err := vfs.resolve("remove", name, false)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Remove(path)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(name, path, err)
 }
 
 // RemoveAll removes path and any children it contains.
@@ -427,9 +519,14 @@ func (vfs *BasePathFS) RemoveAll(path string) error {
 		return nil
 	}
 
-	err := vfs.baseFS.RemoveAll(vfs.ToBasePath(path))
+	basePath, err := vfs.resolve("unlinkat", path, false)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.RemoveAll(basePath)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(path, basePath, err)
 }
 
 // Rename renames (moves) oldpath to newpath.
@@ -437,9 +534,14 @@ func (vfs *BasePathFS) RemoveAll(path string) error {
 // OS-specific restrictions may apply when oldpath and newpath are in different directories.
 // If there is an error, it will be of type *LinkError.
 func (vfs *BasePathFS) Rename(oldname, newname string) error {
-	err := vfs.baseFS.Rename(vfs.ToBasePath(oldname), vfs.ToBasePath(newname))
+	oldpath, newpath, err := vfs.resolveLink("rename", oldname, newname)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Rename(oldpath, newpath)
 
-	return vfs.FromLinkError(err)
+	return vfs.restoreLinkError(oldname, newname, oldpath, newpath, err)
 }
 
 // SameFile reports whether fi1 and fi2 describe the same file.
@@ -487,28 +589,60 @@ func (vfs *BasePathFS) Split(path string) (dir, file string) {
 // Stat returns a FileInfo describing the named file.
 // If there is an error, it will be of type *PathError.
 func (vfs *BasePathFS) Stat(path string) (fs.FileInfo, error) {
-	info, err := vfs.baseFS.Stat(vfs.ToBasePath(path))
+	basePath, err := vfs.resolve("stat", path, true)
+	if err != nil {
+		return nil, err
+	}
 
-	return info, vfs.FromPathError(err)
+	info, err := vfs.baseFS.Stat(basePath)
+
+	return info, vfs.restorePathError(path, basePath, err)
 }
 
 // Sub returns an FS corresponding to the subtree rooted at dir.
 func (vfs *BasePathFS) Sub(dir string) (avfs.VFS, error) {
-	subFS, err := vfs.baseFS.Sub(vfs.ToBasePath(dir))
+	path, err := vfs.resolve("sub", dir, true)
+	if err != nil {
+		return nil, err
+	}
 
-	return subFS, vfs.FromPathError(err)
+	subFS, err := vfs.baseFS.Sub(path)
+
+	return subFS, vfs.restorePathError(dir, path, err)
 }
 
 // Symlink creates newname as a symbolic link to oldname.
 // If there is an error, it will be of type *LinkError.
+//
+// An absolute oldname is relative to the base path.
+// A relative oldname that would resolve outside the base path is rejected.
 func (vfs *BasePathFS) Symlink(oldname, newname string) error {
 	const op = "symlink"
 
-	if vfs.OSType() == avfs.OsWindows {
-		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: avfs.ErrWinPrivilegeNotHeld}
+	basePath := vfs.BasePath()
+
+	baseNew, err := vfs.resolve(op, newname, false)
+	if e, ok := err.(*fs.PathError); ok {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: e.Err}
+	}
+
+	baseOld := oldname
+
+	if vfs.IsAbs(oldname) {
+		baseOld = vfs.ToBasePath(oldname)
+	} else {
+		absNew, _ := vfs.baseFS.Abs(baseNew)
+		if !vfs.isInBase(basePath, vfs.Join(vfs.Dir(absNew), oldname)) {
+			return &os.LinkError{Op: op, Old: oldname, New: newname, Err: vfs.errPermDenied()}
+		}
 	}
 
-	return &os.LinkError{Op: op, Old: oldname, New: newname, Err: avfs.ErrPermDenied}
+	err = vfs.baseFS.Symlink(baseOld, baseNew)
+	if e, ok := err.(*os.LinkError); ok {
+		return &os.LinkError{Op: e.Op, Old: oldname, New: newname, Err: e.Err}
+	}
+
+	return err
 }
 
 // TempDir returns the default directory to use for temporary files.
@@ -540,9 +674,14 @@ func (vfs *BasePathFS) ToSysStat(info fs.FileInfo) avfs.SysStater {
 // If the file is a symbolic link, it changes the size of the link's target.
 // If there is an error, it will be of type *PathError.
 func (vfs *BasePathFS) Truncate(name string, size int64) error {
-	err := vfs.baseFS.Truncate(vfs.ToBasePath(name), size)
+	path, err := vfs.resolve("truncate", name, true)
+	if err != nil {
+		return err
+	}
+
+	err = vfs.baseFS.Truncate(path, size)
 
-	return vfs.FromPathError(err)
+	return vfs.restorePathError(name, path, err)
 }
 
 // UMask returns the file mode creation mask.
@@ -39,54 +39,99 @@ func New(baseFS avfs.VFS, basePath string) *BasePathFS {
 func NewWithErr(baseFS avfs.VFS, basePath string) (*BasePathFS, error) {
 	const op = "basepath"
 
-	absPath, err := baseFS.Abs(basePath)
+	absPath, err := checkBasePath(op, baseFS, basePath)
 	if err != nil {
 		return nil, err
 	}
 
-	info, err := baseFS.Stat(absPath)
+	vfs := &BasePathFS{
+		baseFS:   baseFS,
+		basePath: absPath,
+	}
+
+	_ = vfs.SetFeatures(baseFS.Features() &^ (avfs.FeatOpenat | avfs.FeatRenameFlags | avfs.FeatSpecialFiles |
+		avfs.FeatStatFS | avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup | avfs.FeatTmpFile | avfs.FeatXattr))
+
+	return vfs, nil
+}
+
+// checkBasePath returns the absolute path of basePath in baseFS
+// or an error if basePath is not an existing directory.
+func checkBasePath(op string, baseFS avfs.VFS, basePath string) (string, error) {
+	absPath, err := baseFS.Abs(basePath)
 	if err != nil {
-		err = &fs.PathError{Op: op, Path: basePath, Err: errors.Unwrap(err)}
+		return "", err
+	}
 
-		return nil, err
+	info, err := baseFS.Stat(absPath)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: basePath, Err: errors.Unwrap(err)}
 	}
 
 	if !info.IsDir() {
-		err = &fs.PathError{Op: op, Path: basePath, Err: avfs.ErrNotADirectory}
-
-		return nil, err
+		return "", &fs.PathError{Op: op, Path: basePath, Err: avfs.ErrNotADirectory}
 	}
 
-	vfs := &BasePathFS{
-		baseFS:   baseFS,
-		basePath: absPath,
+	return absPath, nil
+}
+
+// BasePath returns the absolute path of the base directory in the base file system.
+func (vfs *BasePathFS) BasePath() string {
+	vfs.mu.RLock()
+	defer vfs.mu.RUnlock()
+
+	return vfs.basePath
+}
+
+// Rebase switches the base directory to newBase, an existing directory of the base file system.
+// Files already opened keep the base path they were opened with,
+// new path translations use newBase.
+// If there is an error, it will be of type *PathError.
+func (vfs *BasePathFS) Rebase(newBase string) error {
+	const op = "rebase"
+
+	absPath, err := checkBasePath(op, vfs.baseFS, newBase)
+	if err != nil {
+		return err
 	}
 
-	_ = vfs.SetFeatures(baseFS.Features() &^ avfs.FeatSymlink)
+	vfs.mu.Lock()
+	vfs.basePath = absPath
+	vfs.mu.Unlock()
 
-	return vfs, nil
+	return nil
 }
 
 // FromBasePath returns a BasePathFS path from an internal path.
 // When the base path is "/base/path", FromBasePath("/base/path/tmp") returns "/tmp".
 func (vfs *BasePathFS) FromBasePath(path string) string {
-	if !strings.HasPrefix(path, vfs.basePath) {
-		panic("path must start with " + vfs.basePath + " : " + path)
+	return vfs.fromBasePath(vfs.BasePath(), path)
+}
+
+// fromBasePath returns a BasePathFS path from an internal path relative to basePath.
+func (vfs *BasePathFS) fromBasePath(basePath, path string) string {
+	if !strings.HasPrefix(path, basePath) {
+		panic("path must start with " + basePath + " : " + path)
 	}
 
 	vl := avfs.VolumeNameLen(vfs, path)
 
-	return vfs.Join(path[:vl], path[len(vfs.basePath):], string(vfs.PathSeparator()))
+	return vfs.Join(path[:vl], path[len(basePath):], string(vfs.PathSeparator()))
 }
 
 // FromPathError restore paths in fs.PathError if necessary.
 func (vfs *BasePathFS) FromPathError(err error) error {
+	return vfs.fromPathError(vfs.BasePath(), err)
+}
+
+// fromPathError restore paths in fs.PathError relative to basePath if necessary.
+func (vfs *BasePathFS) fromPathError(basePath string, err error) error {
 	e, ok := err.(*fs.PathError)
 	if !ok {
 		return err
 	}
 
-	return &fs.PathError{Op: e.Op, Path: vfs.FromBasePath(e.Path), Err: e.Err}
+	return &fs.PathError{Op: e.Op, Path: vfs.fromBasePath(basePath, e.Path), Err: e.Err}
 }
 
 // FromLinkError restore paths in os.LinkError if necessary.
@@ -99,17 +144,212 @@ func (vfs *BasePathFS) FromLinkError(err error) error {
 	return &os.LinkError{Op: e.Op, Old: vfs.FromBasePath(e.Old), New: vfs.FromBasePath(e.New), Err: e.Err}
 }
 
+// fromSymlinkPathError restores paths in fs.PathError returned by symbolic link functions.
+// Paths outside basePath are replaced by path to avoid leaking the paths of the base file system.
+func (vfs *BasePathFS) fromSymlinkPathError(basePath, path string, err error) error {
+	e, ok := err.(*fs.PathError)
+	if !ok {
+		return err
+	}
+
+	if !vfs.isInBase(basePath, e.Path) {
+		return &fs.PathError{Op: e.Op, Path: path, Err: e.Err}
+	}
+
+	return vfs.fromPathError(basePath, err)
+}
+
+// isInBase returns true if the absolute path is basePath or one of its descendants.
+func (vfs *BasePathFS) isInBase(basePath, path string) bool {
+	if !strings.HasPrefix(path, basePath) {
+		return false
+	}
+
+	return len(path) == len(basePath) || vfs.IsPathSeparator(path[len(basePath)]) ||
+		vfs.IsPathSeparator(basePath[len(basePath)-1])
+}
+
+// resolve returns the path of the base file system to use for an operation op on name,
+// after the evaluation of the symbolic links of name in the base file system.
+// The last element of name is evaluated only if followLast is true.
+//
+// Symbolic links are evaluated in the base path: a symbolic link with an absolute target outside
+// the base path or a relative target going above the base path returns an error of type *PathError
+// wrapping a permission denied error, as Symlink does when the link is created.
+// If no symbolic link was evaluated, the returned path is ToBasePath(name), otherwise it is the path
+// of name in the base file system with the symbolic links of its directory evaluated.
+func (vfs *BasePathFS) resolve(op, name string, followLast bool) (string, error) {
+	if !vfs.HasFeature(avfs.FeatSymlink) {
+		return vfs.ToBasePath(name), nil
+	}
+
+	resolved, slCount, err := vfs.walk(op, name, false)
+	if err != nil {
+		return "", err
+	}
+
+	if followLast {
+		_, lastCount, err := vfs.walk(op, name, true)
+		if err != nil {
+			return "", err
+		}
+
+		slCount += lastCount
+	}
+
+	if slCount == 0 {
+		return vfs.ToBasePath(name), nil
+	}
+
+	return resolved, nil
+}
+
+// walk evaluates the symbolic links of name in the base file system, starting from the base path.
+// It returns the resolved path and the number of symbolic links evaluated.
+// The last element of name is evaluated only if followLast is true.
+func (vfs *BasePathFS) walk(op, name string, followLast bool) (resolved string, slCount int, err error) {
+	basePath := vfs.BasePath()
+
+	path, err := vfs.Abs(name)
+	if err != nil {
+		return "", 0, err
+	}
+
+	parts := vfs.splitPath(path[avfs.VolumeNameLen(vfs, path):])
+	resolved = basePath
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		if part == "." {
+			continue
+		}
+
+		if part == ".." {
+			if resolved != basePath {
+				resolved = vfs.baseFS.Dir(resolved)
+
+				continue
+			}
+
+			if slCount > 0 {
+				return "", 0, &fs.PathError{Op: op, Path: name, Err: vfs.errPermDenied()}
+			}
+
+			continue
+		}
+
+		next := vfs.baseFS.Join(resolved, part)
+		if len(parts) == 0 && !followLast {
+			resolved = next
+
+			break
+		}
+
+		info, err := vfs.baseFS.Lstat(next)
+		if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+
+			continue
+		}
+
+		slCount++
+		if slCount > avfs.MaxSymlinks {
+			return "", 0, &fs.PathError{Op: op, Path: name, Err: avfs.ErrTooManySymlinks}
+		}
+
+		link, err := vfs.baseFS.Readlink(next)
+		if err != nil {
+			resolved = next
+
+			continue
+		}
+
+		if vfs.baseFS.IsAbs(link) {
+			if !vfs.isInBase(basePath, link) {
+				return "", 0, &fs.PathError{Op: op, Path: name, Err: vfs.errPermDenied()}
+			}
+
+			link = link[len(basePath):]
+			resolved = basePath
+		}
+
+		parts = append(vfs.splitPath(link), parts...)
+	}
+
+	return resolved, slCount, nil
+}
+
+// resolveLink returns the paths of the base file system to use for an operation op
+// on oldname and newname, like resolve without evaluating their last elements.
+// If there is an error, it will be of type *LinkError.
+func (vfs *BasePathFS) resolveLink(op, oldname, newname string) (oldpath, newpath string, err error) {
+	oldpath, err = vfs.resolve(op, oldname, false)
+	if err == nil {
+		newpath, err = vfs.resolve(op, newname, false)
+	}
+
+	if e, ok := err.(*fs.PathError); ok {
+		return "", "", &os.LinkError{Op: op, Old: oldname, New: newname, Err: e.Err}
+	}
+
+	return oldpath, newpath, err
+}
+
+// splitPath returns the non-empty elements of path.
+func (vfs *BasePathFS) splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r < 0x80 && vfs.IsPathSeparator(uint8(r))
+	})
+}
+
+// restorePathError restores the paths in a fs.PathError returned by an operation on path,
+// the path returned by resolve for name.
+// If symbolic links were evaluated, the path of the error is replaced by name.
+func (vfs *BasePathFS) restorePathError(name, path string, err error) error {
+	e, ok := err.(*fs.PathError)
+	if !ok || path == vfs.ToBasePath(name) {
+		return vfs.FromPathError(err)
+	}
+
+	return &fs.PathError{Op: e.Op, Path: name, Err: e.Err}
+}
+
+// restoreLinkError restores the paths in an os.LinkError returned by an operation
+// on oldpath and newpath, the paths returned by resolve for oldname and newname.
+// If symbolic links were evaluated, the paths of the error are replaced by oldname and newname.
+func (vfs *BasePathFS) restoreLinkError(oldname, newname, oldpath, newpath string, err error) error {
+	e, ok := err.(*os.LinkError)
+	if !ok || (oldpath == vfs.ToBasePath(oldname) && newpath == vfs.ToBasePath(newname)) {
+		return vfs.FromLinkError(err)
+	}
+
+	return &os.LinkError{Op: e.Op, Old: oldname, New: newname, Err: e.Err}
+}
+
+// errPermDenied returns the permission denied error of the OS type of the file system.
+func (vfs *BasePathFS) errPermDenied() error {
+	if vfs.OSType() == avfs.OsWindows {
+		return avfs.ErrWinAccessDenied
+	}
+
+	return avfs.ErrPermDenied
+}
+
 // ToBasePath transforms a BasePathFS path to an internal path.
 // When the base path is "/base/path", ToBasePath("/tmp") returns "/base/path/tmp".
 func (vfs *BasePathFS) ToBasePath(path string) string {
+	basePath := vfs.BasePath()
+
 	if path == "" || path == "/" {
-		return vfs.basePath
+		return basePath
 	}
 
 	if vfs.IsAbs(path) {
 		vl := avfs.VolumeNameLen(vfs, path)
 
-		return vfs.basePath + path[vl:]
+		return basePath + path[vl:]
 	}
 
 	return path
@@ -125,6 +365,12 @@ func (vfs *BasePathFS) OSType() avfs.OSType {
 	return vfs.baseFS.OSType()
 }
 
+// ResolveBackend returns the base file system and the path used by the base file system
+// to handle an operation on path.
+func (vfs *BasePathFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	return vfs.baseFS, vfs.ToBasePath(path)
+}
+
 // Type returns the type of the fileSystem or Identity manager.
 func (*BasePathFS) Type() string {
 	return "BasePathFS"
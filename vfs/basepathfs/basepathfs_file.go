@@ -18,6 +18,7 @@ package basepathfs
 
 import (
 	"io/fs"
+	"time"
 )
 
 // Chdir changes the current working directory to the file,
@@ -26,7 +27,7 @@ import (
 func (f *BasePathFile) Chdir() error {
 	err := f.baseFile.Chdir()
 
-	return f.vfs.FromPathError(err)
+	return f.vfs.fromPathError(f.basePath, err)
 }
 
 // Chmod changes the mode of the file to mode.
@@ -34,7 +35,7 @@ func (f *BasePathFile) Chdir() error {
 func (f *BasePathFile) Chmod(mode fs.FileMode) error {
 	err := f.baseFile.Chmod(mode)
 
-	return f.vfs.FromPathError(err)
+	return f.vfs.fromPathError(f.basePath, err)
 }
 
 // Chown changes the numeric uid and gid of the named file.
@@ -45,7 +46,7 @@ func (f *BasePathFile) Chmod(mode fs.FileMode) error {
 func (f *BasePathFile) Chown(uid, gid int) error {
 	err := f.baseFile.Chown(uid, gid)
 
-	return f.vfs.FromPathError(err)
+	return f.vfs.fromPathError(f.basePath, err)
 }
 
 // Close closes the File, rendering it unusable for I/O.
@@ -54,7 +55,7 @@ func (f *BasePathFile) Chown(uid, gid int) error {
 func (f *BasePathFile) Close() error {
 	err := f.baseFile.Close()
 
-	return f.vfs.FromPathError(err)
+	return f.vfs.fromPathError(f.basePath, err)
 }
 
 // Fd returns the integer Unix file descriptor referencing the open file.
@@ -66,7 +67,7 @@ func (f *BasePathFile) Fd() uintptr {
 
 // Name returns the link of the file as presented to Open.
 func (f *BasePathFile) Name() string {
-	return f.vfs.FromBasePath(f.baseFile.Name())
+	return f.name
 }
 
 // Read reads up to len(b) bytes from the MemFile.
@@ -75,7 +76,7 @@ func (f *BasePathFile) Name() string {
 func (f *BasePathFile) Read(b []byte) (n int, err error) {
 	n, err = f.baseFile.Read(b)
 
-	return n, f.vfs.FromPathError(err)
+	return n, f.vfs.fromPathError(f.basePath, err)
 }
 
 // ReadAt reads len(b) bytes from the File starting at byte offset off.
@@ -85,7 +86,7 @@ func (f *BasePathFile) Read(b []byte) (n int, err error) {
 func (f *BasePathFile) ReadAt(b []byte, off int64) (n int, err error) {
 	n, err = f.baseFile.ReadAt(b, off)
 
-	return n, f.vfs.FromPathError(err)
+	return n, f.vfs.fromPathError(f.basePath, err)
 }
 
 // ReadDir reads the contents of the directory associated with the file f
@@ -101,7 +102,7 @@ func (f *BasePathFile) ReadAt(b []byte, off int64) (n int, err error) {
 func (f *BasePathFile) ReadDir(n int) ([]fs.DirEntry, error) {
 	de, err := f.baseFile.ReadDir(n)
 
-	return de, f.vfs.FromPathError(err)
+	return de, f.vfs.fromPathError(f.basePath, err)
 }
 
 // Readdirnames reads and returns a slice of names from the directory f.
@@ -119,7 +120,7 @@ func (f *BasePathFile) ReadDir(n int) ([]fs.DirEntry, error) {
 func (f *BasePathFile) Readdirnames(n int) (names []string, err error) {
 	names, err = f.baseFile.Readdirnames(n)
 
-	return names, f.vfs.FromPathError(err)
+	return names, f.vfs.fromPathError(f.basePath, err)
 }
 
 // Seek sets the offset for the next Read or Write on file to offset, interpreted
@@ -130,7 +131,34 @@ func (f *BasePathFile) Readdirnames(n int) (names []string, err error) {
 func (f *BasePathFile) Seek(offset int64, whence int) (ret int64, err error) {
 	ret, err = f.baseFile.Seek(offset, whence)
 
-	return ret, f.vfs.FromPathError(err)
+	return ret, f.vfs.fromPathError(f.basePath, err)
+}
+
+// SetDeadline sets the read and write deadlines for a File.
+// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+// A zero value for t means I/O operations will not time out.
+func (f *BasePathFile) SetDeadline(t time.Time) error {
+	err := f.baseFile.SetDeadline(t)
+
+	return f.vfs.fromPathError(f.basePath, err)
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *BasePathFile) SetReadDeadline(t time.Time) error {
+	err := f.baseFile.SetReadDeadline(t)
+
+	return f.vfs.fromPathError(f.basePath, err)
+}
+
+// SetWriteDeadline sets the deadline for any future Write calls and any
+// currently-blocked Write call.
+// A zero value for t means Write will not time out.
+func (f *BasePathFile) SetWriteDeadline(t time.Time) error {
+	err := f.baseFile.SetWriteDeadline(t)
+
+	return f.vfs.fromPathError(f.basePath, err)
 }
 
 // Stat returns the FileInfo structure describing file.
@@ -138,7 +166,7 @@ func (f *BasePathFile) Seek(offset int64, whence int) (ret int64, err error) {
 func (f *BasePathFile) Stat() (fs.FileInfo, error) {
 	info, err := f.baseFile.Stat()
 
-	return info, f.vfs.FromPathError(err)
+	return info, f.vfs.fromPathError(f.basePath, err)
 }
 
 // Sync commits the current contents of the file to stable storage.
@@ -147,7 +175,7 @@ func (f *BasePathFile) Stat() (fs.FileInfo, error) {
 func (f *BasePathFile) Sync() error {
 	err := f.baseFile.Sync()
 
-	return f.vfs.FromPathError(err)
+	return f.vfs.fromPathError(f.basePath, err)
 }
 
 // Truncate changes the size of the file.
@@ -156,7 +184,7 @@ func (f *BasePathFile) Sync() error {
 func (f *BasePathFile) Truncate(size int64) error {
 	err := f.baseFile.Truncate(size)
 
-	return f.vfs.FromPathError(err)
+	return f.vfs.fromPathError(f.basePath, err)
 }
 
 // Write writes len(b) bytes to the File.
@@ -165,7 +193,7 @@ func (f *BasePathFile) Truncate(size int64) error {
 func (f *BasePathFile) Write(b []byte) (n int, err error) {
 	n, err = f.baseFile.Write(b)
 
-	return n, f.vfs.FromPathError(err)
+	return n, f.vfs.fromPathError(f.basePath, err)
 }
 
 // WriteAt writes len(b) bytes to the File starting at byte offset off.
@@ -174,7 +202,7 @@ func (f *BasePathFile) Write(b []byte) (n int, err error) {
 func (f *BasePathFile) WriteAt(b []byte, off int64) (n int, err error) {
 	n, err = f.baseFile.WriteAt(b, off)
 
-	return n, f.vfs.FromPathError(err)
+	return n, f.vfs.fromPathError(f.basePath, err)
 }
 
 // WriteString is like Write, but writes the contents of string s rather than
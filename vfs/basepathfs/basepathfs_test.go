@@ -19,6 +19,7 @@
 package basepathfs_test
 
 import (
+	"os"
 	"strings"
 	"testing"
 
@@ -32,6 +33,9 @@ var (
 	// Tests that basepathfs.BasePathFS struct implements avfs.VFS interface.
 	_ avfs.VFS = &basepathfs.BasePathFS{}
 
+	// Tests that basepathfs.BasePathFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &basepathfs.BasePathFS{}
+
 	// Tests that basepathfs.BasePathFS struct implements avfs.VFSBase interface.
 	_ avfs.VFSBase = &basepathfs.BasePathFS{}
 
@@ -95,8 +99,8 @@ func TestBasePathFSOptions(t *testing.T) {
 
 func TestBasePathFSFeatures(t *testing.T) {
 	vfs := basepathfs.New(memfs.New(), "/")
-	if vfs.HasFeature(avfs.FeatSymlink) {
-		t.Errorf("Features : want FeatSymlink missing, got present")
+	if !vfs.HasFeature(avfs.FeatSymlink) {
+		t.Errorf("Features : want FeatSymlink present, got missing")
 	}
 
 	if !vfs.HasFeature(avfs.FeatIdentityMgr) {
@@ -121,6 +125,43 @@ func TestBasePathFSOSType(t *testing.T) {
 	}
 }
 
+func TestBasePathFSAbs(t *testing.T) {
+	baseFS := memfs.New()
+	basePath := "/tmp/base"
+
+	for _, dir := range []string{basePath + "/x", basePath + "ment/x"} {
+		dir = avfs.FromUnixPath(baseFS, dir)
+
+		err := baseFS.MkdirAll(dir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAll %s", dir)
+	}
+
+	vfs := basepathfs.New(baseFS, avfs.FromUnixPath(baseFS, basePath))
+
+	absTests := []struct{ Wd, Abs string }{
+		{Wd: basePath, Abs: "/f"},
+		{Wd: basePath + "/x", Abs: "/x/f"},
+		{Wd: basePath + "ment/x", Abs: "/f"},
+		{Wd: "/tmp", Abs: "/f"},
+	}
+
+	for _, at := range absTests {
+		wd := avfs.FromUnixPath(baseFS, at.Wd)
+
+		err := baseFS.Chdir(wd)
+		test.RequireNoError(t, err, "Chdir %s", wd)
+
+		want := avfs.FromUnixPath(vfs, at.Abs)
+
+		abs, err := vfs.Abs("f")
+		test.RequireNoError(t, err, "Abs")
+
+		if abs != want {
+			t.Errorf("Abs %s : want path to be %s, got %s", wd, want, abs)
+		}
+	}
+}
+
 func TestBasePathFSToBasePath(t *testing.T) {
 	vfs, basePath := initFS(t)
 
@@ -167,3 +208,235 @@ func TestBasePathFSFromBasePath(t *testing.T) {
 		}
 	}
 }
+
+func TestBasePathFSRebase(t *testing.T) {
+	baseFS := memfs.New()
+	baseA := avfs.FromUnixPath(baseFS, "/base/a")
+	baseB := avfs.FromUnixPath(baseFS, "/base/b")
+
+	for _, dir := range []string{baseA, baseB} {
+		err := baseFS.MkdirAll(dir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAll %s", dir)
+	}
+
+	vfs := basepathfs.New(baseFS, baseA)
+	path := avfs.FromUnixPath(vfs, "/file.txt")
+
+	err := vfs.WriteFile(path, []byte("A"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	f, err := vfs.OpenFile(path, os.O_RDONLY, 0)
+	test.RequireNoError(t, err, "OpenFile %s", path)
+
+	defer f.Close() //nolint:errcheck // Ignore errors.
+
+	err = baseFS.WriteFile(baseFS.Join(baseB, "file.txt"), []byte("B"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", baseB)
+
+	err = vfs.Rebase(baseB)
+	test.RequireNoError(t, err, "Rebase %s", baseB)
+
+	if vfs.BasePath() != baseB {
+		t.Errorf("BasePath : want base path to be %s, got %s", baseB, vfs.BasePath())
+	}
+
+	content, err := vfs.ReadFile(path)
+	test.RequireNoError(t, err, "ReadFile %s", path)
+
+	if string(content) != "B" {
+		t.Errorf("ReadFile : want content to be B, got %s", content)
+	}
+
+	if f.Name() != path {
+		t.Errorf("Name : want name of the file opened before Rebase to be %s, got %s", path, f.Name())
+	}
+
+	buf := make([]byte, 1)
+
+	_, err = f.Read(buf)
+	test.RequireNoError(t, err, "Read %s", path)
+
+	if string(buf) != "A" {
+		t.Errorf("Read : want file opened before Rebase to read A, got %s", buf)
+	}
+
+	t.Run("RebaseErrors", func(t *testing.T) {
+		nonExistingDir := avfs.FromUnixPath(baseFS, "/non/existing")
+
+		err = vfs.Rebase(nonExistingDir)
+		test.AssertPathError(t, err).Op("rebase").Path(nonExistingDir).Err(avfs.ErrNoSuchFileOrDir, avfs.ErrWinPathNotFound).Test()
+
+		existingFile := baseFS.Join(baseB, "file.txt")
+
+		err = vfs.Rebase(existingFile)
+		test.AssertPathError(t, err).Op("rebase").Path(existingFile).Err(avfs.ErrNotADirectory).Test()
+
+		if vfs.BasePath() != baseB {
+			t.Errorf("BasePath : want base path to be unchanged %s, got %s", baseB, vfs.BasePath())
+		}
+	})
+}
+
+func TestBasePathFSSymlink(t *testing.T) {
+	vfs, basePath := initFS(t)
+	baseFS, _ := vfs.ResolveBackend("/")
+
+	dir := avfs.FromUnixPath(vfs, "/dir")
+	target := vfs.Join(dir, "target.txt")
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	err = vfs.WriteFile(target, []byte("target"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", target)
+
+	t.Run("SymlinkAbs", func(t *testing.T) {
+		link := vfs.Join(dir, "absLink")
+
+		err = vfs.Symlink(target, link)
+		test.RequireNoError(t, err, "Symlink %s", link)
+
+		baseLink, err := baseFS.Readlink(vfs.ToBasePath(link))
+		test.RequireNoError(t, err, "Readlink %s", link)
+
+		if wantLink := vfs.ToBasePath(target); baseLink != wantLink {
+			t.Errorf("Readlink : want base link to be %s, got %s", wantLink, baseLink)
+		}
+
+		gotLink, err := vfs.Readlink(link)
+		test.RequireNoError(t, err, "Readlink %s", link)
+
+		if gotLink != target {
+			t.Errorf("Readlink : want link to be %s, got %s", target, gotLink)
+		}
+
+		gotPath, err := vfs.EvalSymlinks(link)
+		test.RequireNoError(t, err, "EvalSymlinks %s", link)
+
+		if gotPath != target {
+			t.Errorf("EvalSymlinks : want path to be %s, got %s", target, gotPath)
+		}
+
+		content, err := vfs.ReadFile(link)
+		test.RequireNoError(t, err, "ReadFile %s", link)
+
+		if string(content) != "target" {
+			t.Errorf("ReadFile : want content to be target, got %s", content)
+		}
+	})
+
+	t.Run("SymlinkRel", func(t *testing.T) {
+		link := vfs.Join(dir, "relLink")
+
+		err = vfs.Symlink("target.txt", link)
+		test.RequireNoError(t, err, "Symlink %s", link)
+
+		gotLink, err := vfs.Readlink(link)
+		test.RequireNoError(t, err, "Readlink %s", link)
+
+		if gotLink != "target.txt" {
+			t.Errorf("Readlink : want link to be target.txt, got %s", gotLink)
+		}
+
+		gotPath, err := vfs.EvalSymlinks(link)
+		test.RequireNoError(t, err, "EvalSymlinks %s", link)
+
+		if gotPath != target {
+			t.Errorf("EvalSymlinks : want path to be %s, got %s", target, gotPath)
+		}
+	})
+
+	t.Run("SymlinkRelOutsideBase", func(t *testing.T) {
+		link := vfs.Join(dir, "outsideLink")
+		oldname := avfs.FromUnixPath(vfs, "../../../etc")
+
+		err = vfs.Symlink(oldname, link)
+		test.AssertLinkError(t, err).Op("symlink").Old(oldname).New(link).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+	})
+
+	t.Run("SymlinkOutsideBase", func(t *testing.T) {
+		link := vfs.Join(dir, "baseLink")
+		outside := baseFS.Join(baseFS.Dir(basePath), "outside")
+
+		err = baseFS.Symlink(outside, vfs.ToBasePath(link))
+		test.RequireNoError(t, err, "Symlink %s", link)
+
+		gotLink, err := vfs.Readlink(link)
+		test.AssertPathError(t, err).Op("readlink").Path(link).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+
+		if gotLink != "" {
+			t.Errorf("Readlink : want link to be empty, got %s", gotLink)
+		}
+
+		_, err = vfs.EvalSymlinks(link)
+		test.AssertPathError(t, err).Op("lstat").Path(link).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+
+		if strings.Contains(err.Error(), basePath) {
+			t.Errorf("EvalSymlinks : want error not to contain the base path, got %v", err)
+		}
+
+		_, err = vfs.Stat(link)
+		test.AssertPathError(t, err).Op("stat").Path(link).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+
+		_, err = vfs.Open(link)
+		test.AssertPathError(t, err).Op("open").Path(link).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+	})
+
+	t.Run("SymlinkEscapeByRename", func(t *testing.T) {
+		outside := baseFS.Join(baseFS.Dir(basePath), "secret")
+
+		err = baseFS.WriteFile(outside, []byte("secret"), avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", outside)
+
+		// The relative link stays in the base path where it is created but not once moved to the root.
+		link := vfs.Join(dir, "escapeLink")
+		oldname := avfs.FromUnixPath(vfs, "../secret")
+
+		err = vfs.Symlink(oldname, link)
+		test.RequireNoError(t, err, "Symlink %s", link)
+
+		movedLink := avfs.FromUnixPath(vfs, "/escapeLink")
+
+		err = vfs.Rename(link, movedLink)
+		test.RequireNoError(t, err, "Rename %s %s", link, movedLink)
+
+		content, err := vfs.ReadFile(movedLink)
+		test.AssertPathError(t, err).Op("open").Path(movedLink).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+
+		if len(content) != 0 {
+			t.Errorf("ReadFile : want no content read outside the base path, got %s", content)
+		}
+
+		linkedDir := avfs.FromUnixPath(vfs, "/escapeDir")
+
+		err = vfs.Symlink(avfs.FromUnixPath(vfs, ".."), vfs.Join(dir, "parentLink"))
+		test.RequireNoError(t, err, "Symlink %s", linkedDir)
+
+		err = vfs.Rename(vfs.Join(dir, "parentLink"), linkedDir)
+		test.RequireNoError(t, err, "Rename %s", linkedDir)
+
+		secret := vfs.Join(linkedDir, "secret")
+
+		_, err = vfs.Stat(secret)
+		test.AssertPathError(t, err).Op("stat").Path(secret).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+
+		err = vfs.WriteFile(secret, []byte("overwritten"), avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("open").Path(secret).
+			OSType(avfs.OsLinux).Err(avfs.ErrPermDenied).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+	})
+}
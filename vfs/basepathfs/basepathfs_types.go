@@ -17,18 +17,23 @@
 package basepathfs
 
 import (
+	"sync"
+
 	"github.com/avfs/avfs"
 )
 
 // BasePathFS implements a base path file system.
 type BasePathFS struct {
-	baseFS          avfs.VFS // baseFS is the base file system.
-	basePath        string   // basePath is the absolute path prepended to all files of the base file system.
-	avfs.FeaturesFn          // FeaturesFn provides features functions to a file system or an identity manager.
+	baseFS          avfs.VFS     // baseFS is the base file system.
+	basePath        string       // basePath is the absolute path prepended to all files of the base file system.
+	mu              sync.RWMutex // mu is the RWMutex used to access basePath.
+	avfs.FeaturesFn              // FeaturesFn provides features functions to a file system or an identity manager.
 }
 
 // BasePathFile represents an open file descriptor.
 type BasePathFile struct {
 	baseFile avfs.File   // baseFile represents an open file descriptor from the base file system.
 	vfs      *BasePathFS // vfs is the base path file system of the file.
+	basePath string      // basePath is the base path of the file system when the file was opened.
+	name     string      // name is the name of the file as presented to Open.
 }
@@ -0,0 +1,58 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package cachefs
+
+import (
+	"container/list"
+
+	"github.com/avfs/avfs"
+)
+
+// New returns a new CacheFS file system from a baseFS file system.
+// At most maxBytes bytes of file contents are kept in memory.
+func New(baseFS avfs.VFS, maxBytes int64) *CacheFS {
+	vfs := &CacheFS{
+		baseFS: baseFS,
+		pages: &pageCache{
+			files:    make(map[string]*cachedFile),
+			lru:      list.New(),
+			maxBytes: maxBytes,
+		},
+		stats: &statCache{entries: make(map[statKey]statEntry)},
+	}
+
+	_ = vfs.SetFeatures(baseFS.Features() &^ (avfs.FeatOpenat | avfs.FeatRenameFlags | avfs.FeatSpecialFiles |
+		avfs.FeatStatFS | avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup | avfs.FeatTmpFile | avfs.FeatXattr))
+
+	return vfs
+}
+
+// Name returns the name of the fileSystem.
+func (vfs *CacheFS) Name() string {
+	return vfs.baseFS.Name()
+}
+
+// ResolveBackend returns the base file system and the path used by the base file system
+// to handle an operation on path.
+func (vfs *CacheFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	return vfs.baseFS, path
+}
+
+// Type returns the type of the fileSystem or Identity manager.
+func (*CacheFS) Type() string {
+	return "CacheFS"
+}
@@ -0,0 +1,317 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package cachefs
+
+import (
+	"container/list"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+const (
+	// pageSize is the size of a cached page.
+	pageSize = 64 * 1024
+
+	// statTTL is the time to live of the file information cached by Stat and Lstat.
+	statTTL = time.Second
+)
+
+// absPath returns the absolute path used as a key of the caches.
+func (vfs *CacheFS) absPath(path string) string {
+	absPath, err := vfs.baseFS.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	return absPath
+}
+
+// invalidate removes the cached contents of the files and all the cached file information.
+func (vfs *CacheFS) invalidate(paths ...string) {
+	vfs.stats.clear()
+
+	for _, path := range paths {
+		vfs.pages.invalidate(vfs.absPath(path), "")
+	}
+}
+
+// invalidateTree removes the cached contents of the files or directories, including their descendants,
+// and all the cached file information.
+func (vfs *CacheFS) invalidateTree(paths ...string) {
+	vfs.stats.clear()
+
+	sep := string(vfs.PathSeparator())
+
+	for _, path := range paths {
+		absPath := vfs.absPath(path)
+
+		prefix := absPath
+		if !strings.HasSuffix(prefix, sep) {
+			prefix += sep
+		}
+
+		vfs.pages.invalidate(absPath, prefix)
+	}
+}
+
+// stat returns the file information of path from the cache if it is still valid,
+// otherwise from the base file system.
+func (vfs *CacheFS) stat(path string, lstat bool) (fs.FileInfo, error) {
+	key := statKey{path: vfs.absPath(path), lstat: lstat}
+
+	if info, ok := vfs.stats.get(key); ok {
+		return info, nil
+	}
+
+	var (
+		info fs.FileInfo
+		err  error
+	)
+
+	if lstat {
+		info, err = vfs.baseFS.Lstat(path)
+	} else {
+		info, err = vfs.baseFS.Stat(path)
+	}
+
+	if err != nil {
+		return info, err
+	}
+
+	vfs.stats.put(key, info)
+
+	return info, nil
+}
+
+// open validates the cached content of the file path against its size and modification time
+// and returns the generation of the cached content.
+func (pc *pageCache) open(path string, size int64, modTime time.Time) uint64 {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	cf, ok := pc.files[path]
+	if ok && cf.size == size && cf.modTime.Equal(modTime) {
+		return cf.gen
+	}
+
+	if ok {
+		pc.remove(cf)
+	}
+
+	pc.gen++
+	pc.files[path] = &cachedFile{
+		pages:   make(map[int64]*list.Element),
+		modTime: modTime,
+		size:    size,
+		gen:     pc.gen,
+	}
+
+	return pc.gen
+}
+
+// get returns the page index of the file path if it is cached for the generation gen.
+func (pc *pageCache) get(path string, gen uint64, index int64) ([]byte, bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	cf, ok := pc.files[path]
+	if !ok || cf.gen != gen {
+		return nil, false
+	}
+
+	e, ok := cf.pages[index]
+	if !ok {
+		return nil, false
+	}
+
+	pc.lru.MoveToFront(e)
+
+	return e.Value.(*page).data, true //nolint:forcetypeassert // lru only contains pages.
+}
+
+// put adds the page index of the file path to the cache if the generation gen is still current,
+// and evicts the least recently used pages to stay below the maximum size of the cache.
+// A file whose last page is evicted is removed from the cache.
+func (pc *pageCache) put(path string, gen uint64, index int64, data []byte) {
+	if int64(len(data)) > pc.maxBytes {
+		return
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	cf, ok := pc.files[path]
+	if !ok || cf.gen != gen {
+		return
+	}
+
+	if _, ok = cf.pages[index]; ok {
+		return
+	}
+
+	cf.pages[index] = pc.lru.PushFront(&page{path: path, index: index, data: data})
+	pc.size += int64(len(data))
+
+	for pc.size > pc.maxBytes {
+		e := pc.lru.Back()
+		p := e.Value.(*page) //nolint:forcetypeassert // lru only contains pages.
+
+		pc.lru.Remove(e)
+		pc.size -= int64(len(p.data))
+
+		// The file is forgotten with its last page, so the cache doesn't grow with the number of files read.
+		evicted := pc.files[p.path]
+		delete(evicted.pages, p.index)
+
+		if len(evicted.pages) == 0 {
+			delete(pc.files, p.path)
+		}
+	}
+}
+
+// invalidate removes the cached content of the file path and of the files starting with prefix if not empty.
+func (pc *pageCache) invalidate(path, prefix string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	for p, cf := range pc.files {
+		if p == path || (prefix != "" && strings.HasPrefix(p, prefix)) {
+			pc.remove(cf)
+			delete(pc.files, p)
+		}
+	}
+}
+
+// remove removes all the cached pages of the file cf.
+func (pc *pageCache) remove(cf *cachedFile) {
+	for index, e := range cf.pages {
+		p := e.Value.(*page) //nolint:forcetypeassert // lru only contains pages.
+
+		pc.lru.Remove(e)
+		pc.size -= int64(len(p.data))
+		delete(cf.pages, index)
+	}
+}
+
+// get returns the cached file information of key if it has not expired.
+func (sc *statCache) get(key statKey) (fs.FileInfo, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	se, ok := sc.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(se.expires) {
+		delete(sc.entries, key)
+
+		return nil, false
+	}
+
+	return se.info, true
+}
+
+// put adds the file information info of key to the cache.
+func (sc *statCache) put(key statKey, info fs.FileInfo) {
+	sc.mu.Lock()
+	sc.entries[key] = statEntry{info: info, expires: time.Now().Add(statTTL)}
+	sc.mu.Unlock()
+}
+
+// clear removes all the cached file information.
+func (sc *statCache) clear() {
+	sc.mu.Lock()
+	clear(sc.entries)
+	sc.mu.Unlock()
+}
+
+// startCache enables the cache for a regular file opened read only
+// and drops the cached content of the file if it has changed since it was cached.
+func (f *CacheFile) startCache() {
+	if f.vfs.pages.maxBytes <= 0 {
+		return
+	}
+
+	info, err := f.baseFile.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		return
+	}
+
+	f.size = info.Size()
+	f.gen = f.vfs.pages.open(f.path, f.size, info.ModTime())
+	f.cached = true
+}
+
+// stopCache disables the cache of the file, the offset of the base file is set to the current offset.
+func (f *CacheFile) stopCache() error {
+	if !f.cached {
+		return nil
+	}
+
+	f.cached = false
+
+	_, err := f.baseFile.Seek(f.at, io.SeekStart)
+
+	return err
+}
+
+// readAt reads len(b) bytes of a cached file starting at byte offset off,
+// reading missing pages from the base file system.
+func (f *CacheFile) readAt(b []byte, off int64) (n int, err error) {
+	for n < len(b) {
+		pos := off + int64(n)
+		if pos >= f.size {
+			return n, io.EOF
+		}
+
+		index := pos / pageSize
+
+		data, ok := f.vfs.pages.get(f.path, f.gen, index)
+		if !ok {
+			data, err = f.readPage(index)
+			if err != nil {
+				return n, err
+			}
+
+			f.vfs.pages.put(f.path, f.gen, index, data)
+		}
+
+		start := pos - index*pageSize
+		if start >= int64(len(data)) {
+			return n, io.EOF
+		}
+
+		n += copy(b[n:], data[start:])
+	}
+
+	return n, nil
+}
+
+// readPage reads the page index of the file from the base file system.
+func (f *CacheFile) readPage(index int64) ([]byte, error) {
+	data := make([]byte, pageSize)
+
+	n, err := f.baseFile.ReadAt(data, index*pageSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return data[:n], nil
+}
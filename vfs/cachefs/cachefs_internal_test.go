@@ -0,0 +1,50 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package cachefs
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+func TestPageCacheEvictFiles(t *testing.T) {
+	vfs := New(memfs.New(), pageSize)
+	data := make([]byte, pageSize)
+
+	for i := 0; i < 10; i++ {
+		path := vfs.Join(vfs.TempDir(), "file"+strconv.Itoa(i))
+
+		err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		_, err = vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+	}
+
+	vfs.pages.mu.Lock()
+	defer vfs.pages.mu.Unlock()
+
+	if n := len(vfs.pages.files); n != 1 {
+		t.Errorf("ReadFile : want only the file of the last cached page to be kept, got %d files", n)
+	}
+}
@@ -0,0 +1,80 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build avfs_race
+
+package cachefs_test
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/cachefs"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+func TestRaceCacheFS(t *testing.T) {
+	vfs := cachefs.New(memfs.New(), 1<<20)
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestRace(t)
+}
+
+func TestRaceCacheFSRead(t *testing.T) {
+	vfs := cachefs.New(memfs.New(), 256*1024)
+
+	path := vfs.Join(vfs.TempDir(), "race.bin")
+	data := bytes.Repeat([]byte("race"), 200_000)
+
+	err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	f, err := vfs.Open(path)
+	test.RequireNoError(t, err, "Open %s", path)
+
+	defer f.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			got, err := vfs.ReadFile(path)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("ReadFile %s : want the content of the file, got an error %v", path, err)
+			}
+		}()
+
+		go func(off int64) {
+			defer wg.Done()
+
+			buf := make([]byte, 4096)
+
+			_, err := f.ReadAt(buf, off)
+			if err != nil || !bytes.Equal(buf, data[off:off+4096]) {
+				t.Errorf("ReadAt %s : want the content of the file, got an error %v", path, err)
+			}
+		}(int64(i) * 100_000)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,231 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package cachefs_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/cachefs"
+	"github.com/avfs/avfs/vfs/hookfs"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+var (
+	// Tests that cachefs.CacheFS struct implements avfs.VFS interface.
+	_ avfs.VFS = &cachefs.CacheFS{}
+
+	// Tests that cachefs.CacheFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &cachefs.CacheFS{}
+
+	// Tests that cachefs.CacheFS struct implements avfs.VFSBase interface.
+	_ avfs.VFSBase = &cachefs.CacheFS{}
+
+	// Tests that cachefs.CacheFile struct implements avfs.File interface.
+	_ avfs.File = &cachefs.CacheFile{}
+)
+
+func TestCacheFS(t *testing.T) {
+	vfs := cachefs.New(memfs.New(), 1<<20)
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestVFSAll(t)
+}
+
+func TestCacheFSNoCache(t *testing.T) {
+	vfs := cachefs.New(memfs.New(), 0)
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestVFSAll(t)
+}
+
+// newCountingFS returns a CacheFS over a HookFS counting the operations of the base file system.
+func newCountingFS(maxBytes int64) (*cachefs.CacheFS, map[string]int) {
+	counts := make(map[string]int)
+	baseFS := hookfs.New(memfs.New(), hookfs.Hooks{
+		Before: func(op string, args ...any) { counts[op]++ },
+	})
+
+	return cachefs.New(baseFS, maxBytes), counts
+}
+
+func TestCacheFSRead(t *testing.T) {
+	vfs, counts := newCountingFS(1 << 20)
+
+	path := vfs.Join(vfs.TempDir(), "cached.txt")
+	data := bytes.Repeat([]byte("0123456789"), 20_000)
+
+	err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	readFile := func(t *testing.T, want []byte) {
+		t.Helper()
+
+		got, err := vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFile %s : want content of %d bytes, got %d bytes", path, len(want), len(got))
+		}
+	}
+
+	readFile(t, data)
+
+	if counts["FileReadAt"] == 0 {
+		t.Errorf("ReadFile : want the first read to read the base file system")
+	}
+
+	clear(counts)
+	readFile(t, data)
+
+	if n := counts["FileRead"] + counts["FileReadAt"]; n != 0 {
+		t.Errorf("ReadFile : want the second read to be served from the cache, got %d base reads", n)
+	}
+
+	t.Run("InvalidateOnWrite", func(t *testing.T) {
+		newData := []byte("new content")
+
+		err = vfs.WriteFile(path, newData, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		clear(counts)
+		readFile(t, newData)
+
+		if counts["FileReadAt"] == 0 {
+			t.Errorf("ReadFile : want a read after a write to read the base file system")
+		}
+	})
+
+	t.Run("InvalidateOnRename", func(t *testing.T) {
+		other := vfs.Join(vfs.TempDir(), "other.txt")
+		otherData := []byte("other content")
+
+		err = vfs.WriteFile(other, otherData, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", other)
+
+		err = vfs.Rename(other, path)
+		test.RequireNoError(t, err, "Rename %s %s", other, path)
+
+		readFile(t, otherData)
+	})
+
+	t.Run("SeekAndReadAt", func(t *testing.T) {
+		err = vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		f, err := vfs.Open(path)
+		test.RequireNoError(t, err, "Open %s", path)
+
+		defer f.Close()
+
+		const off = 70_003
+
+		_, err = f.Seek(off, io.SeekStart)
+		test.RequireNoError(t, err, "Seek %s", path)
+
+		buf := make([]byte, 10)
+
+		_, err = io.ReadFull(f, buf)
+		test.RequireNoError(t, err, "Read %s", path)
+
+		if !bytes.Equal(buf, data[off:off+10]) {
+			t.Errorf("Read : want %s, got %s", data[off:off+10], buf)
+		}
+
+		pos, err := f.Seek(0, io.SeekCurrent)
+		test.RequireNoError(t, err, "Seek %s", path)
+
+		if pos != off+10 {
+			t.Errorf("Seek : want position to be %d, got %d", off+10, pos)
+		}
+
+		n, err := f.ReadAt(buf, int64(len(data)-4))
+		if err != io.EOF || n != 4 {
+			t.Errorf("ReadAt : want 4 bytes and EOF, got %d bytes and %v", n, err)
+		}
+	})
+}
+
+func TestCacheFSMaxBytes(t *testing.T) {
+	const pageSize = 64 * 1024
+
+	vfs, counts := newCountingFS(pageSize)
+
+	path := vfs.Join(vfs.TempDir(), "large.bin")
+	data := make([]byte, 4*pageSize)
+
+	err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	for i := 0; i < 2; i++ {
+		clear(counts)
+
+		_, err = vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+
+		if counts["FileReadAt"] < 3 {
+			t.Errorf("ReadFile : want pages evicted from a cache of one page to be read again, got %d base reads",
+				counts["FileReadAt"])
+		}
+	}
+}
+
+func TestCacheFSStat(t *testing.T) {
+	vfs, counts := newCountingFS(1 << 20)
+
+	path := vfs.Join(vfs.TempDir(), "stat.txt")
+
+	err := vfs.WriteFile(path, []byte("stat"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	for i := 0; i < 3; i++ {
+		_, err = vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+	}
+
+	if counts["Stat"] != 1 {
+		t.Errorf("Stat : want 1 call to the base file system, got %d", counts["Stat"])
+	}
+
+	err = vfs.Chmod(path, 0o600)
+	test.RequireNoError(t, err, "Chmod %s", path)
+
+	info, err := vfs.Stat(path)
+	test.RequireNoError(t, err, "Stat %s", path)
+
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Stat : want mode to be %s, got %s", os.FileMode(0o600), info.Mode().Perm())
+	}
+}
+
+func TestCacheFSFeatures(t *testing.T) {
+	vfs := cachefs.New(memfs.New(), 1<<20)
+
+	if vfs.HasFeature(avfs.FeatTmpFile | avfs.FeatXattr) {
+		t.Errorf("Features : want TmpFile and Xattr features to be removed, got %s", vfs.Features())
+	}
+
+	if !vfs.HasFeature(avfs.FeatSymlink) {
+		t.Errorf("Features : want Symlink feature of the base file system, got %s", vfs.Features())
+	}
+}
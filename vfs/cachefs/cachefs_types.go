@@ -0,0 +1,90 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package cachefs
+
+import (
+	"container/list"
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/avfs/avfs"
+)
+
+// CacheFS implements a file system caching file contents and file information of a base file system
+// using the avfs.VFS interface.
+type CacheFS struct {
+	baseFS          avfs.VFS   // baseFS is the base file system.
+	pages           *pageCache // pages is the cache of file contents.
+	stats           *statCache // stats is the cache of file information returned by Stat and Lstat.
+	avfs.FeaturesFn            // FeaturesFn provides features functions to a file system or an identity manager.
+}
+
+// CacheFile represents an open file descriptor.
+type CacheFile struct {
+	baseFile avfs.File  // baseFile represents an open file descriptor from the base file system.
+	vfs      *CacheFS   // vfs is the cache file system of the file.
+	path     string     // path is the absolute path of the file used as the key of the cache.
+	size     int64      // size is the size of the file when it was opened.
+	at       int64      // at is current position in the file used by Read function when the file is cached.
+	gen      uint64     // gen is the generation of the cached content of the file when it was opened.
+	mu       sync.Mutex // mu is the Mutex used to access at.
+	cached   bool       // cached is true if reads of the file are served from the cache.
+}
+
+// pageCache is a least recently used cache of file pages limited to a maximum number of bytes.
+type pageCache struct {
+	files    map[string]*cachedFile // files contains the cached files where the key is the absolute path.
+	lru      *list.List             // lru is the list of cached pages, the most recently used first.
+	maxBytes int64                  // maxBytes is the maximum number of bytes of all cached pages.
+	size     int64                  // size is the number of bytes of all cached pages.
+	gen      uint64                 // gen is the last generation given to a cached file.
+	mu       sync.Mutex             // mu is the Mutex used to access the cache.
+}
+
+// cachedFile contains the cached pages of a file and the version of the file they belong to.
+type cachedFile struct {
+	pages   map[int64]*list.Element // pages are the cached pages where the key is the page number.
+	modTime time.Time               // modTime is the modification time of the file when the pages were cached.
+	size    int64                   // size is the size of the file when the pages were cached.
+	gen     uint64                  // gen is incremented each time the cached content of the file is invalidated.
+}
+
+// page is a page of a cached file.
+type page struct {
+	path  string // path is the absolute path of the file.
+	index int64  // index is the page number in the file.
+	data  []byte // data is the content of the page.
+}
+
+// statCache is a cache of file information with a time to live.
+type statCache struct {
+	entries map[statKey]statEntry // entries contains the cached file information.
+	mu      sync.Mutex            // mu is the Mutex used to access entries.
+}
+
+// statKey is the key of a cached file information.
+type statKey struct {
+	path  string // path is the absolute path of the file.
+	lstat bool   // lstat is true for a file information returned by Lstat.
+}
+
+// statEntry is a cached file information.
+type statEntry struct {
+	info    fs.FileInfo // info is the file information.
+	expires time.Time   // expires is the time after which the entry is no longer valid.
+}
@@ -181,7 +181,14 @@ func (vfs *FailFS) CreateTemp(dir, pattern string) (avfs.File, error) {
 		return &FailFile{}, err
 	}
 
-	return vfs.baseFS.CreateTemp(dir, pattern)
+	bf, err := vfs.baseFS.CreateTemp(dir, pattern)
+
+	f := &FailFile{
+		baseFile: bf,
+		vfs:      vfs,
+	}
+
+	return f, err
 }
 
 // Dir returns all but the last element of path, typically the path's directory.
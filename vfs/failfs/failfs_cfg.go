@@ -28,7 +28,8 @@ func New(baseFS avfs.VFS) *FailFS {
 		failFunc: OkFunc,
 	}
 
-	_ = vfs.SetFeatures(baseFS.Features())
+	_ = vfs.SetFeatures(baseFS.Features() &^ (avfs.FeatOpenat | avfs.FeatRenameFlags | avfs.FeatSpecialFiles |
+		avfs.FeatStatFS | avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup | avfs.FeatTmpFile | avfs.FeatXattr))
 
 	return vfs
 }
@@ -45,6 +46,12 @@ func (vfs *FailFS) Name() string {
 	return vfs.baseFS.Name()
 }
 
+// ResolveBackend returns the base file system and the path used by the base file system
+// to handle an operation on path.
+func (vfs *FailFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	return vfs.baseFS, path
+}
+
 // Type returns the type of the fileSystem or Identity manager.
 func (*FailFS) Type() string {
 	return "FailFS"
@@ -18,6 +18,7 @@ package failfs
 
 import (
 	"io/fs"
+	"time"
 
 	"github.com/avfs/avfs"
 )
@@ -92,7 +93,7 @@ func (f *FailFile) Close() error {
 	}
 
 	name := f.Name()
-	fp := FailParam{Path: name}
+	fp := FailParam{Op: "close", Path: name}
 	vfs := f.vfs
 
 	err := vfs.fail(avfs.FnFileClose, &fp)
@@ -238,6 +239,66 @@ func (f *FailFile) Seek(offset int64, whence int) (ret int64, err error) {
 	return f.baseFile.Seek(offset, whence)
 }
 
+// SetDeadline sets the read and write deadlines for a File.
+// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+// A zero value for t means I/O operations will not time out.
+func (f *FailFile) SetDeadline(t time.Time) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	name := f.Name()
+	fp := FailParam{Op: "SetDeadline", Path: name}
+	vfs := f.vfs
+
+	err := vfs.fail(avfs.FnFileSetDeadline, &fp)
+	if err != nil {
+		return err
+	}
+
+	return f.baseFile.SetDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *FailFile) SetReadDeadline(t time.Time) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	name := f.Name()
+	fp := FailParam{Op: "SetReadDeadline", Path: name}
+	vfs := f.vfs
+
+	err := vfs.fail(avfs.FnFileSetReadDeadline, &fp)
+	if err != nil {
+		return err
+	}
+
+	return f.baseFile.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for any future Write calls and any
+// currently-blocked Write call.
+// A zero value for t means Write will not time out.
+func (f *FailFile) SetWriteDeadline(t time.Time) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	name := f.Name()
+	fp := FailParam{Op: "SetWriteDeadline", Path: name}
+	vfs := f.vfs
+
+	err := vfs.fail(avfs.FnFileSetWriteDeadline, &fp)
+	if err != nil {
+		return err
+	}
+
+	return f.baseFile.SetWriteDeadline(t)
+}
+
 // Stat returns the FileInfo structure describing file.
 // If there is an error, it will be of type *PathError.
 func (f *FailFile) Stat() (info fs.FileInfo, err error) {
@@ -305,7 +366,7 @@ func (f *FailFile) Write(b []byte) (n int, err error) {
 	}
 
 	name := f.Name()
-	fp := FailParam{Op: "write", Path: name}
+	fp := FailParam{Op: "write", Path: name, Size: int64(len(b))}
 	vfs := f.vfs
 
 	err = vfs.fail(avfs.FnFileWrite, &fp)
@@ -313,6 +374,10 @@ func (f *FailFile) Write(b []byte) (n int, err error) {
 		return 0, err
 	}
 
+	if fp.Size < int64(len(b)) {
+		b = b[:fp.Size]
+	}
+
 	return f.baseFile.Write(b)
 }
 
@@ -325,7 +390,7 @@ func (f *FailFile) WriteAt(b []byte, off int64) (n int, err error) {
 	}
 
 	name := f.Name()
-	fp := FailParam{Op: "write", Path: name}
+	fp := FailParam{Op: "write", Path: name, Size: int64(len(b))}
 	vfs := f.vfs
 
 	err = vfs.fail(avfs.FnFileWriteAt, &fp)
@@ -333,6 +398,10 @@ func (f *FailFile) WriteAt(b []byte, off int64) (n int, err error) {
 		return 0, err
 	}
 
+	if fp.Size < int64(len(b)) {
+		b = b[:fp.Size]
+	}
+
 	return f.baseFile.WriteAt(b, off)
 }
 
@@ -0,0 +1,150 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package failfs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/avfs/avfs"
+)
+
+// Injector injects failures in a FailFS file system according to a list of rules.
+// Its FailFunc method should be set using FailFS.SetFailFunc.
+type Injector struct {
+	rules []*injectRule // rules is the list of injection rules.
+	mu    sync.Mutex    // mu is the mutex protecting the rules.
+}
+
+// injectRule is a rule of an Injector.
+type injectRule struct {
+	err       error      // err is the error returned by the failing function.
+	pathGlob  string     // pathGlob is the pattern matched against the path of the function.
+	fn        avfs.FnVFS // fn is the function to fail.
+	after     int        // after is the number of matching calls before the failure.
+	shortSize int64      // shortSize is the number of bytes to write for a short write rule.
+	kind      injectKind // kind is the kind of failure.
+}
+
+// injectKind is the kind of failure injected by a rule.
+type injectKind uint8
+
+const (
+	injectError      injectKind = iota + 1 // injectError returns an error.
+	injectShortWrite                       // injectShortWrite writes fewer bytes than requested without error.
+)
+
+// NewInjector returns a new Injector without any rule.
+func NewInjector() *Injector {
+	return &Injector{}
+}
+
+// InjectError adds a rule to make the function fn fail with the error err
+// when it is called with a path matching pathGlob (all paths if pathGlob is empty).
+// The first after matching calls succeed, the next one fails and the rule is then removed.
+// The error is returned wrapped in a *fs.PathError or a *os.LinkError,
+// except io.EOF which is returned as is to simulate an early end of file on reads.
+func (inj *Injector) InjectError(fn avfs.FnVFS, pathGlob string, err error, after int) {
+	inj.addRule(&injectRule{kind: injectError, fn: fn, pathGlob: pathGlob, err: err, after: after})
+}
+
+// InjectShortWrite adds a rule to make the next write (Write, WriteAt or WriteString) to a file
+// matching pathGlob write at most size bytes and return without error.
+// The first after matching calls succeed, the next one is truncated and the rule is then removed.
+func (inj *Injector) InjectShortWrite(pathGlob string, size int64, after int) {
+	inj.addRule(&injectRule{kind: injectShortWrite, fn: avfs.FnFileWrite, pathGlob: pathGlob, shortSize: size, after: after})
+}
+
+// Reset removes all the rules.
+func (inj *Injector) Reset() {
+	inj.mu.Lock()
+	inj.rules = nil
+	inj.mu.Unlock()
+}
+
+// addRule adds a rule to the injector.
+func (inj *Injector) addRule(rule *injectRule) {
+	inj.mu.Lock()
+	inj.rules = append(inj.rules, rule)
+	inj.mu.Unlock()
+}
+
+// FailFunc is a FailFunc that fails according to the rules of the injector.
+func (inj *Injector) FailFunc(vfs avfs.VFSBase, fn avfs.FnVFS, fp *FailParam) error {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+
+	for i, rule := range inj.rules {
+		if !rule.match(vfs, fn, fp) {
+			continue
+		}
+
+		if rule.after > 0 {
+			rule.after--
+
+			continue
+		}
+
+		inj.rules = append(inj.rules[:i], inj.rules[i+1:]...)
+
+		return rule.apply(fp)
+	}
+
+	return nil
+}
+
+// match returns true if the rule applies to the function fn called with the parameters fp.
+func (rule *injectRule) match(vfs avfs.VFSBase, fn avfs.FnVFS, fp *FailParam) bool {
+	switch {
+	case rule.kind == injectShortWrite:
+		if fn != avfs.FnFileWrite && fn != avfs.FnFileWriteAt {
+			return false
+		}
+	case fn != rule.fn:
+		return false
+	}
+
+	if rule.pathGlob == "" {
+		return true
+	}
+
+	ok, _ := vfs.Match(rule.pathGlob, fp.Path)
+
+	return ok
+}
+
+// apply applies the rule to the parameters fp and returns the injected error if any.
+func (rule *injectRule) apply(fp *FailParam) error {
+	if rule.kind == injectShortWrite {
+		if rule.shortSize < fp.Size {
+			fp.Size = max(rule.shortSize, 0)
+		}
+
+		return nil
+	}
+
+	switch {
+	case rule.err == io.EOF: //nolint:errorlint // io.EOF is never wrapped.
+		return rule.err
+	case rule.fn == avfs.FnLink || rule.fn == avfs.FnRename || rule.fn == avfs.FnSymlink:
+		return &os.LinkError{Op: fp.Op, Old: fp.Path, New: fp.NewPath, Err: rule.err}
+	default:
+		return &fs.PathError{Op: fp.Op, Path: fp.Path, Err: rule.err}
+	}
+}
@@ -19,6 +19,10 @@
 package failfs_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
 	"testing"
 
 	"github.com/avfs/avfs"
@@ -31,6 +35,9 @@ var (
 	// Tests that failfs.FailFS struct implements avfs.VFS interface.
 	_ avfs.VFS = &failfs.FailFS{}
 
+	// Tests that failfs.FailFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &failfs.FailFS{}
+
 	// Tests that failfs.FailFS struct implements avfs.VFSBase interface.
 	_ avfs.VFSBase = &failfs.FailFS{}
 
@@ -58,3 +65,155 @@ func TestFailFSReadOnly(t *testing.T) {
 	ts := test.NewSuiteFS(t, baseFS, vfs)
 	ts.TestVFSAll(t)
 }
+
+func TestFailFSInjector(t *testing.T) {
+	baseFS := orefafs.New()
+	vfs := failfs.New(baseFS)
+	inj := failfs.NewInjector()
+
+	_ = vfs.SetFailFunc(inj.FailFunc)
+
+	path := vfs.Join(vfs.TempDir(), "injector.txt")
+	data := []byte("0123456789")
+
+	t.Run("InjectError", func(t *testing.T) {
+		inj.InjectError(avfs.FnOpenFile, vfs.Join(vfs.TempDir(), "*.txt"), avfs.ErrPermDenied, 1)
+
+		err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		_, err = vfs.ReadFile(path)
+		test.AssertPathError(t, err).Op("open").Path(path).Err(avfs.ErrPermDenied).Test()
+
+		_, err = vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+	})
+
+	t.Run("InjectShortWrite", func(t *testing.T) {
+		f, err := vfs.Create(path)
+		test.RequireNoError(t, err, "Create %s", path)
+
+		defer f.Close()
+
+		inj.InjectShortWrite("", 4, 0)
+
+		n, err := f.Write(data)
+		test.RequireNoError(t, err, "Write %s", path)
+
+		if n != 4 {
+			t.Errorf("Write : want bytes written to be 4, got %d", n)
+		}
+
+		n, err = f.Write(data)
+		test.RequireNoError(t, err, "Write %s", path)
+
+		if n != len(data) {
+			t.Errorf("Write : want bytes written to be %d, got %d", len(data), n)
+		}
+	})
+
+	t.Run("InjectCloseError", func(t *testing.T) {
+		f, err := vfs.Open(path)
+		test.RequireNoError(t, err, "Open %s", path)
+
+		inj.InjectError(avfs.FnFileClose, "", avfs.ErrBadFileDesc, 0)
+
+		err = f.Close()
+		test.AssertPathError(t, err).Op("close").Path(path).Err(avfs.ErrBadFileDesc).Test()
+
+		err = f.Close()
+		test.RequireNoError(t, err, "Close %s", path)
+	})
+
+	t.Run("InjectEOF", func(t *testing.T) {
+		f, err := vfs.Open(path)
+		test.RequireNoError(t, err, "Open %s", path)
+
+		defer f.Close()
+
+		inj.InjectError(avfs.FnFileRead, "", io.EOF, 0)
+
+		buf := make([]byte, len(data))
+
+		n, err := f.Read(buf)
+		if n != 0 || !errors.Is(err, io.EOF) {
+			t.Errorf("Read : want 0, io.EOF, got %d, %v", n, err)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		inj.InjectError(avfs.FnStat, "", avfs.ErrPermDenied, 0)
+		inj.Reset()
+
+		_, err := vfs.Stat(path)
+		if errors.Is(err, fs.ErrPermission) {
+			t.Errorf("Stat : want error to be nil after Reset, got %v", err)
+		}
+	})
+}
+
+func TestFailFSWriteFileAtomic(t *testing.T) {
+	baseFS := orefafs.New()
+	vfs := failfs.New(baseFS)
+	inj := failfs.NewInjector()
+
+	_ = vfs.SetFailFunc(inj.FailFunc)
+
+	dir := vfs.TempDir()
+	path := vfs.Join(dir, "atomic.cfg")
+	original := []byte("original")
+
+	err := vfs.WriteFile(path, original, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	assertIntact := func(t *testing.T) {
+		t.Helper()
+
+		content, err := vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, original) {
+			t.Errorf("WriteFileAtomic : want content to be %s, got %s", original, content)
+		}
+
+		entries, err := vfs.ReadDir(dir)
+		test.RequireNoError(t, err, "ReadDir %s", dir)
+
+		if len(entries) != 1 {
+			t.Errorf("WriteFileAtomic : want only %s in %s, got %d entries", path, dir, len(entries))
+		}
+	}
+
+	t.Run("WriteFileAtomicRenameError", func(t *testing.T) {
+		inj.InjectError(avfs.FnRename, "", avfs.ErrPermDenied, 0)
+
+		err = avfs.WriteFileAtomic(vfs, path, []byte("new content"), avfs.DefaultFilePerm)
+		if !errors.Is(err, avfs.ErrPermDenied) {
+			t.Errorf("WriteFileAtomic : want error to be %v, got %v", avfs.ErrPermDenied, err)
+		}
+
+		assertIntact(t)
+	})
+
+	t.Run("WriteFileAtomicShortWrite", func(t *testing.T) {
+		inj.InjectShortWrite("", 3, 0)
+
+		err = avfs.WriteFileAtomic(vfs, path, []byte("new content"), avfs.DefaultFilePerm)
+		if !errors.Is(err, io.ErrShortWrite) {
+			t.Errorf("WriteFileAtomic : want error to be %v, got %v", io.ErrShortWrite, err)
+		}
+
+		assertIntact(t)
+	})
+
+	t.Run("WriteFileAtomicSyncError", func(t *testing.T) {
+		inj.InjectError(avfs.FnFileSync, "", avfs.ErrBadFileDesc, 0)
+
+		err = avfs.WriteFileAtomic(vfs, path, []byte("new content"), avfs.DefaultFilePerm)
+		if !errors.Is(err, avfs.ErrBadFileDesc) {
+			t.Errorf("WriteFileAtomic : want error to be %v, got %v", avfs.ErrBadFileDesc, err)
+		}
+
+		assertIntact(t)
+	})
+}
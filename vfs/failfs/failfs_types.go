@@ -51,7 +51,7 @@ type FailParam struct {
 	Flag    int         // Flag is the opening flag for Open function.
 	Uid     int         // Uid is used in the Chown and Lchown functions.
 	Gid     int         // Gid is used in the Chown and Lchown functions.
-	Size    int64       // Size is used in Truncate functions.
+	Size    int64       // Size is used in Truncate functions and is the number of bytes to write in Write functions.
 	ATime   time.Time   // ATime is used in the Chtimes functions.
 	MTime   time.Time   // MTime is used in the Chtimes functions.
 }
@@ -0,0 +1,65 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package hookfs
+
+import (
+	"github.com/avfs/avfs"
+)
+
+// New returns a new HookFS file system from a baseFS file system.
+// Without hooks, all operations are forwarded unchanged to the base file system.
+func New(baseFS avfs.VFS, hooks Hooks) *HookFS {
+	vfs := &HookFS{
+		baseFS: baseFS,
+		hooks:  hooks,
+	}
+
+	_ = vfs.SetFeatures(baseFS.Features() &^ (avfs.FeatOpenat | avfs.FeatRenameFlags | avfs.FeatSpecialFiles |
+		avfs.FeatStatFS | avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup | avfs.FeatTmpFile | avfs.FeatXattr))
+
+	return vfs
+}
+
+// before calls the Before hook, if any, for the function fn.
+func (vfs *HookFS) before(fn avfs.FnVFS, args ...any) {
+	if vfs.hooks.Before != nil {
+		vfs.hooks.Before(fn.String(), args...)
+	}
+}
+
+// after calls the After hook, if any, for the function fn.
+func (vfs *HookFS) after(fn avfs.FnVFS, err error, results ...any) {
+	if vfs.hooks.After != nil {
+		vfs.hooks.After(fn.String(), err, results...)
+	}
+}
+
+// Name returns the name of the fileSystem.
+func (vfs *HookFS) Name() string {
+	return vfs.baseFS.Name()
+}
+
+// ResolveBackend returns the base file system and the path used by the base file system
+// to handle an operation on path.
+func (vfs *HookFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	return vfs.baseFS, path
+}
+
+// Type returns the type of the fileSystem or Identity manager.
+func (*HookFS) Type() string {
+	return "HookFS"
+}
@@ -0,0 +1,354 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package hookfs
+
+import (
+	"io/fs"
+	"time"
+
+	"github.com/avfs/avfs"
+)
+
+// Chdir changes the current working directory to the file,
+// which must be a directory.
+// If there is an error, it will be of type *PathError.
+func (f *HookFile) Chdir() error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileChdir)
+
+	err := f.baseFile.Chdir()
+
+	f.vfs.after(avfs.FnFileChdir, err)
+
+	return err
+}
+
+// Chmod changes the mode of the file to mode.
+// If there is an error, it will be of type *PathError.
+func (f *HookFile) Chmod(mode fs.FileMode) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileChmod, mode)
+
+	err := f.baseFile.Chmod(mode)
+
+	f.vfs.after(avfs.FnFileChmod, err)
+
+	return err
+}
+
+// Chown changes the numeric uid and gid of the named file.
+// If there is an error, it will be of type *PathError.
+//
+// On Windows, it always returns the syscall.EWINDOWS error, wrapped
+// in *PathError.
+func (f *HookFile) Chown(uid, gid int) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileChown, uid, gid)
+
+	err := f.baseFile.Chown(uid, gid)
+
+	f.vfs.after(avfs.FnFileChown, err)
+
+	return err
+}
+
+// Close closes the File, rendering it unusable for I/O.
+// On files that support SetDeadline, any pending I/O operations will
+// be canceled and return immediately with an error.
+func (f *HookFile) Close() error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileClose)
+
+	err := f.baseFile.Close()
+
+	f.vfs.after(avfs.FnFileClose, err)
+
+	return err
+}
+
+// Fd returns the integer Unix file descriptor referencing the open file.
+// The file descriptor is valid only until f.Close is called or f is garbage collected.
+// On Unix systems this will cause the SetDeadline methods to stop working.
+func (f *HookFile) Fd() uintptr {
+	return f.baseFile.Fd()
+}
+
+// Name returns the link of the file as presented to Open.
+func (f *HookFile) Name() string {
+	if f.baseFile == nil {
+		return ""
+	}
+
+	return f.baseFile.Name()
+}
+
+// Read reads up to len(b) bytes from the HookFile.
+// It returns the number of bytes read and any error encountered.
+// At end of file, Read returns 0, io.EOF.
+func (f *HookFile) Read(b []byte) (n int, err error) {
+	if f == nil {
+		return 0, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileRead, b)
+
+	n, err = f.baseFile.Read(b)
+
+	f.vfs.after(avfs.FnFileRead, err, n)
+
+	return n, err
+}
+
+// ReadAt reads len(b) bytes from the File starting at byte offset off.
+// It returns the number of bytes read and the error, if any.
+// ReadAt always returns a non-nil error when n < len(b).
+// At end of file, that error is io.EOF.
+func (f *HookFile) ReadAt(b []byte, off int64) (n int, err error) {
+	if f == nil {
+		return 0, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileReadAt, b, off)
+
+	n, err = f.baseFile.ReadAt(b, off)
+
+	f.vfs.after(avfs.FnFileReadAt, err, n)
+
+	return n, err
+}
+
+// ReadDir reads the contents of the directory associated with the file f
+// and returns a slice of DirEntry values in directory order.
+// Subsequent calls on the same file will yield later DirEntry records in the directory.
+//
+// If n > 0, ReadDir returns at most n DirEntry records.
+// In this case, if ReadDir returns an empty slice, it will return an error explaining why.
+// At the end of a directory, the error is io.EOF.
+//
+// If n <= 0, ReadDir returns all the DirEntry records remaining in the directory.
+// When it succeeds, it returns a nil error (not io.EOF).
+func (f *HookFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f == nil {
+		return nil, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileReadDir, n)
+
+	r, err := f.baseFile.ReadDir(n)
+
+	f.vfs.after(avfs.FnFileReadDir, err, r)
+
+	return r, err
+}
+
+// Readdirnames reads and returns a slice of names from the directory f.
+//
+// If n > 0, Readdirnames returns at most n names. In this case, if
+// Readdirnames returns an empty slice, it will return a non-nil error
+// explaining why. At the end of a directory, the error is io.EOF.
+//
+// If n <= 0, Readdirnames returns all the names from the directory in
+// a single slice. In this case, if Readdirnames succeeds (reads all
+// the way to the end of the directory), it returns the slice and a
+// nil error. If it encounters an error before the end of the
+// directory, Readdirnames returns the names read until that point and
+// a non-nil error.
+func (f *HookFile) Readdirnames(n int) (names []string, err error) {
+	if f == nil {
+		return nil, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileReaddirnames, n)
+
+	names, err = f.baseFile.Readdirnames(n)
+
+	f.vfs.after(avfs.FnFileReaddirnames, err, names)
+
+	return names, err
+}
+
+// Seek sets the offset for the next Read or Write on file to offset, interpreted
+// according to whence: 0 means relative to the origin of the file, 1 means
+// relative to the current offset, and 2 means relative to the end.
+// It returns the new offset and an error, if any.
+// The behavior of Seek on a file opened with O_APPEND is not specified.
+func (f *HookFile) Seek(offset int64, whence int) (ret int64, err error) {
+	if f == nil {
+		return 0, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileSeek, offset, whence)
+
+	ret, err = f.baseFile.Seek(offset, whence)
+
+	f.vfs.after(avfs.FnFileSeek, err, ret)
+
+	return ret, err
+}
+
+// SetDeadline sets the read and write deadlines for a File.
+// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+// A zero value for t means I/O operations will not time out.
+func (f *HookFile) SetDeadline(t time.Time) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileSetDeadline, t)
+
+	err := f.baseFile.SetDeadline(t)
+
+	f.vfs.after(avfs.FnFileSetDeadline, err)
+
+	return err
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *HookFile) SetReadDeadline(t time.Time) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileSetReadDeadline, t)
+
+	err := f.baseFile.SetReadDeadline(t)
+
+	f.vfs.after(avfs.FnFileSetReadDeadline, err)
+
+	return err
+}
+
+// SetWriteDeadline sets the deadline for any future Write calls and any
+// currently-blocked Write call.
+// A zero value for t means Write will not time out.
+func (f *HookFile) SetWriteDeadline(t time.Time) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileSetWriteDeadline, t)
+
+	err := f.baseFile.SetWriteDeadline(t)
+
+	f.vfs.after(avfs.FnFileSetWriteDeadline, err)
+
+	return err
+}
+
+// Stat returns the FileInfo structure describing file.
+// If there is an error, it will be of type *PathError.
+func (f *HookFile) Stat() (info fs.FileInfo, err error) {
+	if f == nil {
+		return nil, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileStat)
+
+	info, err = f.baseFile.Stat()
+
+	f.vfs.after(avfs.FnFileStat, err, info)
+
+	return info, err
+}
+
+// Sync commits the current contents of the file to stable storage.
+// Typically, this means flushing the file system's in-memory copy
+// of recently written data to disk.
+func (f *HookFile) Sync() error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileSync)
+
+	err := f.baseFile.Sync()
+
+	f.vfs.after(avfs.FnFileSync, err)
+
+	return err
+}
+
+// Truncate changes the size of the file.
+// It does not change the I/O offset.
+// If there is an error, it will be of type *PathError.
+func (f *HookFile) Truncate(size int64) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileTruncate, size)
+
+	err := f.baseFile.Truncate(size)
+
+	f.vfs.after(avfs.FnFileTruncate, err)
+
+	return err
+}
+
+// Write writes len(b) bytes to the File.
+// It returns the number of bytes written and an error, if any.
+// Write returns a non-nil error when n != len(b).
+func (f *HookFile) Write(b []byte) (n int, err error) {
+	if f == nil {
+		return 0, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileWrite, b)
+
+	n, err = f.baseFile.Write(b)
+
+	f.vfs.after(avfs.FnFileWrite, err, n)
+
+	return n, err
+}
+
+// WriteAt writes len(b) bytes to the File starting at byte offset off.
+// It returns the number of bytes written and an error, if any.
+// WriteAt returns a non-nil error when n != len(b).
+func (f *HookFile) WriteAt(b []byte, off int64) (n int, err error) {
+	if f == nil {
+		return 0, fs.ErrInvalid
+	}
+
+	f.vfs.before(avfs.FnFileWriteAt, b, off)
+
+	n, err = f.baseFile.WriteAt(b, off)
+
+	f.vfs.after(avfs.FnFileWriteAt, err, n)
+
+	return n, err
+}
+
+// WriteString is like Write, but writes the contents of string s rather than
+// a slice of bytes.
+func (f *HookFile) WriteString(s string) (n int, err error) {
+	return f.Write([]byte(s))
+}
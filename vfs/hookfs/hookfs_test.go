@@ -0,0 +1,157 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package hookfs_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/hookfs"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+var (
+	// Tests that hookfs.HookFS struct implements avfs.VFS interface.
+	_ avfs.VFS = &hookfs.HookFS{}
+
+	// Tests that hookfs.HookFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &hookfs.HookFS{}
+
+	// Tests that hookfs.HookFS struct implements avfs.VFSBase interface.
+	_ avfs.VFSBase = &hookfs.HookFS{}
+
+	// Tests that hookfs.HookFile struct implements avfs.File interface.
+	_ avfs.File = &hookfs.HookFile{}
+)
+
+func TestHookFS(t *testing.T) {
+	vfs := hookfs.New(memfs.New(), hookfs.Hooks{})
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestVFSAll(t)
+}
+
+func TestHookFSWithHooks(t *testing.T) {
+	var befores, afters int
+
+	vfs := hookfs.New(memfs.New(), hookfs.Hooks{
+		Before: func(op string, args ...any) { befores++ },
+		After:  func(op string, err error, results ...any) { afters++ },
+	})
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestVFSAll(t)
+
+	if befores == 0 || befores != afters {
+		t.Errorf("want Before and After to be called the same number of times, got %d and %d", befores, afters)
+	}
+}
+
+func TestHookFSCount(t *testing.T) {
+	counts := make(map[string]int)
+
+	var lastOp string
+
+	var lastErr error
+
+	vfs := hookfs.New(memfs.New(), hookfs.Hooks{
+		Before: func(op string, args ...any) { counts[op]++ },
+		After:  func(op string, err error, results ...any) { lastOp, lastErr = op, err },
+	})
+
+	rootDir := vfs.Join(vfs.TempDir(), "walk")
+
+	err := vfs.Mkdir(rootDir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", rootDir)
+
+	const nbFiles = 10
+
+	for i := 0; i < nbFiles; i++ {
+		path := vfs.Join(rootDir, string(rune('a'+i)))
+
+		err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+	}
+
+	t.Run("WalkDir", func(t *testing.T) {
+		clear(counts)
+
+		err = vfs.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+			return err
+		})
+		test.RequireNoError(t, err, "WalkDir %s", rootDir)
+
+		// WalkDir only calls Lstat on the root directory, the entries are read from the directory.
+		if counts["Stat"] != 0 || counts["Lstat"] != 1 {
+			t.Errorf("WalkDir : want Stat and Lstat to be called 0 and 1 times, got %d and %d",
+				counts["Stat"], counts["Lstat"])
+		}
+
+		if counts["WalkDir"] != 1 || counts["OpenFile"] != 1 {
+			t.Errorf("WalkDir : want WalkDir and OpenFile to be called once, got %d and %d",
+				counts["WalkDir"], counts["OpenFile"])
+		}
+	})
+
+	t.Run("WalkFollow", func(t *testing.T) {
+		clear(counts)
+
+		err = avfs.WalkFollow(vfs, rootDir, func(path string, info fs.FileInfo, err error) error {
+			return err
+		})
+		test.RequireNoError(t, err, "WalkFollow %s", rootDir)
+
+		// WalkFollow calls Stat on the root directory and on each file.
+		if counts["Stat"] != nbFiles+1 || counts["Lstat"] != 0 {
+			t.Errorf("WalkFollow : want Stat and Lstat to be called %d and 0 times, got %d and %d",
+				nbFiles+1, counts["Stat"], counts["Lstat"])
+		}
+
+		if counts["ReadDir"] != 1 {
+			t.Errorf("WalkFollow : want ReadDir to be called once, got %d", counts["ReadDir"])
+		}
+	})
+
+	t.Run("Sub", func(t *testing.T) {
+		subFS, err := vfs.Sub(rootDir)
+		test.RequireNoError(t, err, "Sub %s", rootDir)
+
+		clear(counts)
+
+		path := avfs.FromUnixPath(subFS, "/a")
+
+		_, err = subFS.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		if counts["Stat"] != 1 {
+			t.Errorf("Sub : want Stat of the sub file system to be hooked once, got %d", counts["Stat"])
+		}
+	})
+
+	t.Run("After", func(t *testing.T) {
+		path := vfs.Join(rootDir, "nonExisting")
+
+		_, err = vfs.Stat(path)
+		if lastOp != "Stat" || lastErr != err {
+			t.Errorf("After : want op and error to be (Stat, %v), got (%s, %v)", err, lastOp, lastErr)
+		}
+	})
+}
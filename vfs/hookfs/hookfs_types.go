@@ -0,0 +1,39 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package hookfs
+
+import "github.com/avfs/avfs"
+
+// HookFS implements a file system calling hooks around each operation using the avfs.VFS interface.
+type HookFS struct {
+	baseFS          avfs.VFS // baseFS is the base file system.
+	hooks           Hooks    // hooks are the functions called around each operation.
+	avfs.FeaturesFn          // FeaturesFn provides features functions to a file system or an identity manager.
+}
+
+// HookFile represents an open file descriptor.
+type HookFile struct {
+	baseFile avfs.File // baseFile represents an open file descriptor from the base file system.
+	vfs      *HookFS   // vfs is the hook file system of the file.
+}
+
+// Hooks defines the functions called around each operation forwarded to the base file system.
+// A nil function is not called.
+type Hooks struct {
+	Before func(op string, args ...any)               // Before is called before each operation with its arguments.
+	After  func(op string, err error, results ...any) // After is called after each operation with its error and results.
+}
@@ -0,0 +1,126 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package kvfs
+
+import (
+	"io/fs"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/avfs/avfs/vfs/osfs"
+)
+
+// Open opens the file system saved to the file path of the operating system file system (see FileStore).
+// The file is created by the first save if it doesn't exist.
+func Open(path string) (*KvFS, error) {
+	store, err := NewFileStore(osfs.NewWithNoIdm(), path)
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenStore(store)
+}
+
+// OpenStore opens the file system saved to store.
+// If the store is empty, the system directories are created and saved to the store.
+// The store is closed by KvFS.Close.
+func OpenStore(store Store) (*KvFS, error) {
+	baseFS := memfs.New()
+
+	adminFS := baseFS.Clone().(*memfs.MemFS) //nolint:forcetypeassert // Clone of a MemFS is a MemFS.
+	_ = adminFS.SetUser(adminFS.Idm().AdminUser())
+
+	vfs := &KvFS{
+		baseFS:        baseFS,
+		adminFS:       adminFS,
+		store:         store,
+		errPermDenied: avfs.ErrPermDenied,
+		nodes:         make(map[uint64]*savedNode),
+		inos:          make(map[uint64]uint64),
+		dirty:         make(map[uint64]*dirtyNode),
+		files:         make(map[*KvFile]struct{}),
+		nextIno:       1,
+	}
+
+	_ = vfs.SetFeatures(avfs.FeatHardlink | avfs.FeatSymlink | avfs.FeatSyncFS | baseFS.Features()&avfs.FeatIdentityMgr)
+
+	if baseFS.OSType() == avfs.OsWindows {
+		vfs.errPermDenied = avfs.ErrWinAccessDenied
+	}
+
+	snap, err := readSnapshot(store)
+	if err == nil {
+		if snap.hasRoot {
+			err = vfs.restore(snap)
+			if err == nil {
+				err = store.Commit()
+			}
+		} else {
+			err = vfs.flush()
+		}
+	}
+
+	if err != nil {
+		_ = store.Close()
+
+		return nil, err
+	}
+
+	return vfs, nil
+}
+
+// Close saves the file system to its store and closes the store.
+// The file system can still be used after Close, but its changes are no longer saved.
+func (vfs *KvFS) Close() error {
+	vfs.mu.Lock()
+	defer vfs.mu.Unlock()
+
+	if vfs.closed {
+		return fs.ErrClosed
+	}
+
+	vfs.closed = true
+
+	err := vfs.flush()
+	if cerr := vfs.store.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// Name returns the name of the fileSystem.
+func (vfs *KvFS) Name() string {
+	return vfs.baseFS.Name()
+}
+
+// SyncFS saves all the changes of the file system to its store.
+func (vfs *KvFS) SyncFS() error {
+	vfs.mu.Lock()
+	defer vfs.mu.Unlock()
+
+	if vfs.closed {
+		return fs.ErrClosed
+	}
+
+	return vfs.flush()
+}
+
+// Type returns the type of the fileSystem or Identity manager.
+func (*KvFS) Type() string {
+	return "KvFS"
+}
@@ -0,0 +1,512 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package kvfs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+const (
+	// chunkSize is the size of the chunks of file contents saved as separate records.
+	chunkSize = 64 * 1024
+
+	// rootKey is the key of the record containing the inode number of the root directory.
+	rootKey = "root"
+
+	// inodeKeyPrefix starts the keys of inode records, followed by the inode number.
+	inodeKeyPrefix = 'i'
+
+	// direntKeyPrefix starts the keys of directory entry records,
+	// followed by the inode number of the directory and the name of the entry.
+	direntKeyPrefix = 'd'
+
+	// chunkKeyPrefix starts the keys of chunk records, followed by the inode number and the chunk index.
+	chunkKeyPrefix = 'c'
+
+	// hexLen is the length of a number formatted in a key.
+	hexLen = 16
+)
+
+// inoer is implemented by the file information of the memory file system.
+type inoer interface {
+	Ino() uint64
+}
+
+// inoOf returns the inode number of a file information of the memory file system.
+func inoOf(info fs.FileInfo) uint64 {
+	return info.Sys().(inoer).Ino() //nolint:forcetypeassert // The file information comes from a MemFS.
+}
+
+// inodeKey returns the key of the inode record of ino.
+func inodeKey(ino uint64) string {
+	return fmt.Sprintf("%c%016x", inodeKeyPrefix, ino)
+}
+
+// direntKey returns the key of the entry name of the directory ino.
+func direntKey(ino uint64, name string) string {
+	return fmt.Sprintf("%c%016x%s", direntKeyPrefix, ino, name)
+}
+
+// chunkKey returns the key of the chunk index of the file ino.
+func chunkKey(ino uint64, index int64) string {
+	return fmt.Sprintf("%c%016x%016x", chunkKeyPrefix, ino, index)
+}
+
+// parseHex parses the number formatted in key at offset off.
+func parseHex(key string, off int) (uint64, error) {
+	if len(key) < off+hexLen {
+		return 0, ErrCorrupted
+	}
+
+	n, err := strconv.ParseUint(key[off:off+hexLen], 16, 64)
+	if err != nil {
+		return 0, ErrCorrupted
+	}
+
+	return n, nil
+}
+
+// encodeIno returns the value of a record containing the inode number ino.
+func encodeIno(ino uint64) []byte {
+	return binary.AppendUvarint(nil, ino)
+}
+
+// decodeIno returns the inode number of a record encoded by encodeIno.
+func decodeIno(value []byte) (uint64, error) {
+	ino, n := binary.Uvarint(value)
+	if n <= 0 || n != len(value) {
+		return 0, ErrCorrupted
+	}
+
+	return ino, nil
+}
+
+// encode returns the value of the inode record nd.
+func (nd *inode) encode() []byte {
+	b := make([]byte, 0, 5*binary.MaxVarintLen64+len(nd.target))
+	b = binary.AppendUvarint(b, uint64(nd.mode))
+	b = binary.AppendVarint(b, int64(nd.uid))
+	b = binary.AppendVarint(b, int64(nd.gid))
+	b = binary.AppendVarint(b, nd.modTime)
+	b = binary.AppendVarint(b, nd.size)
+
+	return append(b, nd.target...)
+}
+
+// decodeInode returns the inode of a record encoded by inode.encode.
+func decodeInode(value []byte) (*inode, error) {
+	var nums [5]uint64
+
+	for i := range nums {
+		var n int
+
+		if i == 0 {
+			nums[i], n = binary.Uvarint(value)
+		} else {
+			var v int64
+
+			v, n = binary.Varint(value)
+			nums[i] = uint64(v)
+		}
+
+		if n <= 0 {
+			return nil, ErrCorrupted
+		}
+
+		value = value[n:]
+	}
+
+	nd := &inode{
+		mode:    fs.FileMode(nums[0]),
+		uid:     int(int64(nums[1])),
+		gid:     int(int64(nums[2])),
+		modTime: int64(nums[3]),
+		size:    int64(nums[4]),
+		target:  string(value),
+	}
+
+	if nd.size < 0 {
+		return nil, ErrCorrupted
+	}
+
+	return nd, nil
+}
+
+// chunkCount returns the number of chunks of a file of the given size.
+func chunkCount(size int64) int64 {
+	return (size + chunkSize - 1) / chunkSize
+}
+
+// rootPath returns the path of the root directory saved to the store.
+// On Windows, only the default volume is saved.
+func (vfs *KvFS) rootPath() string {
+	if vfs.OSType() == avfs.OsWindows {
+		return avfs.DefaultVolume + string(vfs.PathSeparator())
+	}
+
+	return string(vfs.PathSeparator())
+}
+
+// readSnapshot reads the records of store.
+func readSnapshot(store Store) (*snapshot, error) {
+	snap := &snapshot{
+		inodes:  make(map[uint64]*inode),
+		entries: make(map[uint64][]dirent),
+		chunks:  make(map[uint64]map[int64][]byte),
+	}
+
+	err := store.ForEach(func(key string, value []byte) error {
+		return snap.addRecord(key, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entries := range snap.entries {
+		sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	}
+
+	return snap, nil
+}
+
+// addRecord adds the record key of the store to the snapshot.
+func (snap *snapshot) addRecord(key string, value []byte) error {
+	if key == rootKey {
+		ino, err := decodeIno(value)
+		if err != nil {
+			return err
+		}
+
+		snap.root, snap.hasRoot = ino, true
+
+		return nil
+	}
+
+	if key == "" {
+		return ErrCorrupted
+	}
+
+	ino, err := parseHex(key, 1)
+	if err != nil {
+		return err
+	}
+
+	switch key[0] {
+	case inodeKeyPrefix:
+		nd, err := decodeInode(value)
+		if err != nil {
+			return err
+		}
+
+		snap.inodes[ino] = nd
+	case direntKeyPrefix:
+		childIno, err := decodeIno(value)
+		if err != nil {
+			return err
+		}
+
+		snap.entries[ino] = append(snap.entries[ino], dirent{name: key[1+hexLen:], ino: childIno})
+	case chunkKeyPrefix:
+		index, err := parseHex(key, 1+hexLen)
+		if err != nil {
+			return err
+		}
+
+		if index > math.MaxInt64/chunkSize {
+			return ErrCorrupted
+		}
+
+		chunks := snap.chunks[ino]
+		if chunks == nil {
+			chunks = make(map[int64][]byte)
+			snap.chunks[ino] = chunks
+		}
+
+		chunks[int64(index)] = append([]byte(nil), value...)
+	default:
+		return ErrCorrupted
+	}
+
+	return nil
+}
+
+// restore recreates the files of the snapshot in the base file system.
+// The entries not present in the snapshot, like the system directories removed before the save, are removed.
+// The records not reachable from the root directory are deleted from the store.
+func (vfs *KvFS) restore(snap *snapshot) error {
+	rs := &restorer{
+		vfs:   vfs.adminFS,
+		kv:    vfs,
+		snap:  snap,
+		paths: make(map[uint64]string),
+	}
+
+	nd, ok := snap.inodes[snap.root]
+	if !ok || !nd.mode.IsDir() {
+		return ErrCorrupted
+	}
+
+	root := vfs.rootPath()
+	rs.paths[snap.root] = root
+
+	if err := rs.restoreDir(root, snap.root); err != nil {
+		return err
+	}
+
+	if err := rs.restoreAttrs(root, nd); err != nil {
+		return err
+	}
+
+	if err := rs.addNode(root, snap.root, nd); err != nil {
+		return err
+	}
+
+	// The root directory is never removed.
+	vfs.nodes[snap.root].refs++
+
+	for _, sn := range vfs.nodes {
+		for _, childIno := range sn.entries {
+			vfs.nodes[childIno].refs++
+		}
+	}
+
+	for sino := range snap.inodes {
+		vfs.nextIno = max(vfs.nextIno, sino+1)
+	}
+
+	return rs.deleteUnreachable()
+}
+
+// restorer recreates the files of a snapshot in a file system.
+type restorer struct {
+	vfs   *memfs.MemFS      // vfs is the file system where the files are created.
+	kv    *KvFS             // kv is the file system whose saved nodes are restored.
+	snap  *snapshot         // snap is the snapshot to restore.
+	paths map[uint64]string // paths contains the path of the inodes already restored, used to create hard links.
+}
+
+// addNode adds the node path of inode number sino restored from the store to the saved nodes.
+func (rs *restorer) addNode(path string, sino uint64, nd *inode) error {
+	info, err := rs.vfs.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	sn := &savedNode{rec: *nd, memIno: inoOf(info)}
+
+	if nd.mode.IsDir() {
+		sn.entries = make(map[string]uint64, len(rs.snap.entries[sino]))
+
+		for _, entry := range rs.snap.entries[sino] {
+			sn.entries[entry.name] = entry.ino
+		}
+	}
+
+	rs.kv.nodes[sino] = sn
+	rs.kv.inos[sn.memIno] = sino
+
+	return nil
+}
+
+// deleteUnreachable deletes the records of the snapshot not reachable from the root directory,
+// and the chunks beyond the end of the files.
+func (rs *restorer) deleteUnreachable() error {
+	kv := rs.kv
+
+	for sino := range rs.snap.inodes {
+		if _, ok := kv.nodes[sino]; !ok {
+			if err := kv.store.Delete(inodeKey(sino)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for sino, entries := range rs.snap.entries {
+		if _, ok := kv.nodes[sino]; ok {
+			continue
+		}
+
+		for _, entry := range entries {
+			if err := kv.store.Delete(direntKey(sino, entry.name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for sino, chunks := range rs.snap.chunks {
+		sn, ok := kv.nodes[sino]
+
+		for index := range chunks {
+			if ok && index < chunkCount(sn.rec.size) {
+				continue
+			}
+
+			if err := kv.store.Delete(chunkKey(sino, index)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreDir restores the entries of the directory path of inode number ino.
+func (rs *restorer) restoreDir(path string, ino uint64) error {
+	entries := rs.snap.entries[ino]
+	names := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		names[entry.name] = true
+
+		nd, ok := rs.snap.inodes[entry.ino]
+		if !ok {
+			return ErrCorrupted
+		}
+
+		childPath := rs.vfs.Join(path, entry.name)
+
+		if linkPath, ok := rs.paths[entry.ino]; ok {
+			if nd.mode.IsDir() {
+				return ErrCorrupted
+			}
+
+			if err := rs.vfs.Link(linkPath, childPath); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		rs.paths[entry.ino] = childPath
+
+		if err := rs.restoreNode(childPath, entry.ino, nd); err != nil {
+			return err
+		}
+	}
+
+	dirEntries, err := rs.vfs.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	for _, dirEntry := range dirEntries {
+		if !names[dirEntry.Name()] {
+			if err = rs.vfs.RemoveAll(rs.vfs.Join(path, dirEntry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// restoreNode restores the file, directory or symbolic link path of inode number ino.
+func (rs *restorer) restoreNode(path string, ino uint64, nd *inode) error {
+	var err error
+
+	switch {
+	case nd.mode.IsDir():
+		err = rs.vfs.Mkdir(path, avfs.DefaultDirPerm)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+
+		err = rs.restoreDir(path, ino)
+	case nd.mode&fs.ModeSymlink != 0:
+		err = rs.vfs.Symlink(nd.target, path)
+	case nd.mode.IsRegular():
+		err = rs.restoreFile(path, ino, nd.size)
+	default:
+		err = ErrCorrupted
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err = rs.restoreAttrs(path, nd); err != nil {
+		return err
+	}
+
+	return rs.addNode(path, ino, nd)
+}
+
+// restoreFile creates the file path of inode number ino from its chunks.
+func (rs *restorer) restoreFile(path string, ino uint64, size int64) error {
+	f, err := rs.vfs.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, avfs.DefaultFilePerm)
+	if err != nil {
+		return err
+	}
+
+	for index, data := range rs.snap.chunks[ino] {
+		off := index * chunkSize
+		if off >= size || int64(len(data)) > size-off {
+			err = ErrCorrupted
+
+			break
+		}
+
+		if _, err = f.WriteAt(data, off); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = f.Truncate(size)
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// restoreAttrs restores the permissions, the owner and the modification time of path.
+func (rs *restorer) restoreAttrs(path string, nd *inode) error {
+	if nd.mode&fs.ModeSymlink == 0 {
+		if err := rs.vfs.Chmod(path, nd.mode&^fs.ModeType); err != nil {
+			return err
+		}
+	}
+
+	if rs.vfs.OSType() != avfs.OsWindows {
+		if err := rs.vfs.Lchown(path, nd.uid, nd.gid); err != nil {
+			return err
+		}
+	}
+
+	if nd.mode&fs.ModeSymlink != 0 {
+		return nil
+	}
+
+	modTime := time.Unix(0, nd.modTime)
+
+	return rs.vfs.Chtimes(path, modTime, modTime)
+}
@@ -0,0 +1,97 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build avfs_race
+
+package kvfs_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/kvfs"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+func TestRaceKvFS(t *testing.T) {
+	store, err := kvfs.NewFileStore(memfs.New(), "/kvfs.db")
+	test.RequireNoError(t, err, "NewFileStore")
+
+	vfs, err := kvfs.OpenStore(store)
+	test.RequireNoError(t, err, "OpenStore")
+
+	defer vfs.Close()
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestRace(t)
+}
+
+func TestRaceKvFSSync(t *testing.T) {
+	storeFS := memfs.New()
+
+	store, err := kvfs.NewFileStore(storeFS, "/kvfs.db")
+	test.RequireNoError(t, err, "NewFileStore")
+
+	vfs, err := kvfs.OpenStore(store)
+	test.RequireNoError(t, err, "OpenStore")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			path := vfs.Join(vfs.TempDir(), "file"+strconv.Itoa(i))
+
+			err := vfs.WriteFile(path, []byte(path), avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", path)
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			err := vfs.SyncFS()
+			test.RequireNoError(t, err, "SyncFS")
+		}()
+	}
+
+	wg.Wait()
+
+	err = vfs.Close()
+	test.RequireNoError(t, err, "Close")
+
+	store, err = kvfs.NewFileStore(storeFS, "/kvfs.db")
+	test.RequireNoError(t, err, "NewFileStore")
+
+	vfs, err = kvfs.OpenStore(store)
+	test.RequireNoError(t, err, "OpenStore")
+
+	defer vfs.Close()
+
+	for i := 0; i < 8; i++ {
+		path := vfs.Join(vfs.TempDir(), "file"+strconv.Itoa(i))
+
+		data, err := vfs.ReadFile(path)
+		if err != nil || string(data) != path {
+			t.Errorf("ReadFile %s : want the content of the file, got an error %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,615 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package kvfs
+
+import (
+	"io/fs"
+	"sort"
+	"strings"
+
+	"github.com/avfs/avfs"
+)
+
+// realPath returns the absolute path of name without symbolic links.
+// The last element of name is evaluated only if follow is true.
+func (vfs *KvFS) realPath(name string, follow bool) (string, error) {
+	absPath, err := vfs.baseFS.Abs(name)
+	if err != nil {
+		return "", err
+	}
+
+	if follow {
+		return vfs.adminFS.EvalSymlinks(absPath)
+	}
+
+	dir, base := vfs.baseFS.Split(absPath)
+	if base == "" {
+		return absPath, nil
+	}
+
+	dir, err = vfs.adminFS.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	return vfs.baseFS.Join(dir, base), nil
+}
+
+// markNode marks the node name as changed, the last element of name is evaluated only if follow is true.
+func (vfs *KvFS) markNode(name string, follow bool) {
+	path, err := vfs.realPath(name, follow)
+	if err != nil {
+		return
+	}
+
+	info, err := vfs.adminFS.Lstat(path)
+	if err != nil {
+		return
+	}
+
+	vfs.dmu.Lock()
+	vfs.markLocked(inoOf(info), path)
+	vfs.dmu.Unlock()
+}
+
+// markDir marks the entries of the directory dir as changed.
+func (vfs *KvFS) markDir(dir string) {
+	path, err := vfs.realPath(dir, true)
+	if err != nil {
+		return
+	}
+
+	info, err := vfs.adminFS.Lstat(path)
+	if err != nil || !info.IsDir() {
+		return
+	}
+
+	vfs.dmu.Lock()
+	vfs.markLocked(inoOf(info), path).entries = true
+	vfs.dmu.Unlock()
+}
+
+// markParent marks the entries of the parent directory of name as changed.
+func (vfs *KvFS) markParent(name string) {
+	absPath, err := vfs.baseFS.Abs(name)
+	if err != nil {
+		return
+	}
+
+	vfs.markDir(vfs.baseFS.Dir(absPath))
+}
+
+// markFile marks the file f as changed.
+// The path of a file not opened for writing is the name it was opened with.
+func (vfs *KvFS) markFile(f *KvFile) {
+	vfs.dmu.Lock()
+	ino, path := f.ino, f.path
+	vfs.dmu.Unlock()
+
+	if ino == 0 {
+		vfs.markNode(f.baseFile.Name(), true)
+
+		return
+	}
+
+	vfs.dmu.Lock()
+	vfs.markLocked(ino, path)
+	vfs.dmu.Unlock()
+}
+
+// markChunks marks the chunks of the file f written from offset off to off + n as changed.
+func (vfs *KvFS) markChunks(f *KvFile, off int64, n int) {
+	if n <= 0 || f.ino == 0 {
+		return
+	}
+
+	vfs.dmu.Lock()
+	defer vfs.dmu.Unlock()
+
+	dn := vfs.markLocked(f.ino, f.path)
+	for index := off / chunkSize; index <= (off+int64(n)-1)/chunkSize; index++ {
+		dn.chunks[index] = struct{}{}
+	}
+}
+
+// markLocked returns the dirty node of the node ino whose last known path is path.
+// The dirty mutex of the file system must be locked.
+func (vfs *KvFS) markLocked(ino uint64, path string) *dirtyNode {
+	dn, ok := vfs.dirty[ino]
+	if !ok {
+		dn = &dirtyNode{chunks: make(map[int64]struct{})}
+		vfs.dirty[ino] = dn
+	}
+
+	dn.path = path
+
+	return dn
+}
+
+// movePaths updates the paths of the dirty nodes and the open files after oldPath was renamed to newPath.
+func (vfs *KvFS) movePaths(oldPath, newPath string) {
+	move := func(path string) string {
+		if path == oldPath {
+			return newPath
+		}
+
+		if len(path) > len(oldPath) && strings.HasPrefix(path, oldPath) && vfs.IsPathSeparator(path[len(oldPath)]) {
+			return newPath + path[len(oldPath):]
+		}
+
+		return path
+	}
+
+	vfs.dmu.Lock()
+	defer vfs.dmu.Unlock()
+
+	for _, dn := range vfs.dirty {
+		dn.path = move(dn.path)
+	}
+
+	for f := range vfs.files {
+		f.path = move(f.path)
+	}
+}
+
+// flush saves the changes of the file system to the store and commits them.
+// Only the records of the changed nodes are put, the records of the removed nodes are deleted.
+// The mutex of the file system must be locked.
+func (vfs *KvFS) flush() error {
+	vfs.dmu.Lock()
+	dirty := vfs.dirty
+	vfs.dirty = make(map[uint64]*dirtyNode)
+	vfs.dmu.Unlock()
+
+	fl := &flusher{vfs: vfs, saved: make(map[uint64]bool)}
+
+	err := fl.flush(dirty)
+	if err == nil {
+		err = vfs.store.Commit()
+	}
+
+	if err != nil {
+		// The changes are saved again by the next flush.
+		vfs.dmu.Lock()
+
+		for ino, dn := range dirty {
+			newDn := vfs.markLocked(ino, dn.path)
+			newDn.entries = newDn.entries || dn.entries
+
+			for index := range dn.chunks {
+				newDn.chunks[index] = struct{}{}
+			}
+		}
+
+		vfs.dmu.Unlock()
+	}
+
+	return err
+}
+
+// flusher saves the changes of a file system to its store.
+type flusher struct {
+	vfs   *KvFS             // vfs is the file system to save.
+	saved map[uint64]bool   // saved contains the inode numbers of the store of the nodes entirely saved by the flush.
+	paths map[uint64]string // paths contains the paths of the nodes of the base file system, read only if needed.
+	gc    []uint64          // gc contains the inode numbers of the store of the nodes no longer referenced.
+}
+
+// flush saves the dirty nodes.
+// The entries of the directories are saved first, then the nodes no longer referenced are deleted
+// and finally the inodes and the chunks of the remaining nodes are saved.
+func (fl *flusher) flush(dirty map[uint64]*dirtyNode) error {
+	vfs := fl.vfs
+
+	if len(vfs.nodes) == 0 {
+		return fl.saveRoot()
+	}
+
+	inos := make([]uint64, 0, len(dirty))
+	for ino := range dirty {
+		inos = append(inos, ino)
+	}
+
+	sort.Slice(inos, func(i, j int) bool { return dirty[inos[i]].path < dirty[inos[j]].path })
+
+	var lost []uint64
+
+	for _, ino := range inos {
+		if !dirty[ino].entries {
+			continue
+		}
+
+		found, err := fl.saveDir(ino, dirty[ino].path)
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			lost = append(lost, ino)
+		}
+	}
+
+	fl.collect()
+
+	// The directories still referenced but not found at their last known path are searched in the whole tree.
+	for _, ino := range lost {
+		if _, ok := vfs.inos[ino]; !ok {
+			continue
+		}
+
+		path, err := fl.find(ino)
+		if err != nil {
+			return err
+		}
+
+		if path == "" {
+			continue
+		}
+
+		if _, err = fl.saveDir(ino, path); err != nil {
+			return err
+		}
+	}
+
+	fl.collect()
+
+	for _, ino := range inos {
+		if err := fl.update(ino, dirty[ino]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// saveRoot saves the whole file system to an empty store.
+func (fl *flusher) saveRoot() error {
+	vfs := fl.vfs
+
+	info, err := vfs.adminFS.Lstat(vfs.rootPath())
+	if err != nil {
+		return err
+	}
+
+	ino, err := fl.saveNew(vfs.rootPath(), info)
+	if err != nil {
+		return err
+	}
+
+	// The root directory is never removed.
+	vfs.nodes[ino].refs++
+
+	return vfs.store.Put(rootKey, encodeIno(ino))
+}
+
+// saveDir saves the entries of the directory of inode number ino of the base file system and path.
+// It returns false if path is no longer the path of the directory.
+func (fl *flusher) saveDir(ino uint64, path string) (bool, error) {
+	vfs := fl.vfs
+
+	sino, ok := vfs.inos[ino]
+	if !ok || fl.saved[sino] {
+		return true, nil
+	}
+
+	info, err := vfs.adminFS.Lstat(path)
+	if err != nil || inoOf(info) != ino || !info.IsDir() {
+		return false, nil //nolint:nilerr // The directory is searched or removed by the caller.
+	}
+
+	entries, err := vfs.adminFS.ReadDir(path)
+	if err != nil {
+		return true, err
+	}
+
+	sn := vfs.nodes[sino]
+	names := make(map[string]struct{}, len(entries))
+
+	for _, entry := range entries {
+		name := entry.Name()
+		names[name] = struct{}{}
+
+		childIno, err := fl.saveChild(vfs.baseFS.Join(path, name), entry)
+		if err != nil {
+			return true, err
+		}
+
+		oldIno, ok := sn.entries[name]
+		if ok && oldIno == childIno {
+			continue
+		}
+
+		if ok {
+			fl.unref(oldIno)
+		}
+
+		if err = vfs.store.Put(direntKey(sino, name), encodeIno(childIno)); err != nil {
+			return true, err
+		}
+
+		sn.entries[name] = childIno
+		vfs.nodes[childIno].refs++
+	}
+
+	for name, childIno := range sn.entries {
+		if _, ok := names[name]; ok {
+			continue
+		}
+
+		if err = vfs.store.Delete(direntKey(sino, name)); err != nil {
+			return true, err
+		}
+
+		delete(sn.entries, name)
+		fl.unref(childIno)
+	}
+
+	return true, fl.saveNode(sino, path, info, nil)
+}
+
+// saveChild returns the inode number of the store of the directory entry path,
+// the node is saved if it is not saved yet.
+func (fl *flusher) saveChild(path string, entry fs.DirEntry) (uint64, error) {
+	info, err := entry.Info()
+	if err != nil {
+		return 0, err
+	}
+
+	if sino, ok := fl.vfs.inos[inoOf(info)]; ok {
+		return sino, nil
+	}
+
+	return fl.saveNew(path, info)
+}
+
+// saveNew saves a node not saved yet, and its descendants if it is a directory.
+// It returns the inode number of the node in the store.
+func (fl *flusher) saveNew(path string, info fs.FileInfo) (uint64, error) {
+	vfs := fl.vfs
+
+	sino := vfs.nextIno
+	vfs.nextIno++
+
+	sn := &savedNode{memIno: inoOf(info)}
+	vfs.nodes[sino] = sn
+	vfs.inos[sn.memIno] = sino
+	fl.saved[sino] = true
+
+	if info.IsDir() {
+		sn.entries = make(map[string]uint64)
+
+		entries, err := vfs.adminFS.ReadDir(path)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, entry := range entries {
+			childIno, err := fl.saveChild(vfs.baseFS.Join(path, entry.Name()), entry)
+			if err != nil {
+				return 0, err
+			}
+
+			if err = vfs.store.Put(direntKey(sino, entry.Name()), encodeIno(childIno)); err != nil {
+				return 0, err
+			}
+
+			sn.entries[entry.Name()] = childIno
+			vfs.nodes[childIno].refs++
+		}
+	}
+
+	return sino, fl.saveNode(sino, path, info, nil)
+}
+
+// update saves the inode and the chunks of the dirty node of inode number ino of the base file system.
+func (fl *flusher) update(ino uint64, dn *dirtyNode) error {
+	vfs := fl.vfs
+
+	sino, ok := vfs.inos[ino]
+	if !ok || fl.saved[sino] {
+		return nil
+	}
+
+	path := dn.path
+
+	info, err := vfs.adminFS.Lstat(path)
+	if err != nil || inoOf(info) != ino {
+		if path, err = fl.find(ino); err != nil || path == "" {
+			return err
+		}
+
+		if info, err = vfs.adminFS.Lstat(path); err != nil {
+			return err
+		}
+	}
+
+	return fl.saveNode(sino, path, info, dn.chunks)
+}
+
+// saveNode saves the inode record of the node sino of the store if it changed,
+// and for a file the chunks written since the last save.
+func (fl *flusher) saveNode(sino uint64, path string, info fs.FileInfo, chunks map[int64]struct{}) error {
+	vfs := fl.vfs
+	sn := vfs.nodes[sino]
+	sst := vfs.adminFS.ToSysStat(info)
+
+	rec := inode{
+		modTime: info.ModTime().UnixNano(),
+		size:    info.Size(),
+		uid:     sst.Uid(),
+		gid:     sst.Gid(),
+		mode:    info.Mode(),
+	}
+
+	var err error
+
+	switch {
+	case info.IsDir():
+		rec.size = 0
+	case info.Mode()&fs.ModeSymlink != 0:
+		rec.size = 0
+		rec.target, err = vfs.adminFS.Readlink(path)
+	case info.Mode().IsRegular():
+		err = fl.saveChunks(sino, path, sn.rec.size, rec.size, chunks)
+	default:
+		err = &fs.PathError{Op: "save", Path: path, Err: avfs.ErrOpNotPermitted}
+	}
+
+	// The record of a node saved by saveNew is always put.
+	if err != nil || (rec == sn.rec && !fl.saved[sino]) {
+		return err
+	}
+
+	sn.rec = rec
+
+	return vfs.store.Put(inodeKey(sino), rec.encode())
+}
+
+// saveChunks saves the chunks of the file path of inode number sino of the store whose size changed
+// from oldSize to newSize. The chunks written since the last save, the new chunks and the chunks
+// whose length changed are put, the chunks beyond the end of the file are deleted.
+func (fl *flusher) saveChunks(sino uint64, path string, oldSize, newSize int64, chunks map[int64]struct{}) error {
+	store := fl.vfs.store
+	oldCount, newCount := chunkCount(oldSize), chunkCount(newSize)
+	indexes := make([]int64, 0, len(chunks))
+
+	for index := range chunks {
+		if index < newCount && index < oldCount {
+			indexes = append(indexes, index)
+		}
+	}
+
+	if oldSize != newSize && oldCount > 0 && oldCount <= newCount {
+		if _, ok := chunks[oldCount-1]; !ok {
+			indexes = append(indexes, oldCount-1)
+		}
+	}
+
+	for index := oldCount; index < newCount; index++ {
+		indexes = append(indexes, index)
+	}
+
+	if oldSize != newSize && newCount > 0 && newCount < oldCount {
+		if _, ok := chunks[newCount-1]; !ok {
+			indexes = append(indexes, newCount-1)
+		}
+	}
+
+	for index := newCount; index < oldCount; index++ {
+		if err := store.Delete(chunkKey(sino, index)); err != nil {
+			return err
+		}
+	}
+
+	if len(indexes) == 0 {
+		return nil
+	}
+
+	f, err := fl.vfs.adminFS.Open(path)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	for _, index := range indexes {
+		off := index * chunkSize
+		buf := make([]byte, min(chunkSize, newSize-off))
+
+		if _, err = f.ReadAt(buf, off); err != nil {
+			return err
+		}
+
+		if err = store.Put(chunkKey(sino, index), buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unref removes a reference to the node sino of the store, the node is deleted by collect if it is no longer referenced.
+func (fl *flusher) unref(sino uint64) {
+	sn := fl.vfs.nodes[sino]
+
+	sn.refs--
+	if sn.refs == 0 {
+		fl.gc = append(fl.gc, sino)
+	}
+}
+
+// collect deletes the records of the nodes no longer referenced and of their descendants.
+func (fl *flusher) collect() {
+	vfs := fl.vfs
+
+	for len(fl.gc) > 0 {
+		sino := fl.gc[len(fl.gc)-1]
+		fl.gc = fl.gc[:len(fl.gc)-1]
+
+		sn, ok := vfs.nodes[sino]
+		if !ok || sn.refs > 0 {
+			continue
+		}
+
+		for name, childIno := range sn.entries {
+			_ = vfs.store.Delete(direntKey(sino, name))
+			fl.unref(childIno)
+		}
+
+		for index := range chunkCount(sn.rec.size) {
+			_ = vfs.store.Delete(chunkKey(sino, index))
+		}
+
+		_ = vfs.store.Delete(inodeKey(sino))
+
+		delete(vfs.nodes, sino)
+
+		if vfs.inos[sn.memIno] == sino {
+			delete(vfs.inos, sn.memIno)
+		}
+	}
+}
+
+// find returns a path of the node of inode number ino of the base file system,
+// or an empty string if the node is not found.
+// The tree of the file system is walked on the first call.
+func (fl *flusher) find(ino uint64) (string, error) {
+	if fl.paths != nil {
+		return fl.paths[ino], nil
+	}
+
+	fl.paths = make(map[uint64]string)
+
+	err := fl.vfs.adminFS.WalkDir(fl.vfs.rootPath(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := fl.paths[inoOf(info)]; !ok {
+			fl.paths[inoOf(info)] = path
+		}
+
+		return nil
+	})
+
+	return fl.paths[ino], err
+}
@@ -0,0 +1,399 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package kvfs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/avfs/avfs"
+)
+
+// ErrCorrupted is returned when a store or one of its records can't be decoded.
+var ErrCorrupted = errors.New("kvfs: corrupted store")
+
+const (
+	// fileStoreMagic is written at the beginning of the file of a FileStore.
+	fileStoreMagic = "avfs-kv1"
+
+	// compactMinSize is the minimal size of the file of a FileStore before it is compacted.
+	compactMinSize = 1 << 20
+
+	// opPut and opDelete are the operations of a commit in the file of a FileStore.
+	opPut    = 'p'
+	opDelete = 'd'
+)
+
+// NewFileStore returns a FileStore saved to the file path of the file system vfs.
+// The records of the store are read from the file if it exists.
+//
+// The file is made of the magic string followed by a batch for each commit.
+// A batch is made of the length of its operations, the operations putting or deleting records,
+// and the CRC-32 checksum of the operations. An incomplete batch at the end of the file,
+// left by an interrupted commit, is removed.
+// When the file is larger than twice the size of the records, it is rewritten with a single batch.
+func NewFileStore(vfs avfs.VFS, path string) (*FileStore, error) {
+	s := &FileStore{
+		vfs:     vfs,
+		records: make(map[string][]byte),
+		pending: make(map[string][]byte),
+		path:    path,
+	}
+
+	data, err := vfs.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s, nil
+		}
+
+		return nil, err
+	}
+
+	size, err := s.decode(data)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: path, Err: err}
+	}
+
+	if size < int64(len(data)) {
+		if err = vfs.Truncate(path, size); err != nil {
+			return nil, err
+		}
+	}
+
+	s.size = size
+
+	return s, nil
+}
+
+// Close closes the store without committing pending changes.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fs.ErrClosed
+	}
+
+	s.closed = true
+	s.records = nil
+	s.pending = nil
+
+	return nil
+}
+
+// Commit appends the records put and deleted since the last commit to the file of the store.
+// If the commit fails, the file is truncated to its previous size and the changes remain pending.
+func (s *FileStore) Commit() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fs.ErrClosed
+	}
+
+	if len(s.pending) == 0 {
+		return nil
+	}
+
+	flag := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+
+	var data []byte
+
+	if s.size == 0 {
+		flag |= os.O_TRUNC
+		data = append(data, fileStoreMagic...)
+	}
+
+	data = appendBatch(data, s.pending)
+
+	err := s.writeFile(s.path, flag, data)
+	if err != nil {
+		_ = s.vfs.Truncate(s.path, s.size)
+
+		return err
+	}
+
+	s.size += int64(len(data))
+
+	for key, value := range s.pending {
+		s.apply(key, value)
+	}
+
+	s.pending = make(map[string][]byte)
+
+	if s.size > compactMinSize && s.size > 2*(s.liveSize+int64(len(fileStoreMagic))) {
+		// The file is valid without compaction, it is compacted again by the next commit on error.
+		_ = s.compact()
+	}
+
+	return nil
+}
+
+// Delete removes key from the store, it does nothing if the key doesn't exist.
+func (s *FileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fs.ErrClosed
+	}
+
+	if _, ok := s.records[key]; ok {
+		s.pending[key] = nil
+	} else {
+		delete(s.pending, key)
+	}
+
+	return nil
+}
+
+// ForEach calls fn for each committed record of the store, the value must not be modified or retained by fn.
+func (s *FileStore) ForEach(fn func(key string, value []byte) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fs.ErrClosed
+	}
+
+	for key, value := range s.records {
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Put sets the value of key. The store takes ownership of value.
+func (s *FileStore) Put(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fs.ErrClosed
+	}
+
+	if value == nil {
+		value = []byte{}
+	}
+
+	if old, ok := s.records[key]; ok && bytes.Equal(old, value) {
+		delete(s.pending, key)
+
+		return nil
+	}
+
+	s.pending[key] = value
+
+	return nil
+}
+
+// apply applies to the committed records the put of value to key, or its deletion if value is nil.
+func (s *FileStore) apply(key string, value []byte) {
+	if old, ok := s.records[key]; ok {
+		s.liveSize -= int64(recordSize(key, old))
+		delete(s.records, key)
+	}
+
+	if value != nil {
+		s.records[key] = value
+		s.liveSize += int64(recordSize(key, value))
+	}
+}
+
+// compact rewrites the file of the store with a single batch containing the committed records.
+// The batch is written to a temporary file renamed to the file of the store.
+func (s *FileStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	data := appendBatch([]byte(fileStoreMagic), s.records)
+
+	err := s.writeFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, data)
+	if err == nil {
+		err = s.vfs.Rename(tmpPath, s.path)
+	}
+
+	if err != nil {
+		_ = s.vfs.Remove(tmpPath)
+
+		return err
+	}
+
+	s.size = int64(len(data))
+
+	return nil
+}
+
+// writeFile writes data to the file path opened with flag and syncs it.
+func (s *FileStore) writeFile(path string, flag int, data []byte) error {
+	f, err := s.vfs.OpenFile(path, flag, 0o600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// decode reads the records of the store from data, the content of its file (see NewFileStore).
+// It returns the size of the valid part of data, without an incomplete batch at its end.
+func (s *FileStore) decode(data []byte) (int64, error) {
+	if len(data) < len(fileStoreMagic) {
+		// The file was created by an interrupted commit.
+		if string(data) == fileStoreMagic[:len(data)] {
+			return 0, nil
+		}
+
+		return 0, ErrCorrupted
+	}
+
+	if string(data[:len(fileStoreMagic)]) != fileStoreMagic {
+		return 0, ErrCorrupted
+	}
+
+	off := len(fileStoreMagic)
+
+	for off < len(data) {
+		l, n := binary.Uvarint(data[off:])
+		if n < 0 {
+			return 0, ErrCorrupted
+		}
+
+		// An incomplete batch is the end of the file.
+		left := len(data) - off - n - crc32.Size
+		if n == 0 || left < 0 || l > uint64(left) {
+			break
+		}
+
+		start := off + n
+		end := start + int(l)
+
+		ops := data[start:end]
+		if crc32.ChecksumIEEE(ops) != binary.BigEndian.Uint32(data[end:]) {
+			return 0, ErrCorrupted
+		}
+
+		if err := s.decodeBatch(ops); err != nil {
+			return 0, err
+		}
+
+		off = end + crc32.Size
+	}
+
+	return int64(off), nil
+}
+
+// decodeBatch applies the operations of a batch to the records of the store.
+func (s *FileStore) decodeBatch(ops []byte) error {
+	for len(ops) > 0 {
+		op := ops[0]
+
+		key, n := readBytes(ops[1:])
+		if n <= 0 {
+			return ErrCorrupted
+		}
+
+		ops = ops[1+n:]
+
+		switch op {
+		case opPut:
+			value, n := readBytes(ops)
+			if n <= 0 {
+				return ErrCorrupted
+			}
+
+			ops = ops[n:]
+			s.apply(string(key), value)
+		case opDelete:
+			s.apply(string(key), nil)
+		default:
+			return ErrCorrupted
+		}
+	}
+
+	return nil
+}
+
+// appendBatch appends to data a batch of operations putting the values of records sorted by key,
+// or deleting the records whose value is nil.
+func appendBatch(data []byte, records map[string][]byte) []byte {
+	keys := make([]string, 0, len(records))
+	size := 0
+
+	for key, value := range records {
+		keys = append(keys, key)
+		size += recordSize(key, value)
+	}
+
+	sort.Strings(keys)
+
+	ops := make([]byte, 0, size)
+
+	for _, key := range keys {
+		value := records[key]
+
+		op := byte(opPut)
+		if value == nil {
+			op = opDelete
+		}
+
+		ops = append(ops, op)
+		ops = binary.AppendUvarint(ops, uint64(len(key)))
+		ops = append(ops, key...)
+
+		if value != nil {
+			ops = binary.AppendUvarint(ops, uint64(len(value)))
+			ops = append(ops, value...)
+		}
+	}
+
+	data = binary.AppendUvarint(data, uint64(len(ops)))
+	data = append(data, ops...)
+
+	return binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(ops))
+}
+
+// recordSize returns the maximal size of the operation putting value to key in a batch.
+func recordSize(key string, value []byte) int {
+	return 1 + len(key) + len(value) + 2*binary.MaxVarintLen64
+}
+
+// readBytes reads a length prefixed byte slice from buf.
+// It returns the slice and the number of bytes read, or 0 if buf is too short.
+func readBytes(buf []byte) ([]byte, int) {
+	l, n := binary.Uvarint(buf)
+	if n <= 0 || l > uint64(len(buf)-n) {
+		return nil, 0
+	}
+
+	end := n + int(l)
+
+	return buf[n:end:end], end
+}
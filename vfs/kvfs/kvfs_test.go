@@ -0,0 +1,565 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package kvfs_test
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/kvfs"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/avfs/avfs/vfs/osfs"
+)
+
+var (
+	// Tests that kvfs.KvFS struct implements avfs.VFS interface.
+	_ avfs.VFS = &kvfs.KvFS{}
+
+	// Tests that kvfs.KvFS struct implements avfs.VFSBase interface.
+	_ avfs.VFSBase = &kvfs.KvFS{}
+
+	// Tests that kvfs.KvFS struct implements avfs.FSSyncer interface.
+	_ avfs.FSSyncer = &kvfs.KvFS{}
+
+	// Tests that kvfs.KvFile struct implements avfs.File interface.
+	_ avfs.File = &kvfs.KvFile{}
+
+	// Tests that kvfs.FileStore struct implements kvfs.Store interface.
+	_ kvfs.Store = &kvfs.FileStore{}
+)
+
+// openStore opens the KvFS saved to the file path of storeFS.
+func openStore(t *testing.T, storeFS avfs.VFS, path string) *kvfs.KvFS {
+	t.Helper()
+
+	store, err := kvfs.NewFileStore(storeFS, path)
+	test.RequireNoError(t, err, "NewFileStore %s", path)
+
+	vfs, err := kvfs.OpenStore(store)
+	test.RequireNoError(t, err, "OpenStore %s", path)
+
+	return vfs
+}
+
+func TestKvFS(t *testing.T) {
+	vfs := openStore(t, memfs.New(), "/kvfs.db")
+
+	defer vfs.Close()
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestVFSAll(t)
+}
+
+func TestKvFSPersistence(t *testing.T) {
+	storePath := osfs.New().Join(t.TempDir(), "kvfs.db")
+
+	vfs, err := kvfs.Open(storePath)
+	test.RequireNoError(t, err, "Open %s", storePath)
+
+	modTime := time.Date(2020, 2, 3, 4, 5, 6, 7, time.UTC)
+	dataDir := vfs.Join(vfs.TempDir(), "data")
+	filePath := vfs.Join(dataDir, "file.txt")
+	linkPath := vfs.Join(dataDir, "link.txt")
+	symlinkPath := vfs.Join(dataDir, "symlink")
+	bigPath := vfs.Join(dataDir, "big.bin")
+	bigData := bytes.Repeat([]byte("0123456789"), 20_000)
+
+	err = vfs.Mkdir(dataDir, 0o750)
+	test.RequireNoError(t, err, "Mkdir %s", dataDir)
+
+	err = vfs.WriteFile(filePath, []byte("content"), 0o640)
+	test.RequireNoError(t, err, "WriteFile %s", filePath)
+
+	err = vfs.Chmod(filePath, 0o604)
+	test.RequireNoError(t, err, "Chmod %s", filePath)
+
+	err = vfs.Link(filePath, linkPath)
+	test.RequireNoError(t, err, "Link %s", linkPath)
+
+	err = vfs.Symlink(filePath, symlinkPath)
+	test.RequireNoError(t, err, "Symlink %s", symlinkPath)
+
+	err = vfs.WriteFile(bigPath, bigData, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", bigPath)
+
+	err = vfs.Chtimes(filePath, modTime, modTime)
+	test.RequireNoError(t, err, "Chtimes %s", filePath)
+
+	err = vfs.Chtimes(dataDir, modTime, modTime)
+	test.RequireNoError(t, err, "Chtimes %s", dataDir)
+
+	homeDir := vfs.Join(vfs.TempDir(), "..", "home")
+
+	err = vfs.RemoveAll(homeDir)
+	test.RequireNoError(t, err, "RemoveAll %s", homeDir)
+
+	err = vfs.Close()
+	test.RequireNoError(t, err, "Close")
+
+	vfs, err = kvfs.Open(storePath)
+	test.RequireNoError(t, err, "Open %s", storePath)
+
+	defer vfs.Close()
+
+	t.Run("Content", func(t *testing.T) {
+		for path, want := range map[string][]byte{filePath: []byte("content"), linkPath: []byte("content"), bigPath: bigData} {
+			got, err := vfs.ReadFile(path)
+			test.RequireNoError(t, err, "ReadFile %s", path)
+
+			if !bytes.Equal(got, want) {
+				t.Errorf("ReadFile %s : want content of %d bytes, got %d bytes", path, len(want), len(got))
+			}
+		}
+	})
+
+	t.Run("ModeAndModTime", func(t *testing.T) {
+		for path, want := range map[string]fs.FileMode{dataDir: fs.ModeDir | 0o750, filePath: 0o604} {
+			info, err := vfs.Stat(path)
+			test.RequireNoError(t, err, "Stat %s", path)
+
+			if info.Mode() != want {
+				t.Errorf("Stat %s : want mode to be %s, got %s", path, want, info.Mode())
+			}
+
+			if !info.ModTime().Equal(modTime) {
+				t.Errorf("Stat %s : want modification time to be %s, got %s", path, modTime, info.ModTime())
+			}
+		}
+	})
+
+	t.Run("Links", func(t *testing.T) {
+		fileInfo, err := vfs.Stat(filePath)
+		test.RequireNoError(t, err, "Stat %s", filePath)
+
+		linkInfo, err := vfs.Stat(linkPath)
+		test.RequireNoError(t, err, "Stat %s", linkPath)
+
+		if !vfs.SameFile(fileInfo, linkInfo) || vfs.ToSysStat(fileInfo).Nlink() != 2 {
+			t.Errorf("Stat %s : want a hard link to %s", linkPath, filePath)
+		}
+
+		target, err := vfs.Readlink(symlinkPath)
+		test.RequireNoError(t, err, "Readlink %s", symlinkPath)
+
+		if target != filePath {
+			t.Errorf("Readlink %s : want target to be %s, got %s", symlinkPath, filePath, target)
+		}
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		_, err := vfs.Stat(homeDir)
+		test.AssertPathError(t, err).OpStat().Path(homeDir).Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+}
+
+func TestKvFSSync(t *testing.T) {
+	storeFS := memfs.New()
+	path := "/kvfs.db"
+
+	vfs := openStore(t, storeFS, path)
+
+	defer vfs.Close()
+
+	filePath := vfs.Join(vfs.TempDir(), "file.txt")
+
+	f, err := vfs.Create(filePath)
+	test.RequireNoError(t, err, "Create %s", filePath)
+
+	defer f.Close()
+
+	_, err = f.Write([]byte("synced"))
+	test.RequireNoError(t, err, "Write %s", filePath)
+
+	otherFS := openStore(t, storeFS, path)
+
+	_, err = otherFS.Stat(filePath)
+	test.AssertPathError(t, err).OpStat().Path(filePath).Err(avfs.ErrNoSuchFileOrDir).Test()
+
+	err = otherFS.Close()
+	test.RequireNoError(t, err, "Close")
+
+	err = f.Sync()
+	test.RequireNoError(t, err, "Sync %s", filePath)
+
+	otherFS = openStore(t, storeFS, path)
+
+	got, err := otherFS.ReadFile(filePath)
+	test.RequireNoError(t, err, "ReadFile %s", filePath)
+
+	if string(got) != "synced" {
+		t.Errorf("ReadFile %s : want content to be %q, got %q", filePath, "synced", got)
+	}
+
+	err = otherFS.Close()
+	test.RequireNoError(t, err, "Close")
+}
+
+func TestFileStoreCorrupted(t *testing.T) {
+	storeFS := memfs.New()
+	path := "/kvfs.db"
+
+	vfs := openStore(t, storeFS, path)
+
+	err := vfs.Close()
+	test.RequireNoError(t, err, "Close")
+
+	err = vfs.Close()
+	if !errors.Is(err, fs.ErrClosed) {
+		t.Errorf("Close : want error to be %v, got %v", fs.ErrClosed, err)
+	}
+
+	data, err := storeFS.ReadFile(path)
+	test.RequireNoError(t, err, "ReadFile %s", path)
+
+	data[len(data)/2] ^= 0xff
+
+	err = storeFS.WriteFile(path, data, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	_, err = kvfs.NewFileStore(storeFS, path)
+	test.AssertPathError(t, err).Op("open").Path(path).Err(kvfs.ErrCorrupted).Test()
+}
+
+func TestFileStoreLog(t *testing.T) {
+	storeFS := memfs.New()
+	path := "/store.db"
+
+	store, err := kvfs.NewFileStore(storeFS, path)
+	test.RequireNoError(t, err, "NewFileStore %s", path)
+
+	err = store.Put("first", []byte("1"))
+	test.RequireNoError(t, err, "Put")
+
+	err = store.Commit()
+	test.RequireNoError(t, err, "Commit")
+
+	info, err := storeFS.Stat(path)
+	test.RequireNoError(t, err, "Stat %s", path)
+
+	firstSize := info.Size()
+
+	err = store.Put("second", []byte("2"))
+	test.RequireNoError(t, err, "Put")
+
+	err = store.Delete("first")
+	test.RequireNoError(t, err, "Delete")
+
+	err = store.Commit()
+	test.RequireNoError(t, err, "Commit")
+
+	err = store.Close()
+	test.RequireNoError(t, err, "Close")
+
+	records := func(t *testing.T, store *kvfs.FileStore) map[string]string {
+		t.Helper()
+
+		m := make(map[string]string)
+
+		err := store.ForEach(func(key string, value []byte) error {
+			m[key] = string(value)
+
+			return nil
+		})
+		test.RequireNoError(t, err, "ForEach")
+
+		return m
+	}
+
+	t.Run("Reopen", func(t *testing.T) {
+		store, err := kvfs.NewFileStore(storeFS, path)
+		test.RequireNoError(t, err, "NewFileStore %s", path)
+
+		defer store.Close()
+
+		if got := records(t, store); len(got) != 1 || got["second"] != "2" {
+			t.Errorf("ForEach : want only the record second, got %v", got)
+		}
+	})
+
+	t.Run("TornTail", func(t *testing.T) {
+		err := storeFS.Truncate(path, firstSize+3)
+		test.RequireNoError(t, err, "Truncate %s", path)
+
+		store, err := kvfs.NewFileStore(storeFS, path)
+		test.RequireNoError(t, err, "NewFileStore %s", path)
+
+		defer store.Close()
+
+		if got := records(t, store); len(got) != 1 || got["first"] != "1" {
+			t.Errorf("ForEach : want only the record first, got %v", got)
+		}
+
+		info, err := storeFS.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		if info.Size() != firstSize {
+			t.Errorf("NewFileStore : want the file to be truncated to %d bytes, got %d", firstSize, info.Size())
+		}
+	})
+
+	t.Run("Compaction", func(t *testing.T) {
+		store, err := kvfs.NewFileStore(storeFS, path)
+		test.RequireNoError(t, err, "NewFileStore %s", path)
+
+		value := bytes.Repeat([]byte{'v'}, 100*1024)
+
+		for i := range 50 {
+			value[0] = byte(i)
+
+			err = store.Put("value", bytes.Clone(value))
+			test.RequireNoError(t, err, "Put")
+
+			err = store.Commit()
+			test.RequireNoError(t, err, "Commit")
+		}
+
+		err = store.Close()
+		test.RequireNoError(t, err, "Close")
+
+		info, err := storeFS.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		if maxSize := int64(2 << 20); info.Size() > maxSize {
+			t.Errorf("Commit : want the file to be compacted under %d bytes, got %d", maxSize, info.Size())
+		}
+
+		store, err = kvfs.NewFileStore(storeFS, path)
+		test.RequireNoError(t, err, "NewFileStore %s", path)
+
+		defer store.Close()
+
+		if got := records(t, store); len(got) != 2 || got["value"][0] != 49 {
+			t.Errorf("ForEach : want the records first and value, got %d records", len(got))
+		}
+	})
+}
+
+// countingStore is a FileStore counting the calls to its methods.
+type countingStore struct {
+	*kvfs.FileStore
+	puts     map[string]int
+	deletes  int
+	forEachs int
+}
+
+func (s *countingStore) ForEach(fn func(key string, value []byte) error) error {
+	s.forEachs++
+
+	return s.FileStore.ForEach(fn)
+}
+
+func (s *countingStore) Put(key string, value []byte) error {
+	s.puts[key]++
+
+	return s.FileStore.Put(key, value)
+}
+
+func (s *countingStore) Delete(key string) error {
+	s.deletes++
+
+	return s.FileStore.Delete(key)
+}
+
+// reset resets the counters of the store.
+func (s *countingStore) reset() {
+	s.puts = make(map[string]int)
+	s.deletes = 0
+	s.forEachs = 0
+}
+
+// count returns the number of records of the store.
+func (s *countingStore) count(t *testing.T) int {
+	t.Helper()
+
+	n := 0
+
+	err := s.FileStore.ForEach(func(string, []byte) error {
+		n++
+
+		return nil
+	})
+	test.RequireNoError(t, err, "ForEach")
+
+	return n
+}
+
+func TestKvFSIncremental(t *testing.T) {
+	fileStore, err := kvfs.NewFileStore(memfs.New(), "/kvfs.db")
+	test.RequireNoError(t, err, "NewFileStore")
+
+	store := &countingStore{FileStore: fileStore}
+	store.reset()
+
+	vfs, err := kvfs.OpenStore(store)
+	test.RequireNoError(t, err, "OpenStore")
+
+	defer vfs.Close()
+
+	initialCount := store.count(t)
+	dir := vfs.Join(vfs.TempDir(), "dir")
+
+	err = vfs.MkdirAll(vfs.Join(dir, "a", "b"), avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "MkdirAll %s", dir)
+
+	bigPath := vfs.Join(dir, "a", "big.bin")
+	bigData := bytes.Repeat([]byte("0123456789abcdef"), 32*1024)
+
+	err = vfs.WriteFile(bigPath, bigData, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", bigPath)
+
+	err = vfs.SyncFS()
+	test.RequireNoError(t, err, "SyncFS")
+
+	t.Run("ChangedChunk", func(t *testing.T) {
+		store.reset()
+
+		f, err := vfs.OpenFile(bigPath, os.O_WRONLY, 0)
+		test.RequireNoError(t, err, "OpenFile %s", bigPath)
+
+		_, err = f.WriteAt([]byte("changed"), 200*1024)
+		test.RequireNoError(t, err, "WriteAt %s", bigPath)
+
+		err = f.Close()
+		test.RequireNoError(t, err, "Close %s", bigPath)
+
+		err = vfs.SyncFS()
+		test.RequireNoError(t, err, "SyncFS")
+
+		// The changed chunk and the inode of the file are put.
+		if len(store.puts) != 2 || store.deletes != 0 || store.forEachs != 0 {
+			t.Errorf("SyncFS : want 2 puts, 0 deletes and 0 ForEach, got %d puts (%v), %d deletes and %d ForEach",
+				len(store.puts), store.puts, store.deletes, store.forEachs)
+		}
+	})
+
+	t.Run("NoChange", func(t *testing.T) {
+		store.reset()
+
+		err = vfs.SyncFS()
+		test.RequireNoError(t, err, "SyncFS")
+
+		if len(store.puts) != 0 || store.deletes != 0 {
+			t.Errorf("SyncFS : want no puts and no deletes, got %d puts and %d deletes", len(store.puts), store.deletes)
+		}
+	})
+
+	t.Run("Truncate", func(t *testing.T) {
+		store.reset()
+
+		err = vfs.Truncate(bigPath, 100*1024)
+		test.RequireNoError(t, err, "Truncate %s", bigPath)
+
+		err = vfs.SyncFS()
+		test.RequireNoError(t, err, "SyncFS")
+
+		// The new last chunk and the inode are put, the 6 chunks beyond the end of the file are deleted.
+		if len(store.puts) != 2 || store.deletes != 6 {
+			t.Errorf("SyncFS : want 2 puts and 6 deletes, got %d puts (%v) and %d deletes", len(store.puts), store.puts, store.deletes)
+		}
+	})
+
+	t.Run("RemoveAll", func(t *testing.T) {
+		err = vfs.RemoveAll(dir)
+		test.RequireNoError(t, err, "RemoveAll %s", dir)
+
+		err = vfs.SyncFS()
+		test.RequireNoError(t, err, "SyncFS")
+
+		if got := store.count(t); got != initialCount {
+			t.Errorf("SyncFS : want %d records after the removal, got %d", initialCount, got)
+		}
+	})
+}
+
+func TestKvFSRenameAndLinks(t *testing.T) {
+	storeFS := memfs.New()
+	path := "/kvfs.db"
+
+	vfs := openStore(t, storeFS, path)
+
+	dir := vfs.Join(vfs.TempDir(), "dir")
+	filePath := vfs.Join(dir, "file.txt")
+	linkPath := vfs.Join(vfs.TempDir(), "link.txt")
+	newDir := vfs.Join(vfs.TempDir(), "newDir")
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	f, err := vfs.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "OpenFile %s", filePath)
+
+	_, err = f.Write([]byte("first"))
+	test.RequireNoError(t, err, "Write %s", filePath)
+
+	err = vfs.Link(filePath, linkPath)
+	test.RequireNoError(t, err, "Link %s", linkPath)
+
+	err = vfs.SyncFS()
+	test.RequireNoError(t, err, "SyncFS")
+
+	// The file is written after the rename of its directory.
+	err = vfs.Rename(dir, newDir)
+	test.RequireNoError(t, err, "Rename %s", dir)
+
+	_, err = f.Write([]byte(" second"))
+	test.RequireNoError(t, err, "Write %s", filePath)
+
+	err = vfs.SyncFS()
+	test.RequireNoError(t, err, "SyncFS")
+
+	// The file is written after the removal of the path it was opened with.
+	err = vfs.Remove(vfs.Join(newDir, "file.txt"))
+	test.RequireNoError(t, err, "Remove")
+
+	_, err = f.Write([]byte(" third"))
+	test.RequireNoError(t, err, "Write %s", filePath)
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close %s", filePath)
+
+	err = vfs.Close()
+	test.RequireNoError(t, err, "Close")
+
+	vfs = openStore(t, storeFS, path)
+
+	defer vfs.Close()
+
+	got, err := vfs.ReadFile(linkPath)
+	test.RequireNoError(t, err, "ReadFile %s", linkPath)
+
+	if want := "first second third"; string(got) != want {
+		t.Errorf("ReadFile %s : want content to be %q, got %q", linkPath, want, got)
+	}
+
+	entries, err := vfs.ReadDir(newDir)
+	test.RequireNoError(t, err, "ReadDir %s", newDir)
+
+	if len(entries) != 0 {
+		t.Errorf("ReadDir %s : want no entries, got %d", newDir, len(entries))
+	}
+
+	_, err = vfs.Stat(dir)
+	test.AssertPathError(t, err).OpStat().Path(dir).Err(avfs.ErrNoSuchFileOrDir).Test()
+}
@@ -0,0 +1,124 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package kvfs
+
+import (
+	"io/fs"
+	"sync"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+// KvFS implements a persistent file system whose state is kept in memory and saved to a key-value store.
+type KvFS struct {
+	baseFS          *memfs.MemFS          // baseFS is the memory file system holding the current state.
+	adminFS         *memfs.MemFS          // adminFS is a clone of baseFS used by the administrator to save the files.
+	store           Store                 // store is the key-value store where the state is saved.
+	errPermDenied   error                 // errPermDenied is the error permission denied of the file system.
+	nodes           map[uint64]*savedNode // nodes contains the nodes saved to the store where the key is the inode number of the store.
+	inos            map[uint64]uint64     // inos contains the inode numbers of the store where the key is the inode number of baseFS.
+	dirty           map[uint64]*dirtyNode // dirty contains the nodes changed since the last save where the key is the inode number of baseFS.
+	files           map[*KvFile]struct{}  // files contains the files opened for writing, their paths are updated by Rename.
+	nextIno         uint64                // nextIno is the next free inode number of the store.
+	mu              sync.RWMutex          // mu is read locked by changes of existing nodes and locked by changes of directories and saves.
+	dmu             sync.Mutex            // dmu is the Mutex used to access dirty, files and the paths of the files.
+	closed          bool                  // closed is true when the file system is closed.
+	avfs.FeaturesFn                       // FeaturesFn provides features functions to a file system or an identity manager.
+}
+
+// KvFile represents an open file descriptor.
+type KvFile struct {
+	baseFile avfs.File  // baseFile represents an open file descriptor from the base file system.
+	vfs      *KvFS      // vfs is the file system of the file.
+	path     string     // path is the current path of the file without symbolic links, if the file is opened for writing.
+	ino      uint64     // ino is the inode number of the file in the base file system, if the file is opened for writing.
+	mu       sync.Mutex // mu serializes the reads, writes and seeks to know the offset of each write.
+}
+
+// Store is the key-value store where a KvFS saves its state.
+// A KvFS calls the methods of its store from a single goroutine at a time.
+type Store interface {
+	// ForEach calls fn for each record of the store, the value must not be modified or retained by fn.
+	// It is only called when the file system is opened.
+	ForEach(fn func(key string, value []byte) error) error
+
+	// Put sets the value of key. The store takes ownership of value.
+	Put(key string, value []byte) error
+
+	// Delete removes key from the store, it does nothing if the key doesn't exist.
+	Delete(key string) error
+
+	// Commit makes the records put and deleted since the last commit durable.
+	Commit() error
+
+	// Close closes the store without committing pending changes.
+	Close() error
+}
+
+// FileStore is a Store saved to a single file of a file system.
+// The file is a log of the changes of each commit, compacted when it becomes too large.
+type FileStore struct {
+	vfs      avfs.VFS          // vfs is the file system of the store file.
+	records  map[string][]byte // records contains the committed values of the store where the key is the record key.
+	pending  map[string][]byte // pending contains the values put since the last commit, nil for a deleted record.
+	path     string            // path is the path of the store file.
+	size     int64             // size is the size of the store file.
+	liveSize int64             // liveSize is the size of the committed records once encoded.
+	mu       sync.Mutex        // mu is the Mutex used to access the records.
+	closed   bool              // closed is true when the store is closed.
+}
+
+// inode is the record of a file, a directory or a symbolic link.
+type inode struct {
+	modTime int64       // modTime is the modification time in nanoseconds since the Unix epoch.
+	size    int64       // size is the size of a file.
+	uid     int         // uid is the user id of the owner.
+	gid     int         // gid is the group id of the owner.
+	mode    fs.FileMode // mode is the type and permissions of the inode.
+	target  string      // target is the target of a symbolic link.
+}
+
+// savedNode is a node as saved to the store.
+type savedNode struct {
+	entries map[string]uint64 // entries contains the inode numbers of the store of the entries of a directory.
+	rec     inode             // rec is the inode record of the node.
+	memIno  uint64            // memIno is the inode number of the node in the base file system.
+	refs    int               // refs is the number of directory entries of the store referencing the node.
+}
+
+// dirtyNode is a node changed since the last save.
+type dirtyNode struct {
+	chunks  map[int64]struct{} // chunks contains the indexes of the chunks written since the last save.
+	path    string             // path is the last known path of the node without symbolic links.
+	entries bool               // entries is true if the entries of a directory changed.
+}
+
+// dirent is a directory entry.
+type dirent struct {
+	name string // name is the name of the entry.
+	ino  uint64 // ino is the number of the inode of the entry.
+}
+
+// snapshot is the state of a file system read from a store.
+type snapshot struct {
+	inodes  map[uint64]*inode           // inodes contains the inodes where the key is the inode number.
+	entries map[uint64][]dirent         // entries contains the entries of the directories where the key is the inode number.
+	chunks  map[uint64]map[int64][]byte // chunks contains the chunks of the files where the keys are the inode number and the chunk index.
+	root    uint64                      // root is the inode number of the root directory.
+	hasRoot bool                        // hasRoot is true if the store contains a root directory.
+}
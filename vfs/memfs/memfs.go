@@ -18,7 +18,7 @@
 //
 // it supports several features :
 //   - can emulate Linux or Windows systems regardless of the host system
-//   - checks files permissions
+//   - checks files permissions against the current user (see SetUser), the administrator bypasses the checks
 //   - supports different Identity managers
 //   - supports multiple concurrent users
 //   - supports Hard links
@@ -28,6 +28,8 @@ package memfs
 import (
 	"io/fs"
 	"os"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/avfs/avfs"
@@ -100,7 +102,9 @@ func (vfs *MemFS) Chdir(dir string) error {
 //
 // On Plan 9, the mode's permission bits, ModeAppend, ModeExclusive,
 // and ModeTemporary are used.
-func (vfs *MemFS) Chmod(name string, mode fs.FileMode) error {
+func (vfs *MemFS) Chmod(name string, mode fs.FileMode) (err error) {
+	defer func() { vfs.logOp("Chmod", name, "", err) }()
+
 	const op = "chmod"
 
 	_, child, _, err := vfs.searchNode(name, slmEval)
@@ -111,7 +115,7 @@ func (vfs *MemFS) Chmod(name string, mode fs.FileMode) error {
 	child.Lock()
 	defer child.Unlock()
 
-	if !child.setMode(mode, vfs.User()) {
+	if !child.setMode(vfs.chmodMode(mode), vfs.User()) {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.OpNotPermitted}
 	}
 
@@ -125,7 +129,9 @@ func (vfs *MemFS) Chmod(name string, mode fs.FileMode) error {
 //
 // On Windows or Plan 9, Chown always returns the syscall.EWINDOWS or
 // EPLAN9 error, wrapped in *PathError.
-func (vfs *MemFS) Chown(name string, uid, gid int) error {
+func (vfs *MemFS) Chown(name string, uid, gid int) (err error) {
+	defer func() { vfs.logOp("Chown", name, "", err) }()
+
 	const op = "chown"
 
 	if (vfs.HasFeature(avfs.FeatIdentityMgr) && !vfs.User().IsAdmin()) || vfs.OSType() == avfs.OsWindows {
@@ -150,7 +156,9 @@ func (vfs *MemFS) Chown(name string, uid, gid int) error {
 // The underlying file system may truncate or round the values to a
 // less precise time unit.
 // If there is an error, it will be of type *PathError.
-func (vfs *MemFS) Chtimes(name string, _, mtime time.Time) error {
+func (vfs *MemFS) Chtimes(name string, atime, mtime time.Time) (err error) {
+	defer func() { vfs.logOp("Chtimes", name, "", err) }()
+
 	const op = "chtimes"
 
 	_, child, _, err := vfs.searchNode(name, slmLstat)
@@ -161,7 +169,7 @@ func (vfs *MemFS) Chtimes(name string, _, mtime time.Time) error {
 	child.Lock()
 	defer child.Unlock()
 
-	if !child.setModTime(mtime, vfs.User()) {
+	if !child.setTimes(atime, mtime, vfs.User()) {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.OpNotPermitted}
 	}
 
@@ -290,13 +298,44 @@ func (vfs *MemFS) Join(elem ...string) string {
 	return avfs.Join(vfs, elem...)
 }
 
+// Lchmod changes the mode of the named file to mode.
+// If the file is a symbolic link, it changes the mode of the link itself.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) Lchmod(name string, mode fs.FileMode) (err error) {
+	defer func() { vfs.logOp("Lchmod", name, "", err) }()
+
+	const op = "lchmod"
+
+	if !vfs.HasFeature(avfs.FeatLchmod) {
+		err := &avfs.FeatureMissingError{Err: vfs.err.OpNotPermitted, Feature: avfs.FeatLchmod}
+
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	_, child, _, err := vfs.searchNode(name, slmLstat)
+	if err != vfs.err.FileExists || child == nil {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	child.Lock()
+	defer child.Unlock()
+
+	if !child.setMode(vfs.chmodMode(mode), vfs.User()) {
+		return &fs.PathError{Op: op, Path: name, Err: vfs.err.OpNotPermitted}
+	}
+
+	return nil
+}
+
 // Lchown changes the numeric uid and gid of the named file.
 // If the file is a symbolic link, it changes the uid and gid of the link itself.
 // If there is an error, it will be of type *PathError.
 //
 // On Windows, it always returns the syscall.EWINDOWS error, wrapped
 // in *PathError.
-func (vfs *MemFS) Lchown(name string, uid, gid int) error {
+func (vfs *MemFS) Lchown(name string, uid, gid int) (err error) {
+	defer func() { vfs.logOp("Lchown", name, "", err) }()
+
 	const op = "lchown"
 
 	if (vfs.HasFeature(avfs.FeatIdentityMgr) && !vfs.User().IsAdmin()) || vfs.OSType() == avfs.OsWindows {
@@ -317,7 +356,9 @@ func (vfs *MemFS) Lchown(name string, uid, gid int) error {
 
 // Link creates newname as a hard link to the oldname file.
 // If there is an error, it will be of type *LinkError.
-func (vfs *MemFS) Link(oldname, newname string) error {
+func (vfs *MemFS) Link(oldname, newname string) (err error) {
+	defer func() { vfs.logOp("Link", oldname, newname, err) }()
+
 	const op = "link"
 
 	_, oChild, _, oerr := vfs.searchNode(oldname, slmLstat)
@@ -327,7 +368,7 @@ func (vfs *MemFS) Link(oldname, newname string) error {
 
 	nParent, _, pi, nerr := vfs.searchNode(newname, slmLstat)
 	if !vfs.isNotExist(nerr) {
-		if vfs.OSType() == avfs.OsWindows {
+		if nerr == vfs.err.FileExists && vfs.OSType() == avfs.OsWindows {
 			nerr = avfs.ErrWinAlreadyExists
 		}
 
@@ -352,9 +393,10 @@ func (vfs *MemFS) Link(oldname, newname string) error {
 	}
 
 	c.mu.Lock()
-	nParent.addChild(pi.Part(), c)
+	nParent.addChild(pi.Part(), c, vfs.now())
 
 	c.nlink++
+	c.changed()
 	c.mu.Unlock()
 
 	return nil
@@ -375,9 +417,7 @@ func (vfs *MemFS) Lstat(path string) (fs.FileInfo, error) {
 		return nil, &fs.PathError{Op: op, Path: path, Err: err}
 	}
 
-	fst := child.fillStatFrom(pi.Part())
-
-	return fst, nil
+	return vfs.fileInfo(child, pi.Part()), nil
 }
 
 // Match reports whether name matches the shell file name pattern.
@@ -411,14 +451,22 @@ func (vfs *MemFS) Match(pattern, name string) (matched bool, err error) {
 // Mkdir creates a new directory with the specified name and permission
 // bits (before umask).
 // If there is an error, it will be of type *PathError.
-func (vfs *MemFS) Mkdir(name string, perm fs.FileMode) error {
+func (vfs *MemFS) Mkdir(name string, perm fs.FileMode) (err error) {
+	defer func() { vfs.logOp("Mkdir", name, "", err) }()
+
+	return vfs.mkdir(nil, name, perm)
+}
+
+// mkdir creates a new directory with the specified name relative to the directory node start,
+// or to the current directory if start is nil.
+func (vfs *MemFS) mkdir(start *dirNode, name string, perm fs.FileMode) error {
 	const op = "mkdir"
 
 	if name == "" {
 		return &fs.PathError{Op: op, Path: "", Err: vfs.err.NoSuchDir}
 	}
 
-	parent, _, pi, err := vfs.searchNode(name, slmEval)
+	parent, _, pi, err := vfs.searchNodeAt(start, name, slmEval)
 	if !vfs.isNotExist(err) || !pi.IsLast() {
 		return &fs.PathError{Op: op, Path: name, Err: err}
 	}
@@ -435,6 +483,10 @@ func (vfs *MemFS) Mkdir(name string, perm fs.FileMode) error {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.FileExists}
 	}
 
+	if !vfs.allocInode() {
+		return &fs.PathError{Op: op, Path: name, Err: vfs.err.NoSpaceLeft}
+	}
+
 	_ = vfs.createDir(parent, part, perm)
 
 	return nil
@@ -447,7 +499,9 @@ func (vfs *MemFS) Mkdir(name string, perm fs.FileMode) error {
 // directories that MkdirAll creates.
 // If name is already a directory, MkdirAll does nothing
 // and returns nil.
-func (vfs *MemFS) MkdirAll(path string, perm fs.FileMode) error {
+func (vfs *MemFS) MkdirAll(path string, perm fs.FileMode) (err error) {
+	defer func() { vfs.logOp("MkdirAll", path, "", err) }()
+
 	const op = "mkdir"
 
 	parent, child, pi, err := vfs.searchNode(path, slmEval)
@@ -462,6 +516,10 @@ func (vfs *MemFS) MkdirAll(path string, perm fs.FileMode) error {
 		return &fs.PathError{Op: op, Path: pi.LeftPart(), Err: vfs.err.NotADirectory}
 	}
 
+	if err == vfs.err.NameTooLong {
+		return &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
 	parent.mu.Lock()
 	defer parent.mu.Unlock()
 
@@ -477,6 +535,14 @@ func (vfs *MemFS) MkdirAll(path string, perm fs.FileMode) error {
 			break
 		}
 
+		if len(part) > vfs.nameMax {
+			return &fs.PathError{Op: op, Path: path, Err: vfs.err.NameTooLong}
+		}
+
+		if !vfs.allocInode() {
+			return &fs.PathError{Op: op, Path: path, Err: vfs.err.NoSpaceLeft}
+		}
+
 		dn = vfs.createDir(dn, part, perm)
 
 		if !pi.Next() {
@@ -513,12 +579,26 @@ func (vfs *MemFS) Open(name string) (avfs.File, error) {
 // methods on the returned File can be used for I/O.
 // If there is an error, it will be of type *PathError.
 func (vfs *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File, error) {
+	return vfs.openFile(nil, name, name, flag, perm)
+}
+
+// openFile opens the file name relative to the directory node start, or to the current directory if start is nil.
+// fileName is the name of the returned file.
+func (vfs *MemFS) openFile(start *dirNode, name, fileName string, flag int, perm fs.FileMode) (_ avfs.File, err error) {
 	const op = "open"
 
 	at := int64(0)
 	om := avfs.ToOpenMode(flag)
 
-	parent, child, pi, err := vfs.searchNode(name, slmEval)
+	if om&avfs.OpenWrite != 0 {
+		defer func() { vfs.logOp("OpenFile", fileName, "", err) }()
+	}
+
+	if om&avfs.OpenTmpFile != 0 {
+		return vfs.openTmpFile(start, name, fileName, flag, om, perm)
+	}
+
+	parent, child, pi, err := vfs.searchNodeAt(start, name, slmEval)
 	if err != vfs.err.FileExists && !vfs.isNotExist(err) || !pi.IsLast() {
 		return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: err}
 	}
@@ -539,15 +619,25 @@ func (vfs *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File,
 
 		child = parent.children[part]
 		if child == nil {
-			child = vfs.createFile(parent, part, perm)
+			if !vfs.allocInode() {
+				return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: vfs.err.NoSpaceLeft}
+			}
+
+			fn := vfs.createFile(parent, part, perm)
+			fn.nopen = 1
+			child = fn
+
 			f := &MemFile{
 				nd:       child,
 				vfs:      vfs,
-				name:     name,
-				at:       at,
+				name:     fileName,
+				pos:      &fileOffset{at: at},
+				flag:     flag,
 				openMode: om,
 			}
 
+			f.fd = vfs.fds.alloc(f)
+
 			return f, nil
 		}
 	}
@@ -565,6 +655,11 @@ func (vfs *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File,
 			return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: vfs.err.FileExists}
 		}
 
+		// Named pipes and sockets have no reader or writer on the other side.
+		if c.mode.Type() != 0 {
+			return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: vfs.err.NoDevOrAddr}
+		}
+
 		if om&avfs.OpenTruncate != 0 {
 			c.truncate(0)
 		}
@@ -573,6 +668,10 @@ func (vfs *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File,
 			at = c.size()
 		}
 
+		c.data.inflate()
+
+		atomic.AddInt32(&c.nopen, 1)
+
 	case *dirNode:
 		c.mu.Lock()
 		defer c.mu.Unlock()
@@ -589,11 +688,14 @@ func (vfs *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File,
 	f := &MemFile{
 		nd:       child,
 		vfs:      vfs,
-		name:     name,
-		at:       at,
+		name:     fileName,
+		pos:      &fileOffset{at: at},
+		flag:     flag,
 		openMode: om,
 	}
 
+	f.fd = vfs.fds.alloc(f)
+
 	return f, nil
 }
 
@@ -651,7 +753,9 @@ func (vfs *MemFS) Rel(basepath, targpath string) (string, error) {
 
 // Remove removes the named file or (empty) directory.
 // If there is an error, it will be of type *PathError.
-func (vfs *MemFS) Remove(name string) error {
+func (vfs *MemFS) Remove(name string) (err error) {
+	defer func() { vfs.logOp("Remove", name, "", err) }()
+
 	const op = "remove"
 
 	parent, child, pi, err := vfs.searchNode(name, slmLstat)
@@ -675,13 +779,19 @@ func (vfs *MemFS) Remove(name string) error {
 		}
 	}
 
+	if vfs.isOpenLocked(child) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrWinSharingViolation}
+	}
+
 	part := pi.Part()
 	if parent.children[part] == nil {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.NoSuchDir}
 	}
 
-	parent.removeChild(part)
-	child.delete()
+	parent.removeChild(part, vfs.now())
+	if child.delete() {
+		vfs.freeInode()
+	}
 
 	return nil
 }
@@ -691,7 +801,9 @@ func (vfs *MemFS) Remove(name string) error {
 // it encounters. If the path does not exist, RemoveAll
 // returns nil (no error).
 // If there is an error, it will be of type *PathError.
-func (vfs *MemFS) RemoveAll(path string) error {
+func (vfs *MemFS) RemoveAll(path string) (err error) {
+	defer func() { vfs.logOp("RemoveAll", path, "", err) }()
+
 	const op = "unlinkat"
 
 	if path == "" {
@@ -722,8 +834,10 @@ func (vfs *MemFS) RemoveAll(path string) error {
 		return &fs.PathError{Op: op, Path: path, Err: vfs.err.PermDenied}
 	}
 
-	parent.removeChild(pi.Part())
-	child.delete()
+	parent.removeChild(pi.Part(), vfs.now())
+	if child.delete() {
+		vfs.freeInode()
+	}
 
 	return nil
 }
@@ -744,7 +858,9 @@ func (vfs *MemFS) removeAll(parent *dirNode) error {
 			}
 		}
 
-		child.delete()
+		if child.delete() {
+			vfs.freeInode()
+		}
 	}
 
 	return nil
@@ -754,7 +870,9 @@ func (vfs *MemFS) removeAll(parent *dirNode) error {
 // If newpath already exists and is not a directory, Rename replaces it.
 // OS-specific restrictions may apply when oldpath and newpath are in different directories.
 // If there is an error, it will be of type *LinkError.
-func (vfs *MemFS) Rename(oldpath, newpath string) error {
+func (vfs *MemFS) Rename(oldpath, newpath string) (err error) {
+	defer func() { vfs.logOp("Rename", oldpath, newpath, err) }()
+
 	const op = "rename"
 
 	oParent, oChild, oPI, oErr := vfs.searchNode(oldpath, slmLstat)
@@ -787,16 +905,31 @@ func (vfs *MemFS) Rename(oldpath, newpath string) error {
 		return nil
 	}
 
+	if vfs.isOpenLocked(oChild) || vfs.isOpenLocked(nChild) {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: avfs.ErrWinSharingViolation}
+	}
+
 	switch oChild.(type) {
 	case *dirNode:
-		if !vfs.isNotExist(nErr) {
-			if vfs.OSType() == avfs.OsWindows {
-				nErr = avfs.ErrWinAccessDenied
+		if vfs.isNotExist(nErr) {
+			break
+		}
+
+		if vfs.posixRename {
+			err := vfs.replaceDir(nChild)
+			if err != nil {
+				return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
 			}
 
-			return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: nErr}
+			break
+		}
+
+		if vfs.OSType() == avfs.OsWindows {
+			nErr = avfs.ErrWinAccessDenied
 		}
 
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: nErr}
+
 	case *fileNode:
 		if nChild == nil {
 			break
@@ -804,7 +937,9 @@ func (vfs *MemFS) Rename(oldpath, newpath string) error {
 
 		switch nc := nChild.(type) {
 		case *fileNode:
-			nc.delete()
+			if nc.delete() {
+				vfs.freeInode()
+			}
 		default:
 			err := error(avfs.ErrFileExists)
 			if vfs.OSType() == avfs.OsWindows {
@@ -815,8 +950,10 @@ func (vfs *MemFS) Rename(oldpath, newpath string) error {
 		}
 	}
 
-	nParent.addChild(nPI.Part(), oChild)
-	oParent.removeChild(oPI.Part())
+	mtime := vfs.now()
+
+	nParent.addChild(nPI.Part(), oChild, mtime)
+	oParent.removeChild(oPI.Part(), mtime)
 
 	return nil
 }
@@ -827,6 +964,8 @@ func (vfs *MemFS) Rename(oldpath, newpath string) error {
 // the decision may be based on the path names.
 // SameFile only applies to results returned by this package's Stat.
 // It returns false in other cases.
+// Files are identified by the node id returned by MemInfo.Ino, which is kept by hard links and renames,
+// so fi1 and fi2 must come from the same MemFS instance (or one of its sub file systems).
 func (*MemFS) SameFile(fi1, fi2 fs.FileInfo) bool {
 	fs1, ok1 := fi1.(*MemInfo)
 	if !ok1 {
@@ -859,19 +998,23 @@ func (vfs *MemFS) Split(path string) (dir, file string) {
 // Stat returns a FileInfo describing the named file.
 // If there is an error, it will be of type *PathError.
 func (vfs *MemFS) Stat(path string) (fs.FileInfo, error) {
+	return vfs.stat(nil, path)
+}
+
+// stat returns a FileInfo describing the file path relative to the directory node start,
+// or to the current directory if start is nil.
+func (vfs *MemFS) stat(start *dirNode, path string) (fs.FileInfo, error) {
 	op := "stat"
 	if vfs.OSType() == avfs.OsWindows {
 		op = "CreateFile"
 	}
 
-	_, child, pi, err := vfs.searchNode(path, slmStat)
+	_, child, pi, err := vfs.searchNodeAt(start, path, slmStat)
 	if err != vfs.err.FileExists || child == nil {
 		return nil, &fs.PathError{Op: op, Path: path, Err: err}
 	}
 
-	fst := child.fillStatFrom(pi.Part())
-
-	return fst, nil
+	return vfs.fileInfo(child, pi.Part()), nil
 }
 
 // Sub returns an FS corresponding to the subtree rooted at dir.
@@ -896,9 +1039,19 @@ func (vfs *MemFS) Sub(dir string) (avfs.VFS, error) {
 
 // Symlink creates newname as a symbolic link to oldname.
 // If there is an error, it will be of type *LinkError.
-func (vfs *MemFS) Symlink(oldname, newname string) error {
+func (vfs *MemFS) Symlink(oldname, newname string) (err error) {
+	defer func() { vfs.logOp("Symlink", oldname, newname, err) }()
+
 	const op = "symlink"
 
+	if len(oldname) > vfs.pathMax {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: vfs.err.NameTooLong}
+	}
+
+	if strings.IndexByte(oldname, 0) >= 0 {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: avfs.ErrInvalidArgument}
+	}
+
 	parent, _, pi, nerr := vfs.searchNode(newname, slmLstat)
 	if !vfs.isNotExist(nerr) {
 		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: nerr}
@@ -911,6 +1064,10 @@ func (vfs *MemFS) Symlink(oldname, newname string) error {
 		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: vfs.err.PermDenied}
 	}
 
+	if !vfs.allocInode() {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: vfs.err.NoSpaceLeft}
+	}
+
 	link := vfs.Clean(oldname)
 
 	vfs.createSymlink(parent, pi.Part(), link)
@@ -946,7 +1103,9 @@ func (*MemFS) ToSysStat(info fs.FileInfo) avfs.SysStater {
 // Truncate changes the size of the named file.
 // If the file is a symbolic link, it changes the size of the link's target.
 // If there is an error, it will be of type *PathError.
-func (vfs *MemFS) Truncate(name string, size int64) error {
+func (vfs *MemFS) Truncate(name string, size int64) (err error) {
+	defer func() { vfs.logOp("Truncate", name, "", err) }()
+
 	op := "truncate"
 
 	_, child, _, err := vfs.searchNode(name, slmEval)
@@ -967,12 +1126,18 @@ func (vfs *MemFS) Truncate(name string, size int64) error {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.IsADirectory}
 	}
 
-	if size < 0 {
+	if size < 0 || c.mode.Type() != 0 {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.InvalidArgument}
 	}
 
 	c.mu.Lock()
 	c.truncate(size)
+	c.mtime = vfs.now()
+
+	if atomic.LoadInt32(&c.nopen) == 0 {
+		vfs.compress(&c.data)
+	}
+
 	c.mu.Unlock()
 
 	return nil
@@ -0,0 +1,97 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"io/fs"
+
+	"github.com/avfs/avfs"
+)
+
+// MkdirAt creates a new directory with the specified name relative to the directory of the file
+// and permission bits (before umask).
+// If there is an error, it will be of type *PathError.
+func (f *MemFile) MkdirAt(name string, perm fs.FileMode) (err error) {
+	dn, err := f.dirNodeAt("mkdirat", name)
+	if err != nil {
+		return err
+	}
+
+	vfs := f.vfs
+
+	defer func() { vfs.logOp("Mkdir", f.pathAt(name), "", err) }()
+
+	return vfs.mkdir(dn, name, perm)
+}
+
+// OpenFileAt opens the named file relative to the directory of the file with specified flag (O_RDONLY etc.)
+// and permission bits (before umask), like OpenFile.
+// If there is an error, it will be of type *PathError.
+func (f *MemFile) OpenFileAt(name string, flag int, perm fs.FileMode) (avfs.File, error) {
+	dn, err := f.dirNodeAt("openat", name)
+	if err != nil {
+		return &MemFile{}, err
+	}
+
+	return f.vfs.openFile(dn, name, f.pathAt(name), flag, perm)
+}
+
+// StatAt returns a FileInfo describing the named file relative to the directory of the file.
+// If there is an error, it will be of type *PathError.
+func (f *MemFile) StatAt(name string) (fs.FileInfo, error) {
+	dn, err := f.dirNodeAt("fstatat", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.vfs.stat(dn, name)
+}
+
+// dirNodeAt returns the directory node of the file, from which the names of the *At functions are searched.
+// Names are resolved relatively to the directory node itself and not to the path it was opened with.
+func (f *MemFile) dirNodeAt(op, name string) (*dirNode, error) {
+	if f == nil {
+		return nil, fs.ErrInvalid
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.name == "" || name == "" {
+		return nil, fs.ErrInvalid
+	}
+
+	if f.nd == nil {
+		return nil, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	dn, ok := f.nd.(*dirNode)
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.NotADirectory}
+	}
+
+	return dn, nil
+}
+
+// pathAt returns the name of the file name relative to the directory of the file.
+func (f *MemFile) pathAt(name string) string {
+	if f.vfs.IsAbs(name) {
+		return name
+	}
+
+	return f.vfs.Join(f.name, name)
+}
@@ -17,18 +17,36 @@
 package memfs
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"math"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/avfs/avfs"
 	"github.com/avfs/avfs/idm/memidm"
 )
 
+func init() {
+	avfs.Register("mem", func(*url.URL) (avfs.VFS, error) { return New(), nil })
+}
+
 // New returns a new memory file system (MemFS) with the default Options.
 func New() *MemFS {
 	return NewWithOptions(nil)
 }
 
 // NewWithOptions returns a new memory file system (MemFS) with the selected Options.
+// Without the avfs_setostype build tag, the OS type can't be changed and Options.OSType
+// is silently replaced by the current OS type.
 func NewWithOptions(opts *Options) *MemFS {
 	if opts == nil {
 		opts = &Options{OSType: avfs.OsUnknown}
@@ -39,31 +57,143 @@ func NewWithOptions(opts *Options) *MemFS {
 		idm = memidm.New()
 	}
 
-	features := avfs.FeatHardlink | avfs.FeatSubFS | avfs.FeatSymlink | idm.Features() | avfs.BuildFeatures()
+	features := avfs.FeatDeadline | avfs.FeatHardlink | avfs.FeatOpenat | avfs.FeatSubFS | avfs.FeatSymlink |
+		avfs.FeatTmpFile | avfs.FeatRenameFlags | avfs.FeatSpecialFiles | avfs.FeatStatFS | avfs.FeatXattr |
+		avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup | idm.Features() | avfs.BuildFeatures()
 
 	user := opts.User
 	if opts.User == nil {
 		user = idm.AdminUser()
 	}
 
+	clock := opts.Clock
+	if clock == nil {
+		clock = time.Now
+	}
+
+	blockSize := opts.BlockSize
+	if blockSize <= 0 {
+		blockSize = defaultBlockSize
+	}
+
+	nameMax := opts.NameMax
+	if nameMax <= 0 {
+		nameMax = defaultNameMax
+	}
+
+	pathMax := opts.PathMax
+	if pathMax <= 0 {
+		pathMax = defaultPathMax
+	}
+
 	vfs := &MemFS{
-		dirMode:  fs.ModeDir,
-		fileMode: 0,
-		lastId:   new(uint64),
-		name:     opts.Name,
+		dirMode:      fs.ModeDir,
+		fileMode:     0,
+		lastId:       new(uint64),
+		fds:          new(fdTable),
+		usedInodes:   new(int64),
+		maxInodes:    int64(opts.MaxInodes),
+		capacity:     opts.Capacity,
+		clock:        clock,
+		blockSize:    blockSize,
+		nameMax:      nameMax,
+		pathMax:      pathMax,
+		atimePolicy:  opts.ATimePolicy,
+		dirOrder:     opts.DirOrder,
+		readableDirs: opts.ReadableDirs,
+		posixRename:  opts.PosixRename,
+		name:         opts.Name,
+	}
+
+	if opts.RandSource != nil {
+		vfs.randSource = &lockedSource{src: opts.RandSource}
+	}
+
+	if opts.Dedup {
+		vfs.dedup = newDedupStore()
+	}
+
+	vfs.compression = opts.Compression
+
+	vfs.compressionMinSize = opts.CompressionMinSize
+	if vfs.compressionMinSize <= 0 {
+		vfs.compressionMinSize = 1024
 	}
 
 	_ = vfs.SetFeatures(features)
-	_ = vfs.SetOSType(opts.OSType)
+	if err := vfs.OSTypeFn.SetOSType(opts.OSType); err != nil {
+		// The OS type can't be changed without the avfs_setostype build tag.
+		_ = vfs.OSTypeFn.SetOSType(avfs.CurrentOSType())
+	}
 	_ = vfs.SetIdm(idm)
 	_ = vfs.SetUser(user)
 
+	vfs.lockOpenFiles = opts.ErrorOnRemoveOpen
+	vfs.setupOSType(opts.SystemDirs)
+	_ = vfs.SetUMask(avfs.UMask())
+
+	if err := vfs.createInitialFiles(opts); err != nil {
+		panic(err)
+	}
+
+	// The operations creating the system directories and the initial files are not logged.
+	if opts.OpLogSize > 0 {
+		vfs.opLog = &opLog{ops: make([]LoggedOp, opts.OpLogSize)}
+	}
+
+	return vfs
+}
+
+// ErrSetOSTypeNotEmpty is returned by SetOSType when the file system contains other files
+// than its system directories.
+var ErrSetOSTypeNotEmpty = errors.New("memfs: can't set the OS type of a non empty file system")
+
+// SetOSType sets the operating system type of the file system.
+// The errors, the path separator, the features and the system directories are derived again
+// from the new OS type, the current directory is reset.
+// Since the paths of a populated tree can't be converted safely, the file system must only contain
+// its system directories, otherwise ErrSetOSTypeNotEmpty is returned.
+// The OS type can't be changed without the avfs_setostype build tag (see avfs.ErrSetOSType).
+func (vfs *MemFS) SetOSType(osType avfs.OSType) error {
+	if osType == avfs.OsUnknown {
+		osType = avfs.CurrentOSType()
+	}
+
+	if osType == vfs.OSType() {
+		return nil
+	}
+
+	if !vfs.isEmpty() {
+		return ErrSetOSTypeNotEmpty
+	}
+
+	if err := vfs.OSTypeFn.SetOSType(osType); err != nil {
+		return err
+	}
+
+	vfs.usedInodes = new(int64)
+	vfs.setupOSType(nil)
+
+	return nil
+}
+
+// setupOSType initializes the errors, the features, the root node and the system directories
+// of the file system from its OS type. The default system directories are created if systemDirs is empty.
+func (vfs *MemFS) setupOSType(systemDirs []avfs.DirInfo) {
 	vfs.err.SetOSType(vfs.OSType())
 	vfs.rootNode = vfs.createRootNode()
+	vfs.volumes = nil
+	vfs.dirMode = fs.ModeDir
+	vfs.fileMode = 0
+	_ = vfs.SetCurDir("")
+
+	features := vfs.Features() | avfs.FeatLchmod | avfs.FeatSpecialFiles | avfs.FeatXattr
 
 	var volumeName string
 
 	if vfs.OSType() == avfs.OsWindows {
+		features &^= avfs.FeatLchmod | avfs.FeatSpecialFiles | avfs.FeatXattr
+
 		vfs.dirMode |= avfs.DefaultDirPerm
 		vfs.fileMode |= avfs.DefaultFilePerm
 
@@ -72,14 +202,248 @@ func NewWithOptions(opts *Options) *MemFS {
 		vfs.volumes[volumeName] = vfs.rootNode
 	}
 
-	if len(opts.SystemDirs) == 0 {
-		opts.SystemDirs = avfs.SystemDirs(vfs, volumeName)
+	_ = vfs.SetFeatures(features)
+
+	if len(systemDirs) == 0 {
+		systemDirs = avfs.SystemDirs(vfs, volumeName)
 	}
 
-	_ = avfs.MkSystemDirs(vfs, opts.SystemDirs)
-	_ = vfs.SetUMask(avfs.UMask())
+	vfs.systemDirs = systemDirs
+	_ = avfs.MkSystemDirs(vfs, systemDirs)
+}
 
-	return vfs
+// isEmpty returns true if the file system only contains its system directories.
+func (vfs *MemFS) isEmpty() bool {
+	if vfs.OSType() != avfs.OsWindows {
+		return vfs.hasOnlySystemDirs("", vfs.rootNode)
+	}
+
+	for volumeName, dn := range vfs.volumes {
+		if !vfs.hasOnlySystemDirs(volumeName, dn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasOnlySystemDirs returns true if the descendants of the directory dn named path
+// are system directories or their parents.
+func (vfs *MemFS) hasOnlySystemDirs(path string, dn *dirNode) bool {
+	dn.mu.RLock()
+	defer dn.mu.RUnlock()
+
+	for name, child := range dn.children {
+		childPath := path + string(vfs.PathSeparator()) + name
+
+		c, ok := child.(*dirNode)
+		if !ok || !vfs.isSystemDir(childPath) || !vfs.hasOnlySystemDirs(childPath, c) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSystemDir returns true if path is a system directory or one of its parents.
+func (vfs *MemFS) isSystemDir(path string) bool {
+	for _, dir := range vfs.systemDirs {
+		if dir.Path == path || strings.HasPrefix(dir.Path, path+string(vfs.PathSeparator())) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// createInitialFiles creates the files and directories of Options.InitialContent and Options.InitialFiles.
+// Parent directories are created as needed. A path present in both maps or a file
+// used as the parent of another path is an error.
+func (vfs *MemFS) createInitialFiles(opts *Options) error {
+	files := make(map[string]MapFile, len(opts.InitialContent)+len(opts.InitialFiles))
+
+	for path, data := range opts.InitialContent {
+		files[path] = MapFile{Data: data}
+	}
+
+	for path, mf := range opts.InitialFiles {
+		if _, ok := files[path]; ok {
+			return fmt.Errorf("memfs: initial file %s is defined twice", path)
+		}
+
+		files[path] = mf
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := vfs.createInitialFile(path, files[path]); err != nil {
+			return fmt.Errorf("memfs: initial file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// createInitialFile creates a single file or directory described by mf.
+func (vfs *MemFS) createInitialFile(path string, mf MapFile) error {
+	perm := mf.Mode.Perm()
+
+	if mf.Mode.IsDir() {
+		if perm == 0 {
+			perm = avfs.DefaultDirPerm
+		}
+
+		if err := vfs.MkdirAll(path, perm); err != nil {
+			return err
+		}
+	} else {
+		if perm == 0 {
+			perm = avfs.DefaultFilePerm
+		}
+
+		if err := vfs.MkdirAll(vfs.Dir(path), avfs.DefaultDirPerm); err != nil {
+			return err
+		}
+
+		if err := vfs.WriteFile(path, mf.Data, perm); err != nil {
+			return err
+		}
+	}
+
+	if mf.Mode != 0 {
+		if err := vfs.Chmod(path, mf.Mode&^fs.ModeType); err != nil {
+			return err
+		}
+	}
+
+	if !mf.ModTime.IsZero() {
+		return vfs.Chtimes(path, mf.ModTime, mf.ModTime)
+	}
+
+	return nil
+}
+
+// Clone returns a shallow copy of the current file system.
+// The clone shares its nodes with the original file system,
+// while its current directory and current user are copied and can be changed independently.
+func (vfs *MemFS) Clone() avfs.VFS {
+	newFs := *vfs
+
+	return &newFs
+}
+
+// DumpTo writes the tree of the file system to w for debugging purposes.
+// Each node is written on its own line, indented by its depth, with its mode,
+// uid/gid, size and name. Symbolic links are not followed, their target is written instead.
+func (vfs *MemFS) DumpTo(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	if vfs.OSType() != avfs.OsWindows {
+		dumpNode(bw, string(vfs.PathSeparator()), vfs.rootNode, 0)
+
+		return bw.Flush()
+	}
+
+	vols := vfs.VolumeList()
+	sort.Strings(vols)
+
+	for _, vol := range vols {
+		dumpNode(bw, vol+string(vfs.PathSeparator()), vfs.volumes[vol], 0)
+	}
+
+	return bw.Flush()
+}
+
+// DiskUsage returns the number of bytes allocated to the content of the files, the number of files
+// and the number of directories (including root directories) of the file system.
+// Files with multiple hard links are counted once, holes of sparse files are not counted.
+// The content shared by files with identical content is counted once (see Options.Dedup).
+func (vfs *MemFS) DiskUsage() (used, files, dirs int64) {
+	seen := make(map[*fileNode]struct{})
+	seenShared := make(map[*dedupBuf]struct{})
+
+	var walk func(dn *dirNode)
+
+	walk = func(dn *dirNode) {
+		dirs++
+
+		dn.mu.RLock()
+		children := make([]node, 0, len(dn.children))
+
+		for _, child := range dn.children {
+			children = append(children, child)
+		}
+
+		dn.mu.RUnlock()
+
+		for _, child := range children {
+			switch c := child.(type) {
+			case *dirNode:
+				walk(c)
+			case *fileNode:
+				if _, ok := seen[c]; ok {
+					continue
+				}
+
+				seen[c] = struct{}{}
+
+				c.mu.RLock()
+
+				if db := c.data.shared; db == nil {
+					used += c.data.allocated()
+				} else if _, ok := seenShared[db]; !ok {
+					seenShared[db] = struct{}{}
+					used += c.data.allocated()
+				}
+
+				c.mu.RUnlock()
+
+				files++
+			}
+		}
+	}
+
+	if vfs.OSType() != avfs.OsWindows {
+		walk(vfs.rootNode)
+
+		return used, files, dirs
+	}
+
+	for _, vol := range vfs.volumes {
+		walk(vol)
+	}
+
+	return used, files, dirs
+}
+
+// Generation returns the generation of the named file, incremented on each change of its content or its metadata
+// (access times excepted), it allows to detect a change of a file without calling Stat.
+// If the file is a symbolic link, the generation of the link's target is returned.
+func (vfs *MemFS) Generation(path string) (uint64, error) {
+	const op = "stat"
+
+	_, child, _, err := vfs.searchNode(path, slmStat)
+	if err != vfs.err.FileExists || child == nil {
+		return 0, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	return atomic.LoadUint64(&child.base().gen), nil
+}
+
+// FreeInodes returns the number of files, directories and symbolic links that can still be created.
+// If the number of nodes is not limited (see Options.MaxInodes), FreeInodes returns -1.
+func (vfs *MemFS) FreeInodes() int {
+	if vfs.maxInodes <= 0 {
+		return -1
+	}
+
+	return int(vfs.maxInodes - atomic.LoadInt64(vfs.usedInodes))
 }
 
 // Name returns the name of the fileSystem.
@@ -87,6 +451,73 @@ func (vfs *MemFS) Name() string {
 	return vfs.name
 }
 
+// NextRandom returns the random string used to generate the next name of CreateTemp and MkdirTemp.
+// The names are reproducible if Options.RandSource is set.
+func (vfs *MemFS) NextRandom() string {
+	if vfs.randSource == nil {
+		return strconv.FormatUint(uint64(rand.Uint32()), 10)
+	}
+
+	ls := vfs.randSource
+	ls.mu.Lock()
+	n := ls.src.Int63()
+	ls.mu.Unlock()
+
+	return strconv.FormatUint(uint64(uint32(n)), 10)
+}
+
+// StatFS returns the capacity and the free space of the file system (see Options.Capacity and Options.MaxInodes).
+// An unlimited capacity or number of inodes is reported as math.MaxInt64.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) StatFS(path string) (avfs.FSStat, error) {
+	const op = "statfs"
+
+	_, _, _, err := vfs.searchNode(path, slmEval)
+	if err != vfs.err.FileExists {
+		return avfs.FSStat{}, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	total := vfs.capacity
+	if total <= 0 {
+		total = math.MaxInt64
+	}
+
+	used, _, _ := vfs.DiskUsage()
+	free := max(total-used, 0)
+
+	inodes := vfs.maxInodes
+	if inodes <= 0 {
+		inodes = math.MaxInt64
+	}
+
+	freeInodes := max(inodes-atomic.LoadInt64(vfs.usedInodes), 0)
+
+	st := avfs.FSStat{
+		Total:      uint64(total),
+		Free:       uint64(free),
+		Available:  uint64(free),
+		Inodes:     uint64(inodes),
+		FreeInodes: uint64(freeInodes),
+	}
+
+	return st, nil
+}
+
+// String returns the tree of the file system for debugging purposes (see DumpTo).
+func (vfs *MemFS) String() string {
+	var buf strings.Builder
+
+	_ = vfs.DumpTo(&buf)
+
+	return buf.String()
+}
+
+// SyncFS commits all the pending writes of the file system to its storage.
+// MemFS has no storage, SyncFS does nothing and always returns nil.
+func (*MemFS) SyncFS() error {
+	return nil
+}
+
 // Type returns the type of the fileSystem or Identity manager.
 func (*MemFS) Type() string {
 	return "MemFS"
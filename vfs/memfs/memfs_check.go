@@ -0,0 +1,252 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/avfs/avfs"
+)
+
+// Check verifies the internal invariants of the file system and returns an error
+// describing the first violated invariant and the offending node, or nil if the file system is consistent.
+// It is intended as a testing and debugging aid, the file system should not be modified during the check.
+//
+// The following invariants are checked :
+//   - root directories are directories,
+//   - directory entries have valid names and non nil nodes,
+//   - the type of each node matches its mode,
+//   - a directory is referenced by a single directory entry (no cycles),
+//   - the number of hard links of a file matches the number of directory entries referencing it,
+//   - symbolic links have a non-empty target,
+//   - node ids are unique,
+//   - the number of nodes does not exceed the number of used inodes.
+func (vfs *MemFS) Check() error {
+	c := &checker{
+		vfs:   vfs,
+		dirs:  make(map[*dirNode]string),
+		files: make(map[*fileNode]*fileRefs),
+		ids:   make(map[uint64]string),
+	}
+
+	if vfs.OSType() != avfs.OsWindows {
+		if err := c.checkRoot(string(vfs.PathSeparator()), vfs.rootNode); err != nil {
+			return err
+		}
+	} else {
+		vols := vfs.VolumeList()
+		sort.Strings(vols)
+
+		for _, vol := range vols {
+			if err := c.checkRoot(vol+string(vfs.PathSeparator()), vfs.volumes[vol]); err != nil {
+				return err
+			}
+		}
+	}
+
+	files := make([]*fileRefs, 0, len(c.files))
+	for _, refs := range c.files {
+		files = append(files, refs)
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+
+	for _, refs := range files {
+		if refs.nlink != refs.count {
+			return checkError(refs.path, fmt.Sprintf("file has %d hard links but is referenced by %d directory entries",
+				refs.nlink, refs.count))
+		}
+	}
+
+	used := atomic.LoadInt64(vfs.usedInodes)
+	if c.nodes > used {
+		return checkError(string(vfs.PathSeparator()),
+			fmt.Sprintf("%d nodes found but only %d inodes are used", c.nodes, used))
+	}
+
+	return nil
+}
+
+// checker holds the state of a consistency check of a MemFS.
+type checker struct {
+	vfs   *MemFS                  // vfs is the file system to check.
+	dirs  map[*dirNode]string     // dirs are the paths of the directories already visited.
+	files map[*fileNode]*fileRefs // files are the references of the files already visited.
+	ids   map[uint64]string       // ids are the paths of the node ids already visited.
+	nodes int64                   // nodes is the number of distinct nodes visited, root directories excluded.
+}
+
+// fileRefs counts the directory entries referencing a file node.
+type fileRefs struct {
+	path  string // path is the path of the first directory entry referencing the file.
+	nlink int    // nlink is the number of hard links of the file.
+	count int    // count is the number of directory entries referencing the file.
+}
+
+// checkError returns the error of a violated invariant for the node at path.
+func checkError(path, msg string) error {
+	return fmt.Errorf("memfs check %s : %s", path, msg)
+}
+
+// checkRoot checks the root directory nd of a volume and all its descendants.
+func (c *checker) checkRoot(path string, nd node) error {
+	dn, ok := nd.(*dirNode)
+	if !ok || dn == nil {
+		return checkError(path, "root is not a directory")
+	}
+
+	if !dn.mode.IsDir() {
+		return checkError(path, "root mode "+dn.mode.String()+" is not a directory mode")
+	}
+
+	if err := c.checkId(path, &dn.baseNode); err != nil {
+		return err
+	}
+
+	c.dirs[dn] = path
+
+	return c.checkDir(path, dn)
+}
+
+// checkDir checks the entries of the directory dn located at path.
+func (c *checker) checkDir(path string, dn *dirNode) error {
+	dn.mu.RLock()
+	names := dn.dirNames()
+	sort.Strings(names)
+	nodes := make([]node, len(names))
+
+	for i, name := range names {
+		nodes[i] = dn.children[name]
+	}
+
+	dn.mu.RUnlock()
+
+	for i, name := range names {
+		childPath := c.vfs.Join(path, name)
+
+		if name == "" || name == "." || name == ".." || strings.IndexByte(name, c.vfs.PathSeparator()) >= 0 {
+			return checkError(childPath, fmt.Sprintf("invalid directory entry name %q", name))
+		}
+
+		if err := c.checkNode(childPath, nodes[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkNode checks the node nd located at path.
+func (c *checker) checkNode(path string, nd node) error {
+	switch n := nd.(type) {
+	case nil:
+		return checkError(path, "nil node")
+
+	case *dirNode:
+		if n == nil {
+			return checkError(path, "nil directory node")
+		}
+
+		if prev, ok := c.dirs[n]; ok {
+			return checkError(path, "directory is already referenced by "+prev)
+		}
+
+		c.dirs[n] = path
+		if !n.mode.IsDir() {
+			return checkError(path, "directory mode "+n.mode.String()+" is not a directory mode")
+		}
+
+		if err := c.checkId(path, &n.baseNode); err != nil {
+			return err
+		}
+
+		c.nodes++
+
+		return c.checkDir(path, n)
+
+	case *fileNode:
+		if n == nil {
+			return checkError(path, "nil file node")
+		}
+
+		if refs, ok := c.files[n]; ok {
+			refs.count++
+
+			return nil
+		}
+
+		n.mu.RLock()
+		mode, nlink := n.mode, n.nlink
+		n.mu.RUnlock()
+
+		if t := mode.Type(); t != 0 && t != fs.ModeNamedPipe && t != fs.ModeSocket {
+			return checkError(path, "file mode "+mode.String()+" is not a regular or special file mode")
+		}
+
+		if err := c.checkId(path, &n.baseNode); err != nil {
+			return err
+		}
+
+		c.files[n] = &fileRefs{path: path, nlink: nlink, count: 1}
+		c.nodes++
+
+		return nil
+
+	case *symlinkNode:
+		if n == nil {
+			return checkError(path, "nil symbolic link node")
+		}
+
+		n.mu.RLock()
+		mode, link := n.mode, n.link
+		n.mu.RUnlock()
+
+		if mode&fs.ModeType != fs.ModeSymlink {
+			return checkError(path, "symbolic link mode "+mode.String()+" is not a symbolic link mode")
+		}
+
+		if link == "" {
+			return checkError(path, "symbolic link has an empty target")
+		}
+
+		if err := c.checkId(path, &n.baseNode); err != nil {
+			return err
+		}
+
+		c.nodes++
+
+		return nil
+
+	default:
+		return checkError(path, fmt.Sprintf("invalid node type %T", nd))
+	}
+}
+
+// checkId checks that the id of the node located at path is unique.
+func (c *checker) checkId(path string, bn *baseNode) error {
+	if prev, ok := c.ids[bn.id]; ok {
+		return checkError(path, fmt.Sprintf("node id %d is already used by %s", bn.id, prev))
+	}
+
+	c.ids[bn.id] = path
+
+	return nil
+}
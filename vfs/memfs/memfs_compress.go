@@ -0,0 +1,108 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+)
+
+// compressedData is the compressed content of a file (see Options.Compression).
+type compressedData struct {
+	data []byte          // data is the compressed content.
+	algo CompressionAlgo // algo is the algorithm used to compress data.
+}
+
+// compress compresses the content of the file with the algorithm algo if its size is at least minSize.
+// Only files stored in a single extent without holes and not shared with other files are compressed,
+// the content is left uncompressed if compression doesn't reduce its size.
+func (fd *fileData) compress(algo CompressionAlgo, minSize int64) {
+	if algo == CompressionNone || fd.compressed != nil || fd.shared != nil ||
+		fd.size < minSize || len(fd.extents) != 1 {
+		return
+	}
+
+	e := &fd.extents[0]
+	if e.off != 0 || int64(len(e.data)) != fd.size {
+		return
+	}
+
+	var buf bytes.Buffer
+
+	var w io.WriteCloser
+
+	switch algo {
+	case CompressionGzip:
+		w = gzip.NewWriter(&buf)
+	case CompressionFlate:
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		return
+	}
+
+	if _, err := w.Write(e.data); err != nil {
+		return
+	}
+
+	if err := w.Close(); err != nil || int64(buf.Len()) >= fd.size {
+		return
+	}
+
+	fd.compressed = &compressedData{data: bytes.Clone(buf.Bytes()), algo: algo}
+	fd.extents = nil
+}
+
+// inflate decompresses the content of the file before it is accessed.
+// The content of an open file is never compressed.
+func (fd *fileData) inflate() {
+	cd := fd.compressed
+	if cd == nil {
+		return
+	}
+
+	// The content was compressed by fileData.compress, it can't be corrupted.
+	var r io.Reader
+
+	switch cd.algo {
+	case CompressionGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(cd.data))
+		if err != nil {
+			panic(err)
+		}
+
+		r = zr
+	default:
+		r = flate.NewReader(bytes.NewReader(cd.data))
+	}
+
+	data := make([]byte, fd.size)
+
+	if _, err := io.ReadFull(r, data); err != nil {
+		panic(err)
+	}
+
+	fd.extents = []extent{{data: data}}
+	fd.compressed = nil
+}
+
+// compress compresses the content of the file if compression is enabled (see Options.Compression).
+// The file node must be locked for writing and must not be open.
+func (vfs *MemFS) compress(fd *fileData) {
+	fd.compress(vfs.compression, vfs.compressionMinSize)
+}
@@ -0,0 +1,253 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import "sort"
+
+// fileData is the content of a file.
+// It is stored as a sorted list of non-overlapping extents, the ranges of the file
+// not covered by an extent are holes which are read as zeros without being allocated.
+type fileData struct {
+	extents    []extent        // extents are the allocated ranges of the file sorted by offset, nil if the content is compressed.
+	compressed *compressedData // compressed is the compressed content of the file, nil if the content is not compressed (see Options.Compression).
+	shared     *dedupBuf       // shared is the content shared with other files, nil if the content is not shared (see Options.Dedup).
+	size       int64           // size is the logical size of the file.
+}
+
+// extent is an allocated range of a file.
+type extent struct {
+	data []byte // data is the content of the range.
+	off  int64  // off is the offset of the range in the file.
+}
+
+// end returns the offset following the last byte of the extent.
+func (e *extent) end() int64 {
+	return e.off + int64(len(e.data))
+}
+
+// allocated returns the number of bytes allocated to store the file content.
+func (fd *fileData) allocated() int64 {
+	if fd.compressed != nil {
+		return int64(len(fd.compressed.data))
+	}
+
+	var n int64
+
+	for i := range fd.extents {
+		n += int64(len(fd.extents[i].data))
+	}
+
+	return n
+}
+
+// readAt reads len(b) bytes starting at offset off and returns the number of bytes read.
+// Holes are read as zeros, nothing is read beyond the size of the file.
+func (fd *fileData) readAt(b []byte, off int64) int {
+	if off >= fd.size {
+		return 0
+	}
+
+	n := len(b)
+	if int64(n) > fd.size-off {
+		n = int(fd.size - off)
+	}
+
+	b = b[:n]
+	end := off + int64(n)
+	pos := off
+
+	for i := fd.search(off); i < len(fd.extents) && fd.extents[i].off < end; i++ {
+		e := &fd.extents[i]
+
+		if e.off > pos {
+			clear(b[pos-off : e.off-off])
+			pos = e.off
+		}
+
+		pos += int64(copy(b[pos-off:], e.data[pos-e.off:]))
+	}
+
+	clear(b[pos-off:])
+
+	return n
+}
+
+// reset releases the content of the file.
+func (fd *fileData) reset() {
+	if fd.shared != nil {
+		fd.shared.release()
+		fd.shared = nil
+	}
+
+	fd.compressed = nil
+	fd.extents = nil
+	fd.size = 0
+}
+
+// search returns the index of the first extent ending after offset off.
+func (fd *fileData) search(off int64) int {
+	return sort.Search(len(fd.extents), func(i int) bool { return fd.extents[i].end() > off })
+}
+
+// writeAt writes b starting at offset off, extending the file if needed.
+// Extents overlapping or adjacent to the written range are merged.
+func (fd *fileData) writeAt(b []byte, off int64) {
+	if len(b) == 0 {
+		return
+	}
+
+	fd.inflate()
+	fd.unshare()
+
+	end := off + int64(len(b))
+	if end > fd.size {
+		fd.size = end
+	}
+
+	// Extents in [i, j) overlap or are adjacent to the range [off, end).
+	i := sort.Search(len(fd.extents), func(k int) bool { return fd.extents[k].end() >= off })
+	j := sort.Search(len(fd.extents), func(k int) bool { return fd.extents[k].off > end })
+
+	if i == j {
+		fd.extents = append(fd.extents, extent{})
+		copy(fd.extents[i+1:], fd.extents[i:])
+		fd.extents[i] = extent{off: off, data: append([]byte(nil), b...)}
+
+		return
+	}
+
+	start := min(off, fd.extents[i].off)
+	newEnd := max(end, fd.extents[j-1].end())
+
+	var data []byte
+
+	if first := &fd.extents[i]; first.off == start {
+		// Grow the first extent in place, this keeps sequential writes amortized.
+		data = first.data
+		oldLen := len(data)
+
+		if need := int(newEnd - start); need > oldLen {
+			data = append(data[:oldLen], make([]byte, need-oldLen)...)
+		}
+	} else {
+		data = make([]byte, newEnd-start)
+		copy(data[first.off-start:], first.data)
+	}
+
+	for k := i + 1; k < j; k++ {
+		e := &fd.extents[k]
+		copy(data[e.off-start:], e.data)
+	}
+
+	copy(data[off-start:], b)
+
+	fd.extents[i] = extent{off: start, data: data}
+	fd.extents = append(fd.extents[:i+1], fd.extents[j:]...)
+}
+
+// allocate allocates the holes of the range [off, off+length) of the file, allocated ranges are left unchanged.
+// If keepSize is false, the file is extended if the range ends after the end of the file,
+// otherwise the range is limited to the size of the file.
+func (fd *fileData) allocate(off, length int64, keepSize bool) {
+	fd.inflate()
+
+	end := off + length
+
+	if keepSize {
+		end = min(end, fd.size)
+	} else if end > fd.size {
+		fd.size = end
+	}
+
+	var holes []extent
+
+	pos := off
+	for i := fd.search(off); i < len(fd.extents) && pos < end; i++ {
+		e := &fd.extents[i]
+		if e.off > pos {
+			holes = append(holes, extent{off: pos, data: make([]byte, min(e.off, end)-pos)})
+		}
+
+		pos = e.end()
+	}
+
+	if pos < end {
+		holes = append(holes, extent{off: pos, data: make([]byte, end-pos)})
+	}
+
+	for _, h := range holes {
+		fd.writeAt(h.data, h.off)
+	}
+}
+
+// punchHole deallocates the range [off, off+length) of the file, the size of the file is unchanged.
+func (fd *fileData) punchHole(off, length int64) {
+	end := min(off+length, fd.size)
+	if off >= end {
+		return
+	}
+
+	fd.inflate()
+	fd.unshare()
+
+	extents := make([]extent, 0, len(fd.extents)+1)
+
+	for _, e := range fd.extents {
+		if e.end() <= off || e.off >= end {
+			extents = append(extents, e)
+
+			continue
+		}
+
+		if e.off < off {
+			extents = append(extents, extent{off: e.off, data: e.data[: off-e.off : off-e.off]})
+		}
+
+		if e.end() > end {
+			extents = append(extents, extent{off: end, data: append([]byte(nil), e.data[end-e.off:]...)})
+		}
+	}
+
+	fd.extents = extents
+}
+
+// truncate changes the size of the file.
+// Extending the file creates a hole, shrinking it releases the extents beyond the new size.
+func (fd *fileData) truncate(size int64) {
+	if size == 0 {
+		fd.reset()
+
+		return
+	}
+
+	fd.inflate()
+
+	if size < fd.size {
+		fd.unshare()
+
+		i := fd.search(size)
+		if i < len(fd.extents) && fd.extents[i].off < size {
+			e := &fd.extents[i]
+			e.data = e.data[:size-e.off]
+			i++
+		}
+
+		fd.extents = fd.extents[:i]
+	}
+
+	fd.size = size
+}
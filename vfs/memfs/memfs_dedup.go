@@ -0,0 +1,102 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sync"
+)
+
+// dedupStore stores the content shared by files with identical content (see Options.Dedup).
+type dedupStore struct {
+	bufs map[[sha256.Size]byte]*dedupBuf // bufs are the shared contents indexed by their SHA-256 checksum.
+	mu   sync.Mutex                      // mu is the mutex used to access bufs and the reference counters.
+}
+
+// dedupBuf is a content shared by files.
+type dedupBuf struct {
+	store *dedupStore       // store is the store of the shared content.
+	data  []byte            // data is the shared content, it is never modified.
+	sum   [sha256.Size]byte // sum is the SHA-256 checksum of data.
+	refs  int               // refs is the number of files sharing data.
+}
+
+// newDedupStore returns a new empty dedupStore.
+func newDedupStore() *dedupStore {
+	return &dedupStore{bufs: make(map[[sha256.Size]byte]*dedupBuf)}
+}
+
+// share replaces the content of the file by the content of another file with the same content,
+// or stores it to be shared with the next files with the same content.
+// Only files stored in a single extent without holes are shared.
+func (ds *dedupStore) share(fd *fileData) {
+	if fd.shared != nil || len(fd.extents) != 1 {
+		return
+	}
+
+	e := &fd.extents[0]
+	if e.off != 0 || int64(len(e.data)) != fd.size {
+		return
+	}
+
+	sum := sha256.Sum256(e.data)
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	db, ok := ds.bufs[sum]
+	if !ok {
+		db = &dedupBuf{store: ds, data: e.data[:len(e.data):len(e.data)], sum: sum}
+		ds.bufs[sum] = db
+	} else if !bytes.Equal(db.data, e.data) {
+		return
+	}
+
+	db.refs++
+	e.data = db.data
+	fd.shared = db
+}
+
+// release decrements the number of files sharing the content,
+// the content is removed from the store when it is not shared anymore.
+func (db *dedupBuf) release() {
+	ds := db.store
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	db.refs--
+	if db.refs == 0 {
+		delete(ds.bufs, db.sum)
+	}
+}
+
+// unshare copies the shared content of the file before it is modified.
+func (fd *fileData) unshare() {
+	if fd.shared == nil {
+		return
+	}
+
+	for i := range fd.extents {
+		e := &fd.extents[i]
+		e.data = append([]byte(nil), e.data...)
+	}
+
+	fd.shared.release()
+	fd.shared = nil
+}
@@ -0,0 +1,292 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"bytes"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/avfs/avfs"
+)
+
+// diffEntry is an entry of a tree compared by Diff.
+type diffEntry struct {
+	path string      // path is the absolute path of the entry.
+	info fs.FileInfo // info is the file information returned by Lstat.
+}
+
+// Diff returns the changes needed to turn the tree rooted at root in the file system a
+// into the tree rooted at root in the file system b.
+// Deletions come first, a deleted directory is deleted with its content, then creations and updates
+// come from the root down, so that the changes can be applied in order by ApplyChanges.
+// The types, the contents of files, the targets of symbolic links, the permissions and
+// the modification times of files and symbolic links are compared, the owners are not.
+func Diff(a, b *MemFS, root string) ([]Change, error) {
+	aEntries, err := diffEntries(a, root)
+	if err != nil {
+		return nil, err
+	}
+
+	bEntries, err := diffEntries(b, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var deletes, changes []Change
+
+	replaced := make(map[string]bool)
+
+	for rel, ae := range aEntries {
+		be, ok := bEntries[rel]
+		if ok {
+			same, err := sameType(a, ae, b, be)
+			if err != nil {
+				return nil, err
+			}
+
+			if same {
+				continue
+			}
+		}
+
+		replaced[rel] = true
+	}
+
+	// The descendants of a replaced directory are also replaced, only the directory is deleted.
+	for rel := range replaced {
+		if rel == "." || !replaced[path.Dir(rel)] {
+			deletes = append(deletes, Change{Op: ChangeDelete, Path: aEntries[rel].path})
+		}
+	}
+
+	sort.Slice(deletes, func(i, j int) bool { return deletes[i].Path > deletes[j].Path })
+
+	rels := make([]string, 0, len(bEntries))
+	for rel := range bEntries {
+		rels = append(rels, rel)
+	}
+
+	sort.Strings(rels)
+
+	for _, rel := range rels {
+		ae, ok := aEntries[rel]
+		if replaced[rel] {
+			ok = false
+		}
+
+		c, changed, err := diffChange(a, ae, b, bEntries[rel], ok)
+		if err != nil {
+			return nil, err
+		}
+
+		if changed {
+			changes = append(changes, c)
+		}
+	}
+
+	return append(deletes, changes...), nil
+}
+
+// diffEntries returns the entries of the tree rooted at root indexed by their slash separated relative path.
+func diffEntries(vfs *MemFS, root string) (map[string]diffEntry, error) {
+	entries := make(map[string]diffEntry)
+
+	err := vfs.WalkDir(root, func(name string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := vfs.Rel(root, name)
+		if err != nil {
+			return err
+		}
+
+		info, err := vfs.Lstat(name)
+		if err != nil {
+			return err
+		}
+
+		entries[vfs.ToSlash(rel)] = diffEntry{path: name, info: info}
+
+		return nil
+	})
+
+	return entries, err
+}
+
+// sameType returns true if the entries ae of a and be of b have the same type
+// and, for symbolic links, the same target.
+func sameType(a *MemFS, ae diffEntry, b *MemFS, be diffEntry) (bool, error) {
+	mode := ae.info.Mode()
+	if mode.Type() != be.info.Mode().Type() {
+		return false, nil
+	}
+
+	if mode&fs.ModeSymlink == 0 {
+		return true, nil
+	}
+
+	aLink, err := a.Readlink(ae.path)
+	if err != nil {
+		return false, err
+	}
+
+	bLink, err := b.Readlink(be.path)
+	if err != nil {
+		return false, err
+	}
+
+	return aLink == bLink, nil
+}
+
+// diffChange returns the change needed to turn the entry ae of a into the entry be of b
+// and true if there is a change. If exists is false, ae is ignored and be is created.
+func diffChange(a *MemFS, ae diffEntry, b *MemFS, be diffEntry, exists bool) (Change, bool, error) {
+	mode := be.info.Mode()
+	c := Change{Op: ChangeCreate, Path: be.path, Mode: mode}
+
+	if !mode.IsDir() {
+		c.ModTime = be.info.ModTime()
+	}
+
+	changed := !exists
+
+	switch {
+	case mode&fs.ModeSymlink != 0:
+		link, err := b.Readlink(be.path)
+		if err != nil {
+			return c, false, err
+		}
+
+		c.Link = link
+	case mode.IsRegular():
+		data, err := b.ReadFile(be.path)
+		if err != nil {
+			return c, false, err
+		}
+
+		c.Data = data
+
+		if exists {
+			aData, err := a.ReadFile(ae.path)
+			if err != nil {
+				return c, false, err
+			}
+
+			changed = !bytes.Equal(aData, data)
+		}
+	}
+
+	if !exists {
+		return c, true, nil
+	}
+
+	c.Op = ChangeUpdate
+	changed = changed || ae.info.Mode() != mode || !c.ModTime.IsZero() && !ae.info.ModTime().Equal(c.ModTime)
+
+	return c, changed, nil
+}
+
+// ApplyChanges applies in order the changes returned by Diff to the file system dst.
+// The modes of directories are applied last, so that the content of read only directories can be created.
+// If there is an error, it will be of type *PathError or *LinkError.
+func ApplyChanges(dst *MemFS, changes []Change) error {
+	const op = "applychanges"
+
+	var dirs []Change
+
+	for _, c := range changes {
+		var err error
+
+		switch c.Op {
+		case ChangeDelete:
+			err = dst.RemoveAll(c.Path)
+		case ChangeCreate, ChangeUpdate:
+			if c.Mode.IsDir() {
+				if c.Op == ChangeCreate {
+					err = dst.Mkdir(c.Path, avfs.DefaultDirPerm)
+				}
+
+				dirs = append(dirs, c)
+
+				break
+			}
+
+			err = applyChange(dst, c)
+		default:
+			err = &fs.PathError{Op: op, Path: c.Path, Err: dst.err.InvalidArgument}
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		c := dirs[i]
+		if err := dst.Chmod(c.Path, c.Mode&avfs.FileModeMask); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyChange creates or updates a file, a symbolic link or a special file.
+func applyChange(dst *MemFS, c Change) error {
+	typ := c.Mode.Type()
+
+	switch {
+	case c.Op == ChangeUpdate && typ == 0:
+		// Make sure the file can be written before its mode is set.
+		if err := dst.Chmod(c.Path, avfs.DefaultFilePerm); err != nil {
+			return err
+		}
+
+		if err := dst.WriteFile(c.Path, c.Data, 0); err != nil {
+			return err
+		}
+	case c.Op == ChangeUpdate:
+	case typ == 0:
+		if err := dst.WriteFile(c.Path, c.Data, avfs.DefaultFilePerm); err != nil {
+			return err
+		}
+	case typ == fs.ModeSymlink:
+		if err := dst.Symlink(c.Link, c.Path); err != nil {
+			return err
+		}
+	default:
+		if err := dst.Mknod(c.Path, c.Mode); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case typ != fs.ModeSymlink:
+		if err := dst.Chmod(c.Path, c.Mode&avfs.FileModeMask); err != nil {
+			return err
+		}
+	case dst.HasFeature(avfs.FeatLchmod):
+		if err := dst.Lchmod(c.Path, c.Mode&avfs.FileModeMask); err != nil {
+			return err
+		}
+	}
+
+	return dst.Chtimes(c.Path, c.ModTime, c.ModTime)
+}
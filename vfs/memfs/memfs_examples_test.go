@@ -46,7 +46,7 @@ func ExampleNewWithOptions() {
 	}
 
 	// Output:
-	// Features(Hardlink|IdentityMgr|SetOSType|SubFS|Symlink)
+	// Features(Hardlink|IdentityMgr|SetOSType|SubFS|Symlink|Xattr|Deadline|Openat|TmpFile|RenameFlags|SpecialFiles|StatFS|Lchmod|SyncFS|Dup)
 	// root
 	// /tmp
 	// /root
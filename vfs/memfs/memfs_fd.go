@@ -0,0 +1,89 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"io/fs"
+	"strconv"
+
+	"github.com/avfs/avfs"
+)
+
+// FileFromFd returns the open file of the file system with the file descriptor fd (see MemFile.Fd).
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) FileFromFd(fd uintptr) (avfs.File, error) {
+	const op = "fd"
+
+	f := vfs.fds.get(fd)
+	if f == nil {
+		return nil, &fs.PathError{Op: op, Path: strconv.FormatUint(uint64(fd), 10), Err: vfs.err.BadFileDesc}
+	}
+
+	return f, nil
+}
+
+// alloc assigns the lowest free file descriptor to the open file f and returns it.
+func (ft *fdTable) alloc(f *MemFile) uintptr {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	for i, of := range ft.files {
+		if of == nil {
+			ft.files[i] = f
+
+			return uintptr(i + fdFirst)
+		}
+	}
+
+	ft.files = append(ft.files, f)
+
+	return uintptr(len(ft.files) - 1 + fdFirst)
+}
+
+// free releases the file descriptor fd.
+func (ft *fdTable) free(fd uintptr) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	i := int(fd) - fdFirst
+	if i < 0 || i >= len(ft.files) {
+		return
+	}
+
+	ft.files[i] = nil
+
+	// Shrink the table when the highest file descriptors are freed.
+	n := len(ft.files)
+	for n > 0 && ft.files[n-1] == nil {
+		n--
+	}
+
+	ft.files = ft.files[:n]
+}
+
+// get returns the open file with the file descriptor fd or nil if there is none.
+func (ft *fdTable) get(fd uintptr) *MemFile {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	i := int(fd) - fdFirst
+	if i < 0 || i >= len(ft.files) {
+		return nil
+	}
+
+	return ft.files[i]
+}
@@ -19,11 +19,25 @@ package memfs
 import (
 	"io"
 	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/avfs/avfs"
 )
 
+// CanRead returns true if the file was opened for reading.
+func (f *MemFile) CanRead() bool {
+	return f.openMode&avfs.OpenRead != 0
+}
+
+// CanWrite returns true if the file was opened for writing.
+func (f *MemFile) CanWrite() bool {
+	return f.openMode&avfs.OpenWrite != 0
+}
+
 // Chdir changes the current working directory to the file,
 // which must be a directory.
 // If there is an error, it will be of type *PathError.
@@ -85,7 +99,7 @@ func (f *MemFile) Chmod(mode fs.FileMode) error {
 	nd.Lock()
 	defer nd.Unlock()
 
-	if !nd.setMode(mode, f.vfs.User()) {
+	if !nd.setMode(f.vfs.chmodMode(mode), f.vfs.User()) {
 		return &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.PermDenied}
 	}
 
@@ -154,18 +168,162 @@ func (f *MemFile) Close() error {
 		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
 	}
 
+	if fn, ok := f.nd.(*fileNode); ok {
+		atomic.AddInt32(&fn.nopen, -1)
+
+		if f.tmpFile {
+			// The unnamed temporary file was never linked, its storage is released.
+			fn.mu.Lock()
+			fn.data.reset()
+			fn.mu.Unlock()
+
+			f.vfs.freeInode()
+		} else if f.vfs.dedup != nil || f.vfs.compression != CompressionNone {
+			fn.mu.Lock()
+
+			if f.vfs.dedup != nil && f.openMode&avfs.OpenWrite != 0 {
+				f.vfs.dedup.share(&fn.data)
+			}
+
+			if atomic.LoadInt32(&fn.nopen) == 0 {
+				f.vfs.compress(&fn.data)
+			}
+
+			fn.mu.Unlock()
+		}
+	}
+
+	f.vfs.fds.free(f.fd)
+
 	f.dirEntries = nil
 	f.dirNames = nil
+	f.iterNames = nil
 	f.nd = nil
+	f.fd = ^(uintptr(0))
+
+	return nil
+}
+
+// Dup returns a new file sharing the node and the offset of the file, like the dup system call.
+// A Read, Write or Seek on one of the files moves the offset of the other.
+// Closing one of the files doesn't close the other, each file must be closed.
+// An unnamed temporary file not linked yet can't be duplicated.
+// If there is an error, it will be of type *PathError.
+func (f *MemFile) Dup() (avfs.File, error) {
+	const op = "dup"
+
+	if f == nil {
+		return nil, fs.ErrInvalid
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.name == "" {
+		return nil, fs.ErrInvalid
+	}
+
+	if f.nd == nil {
+		return nil, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	if f.tmpFile {
+		return nil, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.InvalidArgument}
+	}
+
+	if fn, ok := f.nd.(*fileNode); ok {
+		atomic.AddInt32(&fn.nopen, 1)
+	}
+
+	df := &MemFile{
+		nd:       f.nd,
+		vfs:      f.vfs,
+		name:     f.name,
+		pos:      f.pos,
+		flag:     f.flag,
+		openMode: f.openMode,
+	}
+
+	df.fd = f.vfs.fds.alloc(df)
+
+	return df, nil
+}
+
+// Fallocate manipulates the allocated space of the file like the Linux fallocate system call.
+// If mode is 0 or FALLOC_FL_KEEP_SIZE, the holes of the range [off, off+length) are allocated,
+// with FALLOC_FL_KEEP_SIZE the range is limited to the size of the file, otherwise the file is extended if needed.
+// If mode contains FALLOC_FL_PUNCH_HOLE, the range is deallocated and read as zeros,
+// the size of the file is never changed.
+// If there is an error, it will be of type *PathError.
+func (f *MemFile) Fallocate(mode int, off, length int64) error {
+	const op = "fallocate"
+
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.name == "" {
+		return fs.ErrInvalid
+	}
+
+	if f.nd == nil {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	if off < 0 || length <= 0 || mode&^(avfs.FALLOC_FL_KEEP_SIZE|avfs.FALLOC_FL_PUNCH_HOLE) != 0 {
+		return &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.InvalidArgument}
+	}
+
+	nd, ok := f.nd.(*fileNode)
+	if !ok {
+		return &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.IsADirectory}
+	}
+
+	if f.openMode&avfs.OpenWrite == 0 {
+		return &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.BadFileDesc}
+	}
+
+	nd.mu.Lock()
+
+	if mode&avfs.FALLOC_FL_PUNCH_HOLE != 0 {
+		nd.data.punchHole(off, length)
+	} else {
+		nd.data.allocate(off, length, mode&avfs.FALLOC_FL_KEEP_SIZE != 0)
+	}
+
+	nd.mtime = f.vfs.now()
+	nd.changed()
+
+	nd.mu.Unlock()
 
 	return nil
 }
 
 // Fd returns the integer Unix file descriptor referencing the open file.
 // The file descriptor is valid only until f.Close is called or f is garbage collected.
-// On Unix systems this will cause the SetDeadline methods to stop working.
+// MemFS assigns the lowest free file descriptor to each open file (see MemFS.FileFromFd),
+// ^uintptr(0) is returned for a closed file.
 func (f *MemFile) Fd() uintptr {
-	return ^(uintptr(0))
+	if f == nil {
+		return ^(uintptr(0))
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.nd == nil {
+		return ^(uintptr(0))
+	}
+
+	return f.fd
+}
+
+// Flag returns the flags (os.O_RDONLY, os.O_RDWR, os.O_CREATE etc.) the file was opened with.
+func (f *MemFile) Flag() int {
+	return f.flag
 }
 
 // Name returns the link of the file as presented to Open.
@@ -202,8 +360,16 @@ func (f *MemFile) Read(b []byte) (n int, err error) {
 		return 0, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
 	}
 
+	if deadlineExceeded(f.readDeadline) {
+		return 0, &fs.PathError{Op: op, Path: f.name, Err: os.ErrDeadlineExceeded}
+	}
+
 	nd, ok := f.nd.(*fileNode)
 	if !ok {
+		if dn, isDir := f.nd.(*dirNode); isDir && f.vfs.readableDirs {
+			return f.readDirData(dn, b)
+		}
+
 		err = avfs.ErrIsADirectory
 		if f.vfs.OSType() == avfs.OsWindows {
 			err = avfs.ErrWinIncorrectFunc
@@ -216,11 +382,19 @@ func (f *MemFile) Read(b []byte) (n int, err error) {
 		return 0, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.BadFileDesc}
 	}
 
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	f.pos.mu.Lock()
+	defer f.pos.mu.Unlock()
+
 	nd.mu.RLock()
-	n = copy(b, nd.data[f.at:])
+	n = nd.data.readAt(b, f.pos.at)
+	f.vfs.accessed(&nd.baseNode)
 	nd.mu.RUnlock()
 
-	f.at += int64(n)
+	f.pos.at += int64(n)
 
 	if n == 0 {
 		return 0, io.EOF
@@ -229,10 +403,41 @@ func (f *MemFile) Read(b []byte) (n int, err error) {
 	return n, nil
 }
 
+// readDirData reads from the content synthesized for the directory dn (see Options.ReadableDirs).
+// The content is computed on the first read and stays the same for the lifetime of the file.
+func (f *MemFile) readDirData(dn *dirNode, b []byte) (n int, err error) {
+	if f.dirData == nil {
+		dn.mu.RLock()
+		names := dn.dirNames()
+		dn.mu.RUnlock()
+
+		sort.Strings(names)
+		f.dirData = []byte(strings.Join(names, "\n"))
+	}
+
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	f.pos.mu.Lock()
+	defer f.pos.mu.Unlock()
+
+	if f.pos.at >= int64(len(f.dirData)) {
+		return 0, io.EOF
+	}
+
+	n = copy(b, f.dirData[f.pos.at:])
+	f.pos.at += int64(n)
+
+	return n, nil
+}
+
 // ReadAt reads len(b) bytes from the File starting at byte offset off.
 // It returns the number of bytes read and the error, if any.
 // ReadAt always returns a non-nil error when n < len(b).
 // At end of file, that error is io.EOF.
+// ReadAt does not use the file offset, it is safe to call it concurrently
+// with ReadAt and WriteAt on the same file.
 func (f *MemFile) ReadAt(b []byte, off int64) (n int, err error) {
 	const op = "read"
 
@@ -251,6 +456,10 @@ func (f *MemFile) ReadAt(b []byte, off int64) (n int, err error) {
 		return 0, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
 	}
 
+	if deadlineExceeded(f.readDeadline) {
+		return 0, &fs.PathError{Op: op, Path: f.name, Err: os.ErrDeadlineExceeded}
+	}
+
 	nd, ok := f.nd.(*fileNode)
 	if !ok {
 		err = avfs.ErrIsADirectory
@@ -272,11 +481,8 @@ func (f *MemFile) ReadAt(b []byte, off int64) (n int, err error) {
 	nd.mu.RLock()
 	defer nd.mu.RUnlock()
 
-	if int(off) > len(nd.data) {
-		return 0, io.EOF
-	}
-
-	n = copy(b, nd.data[off:])
+	n = nd.data.readAt(b, off)
+	f.vfs.accessed(&nd.baseNode)
 	if n < len(b) {
 		return n, io.EOF
 	}
@@ -295,6 +501,18 @@ func (f *MemFile) ReadAt(b []byte, off int64) (n int, err error) {
 // If n <= 0, ReadDir returns all the DirEntry records remaining in the directory.
 // When it succeeds, it returns a nil error (not io.EOF).
 func (f *MemFile) ReadDir(n int) (entries []fs.DirEntry, err error) {
+	return f.readDir(n, false)
+}
+
+// ReadDirUnsorted is like ReadDir, but the entries are returned in the nondeterministic order
+// of the directory map, whatever the order defined by Options.DirOrder (see OrderUnsorted).
+func (f *MemFile) ReadDirUnsorted(n int) (entries []fs.DirEntry, err error) {
+	return f.readDir(n, true)
+}
+
+// readDir reads the contents of the directory associated with the file f,
+// in the order defined by Options.DirOrder or unsorted.
+func (f *MemFile) readDir(n int, unsorted bool) (entries []fs.DirEntry, err error) {
 	if f == nil {
 		return nil, fs.ErrInvalid
 	}
@@ -325,20 +543,20 @@ func (f *MemFile) ReadDir(n int) (entries []fs.DirEntry, err error) {
 		return nil, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.NotADirectory}
 	}
 
-	if n <= 0 || f.dirEntries == nil {
+	if f.dirEntries == nil {
 		nd.mu.RLock()
-		entries = nd.dirEntries()
+		f.dirEntries = nd.dirEntries(f.vfs.orderedNames(nd, f.dirOrder(unsorted)), f.vfs.blockSize)
+		f.vfs.accessed(&nd.baseNode)
 		nd.mu.RUnlock()
 
 		f.dirIndex = 0
+	}
 
-		if n <= 0 {
-			f.dirEntries = nil
-
-			return entries, nil
-		}
+	if n <= 0 {
+		entries = f.dirEntries[f.dirIndex:]
+		f.dirIndex = len(f.dirEntries)
 
-		f.dirEntries = entries
+		return entries, nil
 	}
 
 	start := f.dirIndex
@@ -359,6 +577,84 @@ func (f *MemFile) ReadDir(n int) (entries []fs.DirEntry, err error) {
 	return f.dirEntries[start:end], nil
 }
 
+// dirOrder returns the order of the directory entries, OrderUnsorted if unsorted is true.
+func (f *MemFile) dirOrder(unsorted bool) DirOrder {
+	if unsorted {
+		return OrderUnsorted
+	}
+
+	return f.vfs.dirOrder
+}
+
+// NextDirEntry returns the next DirEntry of the directory associated with the file f
+// without allocating the whole list of entries like ReadDir(-1) does.
+// The names of the directory are taken on the first call, the read lock of the directory
+// is only held while looking up each entry and entries removed since then are skipped.
+// At the end of the directory, the error is io.EOF.
+func (f *MemFile) NextDirEntry() (fs.DirEntry, error) {
+	if f == nil {
+		return nil, fs.ErrInvalid
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.name == "" {
+		return nil, fs.ErrInvalid
+	}
+
+	op := "readdirent"
+	if f.vfs.OSType() == avfs.OsWindows {
+		op = "readdir"
+	}
+
+	if f.nd == nil {
+		var err error = avfs.ErrFileClosing
+		if f.vfs.OSType() == avfs.OsWindows {
+			err = avfs.ErrWinInvalidHandle
+		}
+
+		return nil, &fs.PathError{Op: op, Path: f.name, Err: err}
+	}
+
+	nd, ok := f.nd.(*dirNode)
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.NotADirectory}
+	}
+
+	if f.iterNames == nil {
+		nd.mu.RLock()
+		f.iterNames = f.vfs.orderedNames(nd, f.vfs.dirOrder)
+		f.vfs.accessed(&nd.baseNode)
+		nd.mu.RUnlock()
+
+		f.iterIndex = 0
+	}
+
+	for f.iterIndex < len(f.iterNames) {
+		name := f.iterNames[f.iterIndex]
+		f.iterIndex++
+
+		nd.mu.RLock()
+		child, ok := nd.children[name]
+		nd.mu.RUnlock()
+
+		if !ok {
+			continue
+		}
+
+		info := child.fillStatFrom(name)
+		info.blksize = f.vfs.blockSize
+
+		return info, nil
+	}
+
+	f.iterIndex = 0
+	f.iterNames = nil
+
+	return nil, io.EOF
+}
+
 // Readdirnames reads and returns a slice of names from the directory f.
 //
 // If n > 0, Readdirnames returns at most n names. In this case, if
@@ -372,6 +668,18 @@ func (f *MemFile) ReadDir(n int) (entries []fs.DirEntry, err error) {
 // directory, Readdirnames returns the names read until that point and
 // a non-nil error.
 func (f *MemFile) Readdirnames(n int) (names []string, err error) {
+	return f.readdirnames(n, false)
+}
+
+// ReaddirnamesUnsorted is like Readdirnames, but the names are returned in the nondeterministic order
+// of the directory map, whatever the order defined by Options.DirOrder (see OrderUnsorted).
+func (f *MemFile) ReaddirnamesUnsorted(n int) (names []string, err error) {
+	return f.readdirnames(n, true)
+}
+
+// readdirnames reads and returns a slice of names from the directory f,
+// in the order defined by Options.DirOrder or unsorted.
+func (f *MemFile) readdirnames(n int, unsorted bool) (names []string, err error) {
 	if f == nil {
 		return nil, fs.ErrInvalid
 	}
@@ -402,20 +710,20 @@ func (f *MemFile) Readdirnames(n int) (names []string, err error) {
 		return nil, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.NotADirectory}
 	}
 
-	if n <= 0 || f.dirNames == nil {
+	if f.dirNames == nil {
 		nd.mu.RLock()
-		names = nd.dirNames()
+		f.dirNames = f.vfs.orderedNames(nd, f.dirOrder(unsorted))
+		f.vfs.accessed(&nd.baseNode)
 		nd.mu.RUnlock()
 
 		f.dirIndex = 0
+	}
 
-		if n <= 0 {
-			f.dirNames = nil
-
-			return names, nil
-		}
+	if n <= 0 {
+		names = f.dirNames[f.dirIndex:]
+		f.dirIndex = len(f.dirNames)
 
-		f.dirNames = names
+		return names, nil
 	}
 
 	start := f.dirIndex
@@ -465,28 +773,31 @@ func (f *MemFile) Seek(offset int64, whence int) (ret int64, err error) {
 	}
 
 	nd.mu.RLock()
-	size := int64(len(nd.data))
+	size := nd.size()
 	nd.mu.RUnlock()
 
+	f.pos.mu.Lock()
+	defer f.pos.mu.Unlock()
+
 	switch whence {
 	case io.SeekStart:
 		if offset < 0 {
 			return 0, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.InvalidArgument}
 		}
 
-		f.at = offset
+		f.pos.at = offset
 	case io.SeekCurrent:
-		if f.at+offset < 0 {
+		if f.pos.at+offset < 0 {
 			return 0, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.InvalidArgument}
 		}
 
-		f.at += offset
+		f.pos.at += offset
 	case io.SeekEnd:
 		if size+offset < 0 {
 			return 0, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.InvalidArgument}
 		}
 
-		f.at = size + offset
+		f.pos.at = size + offset
 	default:
 		if f.vfs.OSType() != avfs.OsWindows {
 			return 0, &fs.PathError{Op: op, Path: f.name, Err: f.vfs.err.InvalidArgument}
@@ -495,7 +806,59 @@ func (f *MemFile) Seek(offset int64, whence int) (ret int64, err error) {
 		return 0, nil
 	}
 
-	return f.at, nil
+	return f.pos.at, nil
+}
+
+// SetDeadline sets the read and write deadlines for a File.
+// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+//
+// I/O operations on a MemFile never block, only operations started after
+// the deadline fail with a *PathError wrapping os.ErrDeadlineExceeded.
+// A zero value for t means I/O operations will not time out.
+func (f *MemFile) SetDeadline(t time.Time) error {
+	return f.setDeadline("SetDeadline", t, true, true)
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *MemFile) SetReadDeadline(t time.Time) error {
+	return f.setDeadline("SetReadDeadline", t, true, false)
+}
+
+// SetWriteDeadline sets the deadline for any future Write calls and any
+// currently-blocked Write call.
+// A zero value for t means Write will not time out.
+func (f *MemFile) SetWriteDeadline(t time.Time) error {
+	return f.setDeadline("SetWriteDeadline", t, false, true)
+}
+
+// setDeadline sets the read and/or write deadlines of an open file.
+func (f *MemFile) setDeadline(op string, t time.Time, read, write bool) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.name == "" {
+		return fs.ErrInvalid
+	}
+
+	if f.nd == nil {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	if read {
+		f.readDeadline = t
+	}
+
+	if write {
+		f.writeDeadline = t
+	}
+
+	return nil
 }
 
 // Stat returns the FileInfo structure describing file.
@@ -526,10 +889,7 @@ func (f *MemFile) Stat() (info fs.FileInfo, err error) {
 		return &MemInfo{}, &fs.PathError{Op: op, Path: f.name, Err: err}
 	}
 
-	name := f.vfs.Base(f.name)
-	fst := f.nd.fillStatFrom(name)
-
-	return fst, nil
+	return f.vfs.fileInfo(f.nd, f.vfs.Base(f.name)), nil
 }
 
 // Sync commits the current contents of the file to stable storage.
@@ -603,7 +963,8 @@ func (f *MemFile) Truncate(size int64) error {
 	nd.mu.Lock()
 
 	nd.truncate(size)
-	nd.mtime = time.Now().UnixNano()
+	nd.mtime = f.vfs.now()
+	nd.changed()
 
 	nd.mu.Unlock()
 
@@ -631,6 +992,10 @@ func (f *MemFile) Write(b []byte) (n int, err error) {
 		return 0, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
 	}
 
+	if deadlineExceeded(f.writeDeadline) {
+		return 0, &fs.PathError{Op: op, Path: f.name, Err: os.ErrDeadlineExceeded}
+	}
+
 	nd, ok := f.nd.(*fileNode)
 	if !ok {
 		err = avfs.ErrBadFileDesc
@@ -650,19 +1015,20 @@ func (f *MemFile) Write(b []byte) (n int, err error) {
 		return 0, &fs.PathError{Op: op, Path: f.name, Err: err}
 	}
 
+	f.pos.mu.Lock()
+	defer f.pos.mu.Unlock()
+
 	nd.mu.Lock()
 
-	n = copy(nd.data[f.at:], b)
-	if n < len(b) {
-		nd.data = append(nd.data, b[n:]...)
-		n = len(b)
-	}
+	nd.data.writeAt(b, f.pos.at)
+	n = len(b)
 
-	nd.mtime = time.Now().UnixNano()
+	nd.mtime = f.vfs.now()
+	nd.changed()
 
 	nd.mu.Unlock()
 
-	f.at += int64(n)
+	f.pos.at += int64(n)
 
 	return n, nil
 }
@@ -670,6 +1036,8 @@ func (f *MemFile) Write(b []byte) (n int, err error) {
 // WriteAt writes len(b) bytes to the File starting at byte offset off.
 // It returns the number of bytes written and an error, if any.
 // WriteAt returns a non-nil error when n != len(b).
+// WriteAt does not use the file offset, it is safe to call it concurrently
+// with ReadAt and WriteAt on the same file.
 func (f *MemFile) WriteAt(b []byte, off int64) (n int, err error) {
 	const op = "write"
 
@@ -692,6 +1060,10 @@ func (f *MemFile) WriteAt(b []byte, off int64) (n int, err error) {
 		return 0, &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
 	}
 
+	if deadlineExceeded(f.writeDeadline) {
+		return 0, &fs.PathError{Op: op, Path: f.name, Err: os.ErrDeadlineExceeded}
+	}
+
 	nd, ok := f.nd.(*fileNode)
 	if !ok {
 		err = avfs.ErrBadFileDesc
@@ -713,14 +1085,11 @@ func (f *MemFile) WriteAt(b []byte, off int64) (n int, err error) {
 
 	nd.mu.Lock()
 
-	diff := off + int64(len(b)) - nd.size()
-	if diff > 0 {
-		nd.data = append(nd.data, make([]byte, diff)...)
-	}
-
-	n = copy(nd.data[off:], b)
+	nd.data.writeAt(b, off)
+	n = len(b)
 
-	nd.mtime = time.Now().UnixNano()
+	nd.mtime = f.vfs.now()
+	nd.changed()
 
 	nd.mu.Unlock()
 
@@ -733,6 +1102,11 @@ func (f *MemFile) WriteString(s string) (n int, err error) {
 	return f.Write([]byte(s))
 }
 
+// deadlineExceeded returns true if the deadline t is set and has passed.
+func deadlineExceeded(t time.Time) bool {
+	return !t.IsZero() && !time.Now().Before(t)
+}
+
 // MemInfo is the implementation of FileInfo returned by Stat and Lstat.
 
 // Info returns the FileInfo for the file or subdirectory described by the entry.
@@ -770,6 +1144,11 @@ func (info *MemInfo) Size() int64 {
 	return info.size
 }
 
+// ATime returns the access time (see Options.ATimePolicy).
+func (info *MemInfo) ATime() time.Time {
+	return time.Unix(0, info.atime)
+}
+
 // Sys returns the underlying data source (can return nil).
 func (info *MemInfo) Sys() any {
 	return info
@@ -791,7 +1170,30 @@ func (info *MemInfo) Uid() int {
 	return info.uid
 }
 
+// Ino returns the unique id of the node, shared by all hard links to the same file.
+func (info *MemInfo) Ino() uint64 {
+	return info.id
+}
+
 // Nlink returns the number of hard links.
 func (info *MemInfo) Nlink() uint64 {
 	return uint64(info.nlink)
 }
+
+// Blksize returns the block size of the file system.
+func (info *MemInfo) Blksize() int64 {
+	return info.blksize
+}
+
+// Blocks returns the number of 512-byte blocks allocated to the file,
+// the allocated size of the file being rounded up to a multiple of the block size.
+// Holes of sparse files are not allocated.
+func (info *MemInfo) Blocks() int64 {
+	if info.blksize <= 0 {
+		return 0
+	}
+
+	allocated := (info.alloc + info.blksize - 1) / info.blksize * info.blksize
+
+	return (allocated + 511) / 512
+}
@@ -17,9 +17,13 @@
 package memfs
 
 import (
+	"bufio"
 	"bytes"
+	"fmt"
 	"io/fs"
+	"math/rand"
 	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -41,6 +45,8 @@ import (
 //	ErrPermDenied when the current user doesn't have permissions on one of the nodes on the path
 //	ErrNotADirectory when a file node is found while the path segmentation is not finished
 //	ErrTooManySymlinks when more than slCountMax symbolic link resolutions have been performed.
+//	ErrNameTooLong when path is longer than pathMax or one of its parts is longer than nameMax.
+//	ErrInvalidArgument when path contains a NUL byte, any other byte is valid in a file name.
 func (vfs *MemFS) searchNode(path string, slMode slMode) (
 	parent *dirNode, child node, pi *avfs.PathIterator[*MemFS], err error,
 ) {
@@ -50,6 +56,18 @@ func (vfs *MemFS) searchNode(path string, slMode slMode) (
 	absPath, _ := vfs.Abs(path)
 	pi = avfs.NewPathIterator[*MemFS](vfs, absPath)
 
+	if len(path) > vfs.pathMax {
+		err = vfs.err.NameTooLong
+
+		return
+	}
+
+	if strings.IndexByte(path, 0) >= 0 {
+		err = avfs.ErrInvalidArgument
+
+		return
+	}
+
 	volNode := vfs.rootNode
 
 	if pi.VolumeNameLen() > 0 {
@@ -67,6 +85,11 @@ func (vfs *MemFS) searchNode(path string, slMode slMode) (
 
 	for pi.Next() {
 		name := pi.Part()
+		if len(name) > vfs.nameMax {
+			err = vfs.err.NameTooLong
+
+			return
+		}
 
 		parent.mu.RLock()
 		child = parent.children[name]
@@ -150,12 +173,233 @@ func (vfs *MemFS) searchNode(path string, slMode slMode) (
 	return parent, parent, pi, vfs.err.FileExists
 }
 
+// searchNodeAt is like searchNode, but a relative path is searched from the directory node start
+// instead of the current directory, like the openat family of system calls.
+// If start is nil or path is absolute or rooted, searchNodeAt is equivalent to searchNode.
+// The parent directories ("..") are resolved from the current location of each directory node,
+// so renaming start or one of its ancestors doesn't change the result.
+// The returned path iterator is positioned on the part where the search stopped.
+func (vfs *MemFS) searchNodeAt(start *dirNode, path string, slMode slMode) (
+	parent *dirNode, child node, pi *avfs.PathIterator[*MemFS], err error,
+) {
+	if start == nil || vfs.IsAbs(path) || avfs.VolumeNameLen(vfs, path) > 0 ||
+		(path != "" && vfs.IsPathSeparator(path[0])) {
+		return vfs.searchNode(path, slMode)
+	}
+
+	slCount := 0
+	slResolved := false
+
+	parts := vfs.splitPath(path)
+	pi = vfs.partsIterator(parts)
+
+	switch {
+	case len(path) > vfs.pathMax:
+		err = vfs.err.NameTooLong
+	case strings.IndexByte(path, 0) >= 0:
+		err = avfs.ErrInvalidArgument
+	case len(parts) == 0:
+		err = vfs.err.NoSuchFile
+	}
+
+	if err != nil {
+		return
+	}
+
+	start.mu.RLock()
+	ok := start.checkPermission(avfs.OpenLookup, vfs.User())
+	start.mu.RUnlock()
+
+	if !ok {
+		err = vfs.err.PermDenied
+
+		return
+	}
+
+	parent = start
+
+	for len(parts) > 0 {
+		pi = vfs.partsIterator(parts)
+
+		name := parts[0]
+		parts = parts[1:]
+
+		if len(name) > vfs.nameMax {
+			err = vfs.err.NameTooLong
+
+			return
+		}
+
+		if name == "." || name == ".." {
+			if name == ".." && !vfs.isRootNode(parent) {
+				dn := parent.parent.Load()
+				if dn == nil {
+					err = vfs.err.NoSuchDir
+
+					return
+				}
+
+				dn.mu.RLock()
+				ok := dn.checkPermission(avfs.OpenLookup, vfs.User())
+				dn.mu.RUnlock()
+
+				if !ok {
+					err = vfs.err.PermDenied
+
+					return
+				}
+
+				parent = dn
+			}
+
+			if len(parts) == 0 {
+				return parent, parent, pi, vfs.err.FileExists
+			}
+
+			continue
+		}
+
+		parent.mu.RLock()
+		child = parent.children[name]
+		parent.mu.RUnlock()
+
+		if child == nil {
+			err = vfs.err.NoSuchDir
+			if len(parts) == 0 {
+				err = vfs.err.NoSuchFile
+			}
+
+			return
+		}
+
+		switch c := child.(type) {
+		case *dirNode:
+			if len(parts) == 0 {
+				err = vfs.err.FileExists
+
+				return
+			}
+
+			c.mu.RLock()
+			ok := c.checkPermission(avfs.OpenLookup, vfs.User())
+			c.mu.RUnlock()
+
+			if !ok {
+				err = vfs.err.PermDenied
+
+				return
+			}
+
+			parent = c
+
+		case *fileNode:
+			// File permissions are checked by the calling function.
+			if len(parts) == 0 {
+				err = vfs.err.FileExists
+
+				return
+			}
+
+			err = vfs.err.NotADirectory
+
+			return
+
+		case *symlinkNode:
+			// Symlinks mode is always 0o777, no need to check permissions.
+			slCount++
+			if slCount > slCountMax {
+				err = vfs.err.TooManySymlinks
+
+				return
+			}
+
+			if len(parts) == 0 {
+				if slMode == slmLstat {
+					err = vfs.err.FileExists
+
+					return
+				}
+
+				// Stat should return the initial path of the symbolic link
+				// and the parent and child nodes of the resolved symbolic link.
+				if slMode == slmStat && !slResolved {
+					slResolved = true
+
+					defer func(piSymLink *avfs.PathIterator[*MemFS]) { //nolint:gocritic // Possible resource leak
+						pi = piSymLink
+					}(pi)
+				}
+			}
+
+			link := c.link
+			if vfs.IsAbs(link) {
+				vl := avfs.VolumeNameLen(vfs, link)
+
+				parent = vfs.rootNode
+				if vl > 0 {
+					parent, ok = vfs.volumes[link[:vl]]
+					if !ok {
+						err = vfs.err.NoSuchDir
+
+						return
+					}
+				}
+
+				link = link[vl:]
+			}
+
+			parts = append(vfs.splitPath(link), parts...)
+		}
+	}
+
+	return parent, parent, pi, vfs.err.FileExists
+}
+
+// isRootNode returns true if the directory node dn is the root of the file system or of a volume.
+func (vfs *MemFS) isRootNode(dn *dirNode) bool {
+	if dn == vfs.rootNode {
+		return true
+	}
+
+	for _, volNode := range vfs.volumes {
+		if dn == volNode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitPath returns the non-empty parts of a relative path.
+func (vfs *MemFS) splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r < 0x80 && vfs.IsPathSeparator(uint8(r))
+	})
+}
+
+// partsIterator returns a path iterator positioned on the first part of parts.
+func (vfs *MemFS) partsIterator(parts []string) *avfs.PathIterator[*MemFS] {
+	sep := string(vfs.PathSeparator())
+
+	pi := avfs.NewPathIterator[*MemFS](vfs, sep+strings.Join(parts, sep))
+	pi.Next()
+
+	return pi
+}
+
+// now returns the current time of the file system clock in nanoseconds.
+func (vfs *MemFS) now() int64 {
+	return vfs.clock().UnixNano()
+}
+
 // createRootNode creates a root node for a file system.
 func (vfs *MemFS) createRootNode() *dirNode {
 	u := vfs.User()
 	dn := &dirNode{
 		baseNode: baseNode{
-			mtime: time.Now().UnixNano(),
+			id:    atomic.AddUint64(vfs.lastId, 1),
+			atime: vfs.now(),
+			mtime: vfs.now(),
 			mode:  fs.ModeDir | 0o755,
 			uid:   u.Uid(),
 			gid:   u.Gid(),
@@ -165,11 +409,73 @@ func (vfs *MemFS) createRootNode() *dirNode {
 	return dn
 }
 
+// allocInode reserves a node and returns false if the maximum number of nodes is reached.
+func (vfs *MemFS) allocInode() bool {
+	for {
+		used := atomic.LoadInt64(vfs.usedInodes)
+		if vfs.maxInodes > 0 && used >= vfs.maxInodes {
+			return false
+		}
+
+		if atomic.CompareAndSwapInt64(vfs.usedInodes, used, used+1) {
+			return true
+		}
+	}
+}
+
+// freeInode releases a node reserved by allocInode.
+func (vfs *MemFS) freeInode() {
+	atomic.AddInt64(vfs.usedInodes, -1)
+}
+
+// chmodMode returns the mode bits used by Chmod depending on the OS type.
+// ModeSetuid, ModeSetgid and ModeSticky are ignored on Windows.
+func (vfs *MemFS) chmodMode(mode fs.FileMode) fs.FileMode {
+	if vfs.OSType() == avfs.OsWindows {
+		return mode &^ (fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+	}
+
+	return mode
+}
+
+// isOpenLocked returns true if nd is an open file that can't be removed or renamed
+// (see Options.ErrorOnRemoveOpen).
+func (vfs *MemFS) isOpenLocked(nd node) bool {
+	fn, ok := nd.(*fileNode)
+
+	return ok && vfs.lockOpenFiles && vfs.OSType() == avfs.OsWindows && atomic.LoadInt32(&fn.nopen) > 0
+}
+
+// replaceDir deletes nd, the existing target of a directory renamed with POSIX semantics
+// (see Options.PosixRename). nd must be an empty directory.
+func (vfs *MemFS) replaceDir(nd node) error {
+	dn, ok := nd.(*dirNode)
+	if !ok {
+		return vfs.err.NotADirectory
+	}
+
+	dn.mu.Lock()
+	defer dn.mu.Unlock()
+
+	if len(dn.children) != 0 {
+		return vfs.err.DirNotEmpty
+	}
+
+	if dn.delete() {
+		vfs.freeInode()
+	}
+
+	return nil
+}
+
 // createDir creates a new directory.
 func (vfs *MemFS) createDir(parent *dirNode, name string, perm fs.FileMode) *dirNode {
+	mtime := vfs.now()
 	child := &dirNode{
 		baseNode: baseNode{
-			mtime: time.Now().UnixNano(),
+			id:    atomic.AddUint64(vfs.lastId, 1),
+			atime: mtime,
+			mtime: mtime,
 			mode:  vfs.dirMode | (perm & avfs.FileModeMask &^ vfs.UMask()),
 			uid:   vfs.User().Uid(),
 			gid:   vfs.User().Gid(),
@@ -177,34 +483,44 @@ func (vfs *MemFS) createDir(parent *dirNode, name string, perm fs.FileMode) *dir
 		children: nil,
 	}
 
-	parent.addChild(name, child)
+	parent.addChild(name, child, mtime)
 
 	return child
 }
 
 // createFile creates a new file.
 func (vfs *MemFS) createFile(parent *dirNode, name string, perm fs.FileMode) *fileNode {
-	child := &fileNode{
+	mtime := vfs.now()
+	child := vfs.newFileNode(perm, mtime)
+	child.nlink = 1
+
+	parent.addChild(name, child, mtime)
+
+	return child
+}
+
+// newFileNode returns a new file node not linked to any directory.
+func (vfs *MemFS) newFileNode(perm fs.FileMode, mtime int64) *fileNode {
+	return &fileNode{
 		baseNode: baseNode{
-			mtime: time.Now().UnixNano(),
+			id:    atomic.AddUint64(vfs.lastId, 1),
+			atime: mtime,
+			mtime: mtime,
 			mode:  vfs.fileMode | (perm & avfs.FileModeMask &^ vfs.UMask()),
 			uid:   vfs.User().Uid(),
 			gid:   vfs.User().Gid(),
 		},
-		id:    atomic.AddUint64(vfs.lastId, 1),
-		nlink: 1,
 	}
-
-	parent.addChild(name, child)
-
-	return child
 }
 
 // createSymlink creates a new symlink.
 func (vfs *MemFS) createSymlink(parent *dirNode, name, link string) *symlinkNode {
+	mtime := vfs.now()
 	child := &symlinkNode{
 		baseNode: baseNode{
-			mtime: time.Now().UnixNano(),
+			id:    atomic.AddUint64(vfs.lastId, 1),
+			atime: mtime,
+			mtime: mtime,
 			mode:  fs.ModeSymlink | fs.ModePerm,
 			uid:   vfs.User().Uid(),
 			gid:   vfs.User().Gid(),
@@ -212,11 +528,82 @@ func (vfs *MemFS) createSymlink(parent *dirNode, name, link string) *symlinkNode
 		link: link,
 	}
 
-	parent.addChild(name, child)
+	parent.addChild(name, child, mtime)
 
 	return child
 }
 
+// dumpNode writes a node named name and its descendants to w, indented by depth.
+func dumpNode(w *bufio.Writer, name string, nd node, depth int) {
+	info := nd.fillStatFrom(name)
+
+	fmt.Fprintf(w, "%s%s %d/%d %d %s", strings.Repeat("  ", depth), info.mode, info.uid, info.gid, info.size, name)
+
+	switch c := nd.(type) {
+	case *symlinkNode:
+		c.mu.RLock()
+		fmt.Fprintf(w, " -> %s\n", c.link)
+		c.mu.RUnlock()
+	case *dirNode:
+		w.WriteByte('\n')
+
+		c.mu.RLock()
+		names := c.dirNames()
+		sort.Strings(names)
+		nodes := make([]node, len(names))
+
+		for i, childName := range names {
+			nodes[i] = c.children[childName]
+		}
+
+		c.mu.RUnlock()
+
+		for i, childName := range names {
+			dumpNode(w, childName, nodes[i], depth+1)
+		}
+	default:
+		w.WriteByte('\n')
+	}
+}
+
+// orderedNames returns the names of the children of the directory dn in the order defined by order.
+// dn must be locked for reading.
+func (vfs *MemFS) orderedNames(dn *dirNode, order DirOrder) []string {
+	names := dn.dirNames()
+	if order == OrderUnsorted {
+		return names
+	}
+
+	// Names are sorted first, so that the other orders don't depend on the map iteration order.
+	sort.Strings(names)
+
+	switch order {
+	case OrderCreation:
+		sort.SliceStable(names, func(i, j int) bool {
+			return dn.children[names[i]].nodeId() < dn.children[names[j]].nodeId()
+		})
+	case OrderRandom:
+		vfs.shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+	default:
+	}
+
+	return names
+}
+
+// shuffle shuffles n elements with the random source of the file system (see Options.RandSource).
+func (vfs *MemFS) shuffle(n int, swap func(i, j int)) {
+	if vfs.randSource == nil {
+		rand.Shuffle(n, swap)
+
+		return
+	}
+
+	ls := vfs.randSource
+	ls.mu.Lock()
+	rand.New(ls.src).Shuffle(n, swap)
+	ls.mu.Unlock()
+}
+
 // isNotExist is IsNotExist without unwrapping.
 func (vfs *MemFS) isNotExist(err error) bool {
 	return err == vfs.err.NoSuchDir || err == vfs.err.NoSuchFile
@@ -235,7 +622,7 @@ func (bn *baseNode) checkPermission(perm avfs.OpenMode, u avfs.UserReader) bool
 	switch {
 	case bn.uid == u.Uid():
 		mode >>= 6
-	case bn.gid == u.Gid():
+	case bn.gid == u.Gid() || inGroups(u, bn.gid):
 		mode >>= 3
 	}
 
@@ -244,26 +631,157 @@ func (bn *baseNode) checkPermission(perm avfs.OpenMode, u avfs.UserReader) bool
 	return mode&perm == perm
 }
 
+// inGroups returns true if gid is one of the supplementary groups of the user (see avfs.GroupsReader).
+func inGroups(u avfs.UserReader, gid int) bool {
+	gr, ok := u.(avfs.GroupsReader)
+	if !ok {
+		return false
+	}
+
+	for _, g := range gr.Groups() {
+		if g.Gid() == gid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getXattr returns the value of the extended attribute name and true if it exists.
+func (bn *baseNode) getXattr(name string) ([]byte, bool) {
+	data, ok := bn.xattrs[name]
+	if !ok {
+		return nil, false
+	}
+
+	return bytes.Clone(data), true
+}
+
+// listXattr returns the sorted names of the extended attributes of the node.
+func (bn *baseNode) listXattr() []string {
+	names := make([]string, 0, len(bn.xattrs))
+	for name := range bn.xattrs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// base returns the common structure of the node.
+func (bn *baseNode) base() *baseNode {
+	return bn
+}
+
+// changed increments the generation of the node after a change of its content or its metadata.
+func (bn *baseNode) changed() {
+	atomic.AddUint64(&bn.gen, 1)
+}
+
+// nodeId returns the unique id of the node.
+func (bn *baseNode) nodeId() uint64 {
+	return bn.id
+}
+
 // Lock locks the node.
 func (bn *baseNode) Lock() {
 	bn.mu.Lock()
 }
 
-// setModTime sets the modification time of the node.
-func (bn *baseNode) setModTime(mtime time.Time, u avfs.UserReader) bool {
+// setTimes sets the access and modification times of the node.
+func (bn *baseNode) setTimes(atime, mtime time.Time, u avfs.UserReader) bool {
 	if bn.uid != u.Uid() && !u.IsAdmin() {
 		return false
 	}
 
+	atomic.StoreInt64(&bn.atime, atime.UnixNano())
 	bn.mtime = mtime.UnixNano()
+	bn.changed()
 
 	return true
 }
 
+// accessed updates the access time of the node read by the current user
+// according to the access time policy (see Options.ATimePolicy).
+// The node must be locked for reading.
+func (vfs *MemFS) accessed(bn *baseNode) {
+	const relatimeDelay = int64(24 * time.Hour)
+
+	now := vfs.now()
+
+	switch vfs.atimePolicy {
+	case ATimeNone:
+		return
+	case ATimeStrict:
+	default:
+		if atime := atomic.LoadInt64(&bn.atime); atime > bn.mtime && now-atime < relatimeDelay {
+			return
+		}
+	}
+
+	atomic.StoreInt64(&bn.atime, now)
+}
+
+// fileInfo returns the file information of the node nd named name.
+// The information cached by a previous call is returned if the node hasn't changed since then.
+func (vfs *MemFS) fileInfo(nd node, name string) *MemInfo {
+	bn := nd.base()
+	gen := atomic.LoadUint64(&bn.gen)
+
+	fst := bn.info.Load()
+	if fst != nil && fst.gen == gen && fst.name == name && fst.blksize == vfs.blockSize &&
+		fst.atime == atomic.LoadInt64(&bn.atime) && !allocChanged(nd, fst) {
+		return fst
+	}
+
+	fst = nd.fillStatFrom(name)
+	fst.blksize = vfs.blockSize
+	fst.gen = gen
+
+	bn.info.Store(fst)
+
+	return fst
+}
+
+// allocChanged returns true if the allocated size of the file node nd is no longer the one of fst.
+// Compressing or inflating the content of a file changes its allocated size, but not its generation.
+func allocChanged(nd node, fst *MemInfo) bool {
+	fn, ok := nd.(*fileNode)
+	if !ok {
+		return false
+	}
+
+	fn.mu.RLock()
+	defer fn.mu.RUnlock()
+
+	return fn.data.allocated() != fst.alloc
+}
+
+// removeXattr removes the extended attribute name and returns true if it existed.
+func (bn *baseNode) removeXattr(name string) bool {
+	_, ok := bn.xattrs[name]
+	delete(bn.xattrs, name)
+	bn.changed()
+
+	return ok
+}
+
 // setOwner sets the owner of the node.
 func (bn *baseNode) setOwner(uid, gid int) {
 	bn.uid = uid
 	bn.gid = gid
+	bn.changed()
+}
+
+// setXattr sets the value of the extended attribute name.
+func (bn *baseNode) setXattr(name string, data []byte) {
+	if bn.xattrs == nil {
+		bn.xattrs = make(map[string][]byte)
+	}
+
+	bn.xattrs[name] = bytes.Clone(data)
+	bn.changed()
 }
 
 // Unlock unlocks the node.
@@ -273,23 +791,34 @@ func (bn *baseNode) Unlock() {
 
 // dirNode
 
-// addChild adds a child to a dirNode.
-func (dn *dirNode) addChild(name string, child node) {
+// addChild adds a child to a dirNode and updates its modification time to mtime.
+func (dn *dirNode) addChild(name string, child node, mtime int64) {
 	if dn.children == nil {
 		dn.children = make(children)
 	}
 
 	dn.children[name] = child
+	dn.mtime = mtime
+	dn.changed()
+
+	if c, ok := child.(*dirNode); ok {
+		c.parent.Store(dn)
+	}
 }
 
-// removeChild removes the child from the parent dirNode.
-func (dn *dirNode) removeChild(name string) {
+// removeChild removes the child from the parent dirNode and updates its modification time to mtime.
+func (dn *dirNode) removeChild(name string, mtime int64) {
 	delete(dn.children, name)
+	dn.mtime = mtime
+	dn.changed()
 }
 
 // delete removes all information from the node.
-func (dn *dirNode) delete() {
+func (dn *dirNode) delete() bool {
 	dn.children = nil
+	dn.parent.Store(nil)
+
+	return true
 }
 
 // fillStatFrom returns a MemInfo (implementation of fs.FileInfo) from a dirNode dn named name.
@@ -297,9 +826,12 @@ func (dn *dirNode) fillStatFrom(name string) *MemInfo {
 	dn.mu.RLock()
 
 	fst := &MemInfo{
+		id:    dn.id,
 		name:  name,
 		size:  dn.size(),
+		alloc: dn.size(),
 		mode:  dn.mode,
+		atime: atomic.LoadInt64(&dn.atime),
 		mtime: dn.mtime,
 		uid:   dn.uid,
 		gid:   dn.gid,
@@ -311,27 +843,24 @@ func (dn *dirNode) fillStatFrom(name string) *MemInfo {
 	return fst
 }
 
-// dirEntries returns a slice of fs.DirEntry from a directory ordered by name.
-func (dn *dirNode) dirEntries() []fs.DirEntry {
-	l := len(dn.children)
-	if l == 0 {
+// dirEntries returns a slice of fs.DirEntry from a directory in the order of names.
+func (dn *dirNode) dirEntries(names []string, blockSize int64) []fs.DirEntry {
+	if len(names) == 0 {
 		return nil
 	}
 
-	entries := make([]fs.DirEntry, l)
-	i := 0
+	entries := make([]fs.DirEntry, len(names))
 
-	for name, nd := range dn.children {
-		entries[i] = nd.fillStatFrom(name)
-		i++
+	for i, name := range names {
+		info := dn.children[name].fillStatFrom(name)
+		info.blksize = blockSize
+		entries[i] = info
 	}
 
-	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
-
 	return entries
 }
 
-// dirNames returns a slice of file names from a directory ordered by name.
+// dirNames returns a slice of file names from a directory in map iteration order (not sorted).
 func (dn *dirNode) dirNames() []string {
 	l := len(dn.children)
 	if l == 0 {
@@ -346,8 +875,6 @@ func (dn *dirNode) dirNames() []string {
 		i++
 	}
 
-	sort.Strings(names)
-
 	return names
 }
 
@@ -359,6 +886,7 @@ func (dn *dirNode) setMode(mode fs.FileMode, u avfs.UserReader) bool {
 
 	dn.mode &^= avfs.FileModeMask
 	dn.mode |= mode & avfs.FileModeMask
+	dn.changed()
 
 	return true
 }
@@ -372,11 +900,17 @@ func (dn *dirNode) size() int64 {
 
 // delete removes all information from the node, decrements the reference counter of the fileNode.
 // If there is no more references, the data is deleted.
-func (fn *fileNode) delete() {
+func (fn *fileNode) delete() bool {
 	fn.nlink--
+	fn.changed()
+
 	if fn.nlink == 0 {
-		fn.data = nil
+		fn.data.reset()
+
+		return true
 	}
+
+	return false
 }
 
 // fillStatFrom returns a MemInfo (implementation of fs.FileInfo) from a fileNode fn named name.
@@ -387,7 +921,9 @@ func (fn *fileNode) fillStatFrom(name string) *MemInfo {
 		id:    fn.id,
 		name:  name,
 		size:  fn.size(),
+		alloc: fn.data.allocated(),
 		mode:  fn.mode,
+		atime: atomic.LoadInt64(&fn.atime),
 		mtime: fn.mtime,
 		uid:   fn.uid,
 		gid:   fn.gid,
@@ -407,38 +943,30 @@ func (fn *fileNode) setMode(mode fs.FileMode, u avfs.UserReader) bool {
 
 	fn.mode &^= avfs.FileModeMask
 	fn.mode |= mode & avfs.FileModeMask
+	fn.changed()
 
 	return true
 }
 
 // size returns the size of the file.
 func (fn *fileNode) size() int64 {
-	return int64(len(fn.data))
+	return fn.data.size
 }
 
 // truncate truncates the file.
+// Extending the file creates a hole which is read as zeros.
 func (fn *fileNode) truncate(size int64) {
-	if size == 0 {
-		fn.data = nil
-
-		return
-	}
-
-	diff := int(size) - len(fn.data)
-	if diff > 0 {
-		fn.data = append(fn.data, bytes.Repeat([]byte{0}, diff)...)
-
-		return
-	}
-
-	fn.data = fn.data[:size]
+	fn.data.truncate(size)
+	fn.changed()
 }
 
 // symlinkNode
 
 // delete removes all information from the node.
-func (sn *symlinkNode) delete() {
+func (sn *symlinkNode) delete() bool {
 	sn.link = ""
+
+	return true
 }
 
 // fillStatFrom returns a MemInfo (implementation of fs.FileInfo) from a symlinkNode named name.
@@ -446,9 +974,12 @@ func (sn *symlinkNode) fillStatFrom(name string) *MemInfo {
 	sn.mu.RLock()
 
 	fst := &MemInfo{
+		id:    sn.id,
 		name:  name,
 		size:  sn.size(),
+		alloc: sn.size(),
 		mode:  sn.mode,
+		atime: atomic.LoadInt64(&sn.atime),
 		mtime: sn.mtime,
 		uid:   sn.uid,
 		gid:   sn.gid,
@@ -460,9 +991,17 @@ func (sn *symlinkNode) fillStatFrom(name string) *MemInfo {
 	return fst
 }
 
-// setMode sets the permissions of the symlink node.
+// setMode sets the permissions of the symlink node (see MemFS.Lchmod).
 func (sn *symlinkNode) setMode(mode fs.FileMode, u avfs.UserReader) bool {
-	return false
+	if sn.uid != u.Uid() && !u.IsAdmin() {
+		return false
+	}
+
+	sn.mode &^= avfs.FileModeMask
+	sn.mode |= mode & avfs.FileModeMask
+	sn.changed()
+
+	return true
 }
 
 func (sn *symlinkNode) size() int64 {
@@ -19,7 +19,10 @@
 package memfs
 
 import (
+	"bytes"
 	"io/fs"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/avfs/avfs"
@@ -137,3 +140,201 @@ func TestSearchNode(t *testing.T) {
 		}
 	}
 }
+
+func TestCheck(t *testing.T) {
+	newFS := func(t *testing.T) (*MemFS, *dirNode) {
+		t.Helper()
+
+		vfs := New()
+
+		for _, dir := range []string{"/a/b", "/c"} {
+			if err := vfs.MkdirAll(dir, avfs.DefaultDirPerm); err != nil {
+				t.Fatalf("MkdirAll %s : want error to be nil, got %v", dir, err)
+			}
+		}
+
+		if err := vfs.WriteFile("/a/f", []byte("data"), avfs.DefaultFilePerm); err != nil {
+			t.Fatalf("WriteFile : want error to be nil, got %v", err)
+		}
+
+		if err := vfs.Link("/a/f", "/c/hl"); err != nil {
+			t.Fatalf("Link : want error to be nil, got %v", err)
+		}
+
+		if err := vfs.Symlink("/a/f", "/c/sl"); err != nil {
+			t.Fatalf("Symlink : want error to be nil, got %v", err)
+		}
+
+		if err := vfs.Check(); err != nil {
+			t.Fatalf("Check : want error to be nil, got %v", err)
+		}
+
+		return vfs, vfs.rootNode
+	}
+
+	child := func(dn *dirNode, path string) node {
+		var nd node = dn
+		for _, name := range strings.Split(strings.Trim(path, "/"), "/") {
+			nd = nd.(*dirNode).children[name]
+		}
+
+		return nd
+	}
+
+	tests := []struct {
+		name    string
+		corrupt func(vfs *MemFS, rn *dirNode)
+		wantErr string
+	}{
+		{
+			name:    "RootNotDir",
+			corrupt: func(vfs *MemFS, rn *dirNode) { rn.mode = 0 },
+			wantErr: "memfs check / : root mode",
+		},
+		{
+			name: "DirCycle",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				child(rn, "/a/b").(*dirNode).children = children{"loop": child(rn, "/a")}
+			},
+			wantErr: "memfs check /a/b/loop : directory is already referenced by /a",
+		},
+		{
+			name: "HardlinkCount",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				child(rn, "/a/f").(*fileNode).nlink = 3
+			},
+			wantErr: "memfs check /a/f : file has 3 hard links but is referenced by 2 directory entries",
+		},
+		{
+			name: "OrphanedLink",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				delete(child(rn, "/c").(*dirNode).children, "hl")
+			},
+			wantErr: "memfs check /a/f : file has 2 hard links but is referenced by 1 directory entries",
+		},
+		{
+			name: "EmptySymlink",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				child(rn, "/c/sl").(*symlinkNode).link = ""
+			},
+			wantErr: "memfs check /c/sl : symbolic link has an empty target",
+		},
+		{
+			name: "InvalidName",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				rn.children["x/y"] = vfs.createDir(rn, "z", avfs.DefaultDirPerm)
+			},
+			wantErr: `memfs check /x/y : invalid directory entry name "x/y"`,
+		},
+		{
+			name: "NilNode",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				rn.children["nil"] = nil
+			},
+			wantErr: "memfs check /nil : nil node",
+		},
+		{
+			name: "DuplicateId",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				child(rn, "/c/sl").(*symlinkNode).id = child(rn, "/a/b").(*dirNode).id
+			},
+			wantErr: "memfs check /c/sl : node id",
+		},
+		{
+			name: "UnusedInodes",
+			corrupt: func(vfs *MemFS, rn *dirNode) {
+				_ = vfs.createDir(rn, "noinode", avfs.DefaultDirPerm)
+			},
+			wantErr: "nodes found but only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vfs, rn := newFS(t)
+			tt.corrupt(vfs, rn)
+
+			err := vfs.Check()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Check : want error to contain %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFileData(t *testing.T) {
+	const maxSize = 256
+
+	var (
+		fd    fileData
+		model []byte // model is the expected content of the file.
+	)
+
+	rnd := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 10000; i++ {
+		off := rnd.Int63n(maxSize)
+		length := rnd.Int63n(maxSize/4) + 1
+
+		switch op := rnd.Intn(5); op {
+		case 0, 1:
+			b := make([]byte, length)
+			for j := range b {
+				b[j] = byte(rnd.Intn(255) + 1)
+			}
+
+			fd.writeAt(b, off)
+
+			if end := off + length; end > int64(len(model)) {
+				model = append(model, make([]byte, end-int64(len(model)))...)
+			}
+
+			copy(model[off:], b)
+		case 2:
+			fd.punchHole(off, length)
+
+			if off < int64(len(model)) {
+				clear(model[off:min(off+length, int64(len(model)))])
+			}
+		case 3:
+			keepSize := rnd.Intn(2) == 0
+			fd.allocate(off, length, keepSize)
+
+			if end := off + length; !keepSize && end > int64(len(model)) {
+				model = append(model, make([]byte, end-int64(len(model)))...)
+			}
+		case 4:
+			fd.truncate(off)
+
+			if off > int64(len(model)) {
+				model = append(model, make([]byte, off-int64(len(model)))...)
+			}
+
+			model = model[:off]
+		}
+
+		if fd.size != int64(len(model)) {
+			t.Fatalf("step %d : want size to be %d, got %d", i, len(model), fd.size)
+		}
+
+		got := make([]byte, maxSize*2)
+		for j := range got {
+			got[j] = 0xff
+		}
+
+		n := fd.readAt(got, 0)
+		if !bytes.Equal(got[:n], model) {
+			t.Fatalf("step %d : want content to be %v, got %v", i, model, got[:n])
+		}
+
+		var prevEnd int64 = -1
+
+		for _, e := range fd.extents {
+			if e.off <= prevEnd || len(e.data) == 0 || e.end() > fd.size {
+				t.Fatalf("step %d : invalid extents %v", i, fd.extents)
+			}
+
+			prevEnd = e.end()
+		}
+	}
+}
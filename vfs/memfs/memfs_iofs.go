@@ -17,23 +17,126 @@
 package memfs
 
 import (
+	"errors"
 	"io/fs"
 	"os"
+	"path"
+	"strings"
+
+	"github.com/avfs/avfs"
 )
 
+// ToIOFS returns an io/fs file system (MemIOFS) sharing the files of the memory file system.
+// The names used by the io/fs functions are unrooted, slash-separated paths (see fs.ValidPath)
+// relative to the root of the file system.
+func (vfs *MemFS) ToIOFS() *MemIOFS {
+	return &MemIOFS{MemFS: *vfs}
+}
+
+// Glob returns the names of all files matching pattern or nil if there is no matching file.
+// The syntax of patterns is the same as in path.Match.
+func (vfs *MemIOFS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	if pattern == "." {
+		return []string{"."}, nil
+	}
+
+	matches, err := vfs.MemFS.Glob(vfs.rootPath() + avfs.FromSlash(&vfs.MemFS, pattern))
+	if err != nil {
+		return nil, err
+	}
+
+	root := vfs.rootPath()
+
+	for i, m := range matches {
+		matches[i] = avfs.ToSlash(&vfs.MemFS, strings.TrimPrefix(m, root))
+	}
+
+	return matches, nil
+}
+
 // Open opens the named file for reading. If successful, methods on
 // the returned file can be used for reading; the associated file
 // descriptor has mode O_RDONLY.
 // If there is an error, it will be of type *PathError.
 func (vfs *MemIOFS) Open(name string) (fs.File, error) {
-	return vfs.OpenFile(name, os.O_RDONLY, 0)
+	const op = "open"
+
+	absPath, err := vfs.toAbsPath(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := vfs.OpenFile(absPath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, restorePathError(err, name)
+	}
+
+	return f, nil
+}
+
+// ReadDir reads the named directory,
+// returning all its directory entries sorted by filename.
+func (vfs *MemIOFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	const op = "open"
+
+	absPath, err := vfs.toAbsPath(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := vfs.MemFS.ReadDir(absPath)
+
+	return entries, restorePathError(err, name)
+}
+
+// ReadFile reads the named file and returns the contents.
+// A successful call returns err == nil, not err == EOF.
+func (vfs *MemIOFS) ReadFile(name string) ([]byte, error) {
+	const op = "open"
+
+	absPath, err := vfs.toAbsPath(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := vfs.MemFS.ReadFile(absPath)
+
+	return data, restorePathError(err, name)
+}
+
+// Stat returns a FileInfo describing the named file.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemIOFS) Stat(name string) (fs.FileInfo, error) {
+	const op = "stat"
+
+	absPath, err := vfs.toAbsPath(op, name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := vfs.MemFS.Stat(absPath)
+
+	return info, restorePathError(err, name)
 }
 
 // Sub returns an FS corresponding to the subtree rooted at dir.
 func (vfs *MemIOFS) Sub(dir string) (fs.FS, error) {
 	const op = "sub"
 
-	_, child, _, err := vfs.searchNode(dir, slmEval)
+	absPath, err := vfs.toAbsPath(op, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "." {
+		return vfs, nil
+	}
+
+	_, child, _, err := vfs.searchNode(absPath, slmEval)
 	if err != vfs.err.FileExists || child == nil {
 		return nil, &fs.PathError{Op: op, Path: dir, Err: err}
 	}
@@ -48,3 +151,31 @@ func (vfs *MemIOFS) Sub(dir string) (fs.FS, error) {
 
 	return &subFS, nil
 }
+
+// rootPath returns the path of the root directory, ending with a path separator.
+func (vfs *MemIOFS) rootPath() string {
+	return avfs.FromUnixPath(&vfs.MemFS, "/")
+}
+
+// toAbsPath returns the absolute path of the io/fs name or an error if the name is not valid.
+func (vfs *MemIOFS) toAbsPath(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	if name == "." {
+		return vfs.rootPath(), nil
+	}
+
+	return vfs.rootPath() + avfs.FromSlash(&vfs.MemFS, name), nil
+}
+
+// restorePathError replaces the path of a *fs.PathError by the io/fs name.
+func restorePathError(err error, name string) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return &fs.PathError{Op: pe.Op, Path: name, Err: pe.Err}
+	}
+
+	return err
+}
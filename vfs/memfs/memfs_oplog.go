@@ -0,0 +1,61 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+// OpLog returns the operations modifying the file system recorded by the operation log,
+// from the oldest to the most recent. Only the last Options.OpLogSize operations are kept.
+// The logged operations are Chmod, Chown, Chtimes, Lchmod, Lchown, Link, Linkat, Mkdir, MkdirAll,
+// Mkfifo, Mknod, OpenFile with a write access, Remove, RemoveAll, Rename, RenameExchange, RenameNoReplace,
+// Symlink, Truncate, SetXattr, LSetXattr and RemoveXattr.
+// If the operation log is disabled, OpLog returns nil.
+func (vfs *MemFS) OpLog() []LoggedOp {
+	ol := vfs.opLog
+	if ol == nil {
+		return nil
+	}
+
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	if !ol.full {
+		return append([]LoggedOp(nil), ol.ops[:ol.next]...)
+	}
+
+	ops := make([]LoggedOp, 0, len(ol.ops))
+	ops = append(ops, ol.ops[ol.next:]...)
+
+	return append(ops, ol.ops[:ol.next]...)
+}
+
+// logOp records an operation in the operation log if it is enabled.
+func (vfs *MemFS) logOp(op, path, newPath string, err error) {
+	ol := vfs.opLog
+	if ol == nil {
+		return
+	}
+
+	ol.mu.Lock()
+	defer ol.mu.Unlock()
+
+	ol.ops[ol.next] = LoggedOp{Err: err, Op: op, Path: path, NewPath: newPath}
+
+	ol.next++
+	if ol.next == len(ol.ops) {
+		ol.next = 0
+		ol.full = true
+	}
+}
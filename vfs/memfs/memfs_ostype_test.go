@@ -0,0 +1,195 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build avfs_setostype
+
+package memfs_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/idm/memidm"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/memfs"
+)
+
+// TestMemFSWindowsSuitePaths runs the path manipulation tests of the suite on a MemFS emulating Windows on any host.
+func TestMemFSWindowsSuitePaths(t *testing.T) {
+	idm := memidm.NewWithOptions(&memidm.Options{OSType: avfs.OsWindows})
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsWindows, Idm: idm})
+
+	ts := test.NewSuitePaths(t, vfs)
+	ts.TestPaths(t)
+}
+
+// TestMemFSWindowsXattr tests that extended attributes are not supported by a MemFS emulating Windows.
+func TestMemFSWindowsXattr(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsWindows})
+
+	if vfs.HasFeature(avfs.FeatXattr) {
+		t.Errorf("HasFeature : want FeatXattr to be missing on Windows")
+	}
+
+	path := `C:\file.txt`
+
+	err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	err = vfs.SetXattr(path, "user.name", []byte("value"), 0)
+	test.AssertPathError(t, err).Op("setxattr").Path(path).Err(avfs.ErrWinNotSupported).
+		FeatureMissing(avfs.FeatXattr).Test()
+
+	_, err = vfs.GetXattr(path, "user.name")
+	test.AssertPathError(t, err).Op("getxattr").Path(path).Err(avfs.ErrWinNotSupported).
+		FeatureMissing(avfs.FeatXattr).Test()
+
+	err = vfs.Remove(path)
+	test.RequireNoError(t, err, "Remove %s", path)
+
+	err = vfs.SetOSType(avfs.OsLinux)
+	test.RequireNoError(t, err, "SetOSType")
+
+	if !vfs.HasFeature(avfs.FeatXattr) {
+		t.Errorf("HasFeature : want FeatXattr to be set on Linux")
+	}
+}
+
+// TestMemFSWindowsMissingFeatures tests that the operations requiring features not available
+// on a MemFS emulating Windows return an error wrapping the missing feature.
+func TestMemFSWindowsMissingFeatures(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsWindows})
+
+	path := `C:\file.txt`
+
+	err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	err = vfs.Lchmod(path, 0o600)
+	test.AssertPathError(t, err).Op("lchmod").Path(path).Err(avfs.ErrWinNotSupported).
+		FeatureMissing(avfs.FeatLchmod).Test()
+
+	fifo := `C:\fifo`
+
+	err = vfs.Mkfifo(fifo, avfs.DefaultFilePerm)
+	test.AssertPathError(t, err).Op("mkfifo").Path(fifo).Err(avfs.ErrWinNotSupported).
+		FeatureMissing(avfs.FeatSpecialFiles).Test()
+}
+
+// TestMemFSWindowsPaths tests the path functions of a MemFS emulating Windows on any host.
+func TestMemFSWindowsPaths(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsWindows})
+
+	if sep := vfs.PathSeparator(); sep != '\\' {
+		t.Errorf("PathSeparator : want separator to be %q, got %q", '\\', sep)
+	}
+
+	for _, c := range []struct {
+		path string
+		want bool
+	}{
+		{path: `C:\x`, want: true},
+		{path: `c:\x\..\y`, want: true},
+		{path: `\x`, want: false},
+		{path: `/x`, want: false},
+		{path: `C:x`, want: false},
+	} {
+		if got := vfs.IsAbs(c.path); got != c.want {
+			t.Errorf("IsAbs(%q) : want %t, got %t", c.path, c.want, got)
+		}
+	}
+
+	if got, want := vfs.Join(`C:\`, "a", "b"), `C:\a\b`; got != want {
+		t.Errorf("Join : want %q, got %q", want, got)
+	}
+
+	if dir, file := vfs.Split(`C:\a\b.txt`); dir != `C:\a\` || file != "b.txt" {
+		t.Errorf("Split : want (%q, %q), got (%q, %q)", `C:\a\`, "b.txt", dir, file)
+	}
+
+	if got, want := vfs.Clean(`C:/a//b\..\c`), `C:\a\c`; got != want {
+		t.Errorf("Clean : want %q, got %q", want, got)
+	}
+
+	if got, want := vfs.Dir(`C:\a\b`), `C:\a`; got != want {
+		t.Errorf("Dir : want %q, got %q", want, got)
+	}
+
+	if got, err := vfs.Rel(`C:\a\b`, `C:\a\c\d`); err != nil || got != `..\c\d` {
+		t.Errorf("Rel : want (%q, nil), got (%q, %v)", `..\c\d`, got, err)
+	}
+
+	path := `C:\a`
+
+	err := vfs.Mkdir(path, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", path)
+
+	err = vfs.Chdir(path)
+	test.RequireNoError(t, err, "Chdir %s", path)
+
+	// A rooted path without a volume name is relative to the volume of the current directory.
+	rooted := `\a\b`
+
+	err = vfs.Mkdir(rooted, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", rooted)
+
+	_, err = vfs.Stat(`C:\a\b`)
+	test.RequireNoError(t, err, "Stat %s", `C:\a\b`)
+}
+
+// TestMemFSErrorOnRemoveOpen tests that open files can't be removed or renamed on a Windows MemFS.
+func TestMemFSErrorOnRemoveOpen(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsWindows, ErrorOnRemoveOpen: true})
+
+	path := `C:\open.txt`
+	newPath := `C:\renamed.txt`
+
+	err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	f, err := vfs.OpenFile(path, os.O_RDONLY, 0)
+	test.RequireNoError(t, err, "OpenFile %s", path)
+
+	err = vfs.Remove(path)
+	test.AssertPathError(t, err).Op("remove").Path(path).Err(avfs.ErrWinSharingViolation).Test()
+
+	err = vfs.Rename(path, newPath)
+	test.AssertLinkError(t, err).Op("rename").Old(path).New(newPath).Err(avfs.ErrWinSharingViolation).Test()
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close %s", path)
+
+	err = vfs.Rename(path, newPath)
+	test.RequireNoError(t, err, "Rename %s %s", path, newPath)
+
+	err = vfs.Remove(newPath)
+	test.RequireNoError(t, err, "Remove %s", newPath)
+
+	t.Run("CreatedFile", func(t *testing.T) {
+		f, err := vfs.OpenFile(path, os.O_CREATE|os.O_WRONLY, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "OpenFile %s", path)
+
+		err = vfs.Remove(path)
+		test.AssertPathError(t, err).Op("remove").Path(path).Err(avfs.ErrWinSharingViolation).Test()
+
+		err = f.Close()
+		test.RequireNoError(t, err, "Close %s", path)
+
+		err = vfs.Remove(path)
+		test.RequireNoError(t, err, "Remove %s", path)
+	})
+}
@@ -0,0 +1,152 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"os"
+	"strings"
+
+	"github.com/avfs/avfs"
+)
+
+// RenameExchange atomically exchanges oldpath and newpath, both must exist.
+// It is the equivalent of the Linux renameat2 system call with the RENAME_EXCHANGE flag.
+// If there is an error, it will be of type *LinkError.
+func (vfs *MemFS) RenameExchange(oldpath, newpath string) (err error) {
+	defer func() { vfs.logOp("RenameExchange", oldpath, newpath, err) }()
+
+	const op = "rename"
+
+	oParent, oChild, oPI, oErr := vfs.searchNode(oldpath, slmLstat)
+	if oErr != vfs.err.FileExists {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: oErr}
+	}
+
+	nParent, nChild, nPI, nErr := vfs.searchNode(newpath, slmLstat)
+	if nErr != vfs.err.FileExists {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: nErr}
+	}
+
+	if oPI.Path() == nPI.Path() {
+		return nil
+	}
+
+	if vfs.isAncestor(oPI.Path(), nPI.Path()) || vfs.isAncestor(nPI.Path(), oPI.Path()) {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.InvalidArgument}
+	}
+
+	oParent.mu.Lock()
+	defer oParent.mu.Unlock()
+
+	if !oParent.checkPermission(avfs.OpenWrite, vfs.User()) {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.PermDenied}
+	}
+
+	if nParent != oParent {
+		nParent.mu.Lock()
+		defer nParent.mu.Unlock()
+
+		if !nParent.checkPermission(avfs.OpenWrite, vfs.User()) {
+			return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.PermDenied}
+		}
+	}
+
+	oPart, nPart := oPI.Part(), nPI.Part()
+
+	// One of the paths may have been renamed or removed since it was searched.
+	if oParent.children[oPart] != oChild || nParent.children[nPart] != nChild {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.NoSuchFile}
+	}
+
+	if vfs.isOpenLocked(oChild) || vfs.isOpenLocked(nChild) {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: avfs.ErrWinSharingViolation}
+	}
+
+	mtime := vfs.now()
+
+	oParent.addChild(oPart, nChild, mtime)
+	nParent.addChild(nPart, oChild, mtime)
+
+	return nil
+}
+
+// RenameNoReplace renames (moves) oldpath to newpath like Rename,
+// but fails with avfs.ErrFileExists if newpath already exists.
+// It is the equivalent of the Linux renameat2 system call with the RENAME_NOREPLACE flag.
+// If there is an error, it will be of type *LinkError.
+func (vfs *MemFS) RenameNoReplace(oldpath, newpath string) (err error) {
+	defer func() { vfs.logOp("RenameNoReplace", oldpath, newpath, err) }()
+
+	const op = "rename"
+
+	oParent, oChild, oPI, oErr := vfs.searchNode(oldpath, slmLstat)
+	if oErr != vfs.err.FileExists {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: oErr}
+	}
+
+	nParent, _, nPI, nErr := vfs.searchNode(newpath, slmLstat)
+	if !vfs.isNotExist(nErr) || !nPI.IsLast() {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: nErr}
+	}
+
+	if vfs.isAncestor(oPI.Path(), nPI.Path()) {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.InvalidArgument}
+	}
+
+	oParent.mu.Lock()
+	defer oParent.mu.Unlock()
+
+	if !oParent.checkPermission(avfs.OpenWrite, vfs.User()) {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.PermDenied}
+	}
+
+	if nParent != oParent {
+		nParent.mu.Lock()
+		defer nParent.mu.Unlock()
+
+		if !nParent.checkPermission(avfs.OpenWrite, vfs.User()) {
+			return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.PermDenied}
+		}
+	}
+
+	oPart, nPart := oPI.Part(), nPI.Part()
+
+	// newpath may have been created since it was searched.
+	if nParent.children[nPart] != nil {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.FileExists}
+	}
+
+	if oParent.children[oPart] != oChild {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: vfs.err.NoSuchFile}
+	}
+
+	if vfs.isOpenLocked(oChild) {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: avfs.ErrWinSharingViolation}
+	}
+
+	mtime := vfs.now()
+
+	nParent.addChild(nPart, oChild, mtime)
+	oParent.removeChild(oPart, mtime)
+
+	return nil
+}
+
+// isAncestor returns true if the directory path dir is a strict ancestor of path.
+func (vfs *MemFS) isAncestor(dir, path string) bool {
+	return strings.HasPrefix(path, dir) && len(path) > len(dir) && vfs.IsPathSeparator(path[len(dir)])
+}
@@ -0,0 +1,77 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"io/fs"
+
+	"github.com/avfs/avfs"
+)
+
+// Mkfifo creates a named pipe (FIFO) with the specified name and permission bits (before umask).
+// Opening a named pipe fails with avfs.ErrNoDevOrAddr since MemFS doesn't emulate the other end of the pipe.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) Mkfifo(name string, perm fs.FileMode) (err error) {
+	defer func() { vfs.logOp("Mkfifo", name, "", err) }()
+
+	return vfs.mknod("mkfifo", name, fs.ModeNamedPipe|perm&fs.ModePerm)
+}
+
+// Mknod creates a special file with the specified name, mode must be the type
+// fs.ModeNamedPipe or fs.ModeSocket combined with the permission bits (before umask).
+// Opening a special file fails with avfs.ErrNoDevOrAddr.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) Mknod(name string, mode fs.FileMode) (err error) {
+	defer func() { vfs.logOp("Mknod", name, "", err) }()
+
+	return vfs.mknod("mknod", name, mode)
+}
+
+// mknod creates a named pipe or a socket node.
+func (vfs *MemFS) mknod(op, name string, mode fs.FileMode) error {
+	if !vfs.HasFeature(avfs.FeatSpecialFiles) {
+		err := &avfs.FeatureMissingError{Err: vfs.err.OpNotPermitted, Feature: avfs.FeatSpecialFiles}
+
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	typ := mode.Type()
+	if (typ != fs.ModeNamedPipe && typ != fs.ModeSocket) || mode&^(fs.ModeType|avfs.FileModeMask) != 0 {
+		return &fs.PathError{Op: op, Path: name, Err: vfs.err.InvalidArgument}
+	}
+
+	parent, _, pi, err := vfs.searchNode(name, slmLstat)
+	if !vfs.isNotExist(err) || !pi.IsLast() {
+		return &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	parent.mu.Lock()
+	defer parent.mu.Unlock()
+
+	if !parent.checkPermission(avfs.OpenWrite, vfs.User()) {
+		return &fs.PathError{Op: op, Path: name, Err: vfs.err.PermDenied}
+	}
+
+	if !vfs.allocInode() {
+		return &fs.PathError{Op: op, Path: name, Err: vfs.err.NoSpaceLeft}
+	}
+
+	fn := vfs.createFile(parent, pi.Part(), mode&avfs.FileModeMask)
+	fn.mode |= typ
+
+	return nil
+}
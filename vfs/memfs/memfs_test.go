@@ -19,8 +19,20 @@
 package memfs_test
 
 import (
+	"bytes"
+	"errors"
+	"io"
 	"io/fs"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
 	"github.com/avfs/avfs"
 	"github.com/avfs/avfs/idm/memidm"
@@ -32,12 +44,48 @@ var (
 	// Tests that memfs.MemFS struct implements avfs.VFS interface.
 	_ avfs.VFS = &memfs.MemFS{}
 
+	// Tests that memfs.MemFS struct implements avfs.Cloner interface.
+	_ avfs.Cloner = &memfs.MemFS{}
+
 	// Tests that memfs.MemFS struct implements avfs.VFSBase interface.
 	_ avfs.VFSBase = &memfs.MemFS{}
 
 	// Tests that memfs.MemFile struct implements avfs.File interface.
 	_ avfs.File = &memfs.MemFile{}
 
+	// Tests that memfs.MemFile struct implements avfs.OpenFlagger interface.
+	_ avfs.OpenFlagger = &memfs.MemFile{}
+
+	// Tests that memfs.MemFile struct implements avfs.DirFile interface.
+	_ avfs.DirFile = &memfs.MemFile{}
+
+	// Tests that memfs.MemFile struct implements avfs.UnsortedDirReader interface.
+	_ avfs.UnsortedDirReader = &memfs.MemFile{}
+
+	// Tests that memfs.MemFile struct implements avfs.Fallocator interface.
+	_ avfs.Fallocator = &memfs.MemFile{}
+
+	// Tests that memfs.MemFile struct implements avfs.FileDuper interface.
+	_ avfs.FileDuper = &memfs.MemFile{}
+
+	// Tests that memfs.MemFS struct implements avfs.TmpFileLinker interface.
+	_ avfs.TmpFileLinker = &memfs.MemFS{}
+
+	// Tests that memfs.MemFS struct implements avfs.FlagRenamer interface.
+	_ avfs.FlagRenamer = &memfs.MemFS{}
+
+	// Tests that memfs.MemFS struct implements avfs.SpecialFileMaker interface.
+	_ avfs.SpecialFileMaker = &memfs.MemFS{}
+
+	// Tests that memfs.MemFS struct implements avfs.RandomNamer interface.
+	_ avfs.RandomNamer = &memfs.MemFS{}
+
+	// Tests that memfs.MemIOFS struct implements fs.GlobFS interface.
+	_ fs.GlobFS = &memfs.MemIOFS{}
+
+	// Tests that memfs.MemIOFS struct implements fs.SubFS interface.
+	_ fs.SubFS = &memfs.MemIOFS{}
+
 	// Tests that memfs.MemInfo struct implements fs.DirEntry interface.
 	_ fs.DirEntry = &memfs.MemInfo{}
 
@@ -56,6 +104,10 @@ func TestMemFS(t *testing.T) {
 
 	ts := test.NewSuiteFS(t, vfs, vfs)
 	ts.TestVFSAll(t)
+
+	if err := vfs.Check(); err != nil {
+		t.Errorf("Check : want error to be nil, got %v", err)
+	}
 }
 
 func TestMemFSWithNoIdm(t *testing.T) {
@@ -118,11 +170,69 @@ func TestMemFSNilPtrFile(t *testing.T) {
 	test.FileNilPtr(t, f)
 }
 
+func TestMemFSCloneCurDir(t *testing.T) {
+	vfs := memfs.New()
+	vfsCloned := vfs.Clone()
+
+	if _, ok := vfsCloned.(avfs.Cloner); !ok {
+		t.Fatalf("Clone : want cloned vfs to be of type avfs.Cloner, got type %T", vfsCloned)
+	}
+
+	wantDir, err := vfs.Getwd()
+	test.RequireNoError(t, err, "Getwd")
+
+	tmpDir := vfs.TempDir()
+
+	err = vfsCloned.Chdir(tmpDir)
+	test.RequireNoError(t, err, "Chdir %s", tmpDir)
+
+	dir, err := vfs.Getwd()
+	test.RequireNoError(t, err, "Getwd")
+
+	if dir != wantDir {
+		t.Errorf("Getwd : want original current directory to be %s, got %s", wantDir, dir)
+	}
+
+	dir, err = vfsCloned.Getwd()
+	test.RequireNoError(t, err, "Getwd")
+
+	if dir != tmpDir {
+		t.Errorf("Getwd : want cloned current directory to be %s, got %s", tmpDir, dir)
+	}
+
+	const relPath = "file"
+
+	absPath, err := vfs.Abs(relPath)
+	test.RequireNoError(t, err, "Abs %s", relPath)
+
+	absPathCloned, err := vfsCloned.Abs(relPath)
+	test.RequireNoError(t, err, "Abs %s", relPath)
+
+	if absPath == absPathCloned {
+		t.Errorf("Abs %s : want paths to differ, got %s for both", relPath, absPath)
+	}
+
+	err = vfsCloned.WriteFile(relPath, nil, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", relPath)
+
+	_, err = vfs.Stat(absPathCloned)
+	test.RequireNoError(t, err, "Stat %s", absPathCloned)
+
+	_, err = vfs.Stat(relPath)
+	test.AssertPathError(t, err).Op("stat").Path(relPath).Err(avfs.ErrNoSuchFileOrDir).OSType(avfs.OsLinux).Test()
+}
+
 func TestMemFSConfig(t *testing.T) {
 	vfs := memfs.New()
 
 	wantFeatures := avfs.FeatHardlink | avfs.FeatSubFS | avfs.FeatSymlink | avfs.FeatIdentityMgr |
+		avfs.FeatXattr | avfs.FeatDeadline | avfs.FeatOpenat | avfs.FeatTmpFile | avfs.FeatRenameFlags |
+		avfs.FeatSpecialFiles | avfs.FeatStatFS | avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup |
 		avfs.BuildFeatures()
+	if vfs.OSType() == avfs.OsWindows {
+		wantFeatures &^= avfs.FeatLchmod | avfs.FeatSpecialFiles
+	}
+
 	if vfs.Features() != wantFeatures {
 		t.Errorf("Features : want Features to be %s, got %s", wantFeatures, vfs.Features())
 	}
@@ -138,9 +248,2524 @@ func TestMemFSConfig(t *testing.T) {
 	}
 }
 
-func BenchmarkMemFSAll(b *testing.B) {
+func TestMemFSToIOFS(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, SystemDirs: []avfs.DirInfo{}})
+
+	files := []string{"a.txt", "dir/b.txt", "dir/sub/c.go", "dir/sub/d.txt", "empty/.keep"}
+	for _, file := range files {
+		path := "/" + file
+
+		err := vfs.MkdirAll(vfs.Dir(path), avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAll %s", path)
+
+		err = vfs.WriteFile(path, []byte(file), avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+	}
+
+	iofs := vfs.ToIOFS()
+
+	err := fstest.TestFS(iofs, files...)
+	test.RequireNoError(t, err, "TestFS")
+
+	t.Run("IOFSGlob", func(t *testing.T) {
+		matches, err := fs.Glob(iofs, "dir/*/*.txt")
+		test.RequireNoError(t, err, "Glob")
+
+		if len(matches) != 1 || matches[0] != "dir/sub/d.txt" {
+			t.Errorf("Glob : want matches to be [dir/sub/d.txt], got %v", matches)
+		}
+
+		_, err = iofs.Glob("[")
+		if err == nil {
+			t.Error("Glob : want error for a malformed pattern, got nil")
+		}
+	})
+
+	t.Run("IOFSSub", func(t *testing.T) {
+		sub, err := fs.Sub(iofs, "dir")
+		test.RequireNoError(t, err, "Sub")
+
+		err = fstest.TestFS(sub, "b.txt", "sub/c.go", "sub/d.txt")
+		test.RequireNoError(t, err, "TestFS")
+
+		_, err = fs.Sub(iofs, "/dir")
+		test.AssertPathError(t, err).Op("sub").Path("/dir").Err(fs.ErrInvalid).Test()
+	})
+
+	t.Run("IOFSInvalidPath", func(t *testing.T) {
+		for _, name := range []string{"/a.txt", "dir/../a.txt", "./a.txt", "dir/"} {
+			_, err := iofs.Open(name)
+			test.AssertPathError(t, err).Op("open").Path(name).Err(fs.ErrInvalid).Test()
+		}
+	})
+}
+
+func TestMemFSMaxInodes(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, MaxInodes: 100})
+
+	if free := memfs.New().FreeInodes(); free != -1 {
+		t.Errorf("FreeInodes : want free inodes to be -1 without limit, got %d", free)
+	}
+
+	initialFree := vfs.FreeInodes()
+	dir := "/inodes"
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	free := vfs.FreeInodes()
+	for i := 0; i < free; i++ {
+		path := vfs.Join(dir, "file"+strconv.Itoa(i))
+
+		err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+	}
+
+	if free = vfs.FreeInodes(); free != 0 {
+		t.Errorf("FreeInodes : want free inodes to be 0, got %d", free)
+	}
+
+	t.Run("MaxInodesReached", func(t *testing.T) {
+		path := vfs.Join(dir, "newFile")
+
+		_, err = vfs.Create(path)
+		test.AssertPathError(t, err).Op("open").Path(path).Err(avfs.ErrNoSpaceLeft).Test()
+
+		newDir := vfs.Join(dir, "newDir")
+
+		err = vfs.Mkdir(newDir, avfs.DefaultDirPerm)
+		test.AssertPathError(t, err).Op("mkdir").Path(newDir).Err(avfs.ErrNoSpaceLeft).Test()
+
+		err = vfs.MkdirAll(newDir, avfs.DefaultDirPerm)
+		test.AssertPathError(t, err).Op("mkdir").Path(newDir).Err(avfs.ErrNoSpaceLeft).Test()
+
+		err = vfs.Symlink(path, vfs.Join(dir, "newSymlink"))
+		test.AssertLinkError(t, err).Op("symlink").Err(avfs.ErrNoSpaceLeft).Test()
+
+		// Hard links share the node of their target.
+		link := vfs.Join(dir, "link")
+
+		err = vfs.Link(vfs.Join(dir, "file0"), link)
+		test.RequireNoError(t, err, "Link %s", link)
+	})
+
+	t.Run("MaxInodesFreed", func(t *testing.T) {
+		err = vfs.Remove(vfs.Join(dir, "file0"))
+		test.RequireNoError(t, err, "Remove")
+
+		if free = vfs.FreeInodes(); free != 0 {
+			t.Errorf("FreeInodes : want free inodes to be 0 while a hard link remains, got %d", free)
+		}
+
+		err = vfs.Remove(vfs.Join(dir, "link"))
+		test.RequireNoError(t, err, "Remove")
+
+		path := vfs.Join(dir, "newFile")
+
+		err = vfs.WriteFile(path, []byte("data"), avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		err = vfs.RemoveAll(dir)
+		test.RequireNoError(t, err, "RemoveAll %s", dir)
+
+		if free = vfs.FreeInodes(); free != initialFree {
+			t.Errorf("FreeInodes : want free inodes to be %d, got %d", initialFree, free)
+		}
+	})
+}
+
+func TestMemFSNameTooLong(t *testing.T) {
+	for _, osType := range []avfs.OSType{avfs.OsLinux, avfs.OsWindows} {
+		// Without the avfs_setostype build tag, the OS type of the host is used.
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: osType})
+		if vfs.OSType() != osType {
+			continue
+		}
+
+		root := string(vfs.PathSeparator())
+		wantErr := error(avfs.ErrNameTooLong)
+
+		if osType == avfs.OsWindows {
+			root = avfs.DefaultVolume + root
+			wantErr = avfs.ErrWinFilenameExcedRange
+		}
+
+		t.Run("NameMax"+osType.String(), func(t *testing.T) {
+			existing := vfs.Join(root, "existing")
+
+			err := vfs.WriteFile(existing, nil, avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", existing)
+
+			name := vfs.Join(root, strings.Repeat("a", 255))
+
+			err = vfs.WriteFile(name, nil, avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", name)
+
+			tooLong := vfs.Join(root, strings.Repeat("a", 256))
+
+			err = vfs.Mkdir(tooLong, avfs.DefaultDirPerm)
+			test.AssertPathError(t, err).Op("mkdir").Path(tooLong).Err(wantErr).Test()
+
+			err = vfs.MkdirAll(vfs.Join(tooLong, "sub"), avfs.DefaultDirPerm)
+			test.AssertPathError(t, err).Op("mkdir").Err(wantErr).Test()
+
+			err = vfs.MkdirAll(vfs.Join(root, "dir", strings.Repeat("b", 256)), avfs.DefaultDirPerm)
+			test.AssertPathError(t, err).Op("mkdir").Err(wantErr).Test()
+
+			_, err = vfs.Create(tooLong)
+			test.AssertPathError(t, err).Op("open").Path(tooLong).Err(wantErr).Test()
+
+			_, err = vfs.OpenFile(tooLong, os.O_RDONLY, 0)
+			test.AssertPathError(t, err).Op("open").Path(tooLong).Err(wantErr).Test()
+
+			err = vfs.Rename(existing, tooLong)
+			test.AssertLinkError(t, err).Op("rename").Old(existing).New(tooLong).Err(wantErr).Test()
+
+			err = vfs.Symlink(existing, tooLong)
+			test.AssertLinkError(t, err).Op("symlink").Old(existing).New(tooLong).Err(wantErr).Test()
+
+			err = vfs.Link(existing, tooLong)
+			test.AssertLinkError(t, err).Op("link").Old(existing).New(tooLong).Err(wantErr).Test()
+		})
+	}
+
+	t.Run("CustomLimits", func(t *testing.T) {
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, NameMax: 8, PathMax: 16})
+
+		err := vfs.Mkdir("/12345678", avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "Mkdir")
+
+		err = vfs.Mkdir("/123456789", avfs.DefaultDirPerm)
+		test.AssertPathError(t, err).Op("mkdir").Err(avfs.ErrNameTooLong).Test()
+
+		err = vfs.Mkdir("/12345678/123456", avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "Mkdir")
+
+		path := "/12345678/123456/a"
+
+		err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("open").Path(path).Err(avfs.ErrNameTooLong).Test()
+
+		err = vfs.Symlink("/12345678/123456/a", "/b")
+		test.AssertLinkError(t, err).Op("symlink").Err(avfs.ErrNameTooLong).Test()
+	})
+}
+
+func TestMemFSClock(t *testing.T) {
+	clockTime := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		clockTime = clockTime.Add(time.Second)
+
+		return clockTime
+	}
+
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, Clock: clock})
+
+	assertModTime := func(t *testing.T, name string) {
+		t.Helper()
+
+		info, err := vfs.Stat(name)
+		test.RequireNoError(t, err, "Stat %s", name)
+
+		if !info.ModTime().Equal(clockTime) {
+			t.Errorf("ModTime %s : want mtime to be %v, got %v", name, clockTime, info.ModTime())
+		}
+	}
+
+	dir := "/clock"
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+	assertModTime(t, dir)
+
+	path := vfs.Join(dir, "file.txt")
+
+	f, err := vfs.Create(path)
+	test.RequireNoError(t, err, "Create %s", path)
+
+	defer f.Close()
+
+	assertModTime(t, path)
+	assertModTime(t, dir)
+
+	_, err = f.Write([]byte("write"))
+	test.RequireNoError(t, err, "Write %s", path)
+	assertModTime(t, path)
+
+	_, err = f.WriteAt([]byte("writeAt"), 2)
+	test.RequireNoError(t, err, "WriteAt %s", path)
+	assertModTime(t, path)
+
+	err = f.Truncate(3)
+	test.RequireNoError(t, err, "Truncate %s", path)
+	assertModTime(t, path)
+
+	err = vfs.Truncate(path, 1)
+	test.RequireNoError(t, err, "Truncate %s", path)
+	assertModTime(t, path)
+
+	err = vfs.Remove(path)
+	test.RequireNoError(t, err, "Remove %s", path)
+	assertModTime(t, dir)
+}
+
+func TestMemFSOpenat(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	dir := "/a/b"
+
+	err := vfs.MkdirAll(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "MkdirAll %s", dir)
+
+	f, err := vfs.Open(dir)
+	test.RequireNoError(t, err, "Open %s", dir)
+
+	defer f.Close()
+
+	df, ok := f.(avfs.DirFile)
+	if !ok {
+		t.Fatalf("Open : want %s to implement avfs.DirFile", dir)
+	}
+
+	// Renaming an ancestor of the directory doesn't change the resolution of names.
+	err = vfs.Rename("/a", "/c")
+	test.RequireNoError(t, err, "Rename")
+
+	t.Run("OpenFileAt", func(t *testing.T) {
+		data := []byte("openat")
+
+		nf, err := df.OpenFileAt("file.txt", os.O_CREATE|os.O_WRONLY, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "OpenFileAt")
+
+		_, err = nf.Write(data)
+		test.RequireNoError(t, err, "Write")
+
+		err = nf.Close()
+		test.RequireNoError(t, err, "Close")
+
+		path := "/c/b/file.txt"
+
+		content, err := vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("ReadFile : want content to be %s, got %s", data, content)
+		}
+	})
+
+	t.Run("MkdirAt", func(t *testing.T) {
+		err = df.MkdirAt("sub", avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAt")
+
+		info, err := df.StatAt("sub")
+		test.RequireNoError(t, err, "StatAt")
+
+		if !info.IsDir() {
+			t.Errorf("StatAt : want sub to be a directory, got %s", info.Mode())
+		}
+
+		_, err = vfs.Stat("/c/b/sub")
+		test.RequireNoError(t, err, "Stat")
+
+		err = df.MkdirAt("sub", avfs.DefaultDirPerm)
+		test.AssertPathError(t, err).Op("mkdir").Path("sub").Err(avfs.ErrFileExists).Test()
+	})
+
+	t.Run("OpenFileAtName", func(t *testing.T) {
+		nf, err := df.OpenFileAt("file.txt", os.O_RDONLY, 0)
+		test.RequireNoError(t, err, "OpenFileAt")
+
+		defer nf.Close()
+
+		if want, got := "/a/b/file.txt", nf.Name(); got != want {
+			t.Errorf("Name : want name to be %s, got %s", want, got)
+		}
+	})
+
+	t.Run("StatAtParent", func(t *testing.T) {
+		info, err := df.StatAt("../b")
+		test.RequireNoError(t, err, "StatAt")
+
+		if !info.IsDir() || info.Name() != "b" {
+			t.Errorf("StatAt : want ../b to be the directory b, got %s %s", info.Name(), info.Mode())
+		}
+	})
+
+	t.Run("StatAtSymlink", func(t *testing.T) {
+		link := "/c/b/link"
+
+		err := vfs.Symlink("../b/sub", link)
+		test.RequireNoError(t, err, "Symlink %s", link)
+
+		info, err := df.StatAt("link")
+		test.RequireNoError(t, err, "StatAt")
+
+		if !info.IsDir() || info.Name() != "link" {
+			t.Errorf("StatAt : want link to be a directory named link, got %s %s", info.Name(), info.Mode())
+		}
+
+		_, err = df.StatAt("link/../file.txt")
+		test.RequireNoError(t, err, "StatAt")
+	})
+
+	t.Run("StatAtNonExisting", func(t *testing.T) {
+		_, err = df.StatAt("nonExisting")
+		test.AssertPathError(t, err).Op("stat").Path("nonExisting").Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+
+	t.Run("OpenFileAtNotADirectory", func(t *testing.T) {
+		path := "/c/b/file.txt"
+
+		ff, err := vfs.Open(path)
+		test.RequireNoError(t, err, "Open %s", path)
+
+		defer ff.Close()
+
+		_, err = ff.(avfs.DirFile).OpenFileAt("file", os.O_RDONLY, 0)
+		test.AssertPathError(t, err).Op("openat").Path(path).Err(avfs.ErrNotADirectory).Test()
+	})
+
+	t.Run("OpenFileAtClosed", func(t *testing.T) {
+		cf, err := vfs.Open("/c")
+		test.RequireNoError(t, err, "Open")
+
+		err = cf.Close()
+		test.RequireNoError(t, err, "Close")
+
+		_, err = cf.(avfs.DirFile).StatAt("b")
+		test.AssertPathError(t, err).Op("fstatat").Path("/c").Err(fs.ErrClosed).Test()
+	})
+
+	t.Run("StatAtRemoved", func(t *testing.T) {
+		path := "/c/removed"
+
+		err := vfs.Mkdir(path, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "Mkdir %s", path)
+
+		rf, err := vfs.Open(path)
+		test.RequireNoError(t, err, "Open %s", path)
+
+		defer rf.Close()
+
+		err = vfs.Remove(path)
+		test.RequireNoError(t, err, "Remove %s", path)
+
+		_, err = rf.(avfs.DirFile).StatAt("file")
+		test.AssertPathError(t, err).Op("stat").Path("file").Err(avfs.ErrNoSuchFileOrDir).Test()
+
+		_, err = rf.(avfs.DirFile).StatAt("../c")
+		test.AssertPathError(t, err).Op("stat").Path("../c").Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+}
+
+func TestMemFSAbs(t *testing.T) {
+	for _, osType := range []avfs.OSType{avfs.OsLinux, avfs.OsWindows} {
+		// Without the avfs_setostype build tag, the OS type of the host is used.
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: osType})
+		if vfs.OSType() != osType {
+			continue
+		}
+
+		curDir := "/home/user"
+		tests := []struct {
+			path, want string
+		}{
+			{path: "", want: "/home/user"},
+			{path: "rel", want: "/home/user/rel"},
+			{path: "rel/../other", want: "/home/user/other"},
+			{path: ".", want: "/home/user"},
+			{path: "..", want: "/home"},
+			{path: "../../..", want: "/"},
+			{path: "/abs/./dir/..", want: "/abs"},
+		}
+
+		if osType == avfs.OsWindows {
+			curDir = `C:\home`
+			tests = []struct {
+				path, want string
+			}{
+				{path: "", want: `C:\home`},
+				{path: "rel", want: `C:\home\rel`},
+				{path: "rel/sub", want: `C:\home\rel\sub`},
+				{path: ".", want: `C:\home`},
+				{path: "..", want: `C:\`},
+				{path: `\abs`, want: `C:\abs`},
+				{path: `C:\abs\.\dir\..`, want: `C:\abs`},
+			}
+		}
+
+		err := vfs.MkdirAll(curDir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAll %s", curDir)
+
+		err = vfs.Chdir(curDir)
+		test.RequireNoError(t, err, "Chdir %s", curDir)
+
+		t.Run("Abs"+osType.String(), func(t *testing.T) {
+			for _, tt := range tests {
+				got, err := vfs.Abs(tt.path)
+				test.RequireNoError(t, err, "Abs %s", tt.path)
+
+				if got != tt.want {
+					t.Errorf("Abs %q : want %q, got %q", tt.path, tt.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestMemFSDotPaths(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+	if vfs.OSType() != avfs.OsLinux {
+		t.Skip("TestMemFSDotPaths needs a Linux OS type")
+	}
+
+	const (
+		parentDir  = "/dot"
+		curDir     = "/dot/cur"
+		siblingDir = "/dot/sibling"
+		file       = "/dot/cur/file"
+	)
+
+	for _, dir := range []string{curDir, siblingDir} {
+		err := vfs.MkdirAll(dir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAll %s", dir)
+	}
+
+	err := vfs.WriteFile(file, []byte("dot"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", file)
+
+	err = vfs.Chdir(curDir)
+	test.RequireNoError(t, err, "Chdir %s", curDir)
+
+	tests := []struct {
+		path, want string
+	}{
+		{path: ".", want: curDir},
+		{path: "./", want: curDir},
+		{path: "..", want: parentDir},
+		{path: "../sibling", want: siblingDir},
+		{path: "./../cur/./file", want: file},
+		{path: "./file", want: file},
+		{path: "../../../..", want: "/"},
+		{path: "/..", want: "/"},
+		{path: "/../dot/./cur/..", want: parentDir},
+	}
+
+	for _, tt := range tests {
+		info, err := vfs.Stat(tt.path)
+		test.RequireNoError(t, err, "Stat %s", tt.path)
+
+		wantInfo, err := vfs.Stat(tt.want)
+		test.RequireNoError(t, err, "Stat %s", tt.want)
+
+		if !vfs.SameFile(info, wantInfo) {
+			t.Errorf("Stat %q : want the same file as %s", tt.path, tt.want)
+		}
+	}
+
+	t.Run("OpenDot", func(t *testing.T) {
+		f, err := vfs.OpenFile(".", os.O_RDONLY, 0)
+		test.RequireNoError(t, err, "Open .")
+
+		defer f.Close()
+
+		names, err := f.Readdirnames(-1)
+		test.RequireNoError(t, err, "Readdirnames .")
+
+		if len(names) != 1 || names[0] != "file" {
+			t.Errorf("Readdirnames . : want [file], got %v", names)
+		}
+	})
+
+	t.Run("OpenDotFile", func(t *testing.T) {
+		f, err := vfs.OpenFile("./file", os.O_RDONLY, 0)
+		test.RequireNoError(t, err, "Open ./file")
+
+		defer f.Close()
+
+		data, err := io.ReadAll(f)
+		test.RequireNoError(t, err, "ReadAll ./file")
+
+		if string(data) != "dot" {
+			t.Errorf("ReadAll ./file : want content to be %q, got %q", "dot", data)
+		}
+	})
+}
+
+func TestMemFSReadableDirs(t *testing.T) {
+	names := []string{"c", "a", "b"}
+
+	setup := func(t *testing.T, vfs *memfs.MemFS) string {
+		dir := vfs.Join(vfs.TempDir(), "readable")
+
+		err := vfs.MkdirAll(dir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAll %s", dir)
+
+		for _, name := range names {
+			path := vfs.Join(dir, name)
+
+			err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", path)
+		}
+
+		return dir
+	}
+
+	t.Run("ReadableDirs", func(t *testing.T) {
+		vfs := memfs.NewWithOptions(&memfs.Options{ReadableDirs: true})
+		dir := setup(t, vfs)
+
+		f, err := vfs.OpenFile(dir, os.O_RDONLY, 0)
+		test.RequireNoError(t, err, "OpenFile %s", dir)
+
+		defer f.Close()
+
+		buf, err := io.ReadAll(f)
+		test.RequireNoError(t, err, "ReadAll %s", dir)
+
+		if want := "a\nb\nc"; string(buf) != want {
+			t.Errorf("Read : want content to be %q, got %q", want, buf)
+		}
+
+		entries, err := f.ReadDir(-1)
+		test.RequireNoError(t, err, "ReadDir %s", dir)
+
+		if len(entries) != len(names) {
+			t.Errorf("ReadDir : want %d entries, got %d", len(names), len(entries))
+		}
+	})
+
+	t.Run("NotReadableDirs", func(t *testing.T) {
+		vfs := memfs.New()
+		dir := setup(t, vfs)
+
+		f, err := vfs.OpenFile(dir, os.O_RDONLY, 0)
+		test.RequireNoError(t, err, "OpenFile %s", dir)
+
+		defer f.Close()
+
+		buf := make([]byte, 8)
+
+		_, err = f.Read(buf)
+		test.AssertPathError(t, err).Op("read").Path(dir).
+			OSType(avfs.OsLinux).Err(avfs.ErrIsADirectory).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinIncorrectFunc).Test()
+	})
+}
+
+func TestMemFSPosixRename(t *testing.T) {
+	setup := func(t *testing.T, vfs *memfs.MemFS) (src, emptyDir, nonEmptyDir, file string) {
+		tmpDir := vfs.TempDir()
+		src = vfs.Join(tmpDir, "src")
+		emptyDir = vfs.Join(tmpDir, "empty")
+		nonEmptyDir = vfs.Join(tmpDir, "nonEmpty")
+		file = vfs.Join(tmpDir, "file")
+
+		for _, dir := range []string{src, emptyDir, nonEmptyDir} {
+			err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+			test.RequireNoError(t, err, "Mkdir %s", dir)
+		}
+
+		for _, path := range []string{vfs.Join(src, "srcFile"), vfs.Join(nonEmptyDir, "nonEmptyFile"), file} {
+			err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", path)
+		}
+
+		return src, emptyDir, nonEmptyDir, file
+	}
+
+	t.Run("EmptyDir", func(t *testing.T) {
+		vfs := memfs.NewWithOptions(&memfs.Options{PosixRename: true})
+		src, emptyDir, _, _ := setup(t, vfs)
+
+		err := vfs.Rename(src, emptyDir)
+		test.RequireNoError(t, err, "Rename %s %s", src, emptyDir)
+
+		_, err = vfs.Stat(src)
+		test.AssertPathError(t, err).OpStat().Path(src).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+
+		path := vfs.Join(emptyDir, "srcFile")
+
+		_, err = vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+	})
+
+	t.Run("NonEmptyDir", func(t *testing.T) {
+		vfs := memfs.NewWithOptions(&memfs.Options{PosixRename: true})
+		src, _, nonEmptyDir, _ := setup(t, vfs)
+
+		err := vfs.Rename(src, nonEmptyDir)
+		test.AssertLinkError(t, err).Op("rename").Old(src).New(nonEmptyDir).
+			OSType(avfs.OsLinux).Err(avfs.ErrDirNotEmpty).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinDirNotEmpty).Test()
+	})
+
+	t.Run("File", func(t *testing.T) {
+		vfs := memfs.NewWithOptions(&memfs.Options{PosixRename: true})
+		src, _, _, file := setup(t, vfs)
+
+		err := vfs.Rename(src, file)
+		test.AssertLinkError(t, err).Op("rename").Old(src).New(file).
+			OSType(avfs.OsLinux).Err(avfs.ErrNotADirectory).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinPathNotFound).Test()
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		vfs := memfs.New()
+		src, emptyDir, _, _ := setup(t, vfs)
+
+		err := vfs.Rename(src, emptyDir)
+		test.AssertLinkError(t, err).Op("rename").Old(src).New(emptyDir).
+			OSType(avfs.OsLinux).Err(avfs.ErrFileExists).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+	})
+}
+
+func TestMemFSDup(t *testing.T) {
 	vfs := memfs.New()
+	path := vfs.Join(vfs.TempDir(), "dup")
+	content := []byte("0123456789")
 
-	ts := test.NewSuiteFS(b, vfs, vfs)
-	ts.BenchAll(b)
+	err := vfs.WriteFile(path, content, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	f, err := vfs.OpenFile(path, os.O_RDONLY, 0)
+	test.RequireNoError(t, err, "OpenFile %s", path)
+
+	df, err := f.(avfs.FileDuper).Dup()
+	test.RequireNoError(t, err, "Dup %s", path)
+
+	if df.Fd() == f.Fd() {
+		t.Errorf("Dup : want a new file descriptor, got %d", df.Fd())
+	}
+
+	var got []byte
+
+	b := make([]byte, 1)
+
+	for i := range len(content) {
+		rf := f
+		if i%2 == 1 {
+			rf = df
+		}
+
+		n, err := rf.Read(b)
+		test.RequireNoError(t, err, "Read %s", path)
+
+		got = append(got, b[:n]...)
+	}
+
+	if !bytes.Equal(got, content) {
+		t.Errorf("Read : want alternate reads to return %q, got %q", content, got)
+	}
+
+	_, err = f.Read(b)
+	if err != io.EOF {
+		t.Errorf("Read : want error to be %v, got %v", io.EOF, err)
+	}
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close %s", path)
+
+	_, err = df.Seek(0, io.SeekStart)
+	test.RequireNoError(t, err, "Seek %s", path)
+
+	_, err = df.Read(b)
+	test.RequireNoError(t, err, "Read %s", path)
+
+	fd := df.Fd()
+
+	err = df.Close()
+	test.RequireNoError(t, err, "Close %s", path)
+
+	_, err = vfs.FileFromFd(fd)
+	test.AssertPathError(t, err).Op("fd").
+		OSType(avfs.OsLinux).Err(avfs.ErrBadFileDesc).Test().
+		OSType(avfs.OsWindows).Err(avfs.ErrWinAccessDenied).Test()
+
+	_, err = df.(avfs.FileDuper).Dup()
+	test.AssertPathError(t, err).Op("dup").Path(path).Err(fs.ErrClosed).Test()
+}
+
+func TestMemFSSetOSType(t *testing.T) {
+	vfs := memfs.New()
+	if !vfs.HasFeature(avfs.FeatSetOSType) {
+		t.Skip("SetOSType : the OS type can't be changed without the avfs_setostype build tag, skipping test")
+	}
+
+	fromOSType, toOSType := vfs.OSType(), avfs.OsWindows
+	if fromOSType == avfs.OsWindows {
+		toOSType = avfs.OsLinux
+	}
+
+	err := vfs.SetOSType(toOSType)
+	test.RequireNoError(t, err, "SetOSType %s", toOSType)
+
+	if vfs.OSType() != toOSType {
+		t.Errorf("OSType : want OS type to be %s, got %s", toOSType, vfs.OSType())
+	}
+
+	wantSep := uint8('/')
+	if toOSType == avfs.OsWindows {
+		wantSep = '\\'
+	}
+
+	if vfs.PathSeparator() != wantSep {
+		t.Errorf("PathSeparator : want separator to be %c, got %c", wantSep, vfs.PathSeparator())
+	}
+
+	if vfs.HasFeature(avfs.FeatLchmod) == (toOSType == avfs.OsWindows) {
+		t.Errorf("Features : want FeatLchmod only on Linux, got %s", vfs.Features())
+	}
+
+	tmpDir := vfs.Join(avfs.FromUnixPath(vfs, "/"), "avfs")
+
+	err = vfs.Mkdir(tmpDir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", tmpDir)
+
+	path := vfs.Join(tmpDir, "nonExisting")
+
+	wantErr := error(avfs.ErrNoSuchFileOrDir)
+	if toOSType == avfs.OsWindows {
+		wantErr = avfs.ErrWinFileNotFound
+	}
+
+	_, err = vfs.Stat(path)
+	if !errors.Is(err, wantErr) {
+		t.Errorf("Stat : want error to be %v, got %v", wantErr, err)
+	}
+
+	err = vfs.SetOSType(fromOSType)
+	if err != memfs.ErrSetOSTypeNotEmpty {
+		t.Errorf("SetOSType : want error to be %v, got %v", memfs.ErrSetOSTypeNotEmpty, err)
+	}
+
+	err = vfs.Remove(tmpDir)
+	test.RequireNoError(t, err, "Remove %s", tmpDir)
+
+	err = vfs.SetOSType(fromOSType)
+	test.RequireNoError(t, err, "SetOSType %s", fromOSType)
+
+	if vfs.OSType() != fromOSType {
+		t.Errorf("OSType : want OS type to be %s, got %s", fromOSType, vfs.OSType())
+	}
+}
+
+func TestMemFSDiff(t *testing.T) {
+	if avfs.CurrentOSType() == avfs.OsWindows {
+		t.Skip("Diff test uses Linux paths")
+	}
+
+	const root = "/sync"
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	a := memfs.NewWithOptions(&memfs.Options{
+		OSType: avfs.OsLinux,
+		InitialFiles: map[string]memfs.MapFile{
+			"/sync/d1/same":    {Data: []byte("same"), ModTime: modTime},
+			"/sync/d1/content": {Data: []byte("old"), ModTime: modTime},
+			"/sync/d1/mode":    {Data: []byte("mode"), Mode: 0o644, ModTime: modTime},
+			"/sync/gone/file":  {Data: []byte("gone")},
+			"/sync/type":       {Data: []byte("file")},
+		},
+	})
+
+	b := memfs.NewWithOptions(&memfs.Options{
+		OSType: avfs.OsLinux,
+		InitialFiles: map[string]memfs.MapFile{
+			"/sync/d1/same":    {Data: []byte("same"), ModTime: modTime},
+			"/sync/d1/content": {Data: []byte("new content"), ModTime: modTime},
+			"/sync/d1/mode":    {Data: []byte("mode"), Mode: 0o600, ModTime: modTime},
+			"/sync/d1/created": {Data: []byte("created")},
+			"/sync/new/sub/f":  {Data: []byte("nested")},
+			"/sync/ro/file":    {Data: []byte("read only")},
+			"/sync/type/file":  {Data: []byte("dir")},
+		},
+	})
+
+	for _, vfs := range []*memfs.MemFS{a, b} {
+		err := vfs.Symlink("d1/same", "/sync/link")
+		test.RequireNoError(t, err, "Symlink")
+	}
+
+	err := b.Remove("/sync/link")
+	test.RequireNoError(t, err, "Remove")
+
+	err = b.Symlink("d1/content", "/sync/link")
+	test.RequireNoError(t, err, "Symlink")
+
+	err = b.Mkfifo("/sync/fifo", 0o600)
+	test.RequireNoError(t, err, "Mkfifo")
+
+	err = b.Chmod("/sync/ro", 0o555)
+	test.RequireNoError(t, err, "Chmod")
+
+	changes, err := memfs.Diff(a, b, root)
+	test.RequireNoError(t, err, "Diff")
+
+	ops := make(map[string]memfs.ChangeOp)
+	for _, c := range changes {
+		ops[c.Path] = c.Op
+	}
+
+	for path, wantOp := range map[string]memfs.ChangeOp{
+		"/sync/gone":       memfs.ChangeDelete,
+		"/sync/type":       memfs.ChangeCreate,
+		"/sync/link":       memfs.ChangeCreate,
+		"/sync/d1/content": memfs.ChangeUpdate,
+		"/sync/d1/mode":    memfs.ChangeUpdate,
+		"/sync/d1/created": memfs.ChangeCreate,
+		"/sync/ro":         memfs.ChangeCreate,
+		"/sync/fifo":       memfs.ChangeCreate,
+	} {
+		if ops[path] != wantOp {
+			t.Errorf("Diff %s : want op to be %d, got %d", path, wantOp, ops[path])
+		}
+	}
+
+	for _, path := range []string{"/sync/d1/same", "/sync/gone/file"} {
+		if op, ok := ops[path]; ok {
+			t.Errorf("Diff %s : want no change, got op %d", path, op)
+		}
+	}
+
+	err = memfs.ApplyChanges(a, changes)
+	test.RequireNoError(t, err, "ApplyChanges")
+
+	test.EqualTrees(t, a, root, b, root, test.CompareOptions{})
+
+	changes, err = memfs.Diff(a, b, root)
+	test.RequireNoError(t, err, "Diff")
+
+	if len(changes) != 0 {
+		t.Errorf("Diff : want no changes after ApplyChanges, got %v", changes)
+	}
+}
+
+func TestMemFSFileFromFd(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+	dir := vfs.TempDir()
+
+	open := func(t *testing.T, name string) avfs.File {
+		t.Helper()
+
+		path := vfs.Join(dir, name)
+
+		f, err := vfs.Create(path)
+		test.RequireNoError(t, err, "Create %s", path)
+
+		return f
+	}
+
+	f1, f2, f3 := open(t, "f1"), open(t, "f2"), open(t, "f3")
+
+	for i, f := range []avfs.File{f1, f2, f3} {
+		if want := uintptr(3 + i); f.Fd() != want {
+			t.Errorf("Fd %s : want fd to be %d, got %d", f.Name(), want, f.Fd())
+		}
+
+		got, err := vfs.FileFromFd(f.Fd())
+		test.RequireNoError(t, err, "FileFromFd %d", f.Fd())
+
+		if got != f {
+			t.Errorf("FileFromFd %d : want file to be %s, got %s", f.Fd(), f.Name(), got.Name())
+		}
+	}
+
+	fd := f1.Fd()
+
+	err := f1.Close()
+	test.RequireNoError(t, err, "Close %s", f1.Name())
+
+	if f1.Fd() != ^uintptr(0) {
+		t.Errorf("Fd : want fd of a closed file to be %d, got %d", ^uintptr(0), f1.Fd())
+	}
+
+	_, err = vfs.FileFromFd(fd)
+	test.AssertPathError(t, err).Op("fd").Path("3").Err(avfs.ErrBadFileDesc).Test()
+
+	f4 := open(t, "f4")
+	if f4.Fd() != fd {
+		t.Errorf("Fd %s : want the freed fd %d to be reused, got %d", f4.Name(), fd, f4.Fd())
+	}
+
+	f5 := open(t, "f5")
+	if want := uintptr(6); f5.Fd() != want {
+		t.Errorf("Fd %s : want fd to be %d, got %d", f5.Name(), want, f5.Fd())
+	}
+
+	for _, f := range []avfs.File{f2, f3, f4, f5} {
+		_ = f.Close()
+	}
+
+	_, err = vfs.FileFromFd(^uintptr(0))
+	test.AssertPathError(t, err).Op("fd").Err(avfs.ErrBadFileDesc).Test()
+}
+
+func TestMemFSRandSource(t *testing.T) {
+	newFS := func() *memfs.MemFS {
+		return memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, RandSource: rand.NewSource(42)})
+	}
+
+	vfs := newFS()
+	dir := vfs.Join(vfs.TempDir(), "rand")
+
+	err := vfs.MkdirAll(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "MkdirAll %s", dir)
+
+	f, err := vfs.CreateTemp(dir, "file-*.txt")
+	test.RequireNoError(t, err, "CreateTemp %s", dir)
+
+	if want := vfs.Join(dir, "file-2524941395.txt"); f.Name() != want {
+		t.Errorf("CreateTemp : want name to be %s, got %s", want, f.Name())
+	}
+
+	_ = f.Close()
+
+	name, err := vfs.MkdirTemp(dir, "dir")
+	test.RequireNoError(t, err, "MkdirTemp %s", dir)
+
+	if want := vfs.Join(dir, "dir2679871259"); name != want {
+		t.Errorf("MkdirTemp : want name to be %s, got %s", want, name)
+	}
+
+	t.Run("RandSourceCollision", func(t *testing.T) {
+		vfs := newFS()
+
+		err := vfs.MkdirAll(dir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "MkdirAll %s", dir)
+
+		// The first generated name already exists, the next value is used.
+		err = vfs.WriteFile(vfs.Join(dir, "2524941395"), nil, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile")
+
+		name, err := vfs.MkdirTemp(dir, "")
+		test.RequireNoError(t, err, "MkdirTemp %s", dir)
+
+		if want := vfs.Join(dir, "2679871259"); name != want {
+			t.Errorf("MkdirTemp : want name to be %s, got %s", want, name)
+		}
+	})
+}
+
+func TestMemFSDirOrder(t *testing.T) {
+	created := []string{"c", "a", "d", "b"}
+	sorted := []string{"a", "b", "c", "d"}
+
+	shuffled := slices.Clone(sorted)
+	rand.New(rand.NewSource(42)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	for _, c := range []struct {
+		order memfs.DirOrder
+		want  []string
+	}{
+		{order: memfs.OrderSorted, want: sorted},
+		{order: memfs.OrderCreation, want: created},
+		{order: memfs.OrderRandom, want: shuffled},
+		{order: memfs.OrderUnsorted, want: sorted},
+	} {
+		vfs := memfs.NewWithOptions(&memfs.Options{DirOrder: c.order, RandSource: rand.NewSource(42)})
+		dir := vfs.Join(vfs.TempDir(), "order")
+
+		err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "Mkdir %s", dir)
+
+		for _, name := range created {
+			path := vfs.Join(dir, name)
+
+			err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", path)
+		}
+
+		f, err := vfs.Open(dir)
+		test.RequireNoError(t, err, "Open %s", dir)
+
+		dirEntries, err := f.ReadDir(-1)
+		test.RequireNoError(t, err, "ReadDir %s", dir)
+
+		_ = f.Close()
+
+		got := make([]string, len(dirEntries))
+		for i, entry := range dirEntries {
+			got[i] = entry.Name()
+		}
+
+		if c.order == memfs.OrderUnsorted {
+			// The order of the directory map is nondeterministic.
+			slices.Sort(got)
+		}
+
+		if !slices.Equal(got, c.want) {
+			t.Errorf("ReadDir %d : want entries to be %v, got %v", c.order, c.want, got)
+		}
+
+		unsorted, err := avfs.ReadDirUnsorted(vfs, dir)
+		test.RequireNoError(t, err, "ReadDirUnsorted %s", dir)
+
+		if len(unsorted) != len(created) {
+			t.Errorf("ReadDirUnsorted %d : want %d entries, got %d", c.order, len(created), len(unsorted))
+		}
+
+		entries, err := vfs.ReadDir(dir)
+		test.RequireNoError(t, err, "ReadDir %s", dir)
+
+		for i, entry := range entries {
+			if entry.Name() != sorted[i] {
+				t.Errorf("ReadDir %d : want MemFS.ReadDir to return sorted entries, got %s at %d", c.order, entry.Name(), i)
+			}
+		}
+	}
+}
+
+func TestMemFSInitialFiles(t *testing.T) {
+	if avfs.CurrentOSType() == avfs.OsWindows {
+		t.Skip("InitialFiles test uses Linux paths")
+	}
+
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	vfs := memfs.NewWithOptions(&memfs.Options{
+		OSType: avfs.OsLinux,
+		InitialContent: map[string][]byte{
+			"/data/a.txt":     []byte("a"),
+			"/data/sub/b.txt": []byte("b"),
+		},
+		InitialFiles: map[string]memfs.MapFile{
+			"/data/c.txt": {Data: []byte("c"), Mode: 0o600, ModTime: modTime},
+			"/data/empty": {Mode: fs.ModeDir | 0o700},
+		},
+	})
+
+	for path, want := range map[string]string{"/data/a.txt": "a", "/data/sub/b.txt": "b", "/data/c.txt": "c"} {
+		buf, err := vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+
+		if string(buf) != want {
+			t.Errorf("ReadFile %s : want content to be %q, got %q", path, want, buf)
+		}
+	}
+
+	info, err := vfs.Stat("/data/c.txt")
+	test.RequireNoError(t, err, "Stat %s", "/data/c.txt")
+
+	if info.Mode() != 0o600 || !info.ModTime().Equal(modTime) {
+		t.Errorf("Stat : want mode %s and mtime %s, got %s and %s", fs.FileMode(0o600), modTime, info.Mode(), info.ModTime())
+	}
+
+	info, err = vfs.Stat("/data/empty")
+	test.RequireNoError(t, err, "Stat %s", "/data/empty")
+
+	if info.Mode() != fs.ModeDir|0o700 {
+		t.Errorf("Stat : want mode %s, got %s", fs.ModeDir|0o700, info.Mode())
+	}
+
+	wantPaths := []string{"/data", "/data/a.txt", "/data/c.txt", "/data/empty", "/data/sub", "/data/sub/b.txt"}
+
+	var gotPaths []string
+
+	err = vfs.WalkDir("/data", func(path string, d fs.DirEntry, err error) error {
+		gotPaths = append(gotPaths, path)
+
+		return err
+	})
+	test.RequireNoError(t, err, "WalkDir %s", "/data")
+
+	if strings.Join(gotPaths, ",") != strings.Join(wantPaths, ",") {
+		t.Errorf("WalkDir : want paths %v, got %v", wantPaths, gotPaths)
+	}
+
+	t.Run("Conflict", func(t *testing.T) {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("NewWithOptions : want a panic for a file used as a directory, got none")
+			}
+		}()
+
+		_ = memfs.NewWithOptions(&memfs.Options{
+			InitialContent: map[string][]byte{
+				"/file":     nil,
+				"/file/sub": nil,
+			},
+		})
+	})
+}
+
+func TestMemFSChmodSpecialBits(t *testing.T) {
+	for _, osType := range []avfs.OSType{avfs.OsLinux, avfs.OsWindows} {
+		// Without the avfs_setostype build tag, the OS type of the host is used.
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: osType})
+		if vfs.OSType() != osType {
+			continue
+		}
+
+		root := string(vfs.PathSeparator())
+		if osType == avfs.OsWindows {
+			root = avfs.DefaultVolume + root
+		}
+
+		path := vfs.Join(root, "special")
+
+		err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		for _, bit := range []fs.FileMode{fs.ModeSetuid, fs.ModeSetgid, fs.ModeSticky} {
+			wantMode := bit | 0o755
+			if osType == avfs.OsWindows {
+				wantMode = 0o755
+			}
+
+			err = vfs.Chmod(path, bit|0o755)
+			test.RequireNoError(t, err, "Chmod %s", path)
+
+			info, err := vfs.Stat(path)
+			test.RequireNoError(t, err, "Stat %s", path)
+
+			if info.Mode() != wantMode {
+				t.Errorf("Stat %s (%s) : want mode to be %s, got %s", path, osType, wantMode, info.Mode())
+			}
+
+			err = vfs.Chmod(path, 0o644)
+			test.RequireNoError(t, err, "Chmod %s", path)
+
+			f, err := vfs.OpenFile(path, os.O_RDONLY, 0)
+			test.RequireNoError(t, err, "OpenFile %s", path)
+
+			err = f.Chmod(bit | 0o755)
+			test.RequireNoError(t, err, "Chmod %s", path)
+
+			info, err = f.Stat()
+			test.RequireNoError(t, err, "Stat %s", path)
+
+			if info.Mode() != wantMode {
+				t.Errorf("File.Stat %s (%s) : want mode to be %s, got %s", path, osType, wantMode, info.Mode())
+			}
+
+			err = f.Close()
+			test.RequireNoError(t, err, "Close %s", path)
+		}
+	}
+}
+
+func TestMemFSDiskUsage(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	assertUsage := func(t *testing.T, wantUsed, wantFiles, wantDirs int64) {
+		t.Helper()
+
+		used, files, dirs := vfs.DiskUsage()
+		if used != wantUsed || files != wantFiles || dirs != wantDirs {
+			t.Errorf("DiskUsage : want (used, files, dirs) to be (%d, %d, %d), got (%d, %d, %d)",
+				wantUsed, wantFiles, wantDirs, used, files, dirs)
+		}
+	}
+
+	_, files0, dirs0 := vfs.DiskUsage()
+
+	dir := "/usage"
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+	assertUsage(t, 0, files0, dirs0+1)
+
+	path := vfs.Join(dir, "file.txt")
+
+	err = vfs.WriteFile(path, []byte("0123456789"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+	assertUsage(t, 10, files0+1, dirs0+1)
+
+	err = vfs.Truncate(path, 1)
+	test.RequireNoError(t, err, "Truncate %s", path)
+	assertUsage(t, 1, files0+1, dirs0+1)
+
+	link := vfs.Join(dir, "link.txt")
+
+	err = vfs.Link(path, link)
+	test.RequireNoError(t, err, "Link %s", link)
+	assertUsage(t, 1, files0+1, dirs0+1)
+
+	t.Run("Blocks", func(t *testing.T) {
+		type blocker interface {
+			Blocks() int64
+			Blksize() int64
+		}
+
+		info, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		sys, ok := info.Sys().(blocker)
+		if !ok {
+			t.Fatalf("Sys : want Sys to implement Blocks and Blksize, got %T", info.Sys())
+		}
+
+		if info.Size() != 1 || sys.Blksize() != 4096 || sys.Blocks() != 8 {
+			t.Errorf("Stat : want (size, blksize, blocks) to be (1, 4096, 8), got (%d, %d, %d)",
+				info.Size(), sys.Blksize(), sys.Blocks())
+		}
+
+		smallFS := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, BlockSize: 512})
+
+		smallPath := "/tmp/small.txt"
+
+		err = smallFS.WriteFile(smallPath, make([]byte, 513), avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", smallPath)
+
+		entries, err := smallFS.ReadDir("/tmp")
+		test.RequireNoError(t, err, "ReadDir")
+
+		entryInfo, err := entries[0].Info()
+		test.RequireNoError(t, err, "Info")
+
+		sys = entryInfo.Sys().(blocker)
+		if sys.Blksize() != 512 || sys.Blocks() != 2 {
+			t.Errorf("ReadDir : want (blksize, blocks) to be (512, 2), got (%d, %d)", sys.Blksize(), sys.Blocks())
+		}
+	})
+
+	err = vfs.Remove(link)
+	test.RequireNoError(t, err, "Remove %s", link)
+	assertUsage(t, 1, files0+1, dirs0+1)
+
+	err = vfs.RemoveAll(dir)
+	test.RequireNoError(t, err, "RemoveAll %s", dir)
+	assertUsage(t, 0, files0, dirs0)
+}
+
+func TestMemFSDedup(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, Dedup: true})
+
+	const size = 1 << 20
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), size/16)
+	path1 := "/tmp/file1"
+	path2 := "/tmp/file2"
+
+	usedBefore, _, _ := vfs.DiskUsage()
+
+	for _, path := range []string{path1, path2} {
+		err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+	}
+
+	used, _, _ := vfs.DiskUsage()
+	if want := usedBefore + size; used != want {
+		t.Errorf("DiskUsage : want used to be %d, got %d", want, used)
+	}
+
+	f, err := vfs.OpenFile(path1, os.O_WRONLY, 0)
+	test.RequireNoError(t, err, "OpenFile %s", path1)
+
+	_, err = f.WriteAt([]byte("modified"), 0)
+	test.RequireNoError(t, err, "WriteAt %s", path1)
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close %s", path1)
+
+	used, _, _ = vfs.DiskUsage()
+	if want := usedBefore + 2*size; used != want {
+		t.Errorf("DiskUsage : want used to be %d, got %d", want, used)
+	}
+
+	content, err := vfs.ReadFile(path2)
+	test.RequireNoError(t, err, "ReadFile %s", path2)
+
+	if !bytes.Equal(content, data) {
+		t.Errorf("ReadFile %s : want content to be unchanged", path2)
+	}
+
+	content, err = vfs.ReadFile(path1)
+	test.RequireNoError(t, err, "ReadFile %s", path1)
+
+	if !bytes.HasPrefix(content, []byte("modified")) || !bytes.Equal(content[8:], data[8:]) {
+		t.Errorf("ReadFile %s : want content to be modified", path1)
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+		for _, path := range []string{path1, path2} {
+			err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", path)
+		}
+
+		used, _, _ := vfs.DiskUsage()
+		if want := usedBefore + 2*size; used != want {
+			t.Errorf("DiskUsage : want used to be %d, got %d", want, used)
+		}
+	})
+}
+
+func TestMemFSCompression(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible content "), 1000)
+	small := []byte("small content")
+
+	for _, algo := range []memfs.CompressionAlgo{memfs.CompressionGzip, memfs.CompressionFlate} {
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, Compression: algo})
+
+		usedBefore, _, _ := vfs.DiskUsage()
+
+		path := "/tmp/compressed.txt"
+
+		err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		info, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		if info.Size() != int64(len(data)) {
+			t.Errorf("Stat %s : want size to be %d, got %d", path, len(data), info.Size())
+		}
+
+		used, _, _ := vfs.DiskUsage()
+		if used -= usedBefore; used <= 0 || used > info.Size()/10 {
+			t.Errorf("DiskUsage : want compressed size to be less than %d, got %d", info.Size()/10, used)
+		}
+
+		content, err := vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+
+		if !bytes.Equal(content, data) {
+			t.Errorf("ReadFile %s : want content to be the original content", path)
+		}
+
+		t.Run("Modify", func(t *testing.T) {
+			f, err := vfs.OpenFile(path, os.O_RDWR, 0)
+			test.RequireNoError(t, err, "OpenFile %s", path)
+
+			_, err = f.WriteAt([]byte("COMPRESSIBLE"), 0)
+			test.RequireNoError(t, err, "WriteAt %s", path)
+
+			if used, _, _ := vfs.DiskUsage(); used-usedBefore != int64(len(data)) {
+				t.Errorf("DiskUsage : want open file to be uncompressed (%d), got %d", len(data), used-usedBefore)
+			}
+
+			err = f.Close()
+			test.RequireNoError(t, err, "Close %s", path)
+
+			want := slices.Clone(data)
+			copy(want, "COMPRESSIBLE")
+
+			content, err := vfs.ReadFile(path)
+			test.RequireNoError(t, err, "ReadFile %s", path)
+
+			if !bytes.Equal(content, want) {
+				t.Errorf("ReadFile %s : want content to be the modified content", path)
+			}
+
+			err = vfs.Truncate(path, 100)
+			test.RequireNoError(t, err, "Truncate %s", path)
+
+			content, err = vfs.ReadFile(path)
+			test.RequireNoError(t, err, "ReadFile %s", path)
+
+			if !bytes.Equal(content, want[:100]) {
+				t.Errorf("ReadFile %s : want content to be %q, got %q", path, want[:100], content)
+			}
+		})
+
+		t.Run("Small", func(t *testing.T) {
+			smallPath := "/tmp/small.txt"
+
+			usedBefore, _, _ := vfs.DiskUsage()
+
+			err := vfs.WriteFile(smallPath, small, avfs.DefaultFilePerm)
+			test.RequireNoError(t, err, "WriteFile %s", smallPath)
+
+			if used, _, _ := vfs.DiskUsage(); used-usedBefore != int64(len(small)) {
+				t.Errorf("DiskUsage : want small file to be stored uncompressed (%d), got %d", len(small), used-usedBefore)
+			}
+		})
+	}
+}
+
+func TestMemFSPermChecks(t *testing.T) {
+	idm := memidm.New()
+	vfs := memfs.NewWithOptions(&memfs.Options{Idm: idm})
+
+	if !vfs.HasFeature(avfs.FeatIdentityMgr) || vfs.OSType() == avfs.OsWindows {
+		t.Skip("permission checks require an identity manager and a Linux OS type")
+	}
+
+	_, err := idm.GroupAdd("users")
+	test.RequireNoError(t, err, "GroupAdd")
+
+	for _, name := range []string{"owner", "other"} {
+		_, err = idm.UserAdd(name, "users")
+		test.RequireNoError(t, err, "UserAdd %s", name)
+	}
+
+	dir := "/tmp/perm"
+	privateFile := vfs.Join(dir, "private")
+	publicFile := vfs.Join(dir, "public")
+
+	err = vfs.SetUserByName("owner")
+	test.RequireNoError(t, err, "SetUserByName")
+
+	err = vfs.Mkdir(dir, 0o755)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	err = vfs.WriteFile(privateFile, []byte("private"), 0o600)
+	test.RequireNoError(t, err, "WriteFile %s", privateFile)
+
+	err = vfs.WriteFile(publicFile, []byte("public"), 0o644)
+	test.RequireNoError(t, err, "WriteFile %s", publicFile)
+
+	err = vfs.SetUserByName("other")
+	test.RequireNoError(t, err, "SetUserByName")
+
+	_, err = vfs.OpenFile(privateFile, os.O_WRONLY, 0)
+	test.AssertPathError(t, err).Op("open").Path(privateFile).ErrPermDenied().Test()
+
+	_, err = vfs.ReadFile(privateFile)
+	test.AssertPathError(t, err).Op("open").Path(privateFile).ErrPermDenied().Test()
+
+	content, err := vfs.ReadFile(publicFile)
+	test.RequireNoError(t, err, "ReadFile %s", publicFile)
+
+	if string(content) != "public" {
+		t.Errorf("ReadFile : want content to be %s, got %s", "public", content)
+	}
+
+	_, err = vfs.OpenFile(publicFile, os.O_WRONLY, 0)
+	test.AssertPathError(t, err).Op("open").Path(publicFile).ErrPermDenied().Test()
+
+	err = vfs.Remove(publicFile)
+	test.AssertPathError(t, err).Op("remove").Path(publicFile).ErrPermDenied().Test()
+
+	newDir := vfs.Join(dir, "new")
+
+	err = vfs.Mkdir(newDir, avfs.DefaultDirPerm)
+	test.AssertPathError(t, err).Op("mkdir").Path(newDir).ErrPermDenied().Test()
+
+	err = vfs.SetUserByName(idm.AdminUser().Name())
+	test.RequireNoError(t, err, "SetUserByName")
+
+	err = vfs.WriteFile(privateFile, []byte("admin"), 0)
+	test.RequireNoError(t, err, "WriteFile %s", privateFile)
+}
+
+func TestMemFSSupplementaryGroups(t *testing.T) {
+	idm := memidm.New()
+	vfs := memfs.NewWithOptions(&memfs.Options{Idm: idm})
+
+	if !vfs.HasFeature(avfs.FeatIdentityMgr) || vfs.OSType() == avfs.OsWindows {
+		t.Skip("supplementary groups require an identity manager and a Linux OS type")
+	}
+
+	g, err := idm.GroupAdd("readers")
+	test.RequireNoError(t, err, "GroupAdd")
+
+	_, err = idm.GroupAdd("others")
+	test.RequireNoError(t, err, "GroupAdd")
+
+	_, err = idm.UserAdd("reader", "others")
+	test.RequireNoError(t, err, "UserAdd")
+
+	path := "/tmp/group-readable"
+
+	err = vfs.WriteFile(path, []byte("data"), 0o640)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	err = vfs.Chown(path, 0, g.Gid())
+	test.RequireNoError(t, err, "Chown %s", path)
+
+	err = vfs.SetUserByName("reader")
+	test.RequireNoError(t, err, "SetUserByName")
+
+	_, err = vfs.ReadFile(path)
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("ReadFile : want error to be %v, got %v", fs.ErrPermission, err)
+	}
+
+	err = idm.AddUserToGroup("reader", g.Name())
+	test.RequireNoError(t, err, "AddUserToGroup")
+
+	_, err = vfs.ReadFile(path)
+	test.RequireNoError(t, err, "ReadFile %s", path)
+}
+
+func TestMemFSOpLog(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OpLogSize: 4})
+
+	if ops := memfs.New().OpLog(); ops != nil {
+		t.Errorf("OpLog : want operation log to be nil when disabled, got %v", ops)
+	}
+
+	dir := vfs.Join(vfs.TempDir(), "oplog")
+	file := vfs.Join(dir, "file")
+	renamed := vfs.Join(dir, "renamed")
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	err = vfs.WriteFile(file, []byte("data"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", file)
+
+	_, err = vfs.ReadFile(file)
+	test.RequireNoError(t, err, "ReadFile %s", file)
+
+	err = vfs.Rename(file, renamed)
+	test.RequireNoError(t, err, "Rename %s", file)
+
+	errRemove := vfs.Remove(file)
+	if errRemove == nil {
+		t.Fatalf("Remove %s : want error, got nil", file)
+	}
+
+	want := []memfs.LoggedOp{
+		{Op: "OpenFile", Path: file},
+		{Op: "Rename", Path: file, NewPath: renamed},
+		{Op: "Remove", Path: file, Err: errRemove},
+	}
+
+	ops := vfs.OpLog()
+	if len(ops) != 4 || !reflect.DeepEqual(ops[1:], want) || ops[0].Op != "Mkdir" {
+		t.Errorf("OpLog : want operations to be Mkdir, %v, got %v", want, ops)
+	}
+
+	err = vfs.Chmod(renamed, 0o600)
+	test.RequireNoError(t, err, "Chmod %s", renamed)
+
+	ops = vfs.OpLog()
+	if len(ops) != 4 || ops[0].Op != "OpenFile" || ops[3].Op != "Chmod" {
+		t.Errorf("OpLog : want the oldest operation to be dropped, got %v", ops)
+	}
+}
+
+func TestMemFSFallocate(t *testing.T) {
+	const blockSize = 4096
+
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	assertSize := func(t *testing.T, path string, wantSize, wantUsed int64) {
+		t.Helper()
+
+		info, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		used, _, _ := vfs.DiskUsage()
+		if info.Size() != wantSize || used != wantUsed {
+			t.Errorf("Stat %s : want (size, used) to be (%d, %d), got (%d, %d)",
+				path, wantSize, wantUsed, info.Size(), used)
+		}
+	}
+
+	t.Run("SparseFile", func(t *testing.T) {
+		const size = 1 << 30
+
+		path := "/tmp/sparse"
+
+		f, err := vfs.Create(path)
+		test.RequireNoError(t, err, "Create %s", path)
+
+		defer f.Close()
+
+		block := bytes.Repeat([]byte{'a'}, blockSize)
+
+		_, err = f.WriteAt(block, size/2)
+		test.RequireNoError(t, err, "WriteAt %s", path)
+
+		err = f.Truncate(size)
+		test.RequireNoError(t, err, "Truncate %s", path)
+
+		assertSize(t, path, size, blockSize)
+
+		info, err := f.Stat()
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		if blocks := vfs.ToSysStat(info).(interface{ Blocks() int64 }).Blocks(); blocks != blockSize/512 {
+			t.Errorf("Blocks : want blocks to be %d, got %d", blockSize/512, blocks)
+		}
+
+		buf := make([]byte, 2*blockSize)
+
+		_, err = f.ReadAt(buf, size/2-blockSize)
+		test.RequireNoError(t, err, "ReadAt %s", path)
+
+		if !bytes.Equal(buf[:blockSize], make([]byte, blockSize)) || !bytes.Equal(buf[blockSize:], block) {
+			t.Errorf("ReadAt : want a hole followed by the written block")
+		}
+
+		err = vfs.Remove(path)
+		test.RequireNoError(t, err, "Remove %s", path)
+	})
+
+	t.Run("PunchHole", func(t *testing.T) {
+		path := "/tmp/punch"
+		data := bytes.Repeat([]byte{'x'}, 3*blockSize)
+
+		err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		assertSize(t, path, 3*blockSize, 3*blockSize)
+
+		f, err := vfs.OpenFile(path, os.O_RDWR, 0)
+		test.RequireNoError(t, err, "OpenFile %s", path)
+
+		err = f.(avfs.Fallocator).Fallocate(avfs.FALLOC_FL_PUNCH_HOLE|avfs.FALLOC_FL_KEEP_SIZE, blockSize, blockSize)
+		test.RequireNoError(t, err, "Fallocate %s", path)
+
+		err = f.Close()
+		test.RequireNoError(t, err, "Close %s", path)
+
+		assertSize(t, path, 3*blockSize, 2*blockSize)
+
+		got, err := vfs.ReadFile(path)
+		test.RequireNoError(t, err, "ReadFile %s", path)
+
+		copy(data[blockSize:2*blockSize], make([]byte, blockSize))
+
+		if !bytes.Equal(got, data) {
+			t.Errorf("ReadFile : want the punched range to be read as zeros")
+		}
+
+		err = vfs.Remove(path)
+		test.RequireNoError(t, err, "Remove %s", path)
+	})
+
+	t.Run("Preallocate", func(t *testing.T) {
+		path := "/tmp/prealloc"
+
+		f, err := vfs.Create(path)
+		test.RequireNoError(t, err, "Create %s", path)
+
+		defer f.Close()
+
+		fa := f.(avfs.Fallocator)
+
+		err = fa.Fallocate(avfs.FALLOC_FL_KEEP_SIZE, 0, blockSize)
+		test.RequireNoError(t, err, "Fallocate %s", path)
+
+		assertSize(t, path, 0, 0)
+
+		err = fa.Fallocate(0, 0, blockSize)
+		test.RequireNoError(t, err, "Fallocate %s", path)
+
+		assertSize(t, path, blockSize, blockSize)
+
+		err = fa.Fallocate(avfs.FALLOC_FL_KEEP_SIZE, blockSize/2, blockSize)
+		test.RequireNoError(t, err, "Fallocate %s", path)
+
+		assertSize(t, path, blockSize, blockSize)
+
+		err = vfs.Remove(path)
+		test.RequireNoError(t, err, "Remove %s", path)
+	})
+
+	t.Run("FallocateErrors", func(t *testing.T) {
+		path := "/tmp/errors"
+
+		err := vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		f, err := vfs.Open(path)
+		test.RequireNoError(t, err, "Open %s", path)
+
+		defer f.Close()
+
+		fa := f.(avfs.Fallocator)
+
+		err = fa.Fallocate(0, 0, blockSize)
+		test.AssertPathError(t, err).Op("fallocate").Path(path).Err(avfs.ErrBadFileDesc).Test()
+
+		err = fa.Fallocate(0x8, 0, blockSize)
+		test.AssertPathError(t, err).Op("fallocate").Path(path).Err(avfs.ErrInvalidArgument).Test()
+
+		err = fa.Fallocate(0, -1, blockSize)
+		test.AssertPathError(t, err).Op("fallocate").Path(path).Err(avfs.ErrInvalidArgument).Test()
+
+		d, err := vfs.Open("/tmp")
+		test.RequireNoError(t, err, "Open /tmp")
+
+		defer d.Close()
+
+		err = d.(avfs.Fallocator).Fallocate(0, 0, blockSize)
+		test.AssertPathError(t, err).Op("fallocate").Path("/tmp").Err(avfs.ErrIsADirectory).Test()
+	})
+}
+
+func TestMemFSSameFile(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	type inoder interface {
+		Ino() uint64
+	}
+
+	dir := "/same"
+	path := vfs.Join(dir, "file.txt")
+	link := vfs.Join(dir, "link.txt")
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	err = vfs.Link(path, link)
+	test.RequireNoError(t, err, "Link %s", link)
+
+	info1, err := vfs.Stat(path)
+	test.RequireNoError(t, err, "Stat %s", path)
+
+	info2, err := vfs.Stat(link)
+	test.RequireNoError(t, err, "Stat %s", link)
+
+	ino1 := info1.Sys().(inoder).Ino()
+	ino2 := info2.Sys().(inoder).Ino()
+
+	if ino1 == 0 || ino1 != ino2 {
+		t.Errorf("Ino : want hard links to share a non zero id, got %d and %d", ino1, ino2)
+	}
+
+	subFS, err := vfs.Sub(dir)
+	test.RequireNoError(t, err, "Sub %s", dir)
+
+	info3, err := subFS.Stat("/file.txt")
+	test.RequireNoError(t, err, "Stat %s", "/file.txt")
+
+	if !vfs.SameFile(info1, info3) {
+		t.Errorf("SameFile : want file seen from a sub file system to be the same file")
+	}
+
+	dirInfo, err := vfs.Stat(dir)
+	test.RequireNoError(t, err, "Stat %s", dir)
+
+	rootInfo, err := subFS.Stat("/")
+	test.RequireNoError(t, err, "Stat %s", "/")
+
+	if !vfs.SameFile(dirInfo, rootInfo) {
+		t.Errorf("SameFile : want sub file system root to be the same file as %s", dir)
+	}
+
+	cloneFS := vfs.Clone()
+
+	info4, err := cloneFS.Stat(path)
+	test.RequireNoError(t, err, "Stat %s", path)
+
+	if !vfs.SameFile(info1, info4) {
+		t.Errorf("SameFile : want file seen from a clone to be the same file")
+	}
+
+	symlink := vfs.Join(dir, "symlink.txt")
+
+	err = vfs.Symlink(path, symlink)
+	test.RequireNoError(t, err, "Symlink %s", symlink)
+
+	slInfo, err := vfs.Lstat(symlink)
+	test.RequireNoError(t, err, "Lstat %s", symlink)
+
+	if vfs.SameFile(info1, slInfo) {
+		t.Errorf("SameFile : want symbolic link %s and its target to be different files", symlink)
+	}
+
+	if ino := slInfo.Sys().(inoder).Ino(); ino == 0 || ino == ino1 {
+		t.Errorf("Ino : want symbolic link to have its own non zero id, got %d", ino)
+	}
+}
+
+func TestMemFSNextDirEntry(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	dir := "/iter"
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	for _, name := range []string{"c", "a", "b"} {
+		path := vfs.Join(dir, name)
+
+		err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+	}
+
+	f, err := vfs.OpenFile(dir, os.O_RDONLY, 0)
+	test.RequireNoError(t, err, "OpenFile %s", dir)
+
+	mf := f.(*memfs.MemFile)
+
+	entry, err := mf.NextDirEntry()
+	test.RequireNoError(t, err, "NextDirEntry")
+
+	if entry.Name() != "a" {
+		t.Errorf("NextDirEntry : want name to be a, got %s", entry.Name())
+	}
+
+	removed := vfs.Join(dir, "b")
+
+	err = vfs.Remove(removed)
+	test.RequireNoError(t, err, "Remove %s", removed)
+
+	entry, err = mf.NextDirEntry()
+	test.RequireNoError(t, err, "NextDirEntry")
+
+	if entry.Name() != "c" {
+		t.Errorf("NextDirEntry : want removed entry to be skipped and name to be c, got %s", entry.Name())
+	}
+
+	_, err = mf.NextDirEntry()
+	if err != io.EOF {
+		t.Errorf("NextDirEntry : want error to be %v, got %v", io.EOF, err)
+	}
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close %s", dir)
+
+	_, err = mf.NextDirEntry()
+	test.AssertPathError(t, err).Op("readdirent").Path(dir).Err(avfs.ErrFileClosing).Test()
+}
+
+func TestMemFSTmpFile(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, MaxInodes: 100})
+
+	dir := "/tmpfile"
+	path := vfs.Join(dir, "linked.txt")
+	data := []byte("anonymous")
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	assertEntries := func(t *testing.T, want int) {
+		t.Helper()
+
+		entries, err := vfs.ReadDir(dir)
+		test.RequireNoError(t, err, "ReadDir %s", dir)
+
+		if len(entries) != want {
+			t.Errorf("ReadDir : want %d entries, got %d", want, len(entries))
+		}
+	}
+
+	f, err := vfs.OpenFile(dir, avfs.O_TMPFILE|os.O_RDWR, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "OpenFile %s", dir)
+
+	_, err = f.Write(data)
+	test.RequireNoError(t, err, "Write")
+
+	assertEntries(t, 0)
+
+	err = vfs.Linkat(f, path)
+	test.RequireNoError(t, err, "Linkat %s", path)
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close")
+
+	assertEntries(t, 1)
+
+	content, err := vfs.ReadFile(path)
+	test.RequireNoError(t, err, "ReadFile %s", path)
+
+	if !bytes.Equal(content, data) {
+		t.Errorf("ReadFile : want content to be %s, got %s", data, content)
+	}
+
+	freeInodes := vfs.FreeInodes()
+
+	t.Run("TmpFileNotLinked", func(t *testing.T) {
+		f, err := vfs.OpenFile(dir, avfs.O_TMPFILE|os.O_WRONLY, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "OpenFile %s", dir)
+
+		err = f.Close()
+		test.RequireNoError(t, err, "Close")
+
+		assertEntries(t, 1)
+
+		err = vfs.Linkat(f, vfs.Join(dir, "closed.txt"))
+		test.AssertLinkError(t, err).Op("linkat").Err(fs.ErrClosed).Test()
+	})
+
+	t.Run("TmpFileErrors", func(t *testing.T) {
+		_, err := vfs.OpenFile(path, avfs.O_TMPFILE|os.O_RDWR, avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("open").Path(path).Err(avfs.ErrNotADirectory).Test()
+
+		f, err := vfs.OpenFile(dir, avfs.O_TMPFILE|os.O_RDWR, avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "OpenFile %s", dir)
+
+		err = vfs.Linkat(f, path)
+		test.AssertLinkError(t, err).Op("linkat").Err(avfs.ErrFileExists).Test()
+
+		missingPath := vfs.Join(dir, "missing", "linked.txt")
+
+		err = vfs.Linkat(f, missingPath)
+		test.AssertLinkError(t, err).Op("linkat").Err(avfs.ErrNoSuchFileOrDir).Test()
+
+		assertEntries(t, 1)
+
+		err = f.Close()
+		test.RequireNoError(t, err, "Close")
+	})
+
+	if got := vfs.FreeInodes(); got != freeInodes {
+		t.Errorf("FreeInodes : want %d, got %d", freeInodes, got)
+	}
+}
+
+func TestMemFSSpecialFiles(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, MaxInodes: 100})
+	if !vfs.HasFeature(avfs.FeatSpecialFiles) {
+		t.Skip("TestMemFSSpecialFiles : skipping test, FeatSpecialFiles is not available")
+	}
+
+	dir := "/special"
+	fifo := vfs.Join(dir, "fifo")
+	sock := vfs.Join(dir, "sock")
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+
+	err = vfs.Mkfifo(fifo, 0o640)
+	test.RequireNoError(t, err, "Mkfifo %s", fifo)
+
+	err = vfs.Mknod(sock, fs.ModeSocket|0o600)
+	test.RequireNoError(t, err, "Mknod %s", sock)
+
+	t.Run("SpecialFilesLstat", func(t *testing.T) {
+		for _, st := range []struct {
+			path string
+			mode fs.FileMode
+		}{
+			{path: fifo, mode: fs.ModeNamedPipe | 0o640&^vfs.UMask()},
+			{path: sock, mode: fs.ModeSocket | 0o600&^vfs.UMask()},
+		} {
+			info, err := vfs.Lstat(st.path)
+			test.RequireNoError(t, err, "Lstat %s", st.path)
+
+			if info.Mode() != st.mode {
+				t.Errorf("Lstat %s : want mode to be %s, got %s", st.path, st.mode, info.Mode())
+			}
+		}
+	})
+
+	t.Run("SpecialFilesReadDir", func(t *testing.T) {
+		entries, err := vfs.ReadDir(dir)
+		test.RequireNoError(t, err, "ReadDir %s", dir)
+
+		wantTypes := map[string]fs.FileMode{"fifo": fs.ModeNamedPipe, "sock": fs.ModeSocket}
+		if len(entries) != len(wantTypes) {
+			t.Fatalf("ReadDir : want %d entries, got %d", len(wantTypes), len(entries))
+		}
+
+		for _, entry := range entries {
+			if wantType := wantTypes[entry.Name()]; entry.Type() != wantType {
+				t.Errorf("ReadDir %s : want type to be %s, got %s", entry.Name(), wantType, entry.Type())
+			}
+		}
+	})
+
+	t.Run("SpecialFilesOpen", func(t *testing.T) {
+		_, err := vfs.Open(fifo)
+		test.AssertPathError(t, err).Op("open").Path(fifo).Err(avfs.ErrNoDevOrAddr).Test()
+
+		_, err = vfs.OpenFile(sock, os.O_WRONLY, 0)
+		test.AssertPathError(t, err).Op("open").Path(sock).Err(avfs.ErrNoDevOrAddr).Test()
+
+		err = vfs.Truncate(fifo, 0)
+		test.AssertPathError(t, err).Op("truncate").Path(fifo).Err(avfs.ErrInvalidArgument).Test()
+	})
+
+	t.Run("SpecialFilesErrors", func(t *testing.T) {
+		err := vfs.Mkfifo(fifo, avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("mkfifo").Path(fifo).Err(avfs.ErrFileExists).Test()
+
+		path := vfs.Join(dir, "regular")
+
+		err = vfs.Mknod(path, avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("mknod").Path(path).Err(avfs.ErrInvalidArgument).Test()
+
+		err = vfs.Mknod(path, fs.ModeDevice|avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("mknod").Path(path).Err(avfs.ErrInvalidArgument).Test()
+
+		missingPath := vfs.Join(dir, "missing", "fifo")
+
+		err = vfs.Mkfifo(missingPath, avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("mkfifo").Path(missingPath).Err(avfs.ErrNoSuchFileOrDir).Test()
+
+		err = vfs.Mknod(missingPath, fs.ModeSocket|avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("mknod").Path(missingPath).Err(avfs.ErrNoSuchFileOrDir).Test()
+
+		_, err = vfs.Lstat(vfs.Join(dir, "missing"))
+		test.AssertPathError(t, err).Op("lstat").Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
+
+	t.Run("SpecialFilesRemove", func(t *testing.T) {
+		err := vfs.Chmod(fifo, 0o600)
+		test.RequireNoError(t, err, "Chmod %s", fifo)
+
+		info, err := vfs.Lstat(fifo)
+		test.RequireNoError(t, err, "Lstat %s", fifo)
+
+		if want := fs.ModeNamedPipe | 0o600; info.Mode() != want {
+			t.Errorf("Chmod %s : want mode to be %s, got %s", fifo, want, info.Mode())
+		}
+
+		if err = vfs.Check(); err != nil {
+			t.Errorf("Check : want error to be nil, got %v", err)
+		}
+
+		err = vfs.RemoveAll(dir)
+		test.RequireNoError(t, err, "RemoveAll %s", dir)
+	})
+}
+
+func TestMemFSStatFS(t *testing.T) {
+	const capacity = 1 << 20
+
+	vfs := memfs.NewWithOptions(&memfs.Options{Capacity: capacity, MaxInodes: 100})
+	dir := vfs.TempDir()
+
+	var _ avfs.FSStater = vfs
+
+	statFS := func(t *testing.T) avfs.FSStat {
+		t.Helper()
+
+		st, err := vfs.StatFS(dir)
+		test.RequireNoError(t, err, "StatFS %s", dir)
+
+		if st.Total != capacity {
+			t.Errorf("StatFS : want Total to be %d, got %d", capacity, st.Total)
+		}
+
+		if st.Inodes != 100 {
+			t.Errorf("StatFS : want Inodes to be %d, got %d", 100, st.Inodes)
+		}
+
+		if st.Available != st.Free {
+			t.Errorf("StatFS : want Available to be %d, got %d", st.Free, st.Available)
+		}
+
+		return st
+	}
+
+	before := statFS(t)
+	path := vfs.Join(dir, "file")
+	data := make([]byte, 10000)
+
+	err := vfs.WriteFile(path, data, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	written := statFS(t)
+	if written.Free > before.Free-uint64(len(data)) {
+		t.Errorf("StatFS : want Free to be at most %d, got %d", before.Free-uint64(len(data)), written.Free)
+	}
+
+	if written.FreeInodes != before.FreeInodes-1 {
+		t.Errorf("StatFS : want FreeInodes to be %d, got %d", before.FreeInodes-1, written.FreeInodes)
+	}
+
+	err = vfs.Remove(path)
+	test.RequireNoError(t, err, "Remove %s", path)
+
+	removed := statFS(t)
+	if removed != before {
+		t.Errorf("StatFS : want %+v after Remove, got %+v", before, removed)
+	}
+
+	t.Run("Unlimited", func(t *testing.T) {
+		vfs := memfs.New()
+
+		st, err := vfs.StatFS(vfs.TempDir())
+		test.RequireNoError(t, err, "StatFS")
+
+		if st.Total != math.MaxInt64 || st.Inodes != math.MaxInt64 {
+			t.Errorf("StatFS : want Total and Inodes to be %d, got %d and %d", int64(math.MaxInt64), st.Total, st.Inodes)
+		}
+	})
+
+	t.Run("NonExisting", func(t *testing.T) {
+		nonExisting := vfs.Join(dir, "nonExisting")
+
+		_, err := vfs.StatFS(nonExisting)
+		test.AssertPathError(t, err).Op("statfs").Path(nonExisting).
+			OSType(avfs.OsLinux).Err(avfs.ErrNoSuchFileOrDir).Test().
+			OSType(avfs.OsWindows).Err(avfs.ErrWinFileNotFound).Test()
+	})
+}
+
+func TestMemFSSyncFS(t *testing.T) {
+	vfs := memfs.New()
+
+	var _ avfs.FSSyncer = vfs
+
+	path := vfs.Join(vfs.TempDir(), "sync")
+
+	f, err := vfs.Create(path)
+	test.RequireNoError(t, err, "Create %s", path)
+
+	_, err = f.Write([]byte("data"))
+	test.RequireNoError(t, err, "Write %s", path)
+
+	err = f.Sync()
+	test.RequireNoError(t, err, "Sync %s", path)
+
+	err = vfs.SyncFS()
+	test.RequireNoError(t, err, "SyncFS")
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close %s", path)
+
+	err = f.Sync()
+	test.AssertPathError(t, err).Op("sync").Path(path).Err(fs.ErrClosed).Test()
+}
+
+func TestMemFSString(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{SystemDirs: []avfs.DirInfo{{Path: "/", Perm: avfs.DefaultDirPerm}}})
+	if vfs.OSType() == avfs.OsWindows {
+		t.Skip("TestMemFSString : skipping test on Windows")
+	}
+
+	err := vfs.MkdirAll("/a/b", 0o755)
+	test.RequireNoError(t, err, "MkdirAll")
+
+	err = vfs.WriteFile("/a/b/file", []byte("data"), 0o644)
+	test.RequireNoError(t, err, "WriteFile")
+
+	err = vfs.Symlink("/a", "/a/b/loop")
+	test.RequireNoError(t, err, "Symlink")
+
+	s := vfs.String()
+
+	for _, want := range []string{
+		"  drwxr-xr-x 0/0 1 a\n",
+		"    drwxr-xr-x 0/0 2 b\n",
+		"      -rw-r--r-- 0/0 4 file\n",
+		"      Lrwxrwxrwx 0/0 1 loop -> /a\n",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("String : want output to contain %q, got\n%s", want, s)
+		}
+	}
+}
+
+func BenchmarkMemFSAll(b *testing.B) {
+	vfs := memfs.New()
+
+	ts := test.NewSuiteFS(b, vfs, vfs)
+	ts.BenchAll(b)
+}
+
+func BenchmarkMemFSReadDirLarge(b *testing.B) {
+	const nbEntries = 100_000
+
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	dir := "/large"
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(b, err, "Mkdir %s", dir)
+
+	for i := 0; i < nbEntries; i++ {
+		path := vfs.Join(dir, strconv.Itoa(i))
+
+		err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+		test.RequireNoError(b, err, "WriteFile %s", path)
+	}
+
+	b.Run("ReadDirSorted", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_, err := vfs.ReadDir(dir)
+			test.RequireNoError(b, err, "ReadDir %s", dir)
+		}
+	})
+
+	b.Run("ReadDirUnsorted", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_, err := avfs.ReadDirUnsorted(vfs, dir)
+			test.RequireNoError(b, err, "ReadDirUnsorted %s", dir)
+		}
+	})
+
+	b.Run("ReadDirNames", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_, err := avfs.ReadDirNames(vfs, dir)
+			test.RequireNoError(b, err, "ReadDirNames %s", dir)
+		}
+	})
+
+	b.Run("ReadDir", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			f, err := vfs.OpenFile(dir, os.O_RDONLY, 0)
+			test.RequireNoError(b, err, "OpenFile %s", dir)
+
+			_, err = f.ReadDir(-1)
+			test.RequireNoError(b, err, "ReadDir %s", dir)
+
+			_ = f.Close()
+		}
+	})
+
+	b.Run("NextDirEntry", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			f, err := vfs.OpenFile(dir, os.O_RDONLY, 0)
+			test.RequireNoError(b, err, "OpenFile %s", dir)
+
+			mf := f.(*memfs.MemFile)
+			for {
+				_, err = mf.NextDirEntry()
+				if err != nil {
+					break
+				}
+			}
+
+			_ = f.Close()
+		}
+	})
+}
+
+func BenchmarkMemFSWalkDirLarge(b *testing.B) {
+	const (
+		nbDirs  = 100
+		nbFiles = 100
+	)
+
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	root := "/large"
+
+	for i := 0; i < nbDirs; i++ {
+		dir := vfs.Join(root, strconv.Itoa(i))
+		_ = test.CreateManyFiles(b, vfs, dir, nbFiles, 0)
+	}
+
+	b.Run("WalkDir", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			err := vfs.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				return err
+			})
+			test.RequireNoError(b, err, "WalkDir %s", root)
+		}
+	})
+
+	// WalkDirInfo calls Lstat for each entry like a walk based on filepath.WalkFunc does.
+	b.Run("WalkDirInfo", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			err := vfs.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				_, err = vfs.Lstat(path)
+
+				return err
+			})
+			test.RequireNoError(b, err, "WalkDir %s", root)
+		}
+	})
+}
+
+func TestMemFSATimePolicy(t *testing.T) {
+	startTime := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		policy                       memfs.ATimePolicy
+		wantFirst, wantNext, wantDay time.Duration
+	}{
+		{policy: memfs.ATimeRelatime, wantFirst: time.Second, wantNext: time.Second, wantDay: 25 * time.Hour},
+		{policy: memfs.ATimeStrict, wantFirst: time.Second, wantNext: 2 * time.Second, wantDay: 25 * time.Hour},
+		{policy: memfs.ATimeNone, wantFirst: 0, wantNext: 0, wantDay: 0},
+	}
+
+	for _, tt := range tests {
+		clockTime := startTime
+		clock := func() time.Time { return clockTime }
+
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, Clock: clock, ATimePolicy: tt.policy})
+
+		dir := "/atime"
+
+		err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+		test.RequireNoError(t, err, "Mkdir %s", dir)
+
+		path := vfs.Join(dir, "file.txt")
+
+		err = vfs.WriteFile(path, []byte("atime"), avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		assertATime := func(name string, want time.Duration) {
+			t.Helper()
+
+			info, err := vfs.Stat(name)
+			test.RequireNoError(t, err, "Stat %s", name)
+
+			mi, ok := info.Sys().(*memfs.MemInfo)
+			if !ok {
+				t.Fatalf("Sys : want type *memfs.MemInfo, got %T", info.Sys())
+			}
+
+			if wantTime := startTime.Add(want); !mi.ATime().Equal(wantTime) {
+				t.Errorf("ATime %s (policy %d) : want %v, got %v", name, tt.policy, wantTime, mi.ATime())
+			}
+		}
+
+		readAll := func(want time.Duration) {
+			t.Helper()
+
+			_, err = vfs.ReadFile(path)
+			test.RequireNoError(t, err, "ReadFile %s", path)
+
+			_, err = vfs.ReadDir(dir)
+			test.RequireNoError(t, err, "ReadDir %s", dir)
+
+			assertATime(path, want)
+			assertATime(dir, want)
+		}
+
+		assertATime(path, 0)
+
+		clockTime = startTime.Add(time.Second)
+		readAll(tt.wantFirst)
+
+		clockTime = startTime.Add(2 * time.Second)
+		readAll(tt.wantNext)
+
+		clockTime = startTime.Add(25 * time.Hour)
+		readAll(tt.wantDay)
+
+		atime := startTime.Add(-time.Hour)
+
+		err = vfs.Chtimes(path, atime, startTime)
+		test.RequireNoError(t, err, "Chtimes %s", path)
+
+		assertATime(path, -time.Hour)
+	}
+}
+
+func TestMemFSGeneration(t *testing.T) {
+	vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux})
+
+	path := "/tmp/generation.txt"
+
+	err := vfs.WriteFile(path, []byte("generation"), avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	gen, err := vfs.Generation(path)
+	test.RequireNoError(t, err, "Generation %s", path)
+
+	assertGeneration := func(t *testing.T, name string, wantChanged bool) {
+		t.Helper()
+
+		newGen, err := vfs.Generation(path)
+		test.RequireNoError(t, err, "Generation %s", path)
+
+		if changed := newGen != gen; changed != wantChanged {
+			t.Errorf("%s : want generation changed to be %t, got %d -> %d", name, wantChanged, gen, newGen)
+		}
+
+		gen = newGen
+	}
+
+	f, err := vfs.OpenFile(path, os.O_RDWR, 0)
+	test.RequireNoError(t, err, "OpenFile %s", path)
+
+	defer f.Close()
+
+	_, err = f.Read(make([]byte, 4))
+	test.RequireNoError(t, err, "Read %s", path)
+	assertGeneration(t, "Read", false)
+
+	_, err = f.Write([]byte("write"))
+	test.RequireNoError(t, err, "Write %s", path)
+	assertGeneration(t, "Write", true)
+
+	err = vfs.Chmod(path, 0o600)
+	test.RequireNoError(t, err, "Chmod %s", path)
+	assertGeneration(t, "Chmod", true)
+
+	mtime := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	err = vfs.Chtimes(path, mtime, mtime)
+	test.RequireNoError(t, err, "Chtimes %s", path)
+	assertGeneration(t, "Chtimes", true)
+
+	err = vfs.Truncate(path, 2)
+	test.RequireNoError(t, err, "Truncate %s", path)
+	assertGeneration(t, "Truncate", true)
+
+	_, err = f.ReadAt(make([]byte, 2), 0)
+	test.RequireNoError(t, err, "ReadAt %s", path)
+	assertGeneration(t, "ReadAt", false)
+
+	t.Run("StatCache", func(t *testing.T) {
+		info1, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		info2, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		if info1 != info2 {
+			t.Errorf("Stat %s : want the cached file information for an unchanged file", path)
+		}
+
+		_, err = f.WriteAt([]byte("changed"), 0)
+		test.RequireNoError(t, err, "WriteAt %s", path)
+
+		info3, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		if info3 == info1 || info3.Size() != int64(len("changed")) {
+			t.Errorf("Stat %s : want new file information of size %d, got size %d", path, len("changed"), info3.Size())
+		}
+	})
+
+	t.Run("Compression", func(t *testing.T) {
+		vfs := memfs.NewWithOptions(&memfs.Options{OSType: avfs.OsLinux, Compression: memfs.CompressionGzip})
+
+		path := "/tmp/compressed.txt"
+
+		err := vfs.WriteFile(path, bytes.Repeat([]byte("compressed"), 1000), avfs.DefaultFilePerm)
+		test.RequireNoError(t, err, "WriteFile %s", path)
+
+		gen, err := vfs.Generation(path)
+		test.RequireNoError(t, err, "Generation %s", path)
+
+		compressed, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		f, err := vfs.Open(path)
+		test.RequireNoError(t, err, "Open %s", path)
+
+		_, err = f.Read(make([]byte, 10))
+		test.RequireNoError(t, err, "Read %s", path)
+
+		inflated, err := vfs.Stat(path)
+		test.RequireNoError(t, err, "Stat %s", path)
+
+		err = f.Close()
+		test.RequireNoError(t, err, "Close %s", path)
+
+		newGen, err := vfs.Generation(path)
+		test.RequireNoError(t, err, "Generation %s", path)
+
+		if newGen != gen {
+			t.Errorf("Read : want generation of a compressed file to be unchanged, got %d -> %d", gen, newGen)
+		}
+
+		blocks := vfs.ToSysStat(compressed).(*memfs.MemInfo).Blocks()
+		if inflatedBlocks := vfs.ToSysStat(inflated).(*memfs.MemInfo).Blocks(); inflatedBlocks <= blocks {
+			t.Errorf("Stat %s : want more blocks for the inflated content, got %d <= %d", path, inflatedBlocks, blocks)
+		}
+	})
+
+	t.Run("GenerationNonExisting", func(t *testing.T) {
+		nonExisting := "/tmp/nonExisting"
+
+		_, err := vfs.Generation(nonExisting)
+		test.AssertPathError(t, err).Op("stat").Path(nonExisting).Err(avfs.ErrNoSuchFileOrDir).Test()
+	})
 }
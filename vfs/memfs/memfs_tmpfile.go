@@ -0,0 +1,125 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"io/fs"
+	"os"
+
+	"github.com/avfs/avfs"
+)
+
+// openTmpFile creates an unnamed temporary file in the directory name (see avfs.O_TMPFILE)
+// relative to the directory node start, or to the current directory if start is nil.
+// fileName is the name of the returned file.
+func (vfs *MemFS) openTmpFile(start *dirNode, name, fileName string, flag int, om avfs.OpenMode, perm fs.FileMode) (
+	avfs.File, error,
+) {
+	const op = "open"
+
+	_, child, _, err := vfs.searchNodeAt(start, name, slmEval)
+	if err != vfs.err.FileExists || child == nil {
+		return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	dn, ok := child.(*dirNode)
+	if !ok {
+		return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: vfs.err.NotADirectory}
+	}
+
+	dn.mu.RLock()
+	ok = dn.checkPermission(avfs.OpenWrite|avfs.OpenLookup, vfs.User())
+	dn.mu.RUnlock()
+
+	if !ok {
+		return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: vfs.err.PermDenied}
+	}
+
+	if !vfs.allocInode() {
+		return &MemFile{}, &fs.PathError{Op: op, Path: name, Err: vfs.err.NoSpaceLeft}
+	}
+
+	fn := vfs.newFileNode(perm, vfs.now())
+	fn.nopen = 1
+
+	f := &MemFile{
+		nd:       fn,
+		vfs:      vfs,
+		name:     fileName,
+		pos:      &fileOffset{},
+		flag:     flag,
+		openMode: om &^ avfs.OpenTmpFile,
+		tmpFile:  true,
+	}
+
+	f.fd = vfs.fds.alloc(f)
+
+	return f, nil
+}
+
+// Linkat links the open file f to newpath, making an unnamed temporary file
+// (opened with avfs.O_TMPFILE) visible in the directory tree.
+// If there is an error, it will be of type *LinkError.
+func (vfs *MemFS) Linkat(f avfs.File, newpath string) (err error) {
+	defer func() { vfs.logOp("Linkat", "", newpath, err) }()
+
+	const op = "linkat"
+
+	mf, ok := f.(*MemFile)
+	if !ok || mf == nil {
+		return &os.LinkError{Op: op, Old: "", New: newpath, Err: fs.ErrInvalid}
+	}
+
+	mf.mu.Lock()
+	defer mf.mu.Unlock()
+
+	if mf.nd == nil {
+		return &os.LinkError{Op: op, Old: mf.name, New: newpath, Err: fs.ErrClosed}
+	}
+
+	fn, ok := mf.nd.(*fileNode)
+	if !ok {
+		return &os.LinkError{Op: op, Old: mf.name, New: newpath, Err: vfs.err.OpNotPermitted}
+	}
+
+	nParent, _, pi, err := vfs.searchNode(newpath, slmLstat)
+	if !vfs.isNotExist(err) || !pi.IsLast() {
+		return &os.LinkError{Op: op, Old: mf.name, New: newpath, Err: err}
+	}
+
+	nParent.mu.Lock()
+	defer nParent.mu.Unlock()
+
+	if !nParent.checkPermission(avfs.OpenWrite, vfs.User()) {
+		return &os.LinkError{Op: op, Old: mf.name, New: newpath, Err: vfs.err.PermDenied}
+	}
+
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if fn.nlink == 0 && !mf.tmpFile {
+		return &os.LinkError{Op: op, Old: mf.name, New: newpath, Err: vfs.err.NoSuchFile}
+	}
+
+	nParent.addChild(pi.Part(), fn, vfs.now())
+
+	fn.nlink++
+	fn.changed()
+	mf.tmpFile = false
+
+	return nil
+}
@@ -18,7 +18,9 @@ package memfs
 
 import (
 	"io/fs"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/avfs/avfs"
@@ -27,6 +29,18 @@ import (
 const (
 	// Maximum number of symlinks in a path.
 	slCountMax = 64
+
+	// Default block size used for block accounting.
+	defaultBlockSize = 4096
+
+	// Default maximum length of a file name (NAME_MAX on Linux).
+	defaultNameMax = 255
+
+	// Default maximum length of a path (PATH_MAX on Linux).
+	defaultPathMax = 4096
+
+	// First file descriptor assigned to an open file, 0 to 2 are the standard streams.
+	fdFirst = 3
 )
 
 // MemIOFS implements a memory file system using the avfs.IOFS interface.
@@ -36,41 +50,181 @@ type MemIOFS struct {
 
 // MemFS implements a memory file system using the avfs.VFS interface.
 type MemFS struct {
-	rootNode        *dirNode    // rootNode represent the root directory of the file system.
-	err             avfs.Errors // err regroups errors depending on the OS emulated.
-	volumes         volumes     // volumes contains the volume names (for Windows only).
-	dirMode         fs.FileMode // dirMode is the default fs.FileMode for a directory.
-	fileMode        fs.FileMode // fileMode is de default fs.FileMode for a file.
-	lastId          *uint64     // lastId is the last unique id used to identify files uniquely.
-	name            string      // name is the name of the file system.
-	avfs.CurDirFn               // CurDirFn provides current directory functions to a file system.
-	avfs.CurUserFn              // CurUserFn provides current user functions to a file system.
-	avfs.IdmFn                  // IdmFn provides identity manager functions to a file system.
-	avfs.UMaskFn                // UMaskFn provides UMask functions to file systems.
-	avfs.FeaturesFn             // FeaturesFn provides features functions to a file system or an identity manager.
-	avfs.OSTypeFn               // OSTypeFn provides OS type functions to a file system or an identity manager.
+	rootNode           *dirNode         // rootNode represent the root directory of the file system.
+	err                avfs.Errors      // err regroups errors depending on the OS emulated.
+	volumes            volumes          // volumes contains the volume names (for Windows only).
+	dirMode            fs.FileMode      // dirMode is the default fs.FileMode for a directory.
+	fileMode           fs.FileMode      // fileMode is de default fs.FileMode for a file.
+	lastId             *uint64          // lastId is the last unique id used to identify nodes uniquely.
+	usedInodes         *int64           // usedInodes is the number of nodes (files, directories and symbolic links) in use.
+	maxInodes          int64            // maxInodes is the maximum number of nodes, 0 means no limit.
+	capacity           int64            // capacity is the size of the file system reported by StatFS, 0 means no limit.
+	blockSize          int64            // blockSize is the block size used to compute the number of blocks of a file.
+	nameMax            int              // nameMax is the maximum length of a file name.
+	pathMax            int              // pathMax is the maximum length of a path.
+	clock              func() time.Time // clock returns the current time used to set modification times.
+	name               string           // name is the name of the file system.
+	systemDirs         []avfs.DirInfo   // systemDirs are the system directories created with the file system.
+	lockOpenFiles      bool             // lockOpenFiles prevents open files to be removed or renamed (Windows only).
+	atimePolicy        ATimePolicy      // atimePolicy defines when the access time is updated by reads (see Options.ATimePolicy).
+	dirOrder           DirOrder         // dirOrder is the order of the entries returned by MemFile.ReadDir, Readdir and Readdirnames.
+	readableDirs       bool             // readableDirs makes Read on a directory return its sorted names (see Options.ReadableDirs).
+	posixRename        bool             // posixRename allows Rename to replace an empty directory (see Options.PosixRename).
+	fds                *fdTable         // fds is the table of the file descriptors of open files, shared with clones.
+	randSource         *lockedSource    // randSource generates the names of temporary files and directories, nil for the default source.
+	opLog              *opLog           // opLog records the operations modifying the file system, nil if disabled (see Options.OpLogSize).
+	compression        CompressionAlgo  // compression is the algorithm used to compress the content of closed files (see Options.Compression).
+	compressionMinSize int64            // compressionMinSize is the minimum size of a compressed file (see Options.CompressionMinSize).
+	dedup              *dedupStore      // dedup stores the content shared by files with identical content, nil if disabled (see Options.Dedup).
+	avfs.CurDirFn                       // CurDirFn provides current directory functions to a file system.
+	avfs.CurUserFn                      // CurUserFn provides current user functions to a file system.
+	avfs.IdmFn                          // IdmFn provides identity manager functions to a file system.
+	avfs.UMaskFn                        // UMaskFn provides UMask functions to file systems.
+	avfs.FeaturesFn                     // FeaturesFn provides features functions to a file system or an identity manager.
+	avfs.OSTypeFn                       // OSTypeFn provides OS type functions to a file system or an identity manager.
 }
 
 // MemFile represents an open file descriptor.
 type MemFile struct {
-	nd         node          // nd is node of the file.
-	vfs        *MemFS        // vfs is the memory file system of the file.
-	name       string        // name is the name of the file.
-	dirEntries []fs.DirEntry // dirEntries stores the file information returned by ReadDir function.
-	dirNames   []string      // dirNames stores the names of the file returned by Readdirnames function.
-	at         int64         // at is current position in the file used by Read and Write functions.
-	dirIndex   int           // dirIndex is the position of the current index for dirEntries ou dirNames slices.
-	mu         sync.RWMutex  // mu is the RWMutex used to access content of MemFile.
-	openMode   avfs.OpenMode // openMode defines the permissions to check for OpenFile and CheckPermission functions.
+	nd            node          // nd is node of the file.
+	vfs           *MemFS        // vfs is the memory file system of the file.
+	name          string        // name is the name of the file.
+	dirEntries    []fs.DirEntry // dirEntries stores the file information returned by ReadDir function.
+	dirNames      []string      // dirNames stores the names of the file returned by Readdirnames function.
+	iterNames     []string      // iterNames stores the names of the directory snapshot used by NextDirEntry function.
+	dirData       []byte        // dirData stores the content of a directory returned by Read function (see Options.ReadableDirs).
+	pos           *fileOffset   // pos is the current position in the file, shared with the files returned by Dup.
+	fd            uintptr       // fd is the file descriptor of the open file (see MemFS.FileFromFd).
+	flag          int           // flag is the flag used to open the file (see Flag).
+	dirIndex      int           // dirIndex is the position of the current index for dirEntries ou dirNames slices.
+	iterIndex     int           // iterIndex is the position of the current index for iterNames slice.
+	mu            sync.RWMutex  // mu is the RWMutex used to access content of MemFile.
+	openMode      avfs.OpenMode // openMode defines the permissions to check for OpenFile and CheckPermission functions.
+	tmpFile       bool          // tmpFile is true for an unnamed temporary file not linked yet (see avfs.O_TMPFILE).
+	readDeadline  time.Time     // readDeadline is the deadline for Read and ReadAt functions, zero means no deadline.
+	writeDeadline time.Time     // writeDeadline is the deadline for Write and WriteAt functions, zero means no deadline.
 }
 
 // Options defines the initialization options of MemFS.
 type Options struct {
-	Idm        avfs.IdentityMgr // Idm is the identity manager of the file system.
-	User       avfs.UserReader  // User is the current user of the file system.
-	Name       string           // Name is the name of the file system.
-	OSType     avfs.OSType      // OSType defines the operating system type.
-	SystemDirs []avfs.DirInfo   // SystemDirs contains data to create system directories.
+	Capacity           int64              // Capacity is the size of the file system in bytes reported by StatFS, unlimited if 0.
+	BlockSize          int64              // BlockSize is the block size used for block accounting (see MemInfo.Blocks), 4096 if 0.
+	Clock              func() time.Time   // Clock returns the current time used to set modification times, time.Now if nil.
+	Idm                avfs.IdentityMgr   // Idm is the identity manager of the file system.
+	User               avfs.UserReader    // User is the current user of the file system.
+	Name               string             // Name is the name of the file system.
+	MaxInodes          int                // MaxInodes is the maximum number of files, directories and symbolic links, 0 means no limit.
+	NameMax            int                // NameMax is the maximum length of a file name, 255 if 0.
+	PathMax            int                // PathMax is the maximum length of a path, 4096 if 0.
+	OSType             avfs.OSType        // OSType defines the operating system type, silently replaced by the current one if it can't be set.
+	RandSource         rand.Source        // RandSource generates reproducible names for CreateTemp and MkdirTemp, math/rand if nil.
+	ATimePolicy        ATimePolicy        // ATimePolicy defines when the access time is updated by reads, ATimeRelatime by default.
+	DirOrder           DirOrder           // DirOrder is the order of the entries returned by MemFile.ReadDir, Readdir and Readdirnames.
+	ReadableDirs       bool               // ReadableDirs makes Read on a directory return its sorted names separated by newlines.
+	ErrorOnRemoveOpen  bool               // ErrorOnRemoveOpen makes Remove and Rename of an open file fail like on Windows (Windows OSType only).
+	PosixRename        bool               // PosixRename allows Rename to replace an existing empty directory by a directory.
+	Dedup              bool               // Dedup shares the content of files with identical content until one of them is modified.
+	Compression        CompressionAlgo    // Compression is the algorithm used to compress the content of closed files, CompressionNone by default.
+	CompressionMinSize int64              // CompressionMinSize is the minimum size of a file to be compressed, 1024 if 0.
+	OpLogSize          int                // OpLogSize is the number of operations kept by the operation log (see MemFS.OpLog), disabled if 0.
+	SystemDirs         []avfs.DirInfo     // SystemDirs contains data to create system directories.
+	InitialContent     map[string][]byte  // InitialContent contains the content of files to create, where the key is the path.
+	InitialFiles       map[string]MapFile // InitialFiles contains files or directories to create, where the key is the path.
+}
+
+// fileOffset is the current position in a file used by Read and Write functions,
+// shared by the files duplicated with MemFile.Dup.
+type fileOffset struct {
+	at int64      // at is the current position in the file.
+	mu sync.Mutex // mu is the mutex used to access at.
+}
+
+// LoggedOp is an operation modifying the file system recorded by the operation log (see MemFS.OpLog).
+type LoggedOp struct {
+	Err     error  // Err is the error returned by the operation, nil if it succeeded.
+	Op      string // Op is the name of the method, like "Mkdir" or "Rename".
+	Path    string // Path is the path of the file.
+	NewPath string // NewPath is the new path of Link, Linkat, Rename and Symlink operations.
+}
+
+// opLog is a bounded log of the operations modifying the file system.
+// When it is full, the oldest operations are overwritten.
+type opLog struct {
+	ops  []LoggedOp // ops is the ring buffer of the logged operations.
+	next int        // next is the index of ops where the next operation is recorded.
+	full bool       // full is true when all the elements of ops are used.
+	mu   sync.Mutex // mu is the mutex used to access the log.
+}
+
+// fdTable is the table of the file descriptors of open files.
+type fdTable struct {
+	files []*MemFile // files are the open files indexed by their file descriptor minus fdFirst.
+	mu    sync.Mutex // mu is the mutex used to access files.
+}
+
+// lockedSource is a rand.Source safe for concurrent use.
+type lockedSource struct {
+	src rand.Source // src is the source of random numbers.
+	mu  sync.Mutex  // mu is the mutex used to access src.
+}
+
+// DirOrder is the order of the directory entries returned by the ReadDir, Readdir and Readdirnames methods of MemFile
+// (see Options.DirOrder). MemFS.ReadDir always sorts the entries by name like os.ReadDir.
+type DirOrder int
+
+const (
+	OrderSorted   DirOrder = iota // OrderSorted returns the entries sorted by name (default).
+	OrderCreation                 // OrderCreation returns the entries in the order the files were created.
+	OrderRandom                   // OrderRandom returns the entries in a random order, reproducible with Options.RandSource.
+	OrderUnsorted                 // OrderUnsorted returns the entries in the nondeterministic order of the directory map, without sorting.
+)
+
+// ATimePolicy defines when the access time of a file or a directory is updated by a read (see Options.ATimePolicy).
+// The access time is returned by MemInfo.ATime.
+type ATimePolicy int
+
+const (
+	ATimeRelatime ATimePolicy = iota // ATimeRelatime updates the access time if it is older than the modification time or than 24 hours (default).
+	ATimeStrict                      // ATimeStrict updates the access time on every read.
+	ATimeNone                        // ATimeNone never updates the access time.
+)
+
+// CompressionAlgo is the algorithm used to compress the content of files (see Options.Compression).
+// The content of a file is compressed when its last open file is closed and decompressed when it is opened again,
+// MemInfo.Size returns the uncompressed size and MemFS.DiskUsage the compressed size.
+// Sparse files and contents shared by several files (see Options.Dedup) are not compressed.
+type CompressionAlgo int
+
+const (
+	CompressionNone  CompressionAlgo = iota // CompressionNone stores the content of files uncompressed (default).
+	CompressionGzip                         // CompressionGzip compresses the content of files with gzip.
+	CompressionFlate                        // CompressionFlate compresses the content of files with DEFLATE.
+)
+
+// MapFile describes a file or a directory created by Options.InitialFiles.
+type MapFile struct {
+	Data    []byte      // Data is the content of the file.
+	Mode    fs.FileMode // Mode is the mode of the file, a directory is created if fs.ModeDir is set.
+	ModTime time.Time   // ModTime is the modification time, the current time if zero.
+}
+
+// ChangeOp is the operation of a Change returned by Diff.
+type ChangeOp int
+
+const (
+	ChangeCreate ChangeOp = iota + 1 // ChangeCreate creates a file, a directory or a symbolic link.
+	ChangeUpdate                     // ChangeUpdate replaces the content, the mode or the modification time of a file or a directory.
+	ChangeDelete                     // ChangeDelete removes a file, a symbolic link or a directory and its content.
+)
+
+// Change is a change returned by Diff and applied by ApplyChanges.
+type Change struct {
+	Op      ChangeOp    // Op is the operation of the change.
+	Path    string      // Path is the absolute path of the file.
+	Mode    fs.FileMode // Mode is the type and the permissions of the file (ChangeCreate and ChangeUpdate).
+	Data    []byte      // Data is the content of a file (ChangeCreate and ChangeUpdate).
+	Link    string      // Link is the target of a symbolic link (ChangeCreate).
+	ModTime time.Time   // ModTime is the modification time of a file or a symbolic link (ChangeCreate and ChangeUpdate).
 }
 
 // node is the interface implemented by dirNode, fileNode and symlinkNode.
@@ -80,21 +234,39 @@ type node interface {
 	// checkPermission returns true if the current user has the desired permissions (perm) on the node.
 	checkPermission(perm avfs.OpenMode, u avfs.UserReader) bool
 
-	// delete removes all information from the node.
-	delete()
+	// delete removes all information from the node and returns true if the node is no longer referenced.
+	delete() bool
+
+	// base returns the common structure of the node.
+	base() *baseNode
 
 	// fillStatFrom returns a *MemInfo (implementation of fs.FileInfo) from a node named name.
 	fillStatFrom(name string) *MemInfo
 
+	// getXattr returns the value of the extended attribute name and true if it exists.
+	getXattr(name string) ([]byte, bool)
+
+	// listXattr returns the sorted names of the extended attributes of the node.
+	listXattr() []string
+
+	// nodeId returns the unique id of the node.
+	nodeId() uint64
+
+	// removeXattr removes the extended attribute name and returns true if it existed.
+	removeXattr(name string) bool
+
 	// setMode sets the permissions of the node.
 	setMode(mode fs.FileMode, u avfs.UserReader) bool
 
-	// setModTime sets the modification time of the node.
-	setModTime(mtime time.Time, u avfs.UserReader) bool
+	// setTimes sets the access and modification times of the node.
+	setTimes(atime, mtime time.Time, u avfs.UserReader) bool
 
 	// setOwner sets the owner of the node.
 	setOwner(uid, gid int)
 
+	// setXattr sets the value of the extended attribute name.
+	setXattr(name string, data []byte)
+
 	// size returns the size of the node.
 	size() int64
 }
@@ -104,8 +276,9 @@ type volumes map[string]*dirNode
 
 // dirNode is the structure for a directory.
 type dirNode struct {
-	children children // children are the nodes present in the directory.
-	baseNode          // baseNode is the common structure of directories, files and symbolic links.
+	children children                // children are the nodes present in the directory.
+	parent   atomic.Pointer[dirNode] // parent is the parent directory, nil for a root or a removed directory.
+	baseNode                         // baseNode is the common structure of directories, files and symbolic links.
 }
 
 // children are the children of a directory.
@@ -113,10 +286,10 @@ type children = map[string]node
 
 // fileNode is the structure for a file.
 type fileNode struct {
-	data     []byte // data is the file content.
-	baseNode        // baseNode is the common structure of directories, files and symbolic links.
-	id       uint64 // id is a unique id to identify a file (used by SameFile function).
-	nlink    int    // nlink is the number of hardlinks to this fileNode.
+	data     fileData // data is the file content.
+	baseNode          // baseNode is the common structure of directories, files and symbolic links.
+	nlink    int      // nlink is the number of hardlinks to this fileNode.
+	nopen    int32    // nopen is the number of open file descriptors of this fileNode.
 }
 
 // symlinkNode is the structure for a symbolic link.
@@ -127,11 +300,16 @@ type symlinkNode struct {
 
 // baseNode is the common structure of directories, files and symbolic links.
 type baseNode struct {
-	mu    sync.RWMutex // mu is the RWMutex used to access the content of the node.
-	mtime int64        // mtime is the modification time.
-	mode  fs.FileMode  // mode represents a file's mode and permission bits.
-	uid   int          // uid is the user id.
-	gid   int          // gid is the group id.
+	xattrs map[string][]byte       // xattrs are the extended attributes of the node.
+	mu     sync.RWMutex            // mu is the RWMutex used to access the content of the node.
+	id     uint64                  // id is a unique id to identify a node (used by SameFile function).
+	info   atomic.Pointer[MemInfo] // info is the cached file information of the node, valid for the generation info.gen.
+	gen    uint64                  // gen is the generation of the node, incremented atomically on each change (see MemFS.Generation).
+	atime  int64                   // atime is the access time, accessed atomically (see Options.ATimePolicy).
+	mtime  int64                   // mtime is the modification time.
+	mode   fs.FileMode             // mode represents a file's mode and permission bits.
+	uid    int                     // uid is the user id.
+	gid    int                     // gid is the group id.
 }
 
 // slMode defines the behavior of searchNode function relatively to symlinks.
@@ -145,12 +323,16 @@ const (
 
 // MemInfo is the implementation of fs.DirEntry (returned by ReadDir) and fs.FileInfo (returned by Stat and Lstat).
 type MemInfo struct {
-	name  string      // name is the name of the file.
-	id    uint64      // id is a unique id to identify a file (used by SameFile function).
-	size  int64       // size is the size of the file.
-	mtime int64       // mtime is the modification time.
-	uid   int         // uid is the user id.
-	gid   int         // gid is the group id.
-	nlink int         // nlink is the number of hardlinks to this fileNode.
-	mode  fs.FileMode // mode represents a file's mode and permission bits.
+	name    string      // name is the name of the file.
+	id      uint64      // id is a unique id to identify a node (used by SameFile function).
+	size    int64       // size is the size of the file.
+	alloc   int64       // alloc is the number of bytes allocated to the file (less than size for sparse files).
+	blksize int64       // blksize is the block size of the file system.
+	gen     uint64      // gen is the generation of the node when the information was taken.
+	atime   int64       // atime is the access time.
+	mtime   int64       // mtime is the modification time.
+	uid     int         // uid is the user id.
+	gid     int         // gid is the group id.
+	nlink   int         // nlink is the number of hardlinks to this fileNode.
+	mode    fs.FileMode // mode represents a file's mode and permission bits.
 }
@@ -0,0 +1,158 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package memfs
+
+import (
+	"io/fs"
+
+	"github.com/avfs/avfs"
+)
+
+// GetXattr returns the value of the extended attribute name of the file path.
+// If the attribute does not exist, the error is avfs.ErrNoData.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) GetXattr(path, name string) ([]byte, error) {
+	return vfs.getXattr("getxattr", path, name, slmEval)
+}
+
+// LGetXattr is like GetXattr but does not follow symbolic links.
+func (vfs *MemFS) LGetXattr(path, name string) ([]byte, error) {
+	return vfs.getXattr("lgetxattr", path, name, slmLstat)
+}
+
+// ListXattr returns the sorted names of the extended attributes of the file path.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) ListXattr(path string) ([]string, error) {
+	const op = "listxattr"
+
+	child, err := vfs.searchXattrNode(path, slmEval, avfs.OpenRead)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	defer child.Unlock()
+
+	return child.listXattr(), nil
+}
+
+// RemoveXattr removes the extended attribute name of the file path.
+// If the attribute does not exist, the error is avfs.ErrNoData.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) RemoveXattr(path, name string) (err error) {
+	defer func() { vfs.logOp("RemoveXattr", path, "", err) }()
+
+	const op = "removexattr"
+
+	child, err := vfs.searchXattrNode(path, slmEval, avfs.OpenWrite)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	defer child.Unlock()
+
+	if !child.removeXattr(name) {
+		return &fs.PathError{Op: op, Path: path, Err: vfs.err.NoData}
+	}
+
+	return nil
+}
+
+// SetXattr sets the value of the extended attribute name of the file path.
+// If flags is avfs.XattrCreate and the attribute already exists, the error is avfs.ErrFileExists.
+// If flags is avfs.XattrReplace and the attribute does not exist, the error is avfs.ErrNoData.
+// If there is an error, it will be of type *PathError.
+func (vfs *MemFS) SetXattr(path, name string, data []byte, flags int) (err error) {
+	defer func() { vfs.logOp("SetXattr", path, "", err) }()
+
+	return vfs.setXattr("setxattr", path, name, data, flags, slmEval)
+}
+
+// LSetXattr is like SetXattr but does not follow symbolic links.
+func (vfs *MemFS) LSetXattr(path, name string, data []byte, flags int) (err error) {
+	defer func() { vfs.logOp("LSetXattr", path, "", err) }()
+
+	return vfs.setXattr("lsetxattr", path, name, data, flags, slmLstat)
+}
+
+// getXattr returns the value of the extended attribute name of the file path.
+func (vfs *MemFS) getXattr(op, path, name string, slMode slMode) ([]byte, error) {
+	child, err := vfs.searchXattrNode(path, slMode, avfs.OpenRead)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	defer child.Unlock()
+
+	data, ok := child.getXattr(name)
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: path, Err: vfs.err.NoData}
+	}
+
+	return data, nil
+}
+
+// setXattr sets the value of the extended attribute name of the file path.
+func (vfs *MemFS) setXattr(op, path, name string, data []byte, flags int, slMode slMode) error {
+	if name == "" || flags&^(avfs.XattrCreate|avfs.XattrReplace) != 0 ||
+		flags == avfs.XattrCreate|avfs.XattrReplace {
+		return &fs.PathError{Op: op, Path: path, Err: vfs.err.InvalidArgument}
+	}
+
+	child, err := vfs.searchXattrNode(path, slMode, avfs.OpenWrite)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	defer child.Unlock()
+
+	_, exists := child.getXattr(name)
+
+	switch {
+	case flags == avfs.XattrCreate && exists:
+		return &fs.PathError{Op: op, Path: path, Err: vfs.err.FileExists}
+	case flags == avfs.XattrReplace && !exists:
+		return &fs.PathError{Op: op, Path: path, Err: vfs.err.NoData}
+	}
+
+	child.setXattr(name, data)
+
+	return nil
+}
+
+// searchXattrNode returns the locked node of the file path
+// if the current user has the permissions perm on it.
+// Extended attributes are not supported on Windows.
+func (vfs *MemFS) searchXattrNode(path string, slMode slMode, perm avfs.OpenMode) (node, error) {
+	if !vfs.HasFeature(avfs.FeatXattr) {
+		return nil, &avfs.FeatureMissingError{Err: vfs.err.OpNotPermitted, Feature: avfs.FeatXattr}
+	}
+
+	_, child, _, err := vfs.searchNode(path, slMode)
+	if err != vfs.err.FileExists || child == nil {
+		return nil, err
+	}
+
+	child.Lock()
+
+	if !child.checkPermission(perm, vfs.User()) {
+		child.Unlock()
+
+		return nil, vfs.err.PermDenied
+	}
+
+	return child, nil
+}
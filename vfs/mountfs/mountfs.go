@@ -31,11 +31,7 @@ import (
 // path name for a given file is not guaranteed to be unique.
 // Abs calls [Clean] on the result.
 func (vfs *MountFS) Abs(path string) (string, error) {
-	if vfs.IsAbs(path) {
-		return vfs.Clean(path), nil
-	}
-
-	return vfs.Join(vfs.CurDir(), path), nil
+	return avfs.Abs(vfs, path, vfs.CurDir())
 }
 
 // Base returns the last element of path.
@@ -186,7 +182,7 @@ func (vfs *MountFS) EvalSymlinks(path string) (string, error) {
 		err = avfs.ErrWinAccessDenied
 	}
 
-	return "", &fs.PathError{Op: op, Path: path, Err: err}
+	return "", &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: err, Feature: avfs.FeatSymlink}}
 }
 
 // FromSlash returns the result of replacing each slash ('/') character
@@ -412,6 +408,7 @@ func (vfs *MountFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File
 		vfs:   vfs,
 		mount: mnt,
 		file:  f,
+		name:  name,
 	}
 
 	return mf, nil
@@ -439,11 +436,12 @@ func (vfs *MountFS) ReadFile(name string) ([]byte, error) {
 func (vfs *MountFS) Readlink(name string) (string, error) {
 	const op = "readlink"
 
+	err := error(avfs.ErrPermDenied)
 	if vfs.OSType() == avfs.OsWindows {
-		return "", &fs.PathError{Op: op, Path: name, Err: avfs.ErrWinNotReparsePoint}
+		err = avfs.ErrWinNotReparsePoint
 	}
 
-	return "", &fs.PathError{Op: op, Path: name, Err: avfs.ErrPermDenied}
+	return "", &fs.PathError{Op: op, Path: name, Err: &avfs.FeatureMissingError{Err: err, Feature: avfs.FeatSymlink}}
 }
 
 // Rel returns a relative path that is lexically equivalent to targpath when
@@ -555,11 +553,12 @@ func (vfs *MountFS) Sub(dir string) (avfs.VFS, error) {
 func (vfs *MountFS) Symlink(oldname, newname string) error {
 	const op = "symlink"
 
+	err := error(avfs.ErrPermDenied)
 	if vfs.OSType() == avfs.OsWindows {
-		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: avfs.ErrWinPrivilegeNotHeld}
+		err = avfs.ErrWinPrivilegeNotHeld
 	}
 
-	return &os.LinkError{Op: op, Old: oldname, New: newname, Err: avfs.ErrPermDenied}
+	return &os.LinkError{Op: op, Old: oldname, New: newname, Err: &avfs.FeatureMissingError{Err: err, Feature: avfs.FeatSymlink}}
 }
 
 // TempDir returns the default directory to use for temporary files.
@@ -37,7 +37,9 @@ func New(rootFS avfs.VFS, basePath string) *MountFS {
 		curMnt:  rootMnt,
 	}
 
-	_ = vfs.SetFeatures(rootFS.Features() &^ (avfs.FeatSymlink | avfs.FeatIdentityMgr))
+	_ = vfs.SetFeatures(rootFS.Features() &^
+		(avfs.FeatDeadline | avfs.FeatSymlink | avfs.FeatIdentityMgr | avfs.FeatOpenat | avfs.FeatRenameFlags |
+			avfs.FeatSpecialFiles | avfs.FeatStatFS | avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup | avfs.FeatTmpFile | avfs.FeatXattr))
 	_ = vfs.SetOSType(avfs.CurrentOSType())
 	_ = vfs.SetCurDir("/")
 
@@ -117,6 +119,14 @@ func (*MountFS) OSType() avfs.OSType {
 	return avfs.OsLinux
 }
 
+// ResolveBackend returns the mounted file system and the path used by the mounted file system
+// to handle an operation on path.
+func (vfs *MountFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	mnt, vfsPath := vfs.pathToMount(path)
+
+	return mnt.vfs, vfsPath
+}
+
 // Type returns the type of the fileSystem or Identity manager.
 func (*MountFS) Type() string {
 	return "MountFS"
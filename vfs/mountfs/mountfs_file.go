@@ -18,6 +18,7 @@ package mountfs
 
 import (
 	"io/fs"
+	"time"
 )
 
 // Chdir changes the current working directory to the file,
@@ -72,7 +73,7 @@ func (f *MountFile) Fd() uintptr {
 
 // Name returns the link of the file as presented to Open.
 func (f *MountFile) Name() string {
-	return f.mount.toAbsPath(f.file.Name())
+	return f.name
 }
 
 // Read reads up to len(b) bytes from the MemFile.
@@ -139,6 +140,33 @@ func (f *MountFile) Seek(offset int64, whence int) (ret int64, err error) {
 	return ret, f.mount.restoreError(err)
 }
 
+// SetDeadline sets the read and write deadlines for a File.
+// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+// A zero value for t means I/O operations will not time out.
+func (f *MountFile) SetDeadline(t time.Time) error {
+	err := f.file.SetDeadline(t)
+
+	return f.mount.restoreError(err)
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *MountFile) SetReadDeadline(t time.Time) error {
+	err := f.file.SetReadDeadline(t)
+
+	return f.mount.restoreError(err)
+}
+
+// SetWriteDeadline sets the deadline for any future Write calls and any
+// currently-blocked Write call.
+// A zero value for t means Write will not time out.
+func (f *MountFile) SetWriteDeadline(t time.Time) error {
+	err := f.file.SetWriteDeadline(t)
+
+	return f.mount.restoreError(err)
+}
+
 // Stat returns the FileInfo structure describing file.
 // If there is an error, it will be of type *PathError.
 func (f *MountFile) Stat() (fs.FileInfo, error) {
@@ -24,6 +24,7 @@ import (
 	"github.com/avfs/avfs"
 	"github.com/avfs/avfs/idm/memidm"
 	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/basepathfs"
 	"github.com/avfs/avfs/vfs/memfs"
 	"github.com/avfs/avfs/vfs/mountfs"
 )
@@ -32,6 +33,9 @@ var (
 	// Tests that mountfs.MountFS struct implements avfs.VFS interface.
 	_ avfs.VFS = &mountfs.MountFS{}
 
+	// Tests that mountfs.MountFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &mountfs.MountFS{}
+
 	// Tests that mountfs.MountFile struct implements avfs.File interface.
 	_ avfs.File = &mountfs.MountFile{}
 )
@@ -56,3 +60,39 @@ func TestMountFS(t *testing.T) {
 	ts := test.NewSuiteFS(t, vfs, vfs)
 	ts.TestVFSAll(t)
 }
+
+func TestMountFSResolveBackend(t *testing.T) {
+	rootFS := memfs.NewWithOptions(&memfs.Options{Name: "rootFS"})
+	dataFS := memfs.NewWithOptions(&memfs.Options{Name: "dataFS"})
+
+	err := dataFS.MkdirAll("/base/data", avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "MkdirAll")
+
+	vfs := mountfs.New(rootFS, "")
+
+	err = vfs.Mount(basepathfs.New(dataFS, "/base"), "/mnt/data", "")
+	test.RequireNoError(t, err, "Mount")
+
+	tests := []struct {
+		path, wantName, wantPath string
+	}{
+		{path: "/etc/passwd", wantName: "rootFS", wantPath: "/etc/passwd"},
+		{path: "/mnt", wantName: "rootFS", wantPath: "/mnt"},
+		{path: "/mnt/data", wantName: "dataFS", wantPath: "/base"},
+		{path: "/mnt/data/file.txt", wantName: "dataFS", wantPath: "/base/file.txt"},
+		{path: "/mnt/data/dir/sub", wantName: "dataFS", wantPath: "/base/dir/sub"},
+	}
+
+	for _, tt := range tests {
+		backend, path := avfs.ResolveBackend(vfs, tt.path)
+
+		if backend.Type() != "MemFS" || backend.Name() != tt.wantName {
+			t.Errorf("ResolveBackend %s : want backend to be MemFS %s, got %s %s",
+				tt.path, tt.wantName, backend.Type(), backend.Name())
+		}
+
+		if path != tt.wantPath {
+			t.Errorf("ResolveBackend %s : want path to be %s, got %s", tt.path, tt.wantPath, path)
+		}
+	}
+}
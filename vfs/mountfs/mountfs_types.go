@@ -53,4 +53,5 @@ type MountFile struct {
 	vfs   *MountFS
 	mount *mount
 	file  avfs.File
+	name  string
 }
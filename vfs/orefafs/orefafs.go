@@ -52,6 +52,10 @@ func (vfs *OrefaFS) Base(path string) string {
 func (vfs *OrefaFS) Chdir(dir string) error {
 	const op = "chdir"
 
+	if hasNul(dir) {
+		return &fs.PathError{Op: op, Path: dir, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(dir)
 
 	vfs.mu.RLock()
@@ -97,6 +101,10 @@ func (vfs *OrefaFS) Chdir(dir string) error {
 func (vfs *OrefaFS) Chmod(name string, mode fs.FileMode) error {
 	const op = "chmod"
 
+	if hasNul(name) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(name)
 
 	vfs.mu.RLock()
@@ -128,6 +136,10 @@ func (vfs *OrefaFS) Chown(name string, uid, gid int) error {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.OpNotPermitted}
 	}
 
+	if hasNul(name) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(name)
 
 	vfs.mu.RLock()
@@ -154,6 +166,10 @@ func (vfs *OrefaFS) Chown(name string, uid, gid int) error {
 func (vfs *OrefaFS) Chtimes(name string, atime, mtime time.Time) error {
 	const op = "chtimes"
 
+	if hasNul(name) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(name)
 
 	vfs.mu.RLock()
@@ -240,7 +256,7 @@ func (vfs *OrefaFS) EvalSymlinks(path string) (string, error) {
 		op = "CreateFile"
 	}
 
-	return "", &fs.PathError{Op: op, Path: path, Err: vfs.err.PermDenied}
+	return "", &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: vfs.err.PermDenied, Feature: avfs.FeatSymlink}}
 }
 
 // FromSlash returns the result of replacing each slash ('/') character
@@ -304,6 +320,10 @@ func (vfs *OrefaFS) Lchown(name string, uid, gid int) error {
 		return &fs.PathError{Op: op, Path: name, Err: vfs.err.OpNotPermitted}
 	}
 
+	if hasNul(name) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(name)
 
 	vfs.mu.RLock()
@@ -326,6 +346,10 @@ func (vfs *OrefaFS) Lchown(name string, uid, gid int) error {
 func (vfs *OrefaFS) Link(oldname, newname string) error {
 	const op = "link"
 
+	if hasNul(oldname) || hasNul(newname) {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: avfs.ErrInvalidArgument}
+	}
+
 	oAbsPath, _ := vfs.Abs(oldname)
 	nAbsPath, _ := vfs.Abs(newname)
 
@@ -445,6 +469,10 @@ func (vfs *OrefaFS) Mkdir(name string, perm fs.FileMode) error {
 		return &fs.PathError{Op: op, Path: "", Err: vfs.err.NoSuchDir}
 	}
 
+	if hasNul(name) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(name)
 	dirName, fileName := avfs.SplitAbs(vfs, absPath)
 
@@ -490,6 +518,10 @@ func (vfs *OrefaFS) Mkdir(name string, perm fs.FileMode) error {
 func (vfs *OrefaFS) MkdirAll(path string, perm fs.FileMode) error {
 	const op = "mkdir"
 
+	if hasNul(path) {
+		return &fs.PathError{Op: op, Path: path, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(path)
 
 	vfs.mu.Lock()
@@ -527,7 +559,8 @@ func (vfs *OrefaFS) MkdirAll(path string, perm fs.FileMode) error {
 		dirName, _ = avfs.SplitAbs(vfs, dirName)
 	}
 
-	for _, absPath = range ds {
+	for i := len(ds) - 1; i >= 0; i-- {
+		absPath = ds[i]
 		_, fileName := avfs.SplitAbs(vfs, absPath)
 
 		parent = vfs.createDir(parent, absPath, fileName, perm)
@@ -564,6 +597,10 @@ func (vfs *OrefaFS) Open(name string) (avfs.File, error) {
 func (vfs *OrefaFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File, error) {
 	const op = "open"
 
+	if hasNul(name) {
+		return &OrefaFile{}, &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	at := int64(0)
 	om := avfs.ToOpenMode(flag)
 
@@ -628,6 +665,7 @@ func (vfs *OrefaFS) OpenFile(name string, flag int, perm fs.FileMode) (avfs.File
 		vfs:      vfs,
 		nd:       child,
 		openMode: om,
+		flag:     flag,
 		name:     name,
 		at:       at,
 	}
@@ -662,7 +700,7 @@ func (vfs *OrefaFS) Readlink(name string) (string, error) {
 		err = avfs.ErrWinNotReparsePoint
 	}
 
-	return "", &fs.PathError{Op: op, Path: name, Err: err}
+	return "", &fs.PathError{Op: op, Path: name, Err: &avfs.FeatureMissingError{Err: err, Feature: avfs.FeatSymlink}}
 }
 
 // Rel returns a relative path that is lexically equivalent to targpath when
@@ -682,6 +720,10 @@ func (vfs *OrefaFS) Rel(basepath, targpath string) (string, error) {
 func (vfs *OrefaFS) Remove(name string) error {
 	const op = "remove"
 
+	if hasNul(name) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(name)
 	dirName, fileName := avfs.SplitAbs(vfs, absPath)
 
@@ -707,7 +749,7 @@ func (vfs *OrefaFS) Remove(name string) error {
 
 	child.remove()
 
-	delete(parent.children, fileName)
+	parent.removeChild(fileName)
 	delete(vfs.nodes, absPath)
 
 	return nil
@@ -724,6 +766,10 @@ func (vfs *OrefaFS) RemoveAll(path string) error {
 		return nil
 	}
 
+	if hasNul(path) {
+		return &fs.PathError{Op: "unlinkat", Path: path, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(path)
 	dirName, fileName := avfs.SplitAbs(vfs, absPath)
 
@@ -743,7 +789,7 @@ func (vfs *OrefaFS) RemoveAll(path string) error {
 
 	child.remove()
 
-	delete(parent.children, fileName)
+	parent.removeChild(fileName)
 	delete(vfs.nodes, absPath)
 
 	return nil
@@ -769,6 +815,10 @@ func (vfs *OrefaFS) removeAll(absPath string, rootNode *node) {
 func (vfs *OrefaFS) Rename(oldname, newname string) error {
 	const op = "rename"
 
+	if hasNul(oldname) || hasNul(newname) {
+		return &os.LinkError{Op: op, Old: oldname, New: newname, Err: avfs.ErrInvalidArgument}
+	}
+
 	oAbsPath, _ := vfs.Abs(oldname)
 	nAbsPath, _ := vfs.Abs(newname)
 
@@ -807,9 +857,8 @@ func (vfs *OrefaFS) Rename(oldname, newname string) error {
 		defer oParent.mu.Unlock()
 	}
 
-	nParent.children[nFileName] = oChild
-
-	delete(oParent.children, oFileName)
+	nParent.addChild(nFileName, oChild)
+	oParent.removeChild(oFileName)
 
 	vfs.mu.Lock()
 	defer vfs.mu.Unlock()
@@ -881,6 +930,10 @@ func (vfs *OrefaFS) Stat(path string) (fs.FileInfo, error) {
 
 // stat is the internal function used by Stat and Lstat.
 func (vfs *OrefaFS) stat(path, op string) (fs.FileInfo, error) {
+	if hasNul(path) {
+		return nil, &fs.PathError{Op: op, Path: path, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(path)
 	dirName, fileName := avfs.SplitAbs(vfs, absPath)
 
@@ -926,7 +979,7 @@ func (vfs *OrefaFS) Symlink(oldname, newname string) error {
 		err = avfs.ErrWinPrivilegeNotHeld
 	}
 
-	return &os.LinkError{Op: op, Old: oldname, New: newname, Err: err}
+	return &os.LinkError{Op: op, Old: oldname, New: newname, Err: &avfs.FeatureMissingError{Err: err, Feature: avfs.FeatSymlink}}
 }
 
 // TempDir returns the default directory to use for temporary files.
@@ -960,6 +1013,10 @@ func (vfs *OrefaFS) ToSysStat(info fs.FileInfo) avfs.SysStater {
 func (vfs *OrefaFS) Truncate(name string, size int64) error {
 	op := "truncate"
 
+	if hasNul(name) {
+		return &fs.PathError{Op: op, Path: name, Err: avfs.ErrInvalidArgument}
+	}
+
 	absPath, _ := vfs.Abs(name)
 
 	vfs.mu.RLock()
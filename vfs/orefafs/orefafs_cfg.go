@@ -18,11 +18,17 @@ package orefafs
 
 import (
 	"io/fs"
+	"net/url"
+	"sync"
 	"time"
 
 	"github.com/avfs/avfs"
 )
 
+func init() {
+	avfs.Register("orefa", func(*url.URL) (avfs.VFS, error) { return New(), nil })
+}
+
 // New returns a new memory file system (OrefaFS) with the default Options.
 func New() *OrefaFS {
 	return NewWithOptions(nil)
@@ -46,11 +52,15 @@ func NewWithOptions(opts *Options) *OrefaFS {
 		dirMode:  fs.ModeDir,
 		fileMode: 0,
 		lastId:   new(uint64),
+		mu:       new(sync.RWMutex),
 		name:     opts.Name,
 	}
 
 	_ = vfs.SetFeatures(features)
-	_ = vfs.SetOSType(opts.OSType)
+	if err := vfs.SetOSType(opts.OSType); err != nil {
+		// The OS type can't be changed without the avfs_setostype build tag.
+		_ = vfs.SetOSType(avfs.CurrentOSType())
+	}
 	_ = vfs.SetIdm(idm)
 	_ = vfs.SetUser(user)
 
@@ -86,6 +96,15 @@ func NewWithOptions(opts *Options) *OrefaFS {
 	return vfs
 }
 
+// Clone returns a shallow copy of the current file system.
+// The clone shares its nodes with the original file system
+// but has its own current directory and current user.
+func (vfs *OrefaFS) Clone() avfs.VFS {
+	newFs := *vfs
+
+	return &newFs
+}
+
 // Name returns the name of the fileSystem.
 func (vfs *OrefaFS) Name() string {
 	return vfs.name
@@ -19,11 +19,22 @@ package orefafs
 import (
 	"io"
 	"io/fs"
+	"os"
 	"time"
 
 	"github.com/avfs/avfs"
 )
 
+// CanRead returns true if the file was opened for reading.
+func (f *OrefaFile) CanRead() bool {
+	return f.openMode&avfs.OpenRead != 0
+}
+
+// CanWrite returns true if the file was opened for writing.
+func (f *OrefaFile) CanWrite() bool {
+	return f.openMode&avfs.OpenWrite != 0
+}
+
 // Chdir changes the current working directory to the file,
 // which must be a directory.
 // If there is an error, it will be of type *PathError.
@@ -151,6 +162,11 @@ func (f *OrefaFile) Fd() uintptr {
 	return ^(uintptr(0))
 }
 
+// Flag returns the flags (os.O_RDONLY, os.O_RDWR, os.O_CREATE etc.) the file was opened with.
+func (f *OrefaFile) Flag() int {
+	return f.flag
+}
+
 // Name returns the link of the file as presented to Open.
 func (f *OrefaFile) Name() string {
 	return f.name
@@ -473,6 +489,47 @@ func (f *OrefaFile) Seek(offset int64, whence int) (ret int64, err error) {
 	return f.at, nil
 }
 
+// SetDeadline sets the read and write deadlines for a File.
+// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+// A zero value for t means I/O operations will not time out.
+func (f *OrefaFile) SetDeadline(t time.Time) error {
+	return f.setDeadline("SetDeadline")
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *OrefaFile) SetReadDeadline(t time.Time) error {
+	return f.setDeadline("SetReadDeadline")
+}
+
+// SetWriteDeadline sets the deadline for any future Write calls and any
+// currently-blocked Write call.
+// A zero value for t means Write will not time out.
+func (f *OrefaFile) SetWriteDeadline(t time.Time) error {
+	return f.setDeadline("SetWriteDeadline")
+}
+
+// setDeadline checks that the file is open, deadlines are not supported by OrefaFS.
+func (f *OrefaFile) setDeadline(op string) error {
+	if f == nil {
+		return fs.ErrInvalid
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.name == "" {
+		return fs.ErrInvalid
+	}
+
+	if f.nd == nil {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	}
+
+	return &fs.PathError{Op: op, Path: f.name, Err: os.ErrNoDeadline}
+}
+
 // Stat returns the FileInfo structure describing file.
 // If there is an error, it will be of type *PathError.
 func (f *OrefaFile) Stat() (info fs.FileInfo, err error) {
@@ -627,12 +684,14 @@ func (f *OrefaFile) Write(b []byte) (n int, err error) {
 
 	nd.mu.Lock()
 
-	n = copy(nd.data[f.at:], b)
-	if n < len(b) {
-		nd.data = append(nd.data, b[n:]...)
-		n = len(b)
+	// Writing after the end of the file fills the gap with zeros.
+	diff := f.at + int64(len(b)) - nd.size()
+	if diff > 0 {
+		nd.data = append(nd.data, make([]byte, diff)...)
 	}
 
+	n = copy(nd.data[f.at:], b)
+
 	nd.mtime = time.Now().UnixNano()
 
 	nd.mu.Unlock()
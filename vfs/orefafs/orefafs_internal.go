@@ -20,19 +20,26 @@ import (
 	"bytes"
 	"io/fs"
 	"sort"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/avfs/avfs"
 )
 
-// addChild adds a child to a node.
+// addChild adds a child to a node and updates its modification time.
 func (nd *node) addChild(name string, child *node) {
 	if nd.children == nil {
 		nd.children = make(children)
 	}
 
 	nd.children[name] = child
+	nd.mtime = time.Now().UnixNano()
+}
+
+// hasNul returns true if path contains a NUL byte, which is not valid in a file name.
+func hasNul(path string) bool {
+	return strings.IndexByte(path, 0) >= 0
 }
 
 // createDir creates a new directory.
@@ -140,6 +147,12 @@ func (nd *node) remove() {
 	}
 }
 
+// removeChild removes a child from a node and updates its modification time.
+func (nd *node) removeChild(name string) {
+	delete(nd.children, name)
+	nd.mtime = time.Now().UnixNano()
+}
+
 // setMode sets the permissions of the file node.
 func (nd *node) setMode(mode fs.FileMode) {
 	nd.mode &^= avfs.FileModeMask
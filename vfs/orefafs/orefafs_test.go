@@ -20,6 +20,7 @@ package orefafs_test
 
 import (
 	"io/fs"
+	"slices"
 	"testing"
 
 	"github.com/avfs/avfs"
@@ -31,12 +32,18 @@ var (
 	// Tests that orefafs.OrefaFS struct implements avfs.VFS interface.
 	_ avfs.VFS = &orefafs.OrefaFS{}
 
+	// Tests that orefafs.OrefaFS struct implements avfs.Cloner interface.
+	_ avfs.Cloner = &orefafs.OrefaFS{}
+
 	// Tests that orefafs.OrefaFS struct implements avfs.VFSBase interface.
 	_ avfs.VFSBase = &orefafs.OrefaFS{}
 
 	// Tests that orefafs.OrefaFile struct implements avfs.File interface.
 	_ avfs.File = &orefafs.OrefaFile{}
 
+	// Tests that orefafs.OrefaFile struct implements avfs.OpenFlagger interface.
+	_ avfs.OpenFlagger = &orefafs.OrefaFile{}
+
 	// Tests that orefafs.OrefaInfo struct implements fs.DirEntry interface.
 	_ fs.DirEntry = &orefafs.OrefaInfo{}
 
@@ -59,6 +66,73 @@ func TestOrefaFS(t *testing.T) {
 	ts.TestVFSAll(t)
 }
 
+func TestOrefaFSRunForFeatures(t *testing.T) {
+	vfs := orefafs.New()
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.RunForFeatures(t)
+
+	skipped := ts.Skipped()
+
+	for _, fn := range []string{"TestEvalSymlink", "TestReadlink", "TestSymlink"} {
+		if !slices.Contains(skipped, fn) {
+			t.Errorf("RunForFeatures : want %s to be skipped, got skipped tests %v", fn, skipped)
+		}
+	}
+
+	if slices.Contains(skipped, "TestLink") {
+		t.Errorf("RunForFeatures : want TestLink not to be skipped, got skipped tests %v", skipped)
+	}
+}
+
+func TestOrefaFSClone(t *testing.T) {
+	vfs := orefafs.New()
+	vfsCloned := vfs.Clone()
+
+	if _, ok := vfsCloned.(avfs.Cloner); !ok {
+		t.Fatalf("Clone : want cloned vfs to be of type avfs.Cloner, got type %T", vfsCloned)
+	}
+
+	tmpDir := vfs.TempDir()
+
+	err := vfsCloned.Chdir(tmpDir)
+	test.RequireNoError(t, err, "Chdir %s", tmpDir)
+
+	if curDir := vfs.CurDir(); curDir == tmpDir {
+		t.Errorf("Chdir : want current directory of the original vfs to be unchanged, got %s", curDir)
+	}
+
+	path := vfs.Join(tmpDir, "shared")
+
+	err = vfs.WriteFile(path, nil, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "WriteFile %s", path)
+
+	_, err = vfsCloned.Stat(path)
+	test.RequireNoError(t, err, "Stat %s", path)
+}
+
+// TestOrefaFSMkdirAllDeepPath tests that MkdirAll creates each missing directory in its parent directory.
+func TestOrefaFSMkdirAllDeepPath(t *testing.T) {
+	vfs := orefafs.New()
+
+	tmpDir := vfs.TempDir()
+	path := vfs.Join(tmpDir, "a", "b", "c", "d")
+
+	err := vfs.MkdirAll(path, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "MkdirAll %s", path)
+
+	for dir := path; dir != tmpDir; dir = vfs.Dir(dir) {
+		parent, name := vfs.Dir(dir), vfs.Base(dir)
+
+		entries, err := vfs.ReadDir(parent)
+		test.RequireNoError(t, err, "ReadDir %s", parent)
+
+		if len(entries) != 1 || entries[0].Name() != name || !entries[0].IsDir() {
+			t.Errorf("ReadDir %s : want only the directory %s, got %v", parent, name, entries)
+		}
+	}
+}
+
 func TestOrefaFSNilPtrFile(t *testing.T) {
 	f := (*orefafs.OrefaFile)(nil)
 
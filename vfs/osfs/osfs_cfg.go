@@ -17,10 +17,35 @@
 package osfs
 
 import (
+	"net/url"
+
 	"github.com/avfs/avfs"
 	"github.com/avfs/avfs/idm/osidm"
+	"github.com/avfs/avfs/vfs/basepathfs"
 )
 
+func init() {
+	avfs.Register("os", newFromURL)
+}
+
+// newFromURL returns a new OS file system with no identity management from an URL.
+// If the URL has a path, the file system is a BasePathFS rooted at this path.
+func newFromURL(u *url.URL) (avfs.VFS, error) {
+	vfs := NewWithNoIdm()
+
+	path := u.Path
+	if path == "" || path == "/" {
+		return vfs, nil
+	}
+
+	// On Windows, "os:///C:/dir" is parsed as the path "/C:/dir".
+	if vfs.OSType() == avfs.OsWindows && len(path) > 2 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+
+	return basepathfs.NewWithErr(vfs, vfs.FromSlash(path))
+}
+
 // New returns a new OS file system with the default Options.
 // Don't use this for a production environment, prefer NewWithNoIdm.
 func New() *OsFS {
@@ -45,6 +70,15 @@ func NewWithOptions(opts *Options) *OsFS {
 	}
 
 	features := avfs.FeatRealFS | avfs.FeatSymlink | avfs.FeatHardlink | idm.Features()
+
+	switch avfs.CurrentOSType() {
+	case avfs.OsLinux:
+		features |= avfs.FeatChroot | avfs.FeatRenameFlags | avfs.FeatStatFS | avfs.FeatSyncFS | avfs.FeatXattr
+	case avfs.OsWindows:
+		features |= avfs.FeatStatFS
+	default:
+	}
+
 	vfs := &OsFS{}
 
 	_ = vfs.SetFeatures(features)
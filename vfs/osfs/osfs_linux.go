@@ -19,8 +19,13 @@
 package osfs
 
 import (
+	"bytes"
 	"io/fs"
+	"os"
+	"runtime"
+	"sort"
 	"syscall"
+	"unsafe"
 
 	"github.com/avfs/avfs"
 )
@@ -66,3 +71,266 @@ func (lst *LinuxSysStat) Uid() int {
 func (lst *LinuxSysStat) Nlink() uint64 {
 	return uint64(lst.Sys.Nlink) //nolint:unconvert // required for 32 bits systems.
 }
+
+// GetXattr returns the value of the extended attribute name of the file path.
+// If the attribute does not exist, the error is avfs.ErrNoData.
+// If there is an error, it will be of type *PathError.
+func (vfs *OsFS) GetXattr(path, name string) ([]byte, error) {
+	const op = "getxattr"
+
+	data, err := getXattr(path, name, syscall.SYS_GETXATTR)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	return data, nil
+}
+
+// LGetXattr is like GetXattr but does not follow symbolic links.
+func (vfs *OsFS) LGetXattr(path, name string) ([]byte, error) {
+	const op = "lgetxattr"
+
+	data, err := getXattr(path, name, syscall.SYS_LGETXATTR)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	return data, nil
+}
+
+// ListXattr returns the sorted names of the extended attributes of the file path.
+// If there is an error, it will be of type *PathError.
+func (vfs *OsFS) ListXattr(path string) ([]string, error) {
+	const op = "listxattr"
+
+	var buf []byte
+
+	for {
+		size, err := syscall.Listxattr(path, nil)
+		if err != nil {
+			return nil, &fs.PathError{Op: op, Path: path, Err: err}
+		}
+
+		buf = make([]byte, size)
+
+		n, err := syscall.Listxattr(path, buf)
+		if err == syscall.ERANGE {
+			continue // The list of attributes has grown since the first call.
+		}
+
+		if err != nil {
+			return nil, &fs.PathError{Op: op, Path: path, Err: err}
+		}
+
+		buf = buf[:n]
+
+		break
+	}
+
+	names := []string{}
+
+	for _, name := range bytes.Split(buf, []byte{0}) {
+		if len(name) != 0 {
+			names = append(names, string(name))
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// RemoveXattr removes the extended attribute name of the file path.
+// If the attribute does not exist, the error is avfs.ErrNoData.
+// If there is an error, it will be of type *PathError.
+func (vfs *OsFS) RemoveXattr(path, name string) error {
+	const op = "removexattr"
+
+	err := syscall.Removexattr(path, name)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	return nil
+}
+
+// SetXattr sets the value of the extended attribute name of the file path.
+// If flags is avfs.XattrCreate and the attribute already exists, the error is avfs.ErrFileExists.
+// If flags is avfs.XattrReplace and the attribute does not exist, the error is avfs.ErrNoData.
+// If there is an error, it will be of type *PathError.
+func (vfs *OsFS) SetXattr(path, name string, data []byte, flags int) error {
+	const op = "setxattr"
+
+	err := setXattr(path, name, data, flags, syscall.SYS_SETXATTR)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	return nil
+}
+
+// LSetXattr is like SetXattr but does not follow symbolic links.
+func (vfs *OsFS) LSetXattr(path, name string, data []byte, flags int) error {
+	const op = "lsetxattr"
+
+	err := setXattr(path, name, data, flags, syscall.SYS_LSETXATTR)
+	if err != nil {
+		return &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	return nil
+}
+
+// getXattr returns the value of an extended attribute using the getxattr or lgetxattr system call (trap).
+func getXattr(path, name string, trap uintptr) ([]byte, error) {
+	for {
+		size, err := xattrSyscall(trap, path, name, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		data := make([]byte, size)
+
+		n, err := xattrSyscall(trap, path, name, data, 0)
+		if err == syscall.ERANGE {
+			continue // The value has grown since the first call.
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return data[:n], nil
+	}
+}
+
+// setXattr sets the value of an extended attribute using the setxattr or lsetxattr system call (trap).
+func setXattr(path, name string, data []byte, flags int, trap uintptr) error {
+	_, err := xattrSyscall(trap, path, name, data, flags)
+
+	return err
+}
+
+// xattrSyscall calls one of the getxattr, lgetxattr, setxattr, lsetxattr system calls (trap).
+func xattrSyscall(trap uintptr, path, name string, data []byte, flags int) (int, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := syscall.BytePtrFromString(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var d unsafe.Pointer
+	if len(data) > 0 {
+		d = unsafe.Pointer(&data[0])
+	}
+
+	r, _, errno := syscall.Syscall6(trap, uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(n)),
+		uintptr(d), uintptr(len(data)), uintptr(flags), 0)
+	if errno != 0 {
+		return 0, errno
+	}
+
+	return int(r), nil
+}
+
+// Flags of the renameat2 system call.
+const (
+	renameNoReplace = 0x1 // renameNoReplace is the RENAME_NOREPLACE flag.
+	renameExchange  = 0x2 // renameExchange is the RENAME_EXCHANGE flag.
+)
+
+// sysRenameat2 is the number of the renameat2 system call,
+// which is not defined by the syscall package for all architectures.
+var sysRenameat2 = map[string]uintptr{ //nolint:gochecknoglobals // Read only map.
+	"386":      353,
+	"amd64":    316,
+	"arm":      382,
+	"arm64":    276,
+	"loong64":  276,
+	"mips":     4351,
+	"mipsle":   4351,
+	"mips64":   5311,
+	"mips64le": 5311,
+	"ppc64":    357,
+	"ppc64le":  357,
+	"riscv64":  276,
+	"s390x":    347,
+}[runtime.GOARCH]
+
+// RenameExchange atomically exchanges oldpath and newpath, both must exist.
+// If there is an error, it will be of type *LinkError.
+func (vfs *OsFS) RenameExchange(oldpath, newpath string) error {
+	return renameat2(oldpath, newpath, renameExchange)
+}
+
+// RenameNoReplace renames (moves) oldpath to newpath like Rename,
+// but fails with avfs.ErrFileExists if newpath already exists.
+// If there is an error, it will be of type *LinkError.
+func (vfs *OsFS) RenameNoReplace(oldpath, newpath string) error {
+	return renameat2(oldpath, newpath, renameNoReplace)
+}
+
+// renameat2 calls the renameat2 system call with paths relative to the current directory.
+func renameat2(oldpath, newpath string, flags int) error {
+	const op = "rename"
+
+	if sysRenameat2 == 0 {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: syscall.ENOSYS}
+	}
+
+	o, err := syscall.BytePtrFromString(oldpath)
+	if err != nil {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+	}
+
+	n, err := syscall.BytePtrFromString(newpath)
+	if err != nil {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+	}
+
+	dirFd := -0x64 // AT_FDCWD
+
+	_, _, errno := syscall.Syscall6(sysRenameat2, uintptr(dirFd), uintptr(unsafe.Pointer(o)),
+		uintptr(dirFd), uintptr(unsafe.Pointer(n)), uintptr(flags), 0)
+	if errno != 0 {
+		return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: errno}
+	}
+
+	return nil
+}
+
+// StatFS returns the capacity and the free space of the file system containing path.
+// If there is an error, it will be of type *PathError.
+func (vfs *OsFS) StatFS(path string) (avfs.FSStat, error) {
+	const op = "statfs"
+
+	var st syscall.Statfs_t
+
+	err := syscall.Statfs(path, &st)
+	if err != nil {
+		return avfs.FSStat{}, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	bsize := uint64(st.Bsize) //nolint:gosec // Block size is always positive.
+
+	fst := avfs.FSStat{
+		Total:      st.Blocks * bsize,
+		Free:       st.Bfree * bsize,
+		Available:  st.Bavail * bsize,
+		Inodes:     st.Files,
+		FreeInodes: st.Ffree,
+	}
+
+	return fst, nil
+}
+
+// SyncFS commits all the pending writes of the file systems to disk, like the sync system call.
+func (vfs *OsFS) SyncFS() error {
+	syscall.Sync()
+
+	return nil
+}
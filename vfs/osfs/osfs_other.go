@@ -21,6 +21,7 @@ package osfs
 import (
 	"io/fs"
 	"math"
+	"os"
 
 	"github.com/avfs/avfs"
 )
@@ -30,7 +31,7 @@ import (
 func (vfs *OsFS) Chroot(path string) error {
 	const op = "chroot"
 
-	return &fs.PathError{Op: op, Path: path, Err: avfs.ErrOpNotPermitted}
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatChroot}}
 }
 
 // ToSysStat takes a value from fs.FileInfo.Sys() and returns a value that implements interface avfs.SysStater.
@@ -58,3 +59,80 @@ func (oss *OtherSysStat) Uid() int {
 func (oss *OtherSysStat) Nlink() uint64 {
 	return 1
 }
+
+// GetXattr returns the value of the extended attribute name of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) GetXattr(path, name string) ([]byte, error) {
+	const op = "getxattr"
+
+	return nil, &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatXattr}}
+}
+
+// LGetXattr is like GetXattr but does not follow symbolic links.
+func (vfs *OsFS) LGetXattr(path, name string) ([]byte, error) {
+	const op = "lgetxattr"
+
+	return nil, &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatXattr}}
+}
+
+// ListXattr returns the sorted names of the extended attributes of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) ListXattr(path string) ([]string, error) {
+	const op = "listxattr"
+
+	return nil, &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatXattr}}
+}
+
+// RemoveXattr removes the extended attribute name of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) RemoveXattr(path, name string) error {
+	const op = "removexattr"
+
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatXattr}}
+}
+
+// SetXattr sets the value of the extended attribute name of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) SetXattr(path, name string, data []byte, flags int) error {
+	const op = "setxattr"
+
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatXattr}}
+}
+
+// LSetXattr is like SetXattr but does not follow symbolic links.
+func (vfs *OsFS) LSetXattr(path, name string, data []byte, flags int) error {
+	const op = "lsetxattr"
+
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatXattr}}
+}
+
+// RenameExchange atomically exchanges oldpath and newpath, both must exist.
+// It is not supported on this operating system.
+func (vfs *OsFS) RenameExchange(oldpath, newpath string) error {
+	const op = "rename"
+
+	err := &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatRenameFlags}
+
+	return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+}
+
+// RenameNoReplace renames (moves) oldpath to newpath like Rename,
+// but fails if newpath already exists.
+// It is not supported on this operating system.
+func (vfs *OsFS) RenameNoReplace(oldpath, newpath string) error {
+	const op = "rename"
+
+	err := &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatRenameFlags}
+
+	return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+}
+
+// StatFS returns the capacity and the free space of the file system containing path.
+// It is not supported on this operating system.
+func (vfs *OsFS) StatFS(path string) (avfs.FSStat, error) {
+	const op = "statfs"
+
+	err := &avfs.FeatureMissingError{Err: avfs.ErrOpNotPermitted, Feature: avfs.FeatStatFS}
+
+	return avfs.FSStat{}, &fs.PathError{Op: op, Path: path, Err: err}
+}
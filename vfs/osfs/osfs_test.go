@@ -34,6 +34,9 @@ var (
 	// Tests that osfs.OsFS struct implements avfs.VFSBase interface.
 	_ avfs.VFSBase = &osfs.OsFS{}
 
+	// Tests that osfs.OsFS struct implements avfs.FlagRenamer interface.
+	_ avfs.FlagRenamer = &osfs.OsFS{}
+
 	// Tests that os.File struct implements avfs.File interface.
 	_ avfs.File = &os.File{}
 )
@@ -63,7 +66,11 @@ func TestOsFSConfig(t *testing.T) {
 
 	wantFeatures := avfs.FeatHardlink | avfs.FeatRealFS | avfs.FeatSymlink
 	if vfs.OSType() == avfs.OsLinux {
-		wantFeatures |= avfs.FeatIdentityMgr
+		wantFeatures |= avfs.FeatChroot | avfs.FeatIdentityMgr | avfs.FeatRenameFlags | avfs.FeatSyncFS | avfs.FeatXattr
+	}
+
+	if vfs.OSType() == avfs.OsLinux || vfs.OSType() == avfs.OsWindows {
+		wantFeatures |= avfs.FeatStatFS
 	}
 
 	if !vfs.User().IsAdmin() && vfs.OSType() != avfs.OsWindows {
@@ -91,3 +98,38 @@ func BenchmarkOsFSAll(b *testing.B) {
 	ts := test.NewSuiteFS(b, vfs, vfs)
 	ts.BenchAll(b)
 }
+
+func TestOsFSStatFS(t *testing.T) {
+	vfs := osfs.New()
+	dir := vfs.TempDir()
+
+	if !vfs.HasFeature(avfs.FeatStatFS) {
+		_, err := vfs.StatFS(dir)
+		test.AssertPathError(t, err).Op("statfs").Path(dir).Err(avfs.ErrOpNotPermitted).
+			FeatureMissing(avfs.FeatStatFS).Test()
+
+		return
+	}
+
+	st, err := vfs.StatFS(dir)
+	test.RequireNoError(t, err, "StatFS %s", dir)
+
+	if st.Total == 0 || st.Free > st.Total || st.Available > st.Free {
+		t.Errorf("StatFS : want Available <= Free <= Total and Total > 0, got %+v", st)
+	}
+}
+
+func TestOsFSSyncFS(t *testing.T) {
+	vfs := osfs.New()
+	if !vfs.HasFeature(avfs.FeatSyncFS) {
+		t.Skipf("SyncFS is not supported on %s", vfs.OSType())
+	}
+
+	fsSyncer, ok := avfs.VFS(vfs).(avfs.FSSyncer)
+	if !ok {
+		t.Fatal("SyncFS : want OsFS to implement avfs.FSSyncer")
+	}
+
+	err := fsSyncer.SyncFS()
+	test.RequireNoError(t, err, "SyncFS")
+}
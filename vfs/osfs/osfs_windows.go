@@ -21,6 +21,9 @@ package osfs
 import (
 	"io/fs"
 	"math"
+	"os"
+	"syscall"
+	"unsafe"
 
 	"github.com/avfs/avfs"
 )
@@ -30,7 +33,7 @@ import (
 func (vfs *OsFS) Chroot(path string) error {
 	const op = "chroot"
 
-	return &fs.PathError{Op: op, Path: path, Err: avfs.ErrWinNotSupported}
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatChroot}}
 }
 
 // ToSysStat takes a value from fs.FileInfo.Sys() and returns a value that implements interface avfs.SysStater.
@@ -58,3 +61,95 @@ func (wss *WindowsSysStat) Uid() int {
 func (wss *WindowsSysStat) Nlink() uint64 {
 	return 1
 }
+
+// GetXattr returns the value of the extended attribute name of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) GetXattr(path, name string) ([]byte, error) {
+	const op = "getxattr"
+
+	return nil, &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatXattr}}
+}
+
+// LGetXattr is like GetXattr but does not follow symbolic links.
+func (vfs *OsFS) LGetXattr(path, name string) ([]byte, error) {
+	const op = "lgetxattr"
+
+	return nil, &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatXattr}}
+}
+
+// ListXattr returns the sorted names of the extended attributes of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) ListXattr(path string) ([]string, error) {
+	const op = "listxattr"
+
+	return nil, &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatXattr}}
+}
+
+// RemoveXattr removes the extended attribute name of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) RemoveXattr(path, name string) error {
+	const op = "removexattr"
+
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatXattr}}
+}
+
+// SetXattr sets the value of the extended attribute name of the file path.
+// Extended attributes are not supported on this operating system.
+func (vfs *OsFS) SetXattr(path, name string, data []byte, flags int) error {
+	const op = "setxattr"
+
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatXattr}}
+}
+
+// LSetXattr is like SetXattr but does not follow symbolic links.
+func (vfs *OsFS) LSetXattr(path, name string, data []byte, flags int) error {
+	const op = "lsetxattr"
+
+	return &fs.PathError{Op: op, Path: path, Err: &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatXattr}}
+}
+
+// RenameExchange atomically exchanges oldpath and newpath, both must exist.
+// It is not supported on this operating system.
+func (vfs *OsFS) RenameExchange(oldpath, newpath string) error {
+	const op = "rename"
+
+	err := &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatRenameFlags}
+
+	return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+}
+
+// RenameNoReplace renames (moves) oldpath to newpath like Rename,
+// but fails if newpath already exists.
+// It is not supported on this operating system.
+func (vfs *OsFS) RenameNoReplace(oldpath, newpath string) error {
+	const op = "rename"
+
+	err := &avfs.FeatureMissingError{Err: avfs.ErrWinNotSupported, Feature: avfs.FeatRenameFlags}
+
+	return &os.LinkError{Op: op, Old: oldpath, New: newpath, Err: err}
+}
+
+// procGetDiskFreeSpaceExW is the GetDiskFreeSpaceExW function of kernel32.dll used by StatFS.
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// StatFS returns the capacity and the free space of the volume containing path.
+// Inode counts are not available on Windows and are reported as 0.
+// If there is an error, it will be of type *PathError.
+func (vfs *OsFS) StatFS(path string) (avfs.FSStat, error) {
+	const op = "statfs"
+
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return avfs.FSStat{}, &fs.PathError{Op: op, Path: path, Err: err}
+	}
+
+	var st avfs.FSStat
+
+	r, _, errno := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(p)),
+		uintptr(unsafe.Pointer(&st.Available)), uintptr(unsafe.Pointer(&st.Total)), uintptr(unsafe.Pointer(&st.Free)))
+	if r == 0 {
+		return avfs.FSStat{}, &fs.PathError{Op: op, Path: path, Err: errno}
+	}
+
+	return st, nil
+}
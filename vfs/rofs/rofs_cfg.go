@@ -28,7 +28,8 @@ func New(baseFS avfs.VFS) *RoFS {
 		errPermDenied:     avfs.ErrPermDenied,
 	}
 
-	_ = vfs.SetFeatures(baseFS.Features()&^avfs.FeatIdentityMgr | avfs.FeatReadOnly)
+	_ = vfs.SetFeatures(baseFS.Features()&^(avfs.FeatIdentityMgr|avfs.FeatOpenat|avfs.FeatRenameFlags|avfs.FeatSpecialFiles|
+		avfs.FeatStatFS|avfs.FeatLchmod|avfs.FeatSyncFS|avfs.FeatDup|avfs.FeatTmpFile|avfs.FeatXattr) | avfs.FeatReadOnly)
 
 	if baseFS.OSType() == avfs.OsWindows {
 		vfs.errOpNotPermitted = avfs.ErrWinNotSupported
@@ -48,6 +49,12 @@ func (vfs *RoFS) OSType() avfs.OSType {
 	return vfs.baseFS.OSType()
 }
 
+// ResolveBackend returns the base file system and the path used by the base file system
+// to handle an operation on path.
+func (vfs *RoFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	return vfs.baseFS, path
+}
+
 // Type returns the type of the fileSystem or Identity manager.
 func (*RoFS) Type() string {
 	return "RoFS"
@@ -18,6 +18,7 @@ package rofs
 
 import (
 	"io/fs"
+	"time"
 
 	"github.com/avfs/avfs"
 )
@@ -121,6 +122,27 @@ func (f *RoFile) Seek(offset int64, whence int) (ret int64, err error) {
 	return f.baseFile.Seek(offset, whence)
 }
 
+// SetDeadline sets the read and write deadlines for a File.
+// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+// A zero value for t means I/O operations will not time out.
+func (f *RoFile) SetDeadline(t time.Time) error {
+	return f.baseFile.SetDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for future Read calls and any
+// currently-blocked Read call.
+// A zero value for t means Read will not time out.
+func (f *RoFile) SetReadDeadline(t time.Time) error {
+	return f.baseFile.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for any future Write calls and any
+// currently-blocked Write call.
+// A zero value for t means Write will not time out.
+func (f *RoFile) SetWriteDeadline(t time.Time) error {
+	return f.baseFile.SetWriteDeadline(t)
+}
+
 // Stat returns the FileInfo structure describing file.
 // If there is an error, it will be of type *PathError.
 func (f *RoFile) Stat() (fs.FileInfo, error) {
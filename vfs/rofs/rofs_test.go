@@ -31,6 +31,9 @@ var (
 	// Tests that rofs.RoFS struct implements avfs.VFS interface.
 	_ avfs.VFS = &rofs.RoFS{}
 
+	// Tests that rofs.RoFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &rofs.RoFS{}
+
 	// Tests that rofs.RoFS struct implements avfs.VFSBase interface.
 	_ avfs.VFSBase = &rofs.RoFS{}
 
@@ -0,0 +1,236 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package securefs
+
+import (
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/basepathfs"
+)
+
+// New returns a new file system (SecureFS) confined beneath the directory root of baseFS.
+func New(baseFS avfs.VFS, root string) *SecureFS {
+	vfs, err := NewWithErr(baseFS, root)
+	if err != nil {
+		panic(err)
+	}
+
+	return vfs
+}
+
+// NewWithErr returns a new file system (SecureFS) confined beneath the directory root of baseFS.
+func NewWithErr(baseFS avfs.VFS, root string) (*SecureFS, error) {
+	bpFS, err := basepathfs.NewWithErr(baseFS, root)
+	if err != nil {
+		return nil, err
+	}
+
+	vfs := &SecureFS{
+		baseFS:            baseFS,
+		bpFS:              bpFS,
+		errOpNotPermitted: avfs.ErrOpNotPermitted,
+	}
+
+	_ = vfs.SetFeatures(bpFS.Features())
+
+	if baseFS.OSType() == avfs.OsWindows {
+		vfs.errOpNotPermitted = avfs.ErrWinNotSupported
+	}
+
+	return vfs, nil
+}
+
+// Root returns the absolute path of the root directory in the base file system.
+func (vfs *SecureFS) Root() string {
+	return vfs.bpFS.BasePath()
+}
+
+// resolve returns the path to use for an operation on name, after checking that
+// the evaluation of the symbolic links of name stays beneath the root directory.
+// The last element of name is evaluated only if followLast is true.
+//
+// If no symbolic link was evaluated, name is returned unchanged, otherwise the returned path
+// is the absolute path of name with the symbolic links of its directory resolved.
+// A symbolic link with an absolute target outside the root directory or a relative target
+// going above the root directory returns an error of type *PathError wrapping errOpNotPermitted.
+//
+// The check and the operation are not atomic: a concurrent modification of the base file system
+// between them can't be detected.
+func (vfs *SecureFS) resolve(op, name string, followLast bool) (string, error) {
+	resolved, slCount, err := vfs.walk(op, name, false)
+	if err != nil {
+		return "", err
+	}
+
+	if followLast {
+		_, lastCount, err := vfs.walk(op, name, true)
+		if err != nil {
+			return "", err
+		}
+
+		slCount += lastCount
+	}
+
+	if slCount == 0 {
+		return name, nil
+	}
+
+	return vfs.bpFS.FromBasePath(resolved), nil
+}
+
+// walk evaluates the symbolic links of name in the base file system, starting from the root directory.
+// It returns the resolved path and the number of symbolic links evaluated.
+// The last element of name is evaluated only if followLast is true.
+func (vfs *SecureFS) walk(op, name string, followLast bool) (resolved string, slCount int, err error) {
+	root := vfs.Root()
+
+	path := name
+	if !vfs.IsAbs(path) {
+		wd, err := vfs.bpFS.Abs(".")
+		if err != nil {
+			return "", 0, err
+		}
+
+		path = wd + string(vfs.PathSeparator()) + path
+	}
+
+	parts := vfs.splitPath(path[avfs.VolumeNameLen(vfs, path):])
+	resolved = root
+
+	for len(parts) > 0 {
+		part := parts[0]
+		parts = parts[1:]
+
+		if part == "." {
+			continue
+		}
+
+		if part == ".." {
+			if resolved != root {
+				resolved = vfs.baseFS.Dir(resolved)
+
+				continue
+			}
+
+			if slCount > 0 {
+				return "", 0, &fs.PathError{Op: op, Path: name, Err: vfs.errOpNotPermitted}
+			}
+
+			continue
+		}
+
+		next := vfs.baseFS.Join(resolved, part)
+		if len(parts) == 0 && !followLast {
+			resolved = next
+
+			break
+		}
+
+		info, err := vfs.baseFS.Lstat(next)
+		if err != nil || info.Mode()&fs.ModeSymlink == 0 {
+			resolved = next
+
+			continue
+		}
+
+		slCount++
+		if slCount > avfs.MaxSymlinks {
+			return "", 0, &fs.PathError{Op: op, Path: name, Err: avfs.ErrTooManySymlinks}
+		}
+
+		link, err := vfs.baseFS.Readlink(next)
+		if err != nil {
+			resolved = next
+
+			continue
+		}
+
+		if vfs.baseFS.IsAbs(link) {
+			if !vfs.isBeneath(root, link) {
+				return "", 0, &fs.PathError{Op: op, Path: name, Err: vfs.errOpNotPermitted}
+			}
+
+			link = link[len(root):]
+			resolved = root
+		}
+
+		parts = append(vfs.splitPath(link), parts...)
+	}
+
+	return resolved, slCount, nil
+}
+
+// splitPath returns the non-empty elements of path.
+func (vfs *SecureFS) splitPath(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool {
+		return r < 0x80 && vfs.IsPathSeparator(uint8(r))
+	})
+}
+
+// isBeneath returns true if the absolute path is root or one of its descendants.
+func (vfs *SecureFS) isBeneath(root, path string) bool {
+	if !strings.HasPrefix(path, root) {
+		return false
+	}
+
+	return len(path) == len(root) || vfs.IsPathSeparator(path[len(root)]) ||
+		vfs.IsPathSeparator(root[len(root)-1])
+}
+
+// restorePathError replaces the resolved path by name in a fs.PathError.
+func restorePathError(name, path string, err error) error {
+	e, ok := err.(*fs.PathError)
+	if !ok || name == path {
+		return err
+	}
+
+	return &fs.PathError{Op: e.Op, Path: name, Err: e.Err}
+}
+
+// restoreLinkError replaces the resolved paths by oldname and newname in an os.LinkError.
+func restoreLinkError(oldname, newname string, err error) error {
+	e, ok := err.(*os.LinkError)
+	if !ok {
+		return err
+	}
+
+	return &os.LinkError{Op: e.Op, Old: oldname, New: newname, Err: e.Err}
+}
+
+// Name returns the name of the fileSystem.
+func (vfs *SecureFS) Name() string {
+	return vfs.baseFS.Name()
+}
+
+// OSType returns the operating system type of the file system.
+func (vfs *SecureFS) OSType() avfs.OSType {
+	return vfs.baseFS.OSType()
+}
+
+// ResolveBackend returns the base file system and the path used by the base file system
+// to handle an operation on path.
+func (vfs *SecureFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	return vfs.bpFS.ResolveBackend(path)
+}
+
+// Type returns the type of the fileSystem or Identity manager.
+func (*SecureFS) Type() string {
+	return "SecureFS"
+}
@@ -0,0 +1,231 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package securefs_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/avfs/avfs/vfs/osfs"
+	"github.com/avfs/avfs/vfs/securefs"
+)
+
+var (
+	// Tests that securefs.SecureFS struct implements avfs.VFS interface.
+	_ avfs.VFS = &securefs.SecureFS{}
+
+	// Tests that securefs.SecureFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &securefs.SecureFS{}
+
+	// Tests that securefs.SecureFS struct implements avfs.VFSBase interface.
+	_ avfs.VFSBase = &securefs.SecureFS{}
+)
+
+func initTest(t *testing.T) *test.Suite {
+	baseFS := memfs.New()
+	root := avfs.FromUnixPath(baseFS, "/secure/root")
+
+	err := baseFS.MkdirAll(root, avfs.DefaultDirPerm)
+	if err != nil {
+		t.Fatalf("MkdirAll %s : want error to be nil, got %v", root, err)
+	}
+
+	err = avfs.MkSystemDirs(baseFS, avfs.SystemDirs(baseFS, root))
+	if err != nil {
+		t.Fatalf("MkSystemDirs : want error to be nil, got %v", err)
+	}
+
+	vfs := securefs.New(baseFS, root)
+	ts := test.NewSuiteFS(t, vfs, vfs)
+
+	return ts
+}
+
+func TestSecureFS(t *testing.T) {
+	ts := initTest(t)
+	ts.TestVFSAll(t)
+}
+
+func TestSecureFSConfig(t *testing.T) {
+	baseFS := memfs.New()
+	vfs := securefs.New(baseFS, "/")
+
+	if vfs.Type() != "SecureFS" {
+		t.Errorf("Type : want type to be SecureFS, got %s", vfs.Type())
+	}
+
+	if vfs.OSType() != baseFS.OSType() {
+		t.Errorf("OSType : want os type to be %v, got %v", baseFS.OSType(), vfs.OSType())
+	}
+
+	if !vfs.HasFeature(avfs.FeatSymlink) {
+		t.Errorf("Features : want FeatSymlink present, got missing")
+	}
+
+	test.AssertPanic(t, "New", func() {
+		_ = securefs.New(baseFS, avfs.FromUnixPath(baseFS, "/non/existing/dir"))
+	})
+}
+
+// TestSecureFSEscape tests that symbolic links can't be followed outside the root directory.
+func TestSecureFSEscape(t *testing.T) {
+	t.Run("MemFS", func(t *testing.T) {
+		baseFS := memfs.New()
+		secret := avfs.FromUnixPath(baseFS, "/secret")
+
+		err := baseFS.WriteFile(secret, []byte("secret"), avfs.DefaultFilePerm)
+		if err != nil {
+			t.Fatalf("WriteFile : want error to be nil, got %v", err)
+		}
+
+		testEscape(t, baseFS, baseFS.TempDir(), secret)
+	})
+
+	t.Run("OsFS", func(t *testing.T) {
+		baseFS := osfs.New()
+		if baseFS.OSType() != avfs.OsLinux {
+			t.Skip("TestSecureFSEscape : /etc/passwd is only available on Linux, skipping test")
+		}
+
+		if _, err := baseFS.Stat("/etc/passwd"); err != nil {
+			t.Skipf("TestSecureFSEscape : /etc/passwd is not available (%v), skipping test", err)
+		}
+
+		testEscape(t, baseFS, t.TempDir(), "/etc/passwd")
+	})
+}
+
+// testEscape creates a confined file system under tmpDir and checks that target,
+// a file outside the root directory, can't be accessed through symbolic links.
+func testEscape(t *testing.T, baseFS avfs.VFS, tmpDir, target string) {
+	root, err := baseFS.MkdirTemp(tmpDir, "securefs")
+	if err != nil {
+		t.Fatalf("MkdirTemp : want error to be nil, got %v", err)
+	}
+
+	defer baseFS.RemoveAll(root) //nolint:errcheck // Ignore errors.
+
+	rel, err := baseFS.Rel(root, target)
+	if err != nil {
+		t.Fatalf("Rel : want error to be nil, got %v", err)
+	}
+
+	const content = "inside"
+
+	symlinks := map[string]string{
+		"passwd":  target,
+		"relLink": rel,
+		"rootDir": baseFS.Dir(target),
+		"loop":    "loop",
+		"inside":  baseFS.Join(root, "file"),
+		"relFile": "file",
+		"dirLink": "dir",
+	}
+
+	err = baseFS.WriteFile(baseFS.Join(root, "file"), []byte(content), avfs.DefaultFilePerm)
+	if err != nil {
+		t.Fatalf("WriteFile : want error to be nil, got %v", err)
+	}
+
+	err = baseFS.Mkdir(baseFS.Join(root, "dir"), avfs.DefaultDirPerm)
+	if err != nil {
+		t.Fatalf("Mkdir : want error to be nil, got %v", err)
+	}
+
+	for newname, oldname := range symlinks {
+		err = baseFS.Symlink(oldname, baseFS.Join(root, newname))
+		if err != nil {
+			t.Fatalf("Symlink : want error to be nil, got %v", err)
+		}
+	}
+
+	vfs := securefs.New(baseFS, root)
+	errOpNotPermitted := error(avfs.ErrOpNotPermitted)
+
+	if vfs.OSType() == avfs.OsWindows {
+		errOpNotPermitted = avfs.ErrWinNotSupported
+	}
+
+	t.Run("EscapeRead", func(t *testing.T) {
+		targetName := vfs.Base(target)
+
+		for _, path := range []string{
+			"/passwd",
+			"/relLink",
+			"/rootDir/" + targetName,
+			"/dir/../rootDir/" + targetName,
+			"/dirLink/../passwd",
+		} {
+			path = avfs.FromUnixPath(vfs, path)
+
+			_, err = vfs.ReadFile(path)
+			test.AssertPathError(t, err).Op("open").Path(path).Err(errOpNotPermitted).Test()
+
+			_, err = vfs.Stat(path)
+			test.AssertPathError(t, err).Op("stat").Path(path).Err(errOpNotPermitted).Test()
+
+			err = vfs.Chmod(path, 0o777)
+			test.AssertPathError(t, err).Op("chmod").Path(path).Err(errOpNotPermitted).Test()
+		}
+	})
+
+	t.Run("EscapeWrite", func(t *testing.T) {
+		path := avfs.FromUnixPath(vfs, "/rootDir/avfsSecureFS")
+
+		err = vfs.WriteFile(path, []byte(content), avfs.DefaultFilePerm)
+		test.AssertPathError(t, err).Op("open").Path(path).Err(errOpNotPermitted).Test()
+
+		err = vfs.Mkdir(path, avfs.DefaultDirPerm)
+		test.AssertPathError(t, err).Op("mkdir").Path(path).Err(errOpNotPermitted).Test()
+	})
+
+	t.Run("Loop", func(t *testing.T) {
+		path := avfs.FromUnixPath(vfs, "/loop")
+
+		_, err = vfs.Stat(path)
+		test.AssertPathError(t, err).Op("stat").Path(path).Err(avfs.ErrTooManySymlinks).Test()
+	})
+
+	t.Run("Inside", func(t *testing.T) {
+		for _, path := range []string{"/file", "/inside", "/relFile", "/dirLink/../file"} {
+			path = avfs.FromUnixPath(vfs, path)
+
+			data, err := vfs.ReadFile(path)
+			if err != nil || string(data) != content {
+				t.Errorf("ReadFile %s : want content to be %q, got %q, %v", path, content, data, err)
+			}
+		}
+	})
+
+	t.Run("Lstat", func(t *testing.T) {
+		path := avfs.FromUnixPath(vfs, "/passwd")
+
+		info, err := vfs.Lstat(path)
+		if err != nil {
+			t.Fatalf("Lstat : want error to be nil, got %v", err)
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			t.Errorf("Lstat : want %s to be a symbolic link, got mode %s", path, info.Mode())
+		}
+	})
+}
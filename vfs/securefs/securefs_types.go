@@ -0,0 +1,30 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package securefs
+
+import (
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/vfs/basepathfs"
+)
+
+// SecureFS implements a file system confined beneath a root directory.
+type SecureFS struct {
+	baseFS            avfs.VFS               // baseFS is the base file system.
+	bpFS              *basepathfs.BasePathFS // bpFS translates the paths of the file system to paths of the base file system.
+	errOpNotPermitted error                  // errOpNotPermitted is the error returned when a path escapes the root directory.
+	avfs.FeaturesFn                          // FeaturesFn provides features functions to a file system or an identity manager.
+}
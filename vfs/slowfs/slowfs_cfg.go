@@ -0,0 +1,87 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package slowfs
+
+import (
+	"time"
+
+	"github.com/avfs/avfs"
+)
+
+// New returns a new SlowFS file system from a baseFS file system.
+// The sleep function of the options can be replaced by a fake clock (for example one that
+// only advances a counter or honors a context) so that tests don't really wait.
+func New(baseFS avfs.VFS, opts *Options) *SlowFS {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	sleep := opts.Sleep
+	if sleep == nil {
+		sleep = time.Sleep
+	}
+
+	vfs := &SlowFS{
+		baseFS:    baseFS,
+		fnLatency: opts.FnLatency,
+		sleep:     sleep,
+		latency:   opts.Latency,
+		bandwidth: opts.Bandwidth,
+	}
+
+	_ = vfs.SetFeatures(baseFS.Features() &^ (avfs.FeatOpenat | avfs.FeatRenameFlags | avfs.FeatSpecialFiles |
+		avfs.FeatStatFS | avfs.FeatLchmod | avfs.FeatSyncFS | avfs.FeatDup | avfs.FeatTmpFile | avfs.FeatXattr))
+
+	return vfs
+}
+
+// delay waits for the latency of the function fn.
+func (vfs *SlowFS) delay(fn avfs.FnVFS) {
+	d, ok := vfs.fnLatency[fn]
+	if !ok {
+		d = vfs.latency
+	}
+
+	if d > 0 {
+		vfs.sleep(d)
+	}
+}
+
+// transfer waits for the time needed to read or write n bytes with the configured bandwidth.
+func (vfs *SlowFS) transfer(n int) {
+	if vfs.bandwidth <= 0 || n <= 0 {
+		return
+	}
+
+	vfs.sleep(time.Duration(int64(n) * int64(time.Second) / vfs.bandwidth))
+}
+
+// Name returns the name of the fileSystem.
+func (vfs *SlowFS) Name() string {
+	return vfs.baseFS.Name()
+}
+
+// ResolveBackend returns the base file system and the path used by the base file system
+// to handle an operation on path.
+func (vfs *SlowFS) ResolveBackend(path string) (backend avfs.VFS, translatedPath string) {
+	return vfs.baseFS, path
+}
+
+// Type returns the type of the fileSystem or Identity manager.
+func (*SlowFS) Type() string {
+	return "SlowFS"
+}
@@ -0,0 +1,106 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package slowfs_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/avfs/avfs"
+	"github.com/avfs/avfs/test"
+	"github.com/avfs/avfs/vfs/memfs"
+	"github.com/avfs/avfs/vfs/slowfs"
+)
+
+var (
+	// Tests that slowfs.SlowFS struct implements avfs.VFS interface.
+	_ avfs.VFS = &slowfs.SlowFS{}
+
+	// Tests that slowfs.SlowFS struct implements avfs.BackendResolver interface.
+	_ avfs.BackendResolver = &slowfs.SlowFS{}
+
+	// Tests that slowfs.SlowFS struct implements avfs.VFSBase interface.
+	_ avfs.VFSBase = &slowfs.SlowFS{}
+
+	// Tests that slowfs.SlowFile struct implements avfs.File interface.
+	_ avfs.File = &slowfs.SlowFile{}
+)
+
+func TestSlowFS(t *testing.T) {
+	vfs := slowfs.New(memfs.New(), nil)
+
+	ts := test.NewSuiteFS(t, vfs, vfs)
+	ts.TestVFSAll(t)
+}
+
+func TestSlowFSDelays(t *testing.T) {
+	var slept time.Duration
+
+	vfs := slowfs.New(memfs.New(), &slowfs.Options{
+		Latency:   time.Millisecond,
+		FnLatency: map[avfs.FnVFS]time.Duration{avfs.FnStat: 5 * time.Millisecond},
+		Bandwidth: 1000,
+		Sleep:     func(d time.Duration) { slept += d },
+	})
+
+	assertSlept := func(t *testing.T, op string, want time.Duration) {
+		t.Helper()
+
+		if slept != want {
+			t.Errorf("%s : want delay to be %s, got %s", op, want, slept)
+		}
+
+		slept = 0
+	}
+
+	dir := vfs.Join(vfs.TempDir(), "slow")
+
+	err := vfs.Mkdir(dir, avfs.DefaultDirPerm)
+	test.RequireNoError(t, err, "Mkdir %s", dir)
+	assertSlept(t, "Mkdir", time.Millisecond)
+
+	_, err = vfs.Stat(dir)
+	test.RequireNoError(t, err, "Stat %s", dir)
+	assertSlept(t, "Stat", 5*time.Millisecond)
+
+	path := vfs.Join(dir, "file.txt")
+
+	f, err := vfs.OpenFile(path, os.O_CREATE|os.O_RDWR, avfs.DefaultFilePerm)
+	test.RequireNoError(t, err, "OpenFile %s", path)
+	assertSlept(t, "OpenFile", time.Millisecond)
+
+	_, err = f.Write(make([]byte, 500))
+	test.RequireNoError(t, err, "Write %s", path)
+	assertSlept(t, "Write", 500*time.Millisecond)
+
+	n, _ := f.ReadAt(make([]byte, 1000), 250)
+	if n != 250 {
+		t.Errorf("ReadAt : want bytes read to be 250, got %d", n)
+	}
+
+	assertSlept(t, "ReadAt", 250*time.Millisecond)
+
+	err = f.Close()
+	test.RequireNoError(t, err, "Close %s", path)
+	assertSlept(t, "Close", time.Millisecond)
+
+	_ = vfs.Join(dir, "file.txt")
+	assertSlept(t, "Join", 0)
+}
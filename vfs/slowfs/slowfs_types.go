@@ -0,0 +1,47 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package slowfs
+
+import (
+	"time"
+
+	"github.com/avfs/avfs"
+)
+
+// SlowFS implements a slow file system using the avfs.VFS interface.
+type SlowFS struct {
+	baseFS          avfs.VFS                     // baseFS is the base file system.
+	fnLatency       map[avfs.FnVFS]time.Duration // fnLatency is the latency of specific functions.
+	sleep           func(d time.Duration)        // sleep waits for the duration d.
+	latency         time.Duration                // latency is the default latency of operations on files and directories.
+	bandwidth       int64                        // bandwidth is the maximum number of bytes per second read or written.
+	avfs.FeaturesFn                              // FeaturesFn provides features functions to a file system or an identity manager.
+}
+
+// SlowFile represents an open file descriptor.
+type SlowFile struct {
+	baseFile avfs.File // baseFile represents an open file descriptor from the base file system.
+	vfs      *SlowFS   // vfs is the slow file system of the file.
+}
+
+// Options defines the initialization options of SlowFS.
+type Options struct {
+	FnLatency map[avfs.FnVFS]time.Duration // FnLatency overrides Latency for specific functions (avfs.FnStat, avfs.FnOpenFile, ...).
+	Sleep     func(d time.Duration)        // Sleep waits for the duration d, time.Sleep if nil.
+	Latency   time.Duration                // Latency is the delay of each operation on files and directories (Stat, OpenFile, Mkdir, ...).
+	Bandwidth int64                        // Bandwidth is the maximum number of bytes per second read or written, 0 means no limit.
+}
@@ -0,0 +1,341 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs
+
+import (
+	"slices"
+	"strings"
+)
+
+// CleanPath returns the shortest path name equivalent to path for the operating system type osType
+// by purely lexical processing, independently of the current operating system.
+// It applies the same rules as Clean: on Windows, volume names (drive letters and UNC prefixes)
+// are preserved and occurrences of slash are replaced by `\`.
+// For example, CleanPath(OsWindows, `C:\a\..\b`) returns `C:\b` on any host.
+func CleanPath(osType OSType, path string) string {
+	pathSeparator := uint8('/')
+	if osType == OsWindows {
+		pathSeparator = '\\'
+	}
+
+	originalPath := path
+	volLen := osVolumeNameLen(osType, path)
+
+	path = path[volLen:]
+	if path == "" {
+		if volLen > 1 && isOSPathSeparator(osType, originalPath[0]) && isOSPathSeparator(osType, originalPath[1]) {
+			// should be UNC
+			return osFromSlash(osType, originalPath)
+		}
+
+		return originalPath + "."
+	}
+
+	rooted := isOSPathSeparator(osType, path[0])
+
+	// Invariants:
+	//	reading from path; r is index of next byte to process.
+	//	writing to buf; w is index of next byte to write.
+	//	dotdot is index in buf where .. must stop, either because
+	//		it is the leading slash or it is a leading ../../.. prefix.
+	n := len(path)
+	out := lazybuf{path: path, volAndPath: originalPath, volLen: volLen}
+	r, dotdot := 0, 0
+
+	if rooted {
+		out.append(pathSeparator)
+
+		r, dotdot = 1, 1
+	}
+
+	for r < n {
+		switch {
+		case isOSPathSeparator(osType, path[r]):
+			// empty path element
+			r++
+		case path[r] == '.' && (r+1 == n || isOSPathSeparator(osType, path[r+1])):
+			// . element
+			r++
+		case path[r] == '.' && path[r+1] == '.' && (r+2 == n || isOSPathSeparator(osType, path[r+2])):
+			// .. element: remove to last separator
+			r += 2
+
+			switch {
+			case out.w > dotdot:
+				// can backtrack
+				out.w--
+				for out.w > dotdot && !isOSPathSeparator(osType, out.index(out.w)) {
+					out.w--
+				}
+			case !rooted:
+				// cannot backtrack, but not rooted, so append .. element.
+				if out.w > 0 {
+					out.append(pathSeparator)
+				}
+
+				out.append('.')
+				out.append('.')
+				dotdot = out.w
+			}
+		default:
+			// real path element.
+			// add slash if needed
+			if rooted && out.w != 1 || !rooted && out.w != 0 {
+				out.append(pathSeparator)
+			}
+
+			// copy element
+			for ; r < n && !isOSPathSeparator(osType, path[r]); r++ {
+				out.append(path[r])
+			}
+		}
+	}
+
+	// Turn empty string into "."
+	if out.w == 0 {
+		out.append('.')
+	}
+
+	if osType == OsWindows {
+		postClean(pathSeparator, &out) // avoid creating absolute paths on Windows
+	}
+
+	return osFromSlash(osType, out.string())
+}
+
+// isOSPathSeparator reports whether c is a directory separator character for the OS type.
+func isOSPathSeparator(osType OSType, c uint8) bool {
+	if osType != OsWindows {
+		return c == '/'
+	}
+
+	return isSlash(c)
+}
+
+func isSlash(c uint8) bool {
+	return c == '\\' || c == '/'
+}
+
+// osFromSlash returns the result of replacing each slash ('/') character
+// in path with a separator character for the OS type.
+func osFromSlash(osType OSType, path string) string {
+	if osType != OsWindows {
+		return path
+	}
+
+	return strings.ReplaceAll(path, "/", `\`)
+}
+
+// postClean adjusts the results of Clean to avoid turning a relative path
+// into an absolute or rooted one.
+func postClean(pathSeparator uint8, out *lazybuf) {
+	if out.volLen != 0 || out.buf == nil {
+		return
+	}
+
+	// If a ':' appears in the path element at the start of a path,
+	// insert a .\ at the beginning to avoid converting relative paths
+	// like a/../c: into c:.
+	for _, c := range out.buf {
+		if isSlash(c) {
+			break
+		}
+
+		if c == ':' {
+			out.prepend('.', pathSeparator)
+
+			return
+		}
+	}
+
+	// If a path begins with \??\, insert a \. at the beginning
+	// to avoid converting paths like \a\..\??\c:\x into \??\c:\x
+	// (equivalent to c:\x).
+	if len(out.buf) >= 3 && isSlash(out.buf[0]) && out.buf[1] == '?' && out.buf[2] == '?' {
+		out.prepend(pathSeparator, '.')
+	}
+}
+
+// osVolumeNameLen returns length of the leading volume name for the Windows OS type.
+// It returns 0 elsewhere.
+//
+// See:
+// https://learn.microsoft.com/en-us/dotnet/standard/io/file-path-formats
+// https://googleprojectzero.blogspot.com/2016/02/the-definitive-guide-on-win32-to-nt.html
+func osVolumeNameLen(osType OSType, path string) int {
+	if osType != OsWindows {
+		return 0
+	}
+
+	switch {
+	case len(path) >= 2 && path[1] == ':':
+		// Path starts with a drive letter.
+		return 2
+
+	case path == "" || !isSlash(path[0]):
+		// Path does not have a volume component.
+		return 0
+
+	case pathHasPrefixFold(path, `\\.`) ||
+		pathHasPrefixFold(path, `\\?`) || pathHasPrefixFold(path, `\??`):
+		// Path starts with a device prefix: \\.\ for Local Device paths,
+		// or \\?\ or \??\ for Root Local Device paths.
+		switch {
+		case len(path) == 3:
+			return 3 // exactly \\., \\?, or \??
+		case pathHasPrefixFold(path[4:], `UNC`):
+			// The UNC host and share are part of the volume prefix.
+			return validVolumeNameLen(path, uncLen(path, len(`\\.\UNC\`)))
+		}
+
+		// The next component after the device prefix is part of the volume name.
+		_, rest, ok := cutPath(path[4:])
+		if !ok {
+			return validVolumeNameLen(path, len(path))
+		}
+
+		return validVolumeNameLen(path, len(path)-len(rest)-1)
+
+	case len(path) >= 2 && isSlash(path[1]):
+		// Path starts with \\, and is a UNC path.
+		return validVolumeNameLen(path, uncLen(path, 2))
+	}
+
+	return 0
+}
+
+// validVolumeNameLen returns n if path[:n] is a valid Windows volume name.
+// If the volume name contains a ".." path component, it returns 0.
+func validVolumeNameLen(path string, n int) int {
+	for p := path[:n]; p != ""; {
+		var part string
+
+		part, p, _ = cutPath(p)
+		if part == ".." {
+			return 0
+		}
+	}
+
+	return n
+}
+
+// pathHasPrefixFold tests whether the path s begins with prefix,
+// ignoring case and treating all path separators as equivalent.
+// If s is longer than prefix, then s[len(prefix)] must be a path separator.
+func pathHasPrefixFold(s, prefix string) bool {
+	if len(s) < len(prefix) {
+		return false
+	}
+
+	for i := range len(prefix) {
+		if isSlash(prefix[i]) {
+			if !isSlash(s[i]) {
+				return false
+			}
+		} else if toUpper(prefix[i]) != toUpper(s[i]) {
+			return false
+		}
+	}
+
+	return len(s) == len(prefix) || isSlash(s[len(prefix)])
+}
+
+// uncLen returns the length of the volume prefix of a UNC path.
+// prefixLen is the prefix prior to the start of the UNC host;
+// for example, for "//host/share", the prefixLen is len("//")==2.
+func uncLen(path string, prefixLen int) int {
+	count := 0
+
+	for i := prefixLen; i < len(path); i++ {
+		if isSlash(path[i]) {
+			count++
+			if count == 2 {
+				return i
+			}
+		}
+	}
+
+	return len(path)
+}
+
+// cutPath slices path around the first Windows path separator.
+func cutPath(path string) (before, after string, found bool) {
+	for i := range len(path) {
+		if isSlash(path[i]) {
+			return path[:i], path[i+1:], true
+		}
+	}
+
+	return path, "", false
+}
+
+func toUpper(c byte) byte {
+	if 'a' <= c && c <= 'z' {
+		return c - ('a' - 'A')
+	}
+
+	return c
+}
+
+// A lazybuf is a lazily constructed path buffer.
+// It supports append, reading previously appended bytes,
+// and retrieving the final string. It does not allocate a buffer
+// to hold the output until that output diverges from s.
+type lazybuf struct {
+	path       string
+	buf        []byte
+	w          int
+	volAndPath string
+	volLen     int
+}
+
+func (b *lazybuf) index(i int) byte {
+	if b.buf != nil {
+		return b.buf[i]
+	}
+
+	return b.path[i]
+}
+
+func (b *lazybuf) append(c byte) {
+	if b.buf == nil {
+		if b.w < len(b.path) && b.path[b.w] == c {
+			b.w++
+
+			return
+		}
+
+		b.buf = make([]byte, len(b.path))
+		copy(b.buf, b.path[:b.w])
+	}
+
+	b.buf[b.w] = c
+	b.w++
+}
+
+func (b *lazybuf) prepend(prefix ...byte) {
+	b.buf = slices.Insert(b.buf, 0, prefix...)
+	b.w += len(prefix)
+}
+
+func (b *lazybuf) string() string {
+	if b.buf == nil {
+		return b.volAndPath[:b.volLen+b.w]
+	}
+
+	return b.volAndPath[:b.volLen] + string(b.buf[:b.w])
+}
@@ -0,0 +1,115 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+//go:build !avfs_race
+
+package avfs_test
+
+import (
+	"testing"
+
+	"github.com/avfs/avfs"
+)
+
+// TestCleanPath tests CleanPath function for Linux and Windows OS types, independently of the current OS.
+func TestCleanPath(t *testing.T) {
+	cleanTests := []struct {
+		path, linux, windows string
+	}{
+		// Already clean
+		{"abc", "abc", `abc`},
+		{"abc/def", "abc/def", `abc\def`},
+		{"a/b/c", "a/b/c", `a\b\c`},
+		{".", ".", `.`},
+		{"..", "..", `..`},
+		{"../..", "../..", `..\..`},
+		{"../../abc", "../../abc", `..\..\abc`},
+		{"/abc", "/abc", `\abc`},
+		{"/", "/", `\`},
+
+		// Empty is current dir
+		{"", ".", `.`},
+
+		// Remove trailing slash
+		{"abc/", "abc", `abc`},
+		{"abc/def/", "abc/def", `abc\def`},
+		{"./", ".", `.`},
+		{"../../", "../..", `..\..`},
+		{"/abc/", "/abc", `\abc`},
+
+		// Remove doubled slash
+		{"abc//def//ghi", "abc/def/ghi", `abc\def\ghi`},
+		{"abc//", "abc", `abc`},
+
+		// Remove . elements
+		{"abc/./def", "abc/def", `abc\def`},
+		{"/./abc/def", "/abc/def", `\abc\def`},
+		{"abc/.", "abc", `abc`},
+
+		// Remove .. elements
+		{"abc/def/ghi/../jkl", "abc/def/jkl", `abc\def\jkl`},
+		{"abc/def/../ghi/../jkl", "abc/jkl", `abc\jkl`},
+		{"abc/def/..", "abc", `abc`},
+		{"abc/def/../..", ".", `.`},
+		{"/abc/def/../..", "/", `\`},
+		{"abc/def/../../..", "..", `..`},
+		{"/abc/def/../../..", "/", `\`},
+		{"abc/def/../../../ghi/jkl/../../../mno", "../../mno", `..\..\mno`},
+		{"/../abc", "/abc", `\abc`},
+
+		// Combinations
+		{"abc/./../def", "def", `def`},
+		{"abc//./../def", "def", `def`},
+		{"abc/../../././../def", "../../def", `..\..\def`},
+
+		// Backslashes are only separators on Windows
+		{`a\b\..\c`, `a\b\..\c`, `a\c`},
+
+		// Volume names
+		{`c:`, `c:`, `c:.`},
+		{`c:\`, `c:\`, `c:\`},
+		{`c:\abc`, `c:\abc`, `c:\abc`},
+		{`C:\a\..\b`, `C:\a\..\b`, `C:\b`},
+		{`c:abc\..\..\.\.\..\def`, `c:abc\..\..\.\.\..\def`, `c:..\..\def`},
+		{`c:\abc\def\..\..`, `c:\abc\def\..\..`, `c:\`},
+		{`c:\..\abc`, `c:\..\abc`, `c:\abc`},
+		{`c:/abc/../def`, `c:/def`, `c:\def`},
+
+		// UNC paths
+		{`\\host\share\foo\..\bar`, `\\host\share\foo\..\bar`, `\\host\share\bar`},
+		{`//host/share/foo/../baz`, `/host/share/baz`, `\\host\share\baz`},
+		{`\\host\share\foo\..\..\..\..\bar`, `\\host\share\foo\..\..\..\..\bar`, `\\host\share\bar`},
+		{`\\a\b\..\c`, `\\a\b\..\c`, `\\a\b\c`},
+		{`\\a\b`, `\\a\b`, `\\a\b`},
+
+		// Don't allow cleaning to move an element with a colon to the start of the path.
+		{`a/../c:`, `c:`, `.\c:`},
+		{`a\..\c:`, `a\..\c:`, `.\c:`},
+		{`a/../c:/a`, `c:/a`, `.\c:\a`},
+		{`a/../../c:`, `../c:`, `..\c:`},
+		{`foo:bar`, `foo:bar`, `foo:bar`},
+	}
+
+	for _, test := range cleanTests {
+		if s := avfs.CleanPath(avfs.OsLinux, test.path); s != test.linux {
+			t.Errorf("CleanPath(OsLinux, %q) = %q, want %q", test.path, s, test.linux)
+		}
+
+		if s := avfs.CleanPath(avfs.OsWindows, test.path); s != test.windows {
+			t.Errorf("CleanPath(OsWindows, %q) = %q, want %q", test.path, s, test.windows)
+		}
+	}
+}
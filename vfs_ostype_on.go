@@ -21,7 +21,6 @@ package avfs
 import (
 	"errors"
 	"path/filepath"
-	"slices"
 	"strings"
 	"unicode/utf8"
 )
@@ -91,122 +90,7 @@ func Base[T VFSBase](vfs T, path string) string {
 // Getting Dot-Dot Right,”
 // https://9p.io/sys/doc/lexnames.html
 func Clean[T VFSBase](vfs T, path string) string {
-	pathSeparator := vfs.PathSeparator()
-	originalPath := path
-	volLen := VolumeNameLen(vfs, path)
-
-	path = path[volLen:]
-	if path == "" {
-		if volLen > 1 && IsPathSeparator(vfs, originalPath[0]) && IsPathSeparator(vfs, originalPath[1]) {
-			// should be UNC
-			return FromSlash(vfs, originalPath)
-		}
-
-		return originalPath + "."
-	}
-
-	rooted := IsPathSeparator(vfs, path[0])
-
-	// Invariants:
-	//	reading from path; r is index of next byte to process.
-	//	writing to buf; w is index of next byte to write.
-	//	dotdot is index in buf where .. must stop, either because
-	//		it is the leading slash or it is a leading ../../.. prefix.
-	n := len(path)
-	out := lazybuf{path: path, volAndPath: originalPath, volLen: volLen}
-	r, dotdot := 0, 0
-
-	if rooted {
-		out.append(pathSeparator)
-
-		r, dotdot = 1, 1
-	}
-
-	for r < n {
-		switch {
-		case IsPathSeparator(vfs, path[r]):
-			// empty path element
-			r++
-		case path[r] == '.' && (r+1 == n || IsPathSeparator(vfs, path[r+1])):
-			// . element
-			r++
-		case path[r] == '.' && path[r+1] == '.' && (r+2 == n || IsPathSeparator(vfs, path[r+2])):
-			// .. element: remove to last separator
-			r += 2
-
-			switch {
-			case out.w > dotdot:
-				// can backtrack
-				out.w--
-				for out.w > dotdot && !IsPathSeparator(vfs, out.index(out.w)) {
-					out.w--
-				}
-			case !rooted:
-				// cannot backtrack, but not rooted, so append .. element.
-				if out.w > 0 {
-					out.append(pathSeparator)
-				}
-
-				out.append('.')
-				out.append('.')
-				dotdot = out.w
-			}
-		default:
-			// real path element.
-			// add slash if needed
-			if rooted && out.w != 1 || !rooted && out.w != 0 {
-				out.append(pathSeparator)
-			}
-
-			// copy element
-			for ; r < n && !IsPathSeparator(vfs, path[r]); r++ {
-				out.append(path[r])
-			}
-		}
-	}
-
-	// Turn empty string into "."
-	if out.w == 0 {
-		out.append('.')
-	}
-
-	if vfs.OSType() == OsWindows {
-		postClean(vfs, &out) // avoid creating absolute paths on Windows
-	}
-
-	return FromSlash(vfs, out.string())
-}
-
-// postClean adjusts the results of Clean to avoid turning a relative path
-// into an absolute or rooted one.
-func postClean[T VFSBase](vfs T, out *lazybuf) {
-	if out.volLen != 0 || out.buf == nil {
-		return
-	}
-
-	pathSeparator := vfs.PathSeparator()
-
-	// If a ':' appears in the path element at the start of a path,
-	// insert a .\ at the beginning to avoid converting relative paths
-	// like a/../c: into c:.
-	for _, c := range out.buf {
-		if IsPathSeparator(vfs, c) {
-			break
-		}
-
-		if c == ':' {
-			out.prepend('.', pathSeparator)
-
-			return
-		}
-	}
-
-	// If a path begins with \??\, insert a \. at the beginning
-	// to avoid converting paths like \a\..\??\c:\x into \??\c:\x
-	// (equivalent to c:\x).
-	if len(out.buf) >= 3 && IsPathSeparator(vfs, out.buf[0]) && out.buf[1] == '?' && out.buf[2] == '?' {
-		out.prepend(pathSeparator, '.')
-	}
+	return CleanPath(vfs.OSType(), path)
 }
 
 // Dir returns all but the last element of path, typically the path's directory.
@@ -308,10 +192,6 @@ func IsPathSeparator[T VFSBase](vfs T, c uint8) bool {
 	return c == '\\' || c == '/'
 }
 
-func isSlash(c uint8) bool {
-	return c == '\\' || c == '/'
-}
-
 // Join joins any number of path elements into a single path, adding a
 // separating slash if necessary. The result is Cleaned; in particular,
 // all empty strings are ignored.
@@ -388,39 +268,6 @@ func joinWindows[T VFSBase](vfs T, elem []string) string {
 	return Clean(vfs, b.String())
 }
 
-// pathHasPrefixFold tests whether the path s begins with prefix,
-// ignoring case and treating all path separators as equivalent.
-// If s is longer than prefix, then s[len(prefix)] must be a path separator.
-func pathHasPrefixFold(s, prefix string) bool {
-	if len(s) < len(prefix) {
-		return false
-	}
-
-	for i := 0; i < len(prefix); i++ {
-		if isSlash(prefix[i]) {
-			if !isSlash(s[i]) {
-				return false
-			}
-		} else if toUpper(prefix[i]) != toUpper(s[i]) {
-			return false
-		}
-	}
-
-	if len(s) > len(prefix) && !isSlash(s[len(prefix)]) {
-		return false
-	}
-
-	return true
-}
-
-func toUpper(c byte) byte {
-	if 'a' <= c && c <= 'z' {
-		return c - ('a' - 'A')
-	}
-
-	return c
-}
-
 // Match reports whether name matches the shell file name pattern.
 // The pattern syntax is:
 //
@@ -790,96 +637,5 @@ func ToSlash[T VFSBase](vfs T, path string) string {
 // VolumeNameLen returns length of the leading volume name on Windows.
 // It returns 0 elsewhere.
 func VolumeNameLen[T VFSBase](vfs T, path string) int {
-	if vfs.OSType() != OsWindows {
-		return 0
-	}
-
-	if len(path) < 2 {
-		return 0
-	}
-
-	// with drive letter
-	c := path[0]
-	if path[1] == ':' && ('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
-		return 2
-	}
-
-	// is it UNC? https://msdn.microsoft.com/en-us/library/windows/desktop/aa365247(v=vs.85).aspx
-	if l := len(path); l >= 5 && isSlash(path[0]) && isSlash(path[1]) &&
-		!isSlash(path[2]) && path[2] != '.' {
-		// first, leading `\\` and next shouldn't be `\`. its server name.
-		for n := 3; n < l-1; n++ {
-			// second, next '\' shouldn't be repeated.
-			if isSlash(path[n]) {
-				n++
-				// third, following something characters. its share name.
-				if !isSlash(path[n]) {
-					if path[n] == '.' {
-						break
-					}
-
-					for ; n < l; n++ {
-						if isSlash(path[n]) {
-							break
-						}
-					}
-
-					return n
-				}
-
-				break
-			}
-		}
-	}
-
-	return 0
-}
-
-// A lazybuf is a lazily constructed path buffer.
-// It supports append, reading previously appended bytes,
-// and retrieving the final string. It does not allocate a buffer
-// to hold the output until that output diverges from s.
-type lazybuf struct {
-	path       string
-	buf        []byte
-	w          int
-	volAndPath string
-	volLen     int
-}
-
-func (b *lazybuf) index(i int) byte {
-	if b.buf != nil {
-		return b.buf[i]
-	}
-
-	return b.path[i]
-}
-
-func (b *lazybuf) append(c byte) {
-	if b.buf == nil {
-		if b.w < len(b.path) && b.path[b.w] == c {
-			b.w++
-
-			return
-		}
-
-		b.buf = make([]byte, len(b.path))
-		copy(b.buf, b.path[:b.w])
-	}
-
-	b.buf[b.w] = c
-	b.w++
-}
-
-func (b *lazybuf) prepend(prefix ...byte) {
-	b.buf = slices.Insert(b.buf, 0, prefix...)
-	b.w += len(prefix)
-}
-
-func (b *lazybuf) string() string {
-	if b.buf == nil {
-		return b.volAndPath[:b.volLen+b.w]
-	}
-
-	return b.volAndPath[:b.volLen] + string(b.buf[:b.w])
+	return osVolumeNameLen(vfs.OSType(), path)
 }
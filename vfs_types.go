@@ -27,12 +27,20 @@ const (
 	DefaultFilePerm = fs.FileMode(0o666) // DefaultFilePerm is the default permission for files.
 	DefaultName     = "Default"          // DefaultName is the default name.
 	DefaultVolume   = "C:"               // DefaultVolume is the default volume name for Windows.
+	MaxSymlinks     = 40                 // MaxSymlinks is the default maximum number of symbolic links resolved in a path.
 	NotImplemented  = "not implemented"  // NotImplemented is the return string of a non-implemented feature.
 
 	// FileModeMask is the bitmask used for permissions.
 	FileModeMask = fs.ModePerm | fs.ModeSticky | fs.ModeSetuid | fs.ModeSetgid
 )
 
+// BackendResolver is the interface that wraps the ResolveBackend method.
+type BackendResolver interface {
+	// ResolveBackend returns the file system and the translated path
+	// used by the file system to handle an operation on path.
+	ResolveBackend(path string) (backend VFS, translatedPath string)
+}
+
 // Cloner is the interface that wraps the Clone method.
 type Cloner interface {
 	// Clone returns a shallow copy of the current file system (see MemFs).
@@ -43,6 +51,7 @@ type Cloner interface {
 type ChRooter interface {
 	// Chroot changes the root to that specified in path.
 	// If the user has not root privileges avfs.errPermDenied is returned.
+	// If the file system doesn't provide FeatChroot, the error wraps a FeatureMissingError.
 	// If there is an error, it will be of type *PathError.
 	Chroot(path string) error
 }
@@ -53,6 +62,141 @@ type DirInfo struct {
 	Perm fs.FileMode
 }
 
+// TmpFileLinker is the interface implemented by file systems providing the FeatTmpFile feature.
+type TmpFileLinker interface {
+	// Linkat links the unnamed temporary file f (opened with O_TMPFILE) to newpath,
+	// making it visible in the directory tree.
+	// If there is an error, it will be of type *LinkError.
+	Linkat(f File, newpath string) error
+}
+
+// Lchmoder is the interface implemented by file systems providing the FeatLchmod feature.
+type Lchmoder interface {
+	// Lchmod changes the mode of the named file to mode.
+	// If the file is a symbolic link, it changes the mode of the link itself, not of its target.
+	// If there is an error, it will be of type *PathError.
+	Lchmod(name string, mode fs.FileMode) error
+}
+
+// FSStater is the interface implemented by file systems providing the FeatStatFS feature.
+type FSStater interface {
+	// StatFS returns the capacity and the free space of the file system containing path,
+	// like the Linux statfs system call.
+	// If there is an error, it will be of type *PathError.
+	StatFS(path string) (FSStat, error)
+}
+
+// FSSyncer is the interface implemented by file systems providing the FeatSyncFS feature.
+type FSSyncer interface {
+	// SyncFS commits all the pending writes of the file system to its storage, like the Linux sync system call.
+	// It returns nil if the file system has nothing to flush.
+	SyncFS() error
+}
+
+// FSStat is the capacity and the free space of a file system returned by FSStater.StatFS.
+type FSStat struct {
+	Total      uint64 // Total is the size of the file system in bytes.
+	Free       uint64 // Free is the number of free bytes.
+	Available  uint64 // Available is the number of free bytes available to an unprivileged user.
+	Inodes     uint64 // Inodes is the total number of inodes (files, directories, symbolic links).
+	FreeInodes uint64 // FreeInodes is the number of free inodes.
+}
+
+// SpecialFileMaker is the interface implemented by file systems providing the FeatSpecialFiles feature.
+type SpecialFileMaker interface {
+	// Mkfifo creates a named pipe (FIFO) with the specified name and permission bits (before umask).
+	// If there is an error, it will be of type *PathError.
+	Mkfifo(name string, perm fs.FileMode) error
+
+	// Mknod creates a special file with the specified name, mode must be the type
+	// fs.ModeNamedPipe or fs.ModeSocket combined with the permission bits (before umask).
+	// If there is an error, it will be of type *PathError.
+	Mknod(name string, mode fs.FileMode) error
+}
+
+// FlagRenamer is the interface implemented by file systems providing the FeatRenameFlags feature,
+// like the RENAME_NOREPLACE and RENAME_EXCHANGE flags of the Linux renameat2 system call.
+type FlagRenamer interface {
+	// RenameExchange atomically exchanges oldpath and newpath, both must exist.
+	// If there is an error, it will be of type *LinkError.
+	RenameExchange(oldpath, newpath string) error
+
+	// RenameNoReplace renames (moves) oldpath to newpath like Rename,
+	// but fails with ErrFileExists if newpath already exists.
+	// If there is an error, it will be of type *LinkError.
+	RenameNoReplace(oldpath, newpath string) error
+}
+
+// Fallocator is the interface implemented by files supporting sparse files,
+// like the Linux fallocate system call.
+type Fallocator interface {
+	// Fallocate allocates (mode 0 or FALLOC_FL_KEEP_SIZE) or deallocates (FALLOC_FL_PUNCH_HOLE)
+	// the space of the range [off, off+length) of the file.
+	// If there is an error, it will be of type *PathError.
+	Fallocate(mode int, off, length int64) error
+}
+
+// FileDuper is the interface implemented by files that can be duplicated like the dup system call.
+// It is only supported by file systems with the FeatDup feature.
+type FileDuper interface {
+	// Dup returns a new file sharing the node and the offset of the file,
+	// a Read or a Write on one of the files advances the offset of the other.
+	// Closing one of the files doesn't close the other.
+	// If there is an error, it will be of type *PathError.
+	Dup() (File, error)
+}
+
+// RandomNamer is the interface implemented by file systems providing the random part of the names
+// generated by CreateTemp and MkdirTemp, for example to make them reproducible in tests.
+type RandomNamer interface {
+	// NextRandom returns the random string used to generate the next temporary name.
+	NextRandom() string
+}
+
+// OpenFlagger is the interface implemented by files reporting how they were opened.
+type OpenFlagger interface {
+	// CanRead returns true if the file was opened for reading.
+	CanRead() bool
+
+	// CanWrite returns true if the file was opened for writing.
+	CanWrite() bool
+
+	// Flag returns the flags (O_RDONLY, O_RDWR, O_CREATE etc.) the file was opened with.
+	Flag() int
+}
+
+// UnsortedDirReader is the interface implemented by directory files able to read their entries
+// in the order of the file system without sorting them, used by ReadDirNames and ReadDirUnsorted.
+type UnsortedDirReader interface {
+	// ReadDirUnsorted is like ReadDir, but the order of the entries is nondeterministic.
+	ReadDirUnsorted(n int) ([]fs.DirEntry, error)
+
+	// ReaddirnamesUnsorted is like Readdirnames, but the order of the names is nondeterministic.
+	ReaddirnamesUnsorted(n int) ([]string, error)
+}
+
+// DirFile is the interface implemented by directory files of file systems providing the FeatOpenat feature.
+// Like the openat family of system calls, names are resolved relatively to the directory of the file
+// and not to its path, so renaming the directory or one of its ancestors doesn't change the resolution.
+// Parent directories ("..") are resolved from the current location of the directory.
+type DirFile interface {
+	File
+
+	// MkdirAt creates a new directory with the specified name relative to the directory
+	// and permission bits (before umask).
+	// If there is an error, it will be of type *PathError.
+	MkdirAt(name string, perm fs.FileMode) error
+
+	// OpenFileAt opens the named file relative to the directory with specified flag (O_RDONLY etc.)
+	// and permission bits (before umask), like OpenFile.
+	// If there is an error, it will be of type *PathError.
+	OpenFileAt(name string, flag int, perm fs.FileMode) (File, error)
+
+	// StatAt returns a FileInfo describing the named file relative to the directory.
+	// If there is an error, it will be of type *PathError.
+	StatAt(name string) (fs.FileInfo, error)
+}
+
 // File represents a file in the file system.
 type File interface {
 	fs.File
@@ -86,6 +230,7 @@ type File interface {
 	Fd() uintptr
 
 	// Name returns the name of the file as presented to Open.
+	// Like os.File.Name, the name is neither cleaned nor made absolute.
 	Name() string
 
 	// Readdirnames reads and returns a slice of names from the directory f.
@@ -102,6 +247,30 @@ type File interface {
 	// a non-nil error.
 	Readdirnames(n int) (names []string, err error)
 
+	// SetDeadline sets the read and write deadlines for a File.
+	// It is equivalent to calling both SetReadDeadline and SetWriteDeadline.
+	//
+	// Only some kinds of files support setting a deadline (see FeatDeadline).
+	// Calls to SetDeadline for files that do not support deadlines will return os.ErrNoDeadline.
+	//
+	// A deadline is an absolute time after which I/O operations fail instead of blocking.
+	// After a deadline has been exceeded, the error returned by I/O operations is
+	// a *PathError wrapping os.ErrDeadlineExceeded.
+	// A zero value for t means I/O operations will not time out.
+	SetDeadline(t time.Time) error
+
+	// SetReadDeadline sets the deadline for future Read calls and any
+	// currently-blocked Read call.
+	// A zero value for t means Read will not time out.
+	// Not all files support setting deadlines; see SetDeadline.
+	SetReadDeadline(t time.Time) error
+
+	// SetWriteDeadline sets the deadline for any future Write calls and any
+	// currently-blocked Write call.
+	// A zero value for t means Write will not time out.
+	// Not all files support setting deadlines; see SetDeadline.
+	SetWriteDeadline(t time.Time) error
+
 	// Sync commits the current contents of the file to stable storage.
 	// Typically, this means flushing the file system's in-memory copy
 	// of recently written data to disk.
@@ -139,6 +308,43 @@ type VolumeManager interface {
 	VolumeList() []string
 }
 
+// XattrManager is the interface that manages extended attributes of files.
+// Functions prefixed by L do not follow symbolic links.
+// Extended attributes are not supported on Windows, where FeatXattr is never set.
+type XattrManager interface {
+	// GetXattr returns the value of the extended attribute name of the file path.
+	// If the attribute does not exist, the error is ErrNoData.
+	// If there is an error, it will be of type *PathError.
+	GetXattr(path, name string) ([]byte, error)
+
+	// LGetXattr is like GetXattr but does not follow symbolic links.
+	LGetXattr(path, name string) ([]byte, error)
+
+	// ListXattr returns the sorted names of the extended attributes of the file path.
+	// If there is an error, it will be of type *PathError.
+	ListXattr(path string) ([]string, error)
+
+	// RemoveXattr removes the extended attribute name of the file path.
+	// If the attribute does not exist, the error is ErrNoData.
+	// If there is an error, it will be of type *PathError.
+	RemoveXattr(path, name string) error
+
+	// SetXattr sets the value of the extended attribute name of the file path.
+	// If flags is XattrCreate and the attribute already exists, the error is ErrFileExists.
+	// If flags is XattrReplace and the attribute does not exist, the error is ErrNoData.
+	// If there is an error, it will be of type *PathError.
+	SetXattr(path, name string, data []byte, flags int) error
+
+	// LSetXattr is like SetXattr but does not follow symbolic links.
+	LSetXattr(path, name string, data []byte, flags int) error
+}
+
+// Flags used by XattrManager.SetXattr function.
+const (
+	XattrCreate  = 0x1 // XattrCreate fails if the extended attribute already exists.
+	XattrReplace = 0x2 // XattrReplace fails if the extended attribute does not exist.
+)
+
 // OpenMode defines constants used by OpenFile and CheckPermission functions.
 type OpenMode uint16
 
@@ -150,6 +356,22 @@ const (
 	OpenCreate                          // OpenCreate creates a file (os.O_CREATE).
 	OpenCreateExcl                      // OpenCreateExcl creates a non existing file (os.O_EXCL).
 	OpenTruncate                        // OpenTruncate truncates a file (os.O_TRUNC).
+	OpenTmpFile                         // OpenTmpFile creates an unnamed temporary file (O_TMPFILE).
+)
+
+// O_TMPFILE is an OpenFile flag creating an unnamed temporary file in the directory given as path,
+// like the Linux O_TMPFILE flag. It must be combined with os.O_WRONLY or os.O_RDWR.
+// The file is not reachable by any path until it is linked with TmpFileLinker.Linkat,
+// its storage is released when it is closed without being linked.
+// It is only supported by file systems with the FeatTmpFile feature.
+const O_TMPFILE = 0x410000 //nolint:revive,stylecheck // Same name as the Linux flag.
+
+// Modes of Fallocator.Fallocate, same values as the Linux flags.
+//
+//nolint:revive,stylecheck // Same names as the Linux flags.
+const (
+	FALLOC_FL_KEEP_SIZE  = 0x1 // FALLOC_FL_KEEP_SIZE allocates space without changing the file size.
+	FALLOC_FL_PUNCH_HOLE = 0x2 // FALLOC_FL_PUNCH_HOLE deallocates space, the range is read as zeros.
 )
 
 // IOFS is the virtual file system interface implementing io/fs interfaces.
@@ -0,0 +1,991 @@
+//
+//  Copyright 2024 The AVFS authors
+//
+//  Licensed under the Apache License, Version 2.0 (the "License");
+//  you may not use this file except in compliance with the License.
+//  You may obtain a copy of the License at
+//
+//  	http://www.apache.org/licenses/LICENSE-2.0
+//
+//  Unless required by applicable law or agreed to in writing, software
+//  distributed under the License is distributed on an "AS IS" BASIS,
+//  WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//  See the License for the specific language governing permissions and
+//  limitations under the License.
+//
+
+package avfs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// lockOwnerFile is the name of the file storing the owner token of a lock directory (see AcquireLock).
+	lockOwnerFile = "owner"
+
+	// lockBreakDir is the name of the directory created in a stale lock directory
+	// by the caller allowed to remove it (see AcquireLockStale).
+	lockBreakDir = "break"
+)
+
+// AcquireLock acquires the lock path by creating it as a directory, which is atomic:
+// if the lock is already held, the error is a *PathError wrapping ErrFileExists.
+// A token identifying the owner is stored in the directory. The returned release function
+// removes the lock directory if it is still owned by the caller, it can be called several times.
+func AcquireLock(vfs VFSBase, path string) (release func(), err error) {
+	return AcquireLockStale(vfs, path, 0)
+}
+
+// AcquireLockStale is like AcquireLock, but a lock older than staleAge is considered stale
+// (its owner is gone) and is removed before acquiring it again. If staleAge is 0, locks never expire.
+// When several callers find the same lock stale, only one of them removes it,
+// so a lock acquired again in the meantime is never removed.
+func AcquireLockStale(vfs VFSBase, path string, staleAge time.Duration) (release func(), err error) {
+	err = vfs.Mkdir(path, DefaultDirPerm)
+	if errors.Is(err, fs.ErrExist) && staleAge > 0 {
+		broken, errBreak := breakStaleLock(vfs, path, staleAge)
+		if errBreak != nil {
+			return nil, errBreak
+		}
+
+		if broken {
+			err = vfs.Mkdir(path, DefaultDirPerm)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	token := strconv.FormatUint(rand.Uint64(), 36)
+	ownerPath := vfs.Join(path, lockOwnerFile)
+
+	err = vfs.WriteFile(ownerPath, []byte(token), DefaultFilePerm)
+	if err != nil {
+		_ = vfs.RemoveAll(path)
+
+		return nil, err
+	}
+
+	var once sync.Once
+
+	release = func() {
+		once.Do(func() {
+			owner, err := vfs.ReadFile(ownerPath)
+			if err != nil || string(owner) != token {
+				return
+			}
+
+			_ = vfs.RemoveAll(path)
+		})
+	}
+
+	return release, nil
+}
+
+// breakStaleLock removes the lock directory path if it is older than staleAge
+// and returns true if the lock doesn't exist anymore.
+// The caller creating the directory lockBreakDir in the lock directory is the only one allowed to remove it,
+// once it has checked that the lock was not acquired again since it was found stale:
+// the lock directory must be the same file with the same owner token.
+func breakStaleLock(vfs VFSBase, path string, staleAge time.Duration) (bool, error) {
+	// The owner token is read first, a lock acquired again after this read is not found stale
+	// or has a different owner token.
+	ownerPath := vfs.Join(path, lockOwnerFile)
+	owner, _ := vfs.ReadFile(ownerPath)
+
+	info, err := vfs.Lstat(path)
+	if err != nil || !info.IsDir() || time.Since(info.ModTime()) <= staleAge {
+		return errors.Is(err, fs.ErrNotExist), nil
+	}
+
+	breakPath := vfs.Join(path, lockBreakDir)
+
+	err = vfs.Mkdir(breakPath, DefaultDirPerm)
+	if err != nil {
+		// Another caller is removing the lock or has already removed it.
+		return errors.Is(err, fs.ErrNotExist), nil
+	}
+
+	newInfo, err := vfs.Lstat(path)
+	newOwner, _ := vfs.ReadFile(ownerPath)
+
+	if err != nil || !vfs.SameFile(info, newInfo) || !bytes.Equal(owner, newOwner) {
+		// The lock was acquired again since it was found stale.
+		_ = vfs.Remove(breakPath)
+
+		return false, nil
+	}
+
+	if err = vfs.RemoveAll(path); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// AppendFile appends data to the named file, creating it with permissions perm (before umask) if necessary.
+// An existing file is never truncated.
+// If there is an error, it will be of type *PathError.
+func AppendFile(vfs VFSBase, name string, data []byte, perm fs.FileMode) error {
+	f, err := vfs.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, perm)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if err1 := f.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+
+	return err
+}
+
+// Fprintf formats according to a format specifier and writes to the named file,
+// creating it with permissions DefaultFilePerm (before umask) if necessary or truncating it.
+// It returns the number of bytes written and any error encountered.
+func Fprintf(vfs VFSBase, name, format string, a ...any) (int, error) {
+	return writeFormatted(vfs, name, func(w io.Writer) (int, error) {
+		return fmt.Fprintf(w, format, a...)
+	})
+}
+
+// Fprintln formats using the default formats for its operands and writes to the named file like Fprintf.
+// Spaces are always added between operands and a newline is appended.
+// It returns the number of bytes written and any error encountered.
+func Fprintln(vfs VFSBase, name string, a ...any) (int, error) {
+	return writeFormatted(vfs, name, func(w io.Writer) (int, error) {
+		return fmt.Fprintln(w, a...)
+	})
+}
+
+// writeFormatted creates or truncates the named file and writes to it with the function write.
+func writeFormatted(vfs VFSBase, name string, write func(w io.Writer) (int, error)) (int, error) {
+	f, err := vfs.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, DefaultFilePerm)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := write(f)
+	if err1 := f.Close(); err1 != nil && err == nil {
+		err = err1
+	}
+
+	return n, err
+}
+
+// ChmodR changes the mode of root and of all the files and directories of its tree to mode.
+// Symbolic links are not followed and their mode is not changed.
+// If mode gives read and search permissions to the owner, the mode of a directory is changed
+// before its content is read, otherwise it is changed after.
+// The walk continues after an error, entries that can't be read are skipped,
+// and the first error encountered is returned.
+func ChmodR(vfs VFSBase, root string, mode fs.FileMode) error {
+	preOrder := mode&0o500 == 0o500
+
+	return walkR(vfs, root, preOrder, func(path string, d fs.DirEntry) error {
+		if d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+
+		return vfs.Chmod(path, mode)
+	})
+}
+
+// ChownR changes the numeric uid and gid of root and of all the files, directories
+// and symbolic links of its tree. Symbolic links are not followed, Lchown is used to change them.
+// The owner of a directory is changed after its content is read.
+// The walk continues after an error, entries that can't be read are skipped,
+// and the first error encountered is returned.
+func ChownR(vfs VFSBase, root string, uid, gid int) error {
+	return walkR(vfs, root, false, func(path string, _ fs.DirEntry) error {
+		return vfs.Lchown(path, uid, gid)
+	})
+}
+
+// walkR calls fn on root and on each entry of its tree without following symbolic links.
+// If preOrder is true, fn is called on a directory before its content, otherwise after.
+// It returns the first error encountered.
+func walkR(vfs VFSBase, root string, preOrder bool, fn func(path string, d fs.DirEntry) error) error {
+	info, err := vfs.Lstat(root)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+
+	setErr := func(err error) {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	var walk func(path string, d fs.DirEntry)
+
+	walk = func(path string, d fs.DirEntry) {
+		if !d.IsDir() {
+			setErr(fn(path, d))
+
+			return
+		}
+
+		if preOrder {
+			setErr(fn(path, d))
+		}
+
+		entries, err := vfs.ReadDir(path)
+		setErr(err)
+
+		for _, entry := range entries {
+			walk(vfs.Join(path, entry.Name()), entry)
+		}
+
+		if !preOrder {
+			setErr(fn(path, d))
+		}
+	}
+
+	walk(root, fs.FileInfoToDirEntry(info))
+
+	return firstErr
+}
+
+// WalkBreadthFirst walks the file tree rooted at root level by level, calling fn for root,
+// then for the entries of root, then for the entries of its subdirectories and so on.
+// The entries of each directory are visited in lexical order. The directories deeper than maxDepth
+// are not read (root has a depth of 0), the depth is unlimited if maxDepth is negative.
+// Returning filepath.SkipDir on a directory skips its content, on a file it skips the remaining
+// entries of its directory. Returning filepath.SkipAll stops the walk.
+// Symbolic links are not followed. The walk stops at the first error returned by fn
+// or encountered when reading a directory.
+func WalkBreadthFirst(vfs VFSBase, root string, maxDepth int, fn func(path string, d fs.DirEntry) error) error {
+	info, err := vfs.Lstat(root)
+	if err != nil {
+		return err
+	}
+
+	type dirDepth struct {
+		path  string
+		depth int
+	}
+
+	var queue []dirDepth
+
+	switch err = fn(root, fs.FileInfoToDirEntry(info)); {
+	case err == fs.SkipDir || err == fs.SkipAll:
+		return nil
+	case err != nil:
+		return err
+	case info.IsDir():
+		queue = append(queue, dirDepth{path: root})
+	}
+
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
+
+		if maxDepth >= 0 && dir.depth >= maxDepth {
+			continue
+		}
+
+		entries, err := vfs.ReadDir(dir.path)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			path := vfs.Join(dir.path, entry.Name())
+
+			err = fn(path, entry)
+			if err == fs.SkipDir {
+				if entry.IsDir() {
+					continue
+				}
+
+				break
+			}
+
+			if err == fs.SkipAll {
+				return nil
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if entry.IsDir() {
+				queue = append(queue, dirDepth{path: path, depth: dir.depth + 1})
+			}
+		}
+	}
+
+	return nil
+}
+
+// WalkFollow walks the file tree rooted at root like filepath.Walk, calling fn for each file or directory
+// in the tree, including root, in lexical order, but symbolic links are followed:
+// fn receives the file information of the target of a link under the path of the link
+// and the directories pointed by symbolic links are walked.
+// A symbolic link to a directory being walked (an ancestor of the link) is detected with SameFile
+// and reported to fn with a *PathError wrapping ErrTooManySymlinks instead of being walked again:
+// if fn returns nil or filepath.SkipDir the link is skipped, otherwise the walk stops with the error returned.
+// Returning filepath.SkipAll stops the walk.
+func WalkFollow(vfs VFSBase, root string, fn filepath.WalkFunc) error {
+	info, err := vfs.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walkFollow(vfs, root, info, nil, fn)
+	}
+
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+
+	return err
+}
+
+// walkFollow recursively descends path following symbolic links, calling fn.
+// ancestors are the file information of the directories being walked.
+func walkFollow(vfs VFSBase, path string, info fs.FileInfo, ancestors []fs.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	for _, ancestor := range ancestors {
+		if vfs.SameFile(ancestor, info) {
+			return fn(path, info, &fs.PathError{Op: "walk", Path: path, Err: ErrTooManySymlinks})
+		}
+	}
+
+	entries, err := vfs.ReadDir(path)
+
+	err1 := fn(path, info, err)
+	if err != nil || err1 != nil {
+		return err1
+	}
+
+	ancestors = append(ancestors, info)
+
+	for _, entry := range entries {
+		name := vfs.Join(path, entry.Name())
+
+		fileInfo, err := vfs.Stat(name)
+		if err != nil {
+			if err = fn(name, nil, err); err != nil && err != filepath.SkipDir {
+				return err
+			}
+
+			continue
+		}
+
+		err = walkFollow(vfs, name, fileInfo, ancestors, fn)
+		if err != nil && (!fileInfo.IsDir() || err != filepath.SkipDir) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FileType returns the type bits of the named file (fs.ModeDir, fs.ModeSymlink, ...), 0 for a regular file.
+// If the file is a symbolic link, the type of the link itself is returned.
+func FileType(vfs VFSBase, path string) (fs.FileMode, error) {
+	info, err := vfs.Lstat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Mode().Type(), nil
+}
+
+// IsSymlink checks if a given path is a symbolic link, without following it.
+func IsSymlink(vfs VFSBase, path string) (bool, error) {
+	typ, err := FileType(vfs, path)
+	if err != nil {
+		return false, err
+	}
+
+	return typ == fs.ModeSymlink, nil
+}
+
+// Lchmod changes the mode of the named file like Chmod, but if the file is a symbolic link,
+// it changes the mode of the link itself and leaves its target unchanged.
+// If the file system doesn't provide the FeatLchmod feature, the error is a FeatureMissingError
+// wrapping ErrOpNotPermitted (ErrWinNotSupported on Windows).
+// If there is an error, it will be of type *PathError.
+func Lchmod(vfs VFSBase, name string, mode fs.FileMode) error {
+	if lc, ok := vfs.(Lchmoder); ok && vfs.HasFeature(FeatLchmod) {
+		return lc.Lchmod(name, mode)
+	}
+
+	err := error(ErrOpNotPermitted)
+	if vfs.OSType() == OsWindows {
+		err = ErrWinNotSupported
+	}
+
+	return &fs.PathError{Op: "lchmod", Path: name, Err: &FeatureMissingError{Err: err, Feature: FeatLchmod}}
+}
+
+// MkdirAllMode creates a directory named path, along with any necessary parents, like MkdirAll.
+// The created parent directories get the mode intermediate and the directory path gets the mode leaf,
+// these modes are applied with Chmod so they are not modified by the umask.
+// The modes of the already existing directories are not changed.
+func MkdirAllMode(vfs VFSBase, path string, intermediate, leaf fs.FileMode) error {
+	var missing []string
+
+	for dir := path; ; {
+		info, err := vfs.Stat(dir)
+		if err == nil {
+			if info.IsDir() && dir == path {
+				return nil
+			}
+
+			break
+		}
+
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		missing = append(missing, dir)
+
+		parent := vfs.Dir(dir)
+		if parent == dir {
+			break
+		}
+
+		dir = parent
+	}
+
+	if len(missing) == 0 {
+		// path exists and is not a directory, Mkdir returns the appropriate error.
+		return vfs.Mkdir(path, leaf)
+	}
+
+	for i := len(missing) - 1; i >= 0; i-- {
+		dir, mode := missing[i], intermediate
+		if i == 0 {
+			mode = leaf
+		}
+
+		if err := vfs.Mkdir(dir, mode); err != nil {
+			return err
+		}
+
+		if err := vfs.Chmod(dir, mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// EnsureDir creates a directory named path with permissions perm (before umask), along with any necessary parents,
+// if it doesn't exist. It returns nil if path is already a directory
+// and a *PathError wrapping ErrNotADirectory if path exists but is not a directory.
+func EnsureDir(vfs VFSBase, path string, perm fs.FileMode) error {
+	info, err := vfs.Stat(path)
+	if err == nil {
+		if !info.IsDir() {
+			return &fs.PathError{Op: "mkdir", Path: path, Err: ErrNotADirectory}
+		}
+
+		return nil
+	}
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return vfs.MkdirAll(path, perm)
+}
+
+// ListByModTime returns the file information of the files (not the directories) contained in the directory dir
+// sorted by modification time in ascending or descending order.
+// Files with the same modification time are sorted by name.
+func ListByModTime(vfs VFSBase, dir string, ascending bool) ([]fs.FileInfo, error) {
+	entries, err := ReadDir(vfs, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		ti, tj := infos[i].ModTime(), infos[j].ModTime()
+		if ti.Equal(tj) {
+			return infos[i].Name() < infos[j].Name()
+		}
+
+		if ascending {
+			return ti.Before(tj)
+		}
+
+		return ti.After(tj)
+	})
+
+	return infos, nil
+}
+
+// OpenReader opens the named file for reading and returns it as an io.ReadCloser.
+// If there is an error, it will be of type *PathError.
+func OpenReader(vfs VFSBase, name string) (io.ReadCloser, error) {
+	f, err := vfs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// OpenWriter creates or truncates the named file for writing with permissions perm (before umask)
+// and returns it as an io.WriteCloser. Close syncs the file to storage before closing it.
+// If there is an error, it will be of type *PathError.
+func OpenWriter(vfs VFSBase, name string, perm fs.FileMode) (io.WriteCloser, error) {
+	f, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syncWriteCloser{f: f}, nil
+}
+
+// syncWriteCloser is the io.WriteCloser returned by OpenWriter.
+type syncWriteCloser struct {
+	f File // f is the file opened for writing.
+}
+
+// Write writes len(b) bytes from b to the file.
+func (w *syncWriteCloser) Write(b []byte) (int, error) {
+	return w.f.Write(b)
+}
+
+// Close syncs the file and closes it, it returns the first error encountered.
+func (w *syncWriteCloser) Close() error {
+	err := w.f.Sync()
+
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+
+	return err
+}
+
+// RelSymlink creates newname as a symbolic link to oldname, like Symlink,
+// but the stored target is the path of oldname relative to the directory of newname.
+// The link keeps resolving to the same file when a directory containing both paths is moved.
+// If there is an error, it will be of type *LinkError.
+func RelSymlink(vfs VFSBase, oldname, newname string) error {
+	absOld, err := vfs.Abs(oldname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	absNew, err := vfs.Abs(newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	target, err := vfs.Rel(vfs.Dir(absNew), absOld)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	return vfs.Symlink(target, newname)
+}
+
+// SetExecutable adds (executable is true) or removes the execute permission bits of the named file,
+// the other bits of its mode are preserved. Execute permission is only given to the owner, group
+// or others if they have the read permission, so a 0o640 file becomes 0o750.
+// If the file is a symbolic link, the mode of the link's target is changed.
+func SetExecutable(vfs VFSBase, path string, executable bool) error {
+	info, err := vfs.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	mode := info.Mode() & FileModeMask
+	if executable {
+		mode |= (mode & 0o444) >> 2
+	} else {
+		mode &^= 0o111
+	}
+
+	return vfs.Chmod(path, mode)
+}
+
+// Touch creates the named file if it does not exist (empty, with DefaultFilePerm permissions),
+// or sets its access and modification times to the current time if it does, like the touch command.
+// An existing file is never truncated.
+func Touch(vfs VFSBase, name string) error {
+	now := time.Now()
+
+	return TouchAt(vfs, name, now, now)
+}
+
+// TouchAt is like Touch but sets the access and modification times of the file to atime and mtime.
+func TouchAt(vfs VFSBase, name string, atime, mtime time.Time) error {
+	err := vfs.Chtimes(name, atime, mtime)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	f, err := vfs.OpenFile(name, os.O_WRONLY|os.O_CREATE, DefaultFilePerm)
+	if err != nil {
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return vfs.Chtimes(name, atime, mtime)
+}
+
+// WriteFileAtomic writes data to the named file like WriteFile, but readers never see a partially written file:
+// data is written and synced to a temporary file in the same directory, which is then renamed over name.
+// If the file already exists, its permissions are preserved, otherwise it is created with permissions perm (before umask).
+// The temporary file is removed if an error occurs.
+func WriteFileAtomic(vfs VFSBase, name string, data []byte, perm fs.FileMode) (err error) {
+	mode := perm &^ vfs.UMask()
+
+	info, err := vfs.Stat(name)
+	switch {
+	case err == nil:
+		mode = info.Mode() & FileModeMask
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	dir, base := Split(vfs, name)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := vfs.CreateTemp(dir, "."+base+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpName := f.Name()
+
+	defer func() {
+		if err != nil {
+			_ = vfs.Remove(tmpName)
+		}
+	}()
+
+	n, err := f.Write(data)
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if cerr := f.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = vfs.Chmod(tmpName, mode)
+	if err != nil {
+		return err
+	}
+
+	return vfs.Rename(tmpName, name)
+}
+
+// WriteFileMkdirAll writes data to the named file like WriteFile,
+// creating first the missing parent directories with permissions dirPerm (before umask) like MkdirAll.
+func WriteFileMkdirAll(vfs VFSBase, name string, data []byte, perm, dirPerm fs.FileMode) error {
+	err := vfs.MkdirAll(Dir(vfs, name), dirPerm)
+	if err != nil {
+		return err
+	}
+
+	return vfs.WriteFile(name, data, perm)
+}
+
+// CreateFiles creates the files of the map files, where the key is the path and the value the content,
+// with permissions perm (before umask) and the missing parent directories like WriteFileMkdirAll.
+// All the files are processed even if some of them fail, the errors are joined with errors.Join.
+func CreateFiles(vfs VFSBase, files map[string][]byte, perm fs.FileMode) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var errs []error
+
+	for _, name := range names {
+		if err := WriteFileMkdirAll(vfs, name, files[name], perm, DefaultDirPerm); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// RemoveFiles removes the files or empty directories named by paths with Remove.
+// All the paths are processed even if some of them fail, the errors are joined with errors.Join.
+func RemoveFiles(vfs VFSBase, paths []string) error {
+	var errs []error
+
+	for _, path := range paths {
+		if err := vfs.Remove(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// DirSize returns the total size of the regular files of the directory tree rooted at path.
+// Symbolic links are not followed and files with several hard links are counted once.
+// On error, it returns the size computed so far and the first error encountered.
+func DirSize(vfs VFSBase, path string) (int64, error) {
+	return dirSize(vfs, path, false)
+}
+
+// DirSizeSkipErrors is like DirSize but skips the files and directories that can't be read.
+// Only an error on path itself is returned.
+func DirSizeSkipErrors(vfs VFSBase, path string) (int64, error) {
+	return dirSize(vfs, path, true)
+}
+
+// dirSize is the implementation of DirSize and DirSizeSkipErrors.
+func dirSize(vfs VFSBase, path string, skipErrors bool) (int64, error) {
+	var (
+		size  int64
+		links []fs.FileInfo // links are the files with several hard links already counted.
+	)
+
+	err := vfs.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if !skipErrors || p == path {
+				return err
+			}
+
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if skipErrors {
+				return nil
+			}
+
+			return err
+		}
+
+		if vfs.ToSysStat(info).Nlink() > 1 {
+			for _, link := range links {
+				if vfs.SameFile(link, info) {
+					return nil
+				}
+			}
+
+			links = append(links, info)
+		}
+
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
+
+// readDirBatch is the number of directory entries read at once by ReadDirFiltered.
+const readDirBatch = 256
+
+// ReadDirFiltered reads the named directory and returns the file information of the entries
+// for which keep returns true, sorted by name. The directory is read in batches and keep
+// is applied during the iteration, so the entries that are not kept are never accumulated.
+func ReadDirFiltered(vfs VFSBase, dir string, keep func(fs.FileInfo) bool) ([]fs.FileInfo, error) {
+	f, err := vfs.OpenFile(dir, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var infos []fs.FileInfo
+
+	for {
+		entries, err := f.ReadDir(readDirBatch)
+
+		for _, entry := range entries {
+			info, errInfo := entry.Info()
+			if errInfo != nil {
+				if errors.Is(errInfo, fs.ErrNotExist) {
+					// The entry was removed since the directory was read.
+					continue
+				}
+
+				return nil, errInfo
+			}
+
+			if keep(info) {
+				infos = append(infos, info)
+			}
+		}
+
+		if errors.Is(err, io.EOF) || (err == nil && len(entries) == 0) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+
+	return infos, nil
+}
+
+// ReadDirNames reads the named directory and returns the names of its entries.
+// Unlike ReadDir, the names are not sorted, their order depends on the file system and is nondeterministic.
+// If an error occurs reading the directory, ReadDirNames returns the names it was able to read
+// before the error, along with the error.
+func ReadDirNames(vfs VFSBase, name string) ([]string, error) {
+	f, err := vfs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	if ur, ok := f.(UnsortedDirReader); ok {
+		return ur.ReaddirnamesUnsorted(-1)
+	}
+
+	return f.Readdirnames(-1)
+}
+
+// ReadDirUnsorted reads the named directory and returns all its directory entries.
+// Unlike ReadDir, the entries are not sorted, their order depends on the file system and is nondeterministic.
+// If an error occurs reading the directory, ReadDirUnsorted returns the entries it was able to read
+// before the error, along with the error.
+func ReadDirUnsorted(vfs VFSBase, name string) ([]fs.DirEntry, error) {
+	f, err := vfs.OpenFile(name, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	if ur, ok := f.(UnsortedDirReader); ok {
+		return ur.ReadDirUnsorted(-1)
+	}
+
+	return f.ReadDir(-1)
+}
+
+// ResolvePath returns the absolute path name of path after the evaluation of any symbolic links.
+// Each part of the path is checked with Lstat, symbolic links are read with Readlink
+// and replaced in the path with PathIterator.ReplacePart.
+// If more than maxSymlinks symbolic links are resolved, an error wrapping ErrTooManySymlinks is returned,
+// if maxSymlinks <= 0, MaxSymlinks is used.
+// Like PathIterator, ".." parts are resolved lexically before the evaluation of symbolic links.
+// If there is an error, it will be of type *PathError.
+func ResolvePath(vfs VFSBase, path string, maxSymlinks int) (string, error) {
+	if maxSymlinks <= 0 {
+		maxSymlinks = MaxSymlinks
+	}
+
+	absPath, err := vfs.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	slCount := 0
+	pi := NewPathIterator(vfs, absPath)
+
+	for pi.Next() {
+		info, err := vfs.Lstat(pi.LeftPart())
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+
+		slCount++
+		if slCount > maxSymlinks {
+			return "", &fs.PathError{Op: "lstat", Path: path, Err: ErrTooManySymlinks}
+		}
+
+		link, err := vfs.Readlink(pi.LeftPart())
+		if err != nil {
+			return "", err
+		}
+
+		pi.ReplacePart(link)
+	}
+
+	return pi.Path(), nil
+}
+
+// ResolveBackend returns the concrete file system and the translated path that would handle
+// an operation on path, drilling through file systems implementing the BackendResolver interface
+// (BasePathFS, FailFS, MountFS, RoFS, ...).
+func ResolveBackend(vfs VFS, path string) (backend VFS, translatedPath string) {
+	for {
+		br, ok := vfs.(BackendResolver)
+		if !ok {
+			return vfs, path
+		}
+
+		next, nextPath := br.ResolveBackend(path)
+		if next == nil || next == vfs {
+			return vfs, path
+		}
+
+		vfs, path = next, nextPath
+	}
+}